@@ -120,7 +120,7 @@ STRING_LITER: DOUBLE_QUOTE CHARACTERS*? DOUBLE_QUOTE;
 ESCAPED_CHARS: '\\' [0btnfr"'\\];
 
 //comments
-COMMENT: '#' .*? '\n' -> skip;
+COMMENT: '#' .*? '\n' -> channel(HIDDEN);
 //whitespace, whitelines
 WHITESPACE: [\t\n \r] -> skip;
 
@@ -128,4 +128,33 @@ WHITESPACE: [\t\n \r] -> skip;
 AS: 'as';
 IMPORT: 'import';
 ACCESSOR: '::';
+
+//function types and anonymous functions
+FN: 'fn';
+ARROW: '->';
+
+//inheritance
+EXTENDS: 'extends';
+
+//interfaces
+INTERFACE: 'interface';
+IMPLEMENTS: 'implements';
+
+//futures
+FUTURE: 'future';
+JOIN: 'join';
+
+//channels
+CHAN: 'chan';
+MAKE_CHAN: 'make_chan';
+SEND: 'send';
+RECV: 'recv';
+CLOSE: 'close';
+
+//condition variables
+COND: 'cond';
+WAIT: 'wait';
+SIGNAL: 'signal';
+BROADCAST: 'broadcast';
+WITH: 'with';
 IDENT: (LETTERS | UNDERSCORE) (LETTERS | DIGIT | UNDERSCORE)*;
@@ -18,11 +18,19 @@ fieldident: ident (DOT fieldident)?;
 libident: fieldident | ident ACCESSOR fieldident;
 
 userType:
-    STRUCT ident IS declaration+ END
-    | CLASS ident IS declaration+ function* END;
+    STRUCT ident typeparams? IS declaration+ END
+    | CLASS ident typeparams? IS declaration+ function* END
+    | CLASS ident (superclass interfaces? | interfaces) IS declaration* function* END
+    | INTERFACE ident IS signature* END;
+
+superclass: EXTENDS ident;
+
+interfaces: IMPLEMENTS ident (COMMA ident)*;
+
+signature: wacctype ident LPAREN paramlist? RPAREN;
 
 function:
-    wacctype ident LPAREN paramlist? RPAREN IS funcbody END;
+    wacctype ident typeparams? LPAREN paramlist? RPAREN IS funcbody END;
 
 returnable: (RETURN | EXIT) right = expr;
 
@@ -30,6 +38,7 @@ funcbody:
     (stat SEMICOLON)? (
         returnable
         | IF expr THEN funcbody ELSE funcbody ENDIF
+        | WITH fieldident DO funcbody DONE
     );
 
 paramlist: param (COMMA param)*;
@@ -49,6 +58,11 @@ stat:
     | RELEASE fieldident                   # statRelease
     | UP fieldident                        # statUp
     | DOWN fieldident                      # statDown
+    | SEND expr COMMA expr                 # statSend
+    | CLOSE expr                           # statClose
+    | WAIT fieldident COMMA fieldident     # statWait
+    | SIGNAL fieldident                    # statSignal
+    | BROADCAST fieldident                 # statBroadcast
     | RETURN expr                          # statReturn
     | EXIT expr                            # statExit
     | PRINT expr                           # statPrint
@@ -62,6 +76,7 @@ stat:
     | FOR LPAREN newassign SEMICOLON expr SEMICOLON assign RPAREN DO stat DONE 
                                            # statFor
     | BEGIN stat END                       # statBegin
+    | WITH fieldident DO stat DONE         # statWith
     | stat SEMICOLON stat                  # statMultiple
     | WACC libident LPAREN arglist? RPAREN # statWacc
     ;
@@ -84,23 +99,26 @@ assignrhs:
     | NEWPAIR LPAREN expr COMMA expr RPAREN  # rightNewPair
     | pairelem                               # rightPairElem
     | arrayliter                             # rightArrayLiter
-    | libident LBRACES arglist? RBRACES      # rightNewUserType
+    | libident typeargs? LBRACES arglist? RBRACES # rightNewUserType
     | CALL libident LPAREN arglist? RPAREN   # rightFunctionCall
-    | MAKE LPAREN wacctype COMMA expr RPAREN # make;
+    | WACC libident LPAREN arglist? RPAREN   # rightWacc
+    | MAKE LPAREN wacctype COMMA expr RPAREN # make
+    | MAKE_CHAN LPAREN wacctype COMMA expr RPAREN # makeChan
+    | RECV expr                              # rightRecv;
 
 arglist: expr (COMMA expr)*;
 
 pairelem: (FST | SND) right = expr;
 
-wacctype: basetype | arraytype | pairtype | libident;
+wacctype: basetype | arraytype | pairtype | functype | futuretype | chantype | libident typeargs?;
 
-basetype: INT | BOOL | CHAR | STRING | LOCK | SEMA;
+basetype: INT | BOOL | CHAR | STRING | LOCK | SEMA | COND;
 
-arraytype: (pairtype | basetype) (LBRACKET RBRACKET)+;
+arraytype: (pairtype | basetype | futuretype | chantype | libident typeargs?) (LBRACKET RBRACKET)+;
 
 pairtype: PAIR LPAREN pairelemtype COMMA pairelemtype RPAREN;
 
-pairelemtype: basetype | arraytype | PAIR;
+pairelemtype: basetype | arraytype | libident typeargs? | PAIR;
 
 expr:
     intliter {
@@ -121,6 +139,7 @@ expr:
     | fieldident       # exprIdent
     | arrayelem        # exprArrayElem
     | semaliter        # exprSemaLiter
+    | lambda           # exprLambda
     | CHAR_LITER       # exprCharLiter
     | unaryoper expr   # exprUnaryOp
     | <assoc=left> left = expr op = (STAR | DIV | MOD) right = expr  # exprBinop
@@ -137,7 +156,7 @@ expr:
     | expr QMARK expr COLON expr                                     # exprTernaryOp
     | LPAREN expr RPAREN                                             # exprBracketed;
 
-unaryoper: NOT | LEN | ORD | CHR | MINUS | TRYLOCK;
+unaryoper: NOT | LEN | ORD | CHR | MINUS | TRYLOCK | JOIN;
 trailingUnOper: INC | DEC;
 
 arrayelem: fieldident (LBRACKET expr RBRACKET)+;
@@ -149,3 +168,15 @@ semaliter: SEMA LPAREN intliter RPAREN;
 arrayliter: LBRACKET (expr (COMMA expr)*)? RBRACKET;
 
 pairliter: NULL;
+
+functype: FN LPAREN (wacctype (COMMA wacctype)*)? RPAREN ARROW wacctype;
+
+lambda: FN LPAREN paramlist? RPAREN ARROW wacctype IS funcbody END;
+
+typeparams: LESS ident (COMMA ident)* GREATER;
+
+typeargs: LESS wacctype (COMMA wacctype)* GREATER;
+
+futuretype: FUTURE LESS wacctype GREATER;
+
+chantype: CHAN LESS wacctype GREATER;
@@ -4,7 +4,7 @@ if test -f "input.s"; then
     rm input.s
 fi
 
-./compile $1 || exit $?
+./compile -g $1 || exit $?
 
 inputPath=$(dirname $1)/input.s
 mv $inputPath input.s
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"wacc_32/assembly"
+	"wacc_32/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	callsProgram         = "testdata/targets/calls.wacc"
+	runtimeErrorsProgram = "testdata/targets/runtimeErrors.wacc"
+)
+
+//compileFor returns the assembly generated for a file on a target
+func compileFor(t *testing.T, target, file string) string {
+	codeGen, err := assembly.NewCodeGenerator(target)
+	assert.NoError(t, err)
+	code, ok := compile(file, codeGen)
+	assert.True(t, ok)
+	return code
+}
+
+//function returns the code from a label to the next label at the start of a line
+//which isn't local
+func function(code, label string) string {
+	start := strings.Index(code, "\n"+label+":\n")
+	if start < 0 {
+		return ""
+	}
+	body := code[start+len(label)+3:]
+	for _, line := range strings.SplitAfter(body, "\n") {
+		if strings.HasSuffix(line, ":\n") && !strings.HasPrefix(line, ".") {
+			return body[:strings.Index(body, line)]
+		}
+	}
+	return body
+}
+
+//assertCallsDefined checks that every runtime function called is defined
+func assertCallsDefined(t *testing.T, code string, call *regexp.Regexp) {
+	for _, match := range call.FindAllStringSubmatch(code, -1) {
+		assert.Contains(t, code, "\n"+match[1]+":\n")
+	}
+}
+
+//TestAarch64CallingConvention checks that wacc functions are passed their arguments
+//on the stack and return in x0, C functions are passed theirs in x0 and every
+//function saves the frame pointer and link register
+func TestAarch64CallingConvention(t *testing.T) {
+	code := compileFor(t, "aarch64", callsProgram)
+
+	f := function(code, "f")
+	assert.True(t, strings.HasPrefix(f, "\tstp x29, x30, [sp, #-16]!\n\tmov x29, sp\n"), f)
+	assert.Contains(t, f, "\tldp x29, x30, [sp], #16\n\tret\n")
+	assert.Regexp(t, `\tmov x0, x\d+\n`, f)
+
+	main := function(code, "main")
+	assert.Regexp(t, `\tstr x\d+, \[sp\]\n(?:.*\n)*?\tstr w\d+, \[sp, #8\]\n\tbl f\n\tmov x\d+, x0\n`, main)
+	assert.Contains(t, main, "\tmov x0, #12\n\tbl malloc\n")
+	assert.Regexp(t, `\tmov x0, x\d+\n\tbl exit\n`, main)
+}
+
+//TestAarch64PointerSize checks that references take 8 bytes on aarch64, in the
+//arguments of a function, in pairs and in arrays
+func TestAarch64PointerSize(t *testing.T) {
+	code := compileFor(t, "aarch64", callsProgram)
+	assert.Equal(t, types.Size(types.DoubleWord), types.PointerSize())
+
+	args := regexp.MustCompile(`\tldr x\d+, \[sp, #(\d+)\]\n\tldrsw x\d+, \[sp, #(\d+)\]\n`).FindStringSubmatch(function(code, "f"))
+	if assert.Len(t, args, 3) {
+		a, _ := strconv.Atoi(args[1])
+		b, _ := strconv.Atoi(args[2])
+		assert.Equal(t, 8, b-a)
+	}
+
+	main := function(code, "main")
+	assert.Regexp(t, `\tmov x0, #16\n\tbl malloc\n\tmov x\d+, x0\n\tstr x\d+, \[x\d+\]\n\tstr x\d+, \[x\d+, #8\]\n`, main)
+	assert.Contains(t, main, "\tmov x0, #28\n\tbl malloc\n")
+	assert.Regexp(t, `\tstr x\d+, \[x\d+, #20\]\n`, main)
+}
+
+//TestAarch64RuntimeErrors checks that each operation which can fail calls its check,
+//that every check is defined and that errors exit with -1
+func TestAarch64RuntimeErrors(t *testing.T) {
+	code := compileFor(t, "aarch64", runtimeErrorsProgram)
+
+	main := function(code, "main")
+	for _, check := range []string{"p_check_array_index_out_of_bounds", "p_check_divide_by_zero", "p_check_int_overflow", "p_check_null_pointer"} {
+		assert.Contains(t, main, "\tbl "+check+"\n")
+	}
+	assert.Regexp(t, `\tadds? x\d+, x\d+, x\d+\n\tcmp x\d+, w\d+, sxtw\n\tbl p_check_int_overflow\n`, main)
+	assert.Regexp(t, `\tbl p_check_divide_by_zero\n\tsdiv w16, w0, w1\n`, main)
+	assertCallsDefined(t, code, regexp.MustCompile(`\tbl (p_\w+)\n`))
+
+	printError := function(code, "p_print_error")
+	assert.Contains(t, printError, "\tmov x0, #-1\n\tbl exit\n")
+	for _, err := range []string{"ArrayIndexNegativeError", "ArrayIndexTooLargeError", "DivideByZeroError", "IntegerOverflowError", "NullPointerReferenceError"} {
+		assert.Contains(t, code, "\nerr_"+err+":\n")
+		assert.Contains(t, code, "\tadrp x0, err_"+err+"\n\tadd x0, x0, :lo12:err_"+err+"\n\tbl p_print_error\n")
+	}
+}
@@ -0,0 +1,40 @@
+package aarch64
+
+import (
+	architecture "wacc_32/assembly/architectures"
+	ins "wacc_32/assembly/instructions"
+	"wacc_32/types"
+)
+
+//Register numbers which have a special meaning on aarch64
+const (
+	framePointer   = 29
+	linkRegister   = 30
+	stackPointer   = 31
+	pc             = 32 //pseudo register, popping it returns from the function
+	scratch        = 16
+	addrScratch    = 17
+	accumulator    = 9
+	returnRegister = 0
+)
+
+func Config() architecture.Config {
+	return architecture.Config{
+		ProgramCounter:  pc,
+		LinkRegister:    linkRegister,
+		StackPointer:    stackPointer,
+		ScratchRegister: scratch,
+		Accumulator:     accumulator,
+		ReturnRegister:  returnRegister,
+		CalleeSavedRegs: 0xF,        //x0-x3, used to pass arguments
+		CallerSavedRegs: 0x1FF80000, //x19-x28, preserved across libc calls
+		PointerSize:     types.DoubleWord,
+		FrameHeaderSize: 16, //stp x29, x30
+		FrameAlignment:  16,
+		OverflowCond:    ins.NE,
+		MutexSize:       48,
+		MutexAttrSize:   8,
+		SemaphoreSize:   32,
+		CondSize:        48,
+	}
+}
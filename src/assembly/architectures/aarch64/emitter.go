@@ -0,0 +1,516 @@
+package aarch64
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	architecture "wacc_32/assembly/architectures"
+	ins "wacc_32/assembly/instructions"
+	"wacc_32/types"
+)
+
+var _ architecture.Emitter = Emitter{}
+
+//Emitter converts our internal assembly into aarch64 GNU assembly
+//Integers are kept sign extended in the 64 bit x registers, so every checked
+//arithmetic instruction is followed by a compare which sets NE on overflow
+type Emitter struct{}
+
+const (
+	addImmSize  = 4096
+	movImmSize  = 65536
+	ldurImmSize = 256
+)
+
+//directives maps the directives used by the builtins to the symbols they refer to
+var directives = map[ins.Directive]string{
+	".streams": "stdout",
+}
+
+var condStrings = map[ins.Cond]string{
+	ins.EQ: "eq",
+	ins.NE: "ne",
+	ins.GT: "gt",
+	ins.LE: "le",
+	ins.GE: "ge",
+	ins.LT: "lt",
+	ins.AL: "al",
+	ins.NV: "nv",
+	ins.VS: "vs",
+}
+
+func (a64 Emitter) Emit(bss, instr ins.Instruction) string {
+	codeString := ".data\n"
+	codeString += a64.EmitInstruction(bss)
+	codeString += "\n.text\n.global main\n"
+	codeString += a64.EmitInstruction(instr)
+	return codeString + "\n"
+}
+
+func (a64 Emitter) EmitInstruction(instruction ins.Instruction) string {
+	switch instr := instruction.(type) {
+	case ins.Instructions:
+		return a64.EmitInstructions(instr)
+	case ins.NOOP:
+		return a64.EmitNOOP(instr)
+	case ins.Pool:
+		return a64.EmitPool(instr)
+	case ins.Exit:
+		return a64.EmitExit(instr)
+	case ins.Label:
+		return a64.EmitLabel(instr)
+	case ins.FunctionCall:
+		return a64.EmitFunctionCall(instr)
+	case ins.IndirectCall:
+		return a64.EmitIndirectCall(instr)
+	case ins.Branch:
+		return a64.EmitBranch(instr)
+	case ins.Move:
+		return a64.EmitMove(instr)
+	case ins.Compare:
+		return a64.EmitCompare(instr)
+	case ins.Add:
+		return a64.EmitAdd(instr)
+	case ins.Sub:
+		return a64.EmitSub(instr)
+	case ins.Mult:
+		return a64.EmitMult(instr)
+	case ins.Div:
+		return a64.EmitDiv(instr)
+	case ins.Mod:
+		return a64.EmitMod(instr)
+	case ins.Xor:
+		return a64.EmitXor(instr)
+	case ins.StringLiteral:
+		return a64.EmitStringLiteral(instr)
+	case ins.AddressTable:
+		return a64.EmitAddressTable(instr)
+	case ins.BoolExpr:
+		return a64.EmitBoolExpr(instr)
+	case ins.And:
+		return a64.EmitAnd(instr)
+	case ins.Or:
+		return a64.EmitOr(instr)
+	case ins.Neg:
+		return a64.EmitNeg(instr)
+	case types.Size:
+		return a64.EmitSize(instr)
+	case ins.Load:
+		return a64.EmitLoad(instr)
+	case ins.StackInstr:
+		return a64.EmitStackInstr(instr)
+	case ins.Store:
+		return a64.EmitStore(instr)
+	case ins.StoreHeap:
+		return a64.EmitStoreHeap(instr)
+	case ins.FreeHeap:
+		return a64.EmitFreeHeap(instr)
+	case ins.SourceFile:
+		return a64.EmitSourceFile(instr)
+	case ins.Loc:
+		return a64.EmitLoc(instr)
+	case ins.FrameInfo:
+		return a64.EmitFrameInfo(instr)
+	case ins.DebugLabel:
+		return a64.EmitLabel(ins.Label(instr))
+	case ins.DebugInfo:
+		return a64.EmitDebugInfo(instr)
+	case ins.Operand:
+		return a64.EmitOperand(instr)
+	}
+	return ""
+}
+
+func (a64 Emitter) EmitInstructions(is ins.Instructions) string {
+	strs := make([]string, 0, len(is))
+	for _, instr := range is {
+		if str := a64.EmitInstruction(instr); str != "" {
+			strs = append(strs, str)
+		}
+	}
+	return strings.Join(strs, "\n")
+}
+
+func (a64 Emitter) EmitNOOP(_ ins.NOOP) string {
+	return ""
+}
+
+func (a64 Emitter) EmitPool(_ ins.Pool) string {
+	return ".ltorg"
+}
+
+func (a64 Emitter) EmitExit(e ins.Exit) string {
+	return a64.loadOperand(returnRegister, e.Code, types.Word) + "\n\tbl exit"
+}
+
+func (a64 Emitter) EmitLabel(l ins.Label) string {
+	return l.Name + ":"
+}
+
+func (a64 Emitter) EmitFunctionCall(fc ins.FunctionCall) string {
+	return "\tbl " + fc.Name
+}
+
+func (a64 Emitter) EmitIndirectCall(ic ins.IndirectCall) string {
+	return "\tblr " + a64.EmitRegister(ic.Reg)
+}
+
+func (a64 Emitter) EmitBranch(b ins.Branch) string {
+	if b.Condition == ins.AL {
+		return "\tb " + b.Label
+	}
+	return fmt.Sprintf("\tb.%s %s", condStrings[b.Condition], b.Label)
+}
+
+func (a64 Emitter) EmitMove(m ins.Move) string {
+	return a64.loadOperand(m.Dest, m.Src, types.DoubleWord)
+}
+
+func (a64 Emitter) EmitCompare(c ins.Compare) string {
+	setup, left := a64.inRegister(c.Left, scratch)
+	return setup + a64.compare(left, c.Right)
+}
+
+func (a64 Emitter) EmitAdd(a ins.Add) string {
+	return a64.arithmetic("add", "sub", a.Dest, a.Left, a.Right)
+}
+
+func (a64 Emitter) EmitSub(s ins.Sub) string {
+	return a64.arithmetic("sub", "add", s.Dest, s.Left, s.Right)
+}
+
+func (a64 Emitter) EmitMult(m ins.Mult) string {
+	lSetup, l := a64.inRegister(m.Left, scratch)
+	rSetup, r := a64.inRegister(m.Right, addrScratch)
+	d := a64.EmitRegister(m.Dest)
+	return lSetup + rSetup + fmt.Sprintf("\tsmull %s, %s, %s\n", d, wReg(l), wReg(r)) + overflowCheck(m.Dest)
+}
+
+//divide moves the operands into x0 and x1, checks for a zero divisor then runs
+//the 32 bit division, leaving the quotient in the scratch register
+func (a64 Emitter) divide(left, right ins.Operand) string {
+	return a64.loadOperand(0, left, types.Word) + "\n" +
+		a64.loadOperand(1, right, types.Word) + "\n" +
+		"\tbl p_check_divide_by_zero\n" +
+		fmt.Sprintf("\tsdiv %s, w0, w1\n", wReg(scratch))
+}
+
+func (a64 Emitter) EmitDiv(d ins.Div) string {
+	return a64.divide(d.Left, d.Right) +
+		fmt.Sprintf("\tsxtw %s, %s", a64.EmitRegister(d.Dest), wReg(scratch))
+}
+
+func (a64 Emitter) EmitMod(m ins.Mod) string {
+	return a64.divide(m.Left, m.Right) +
+		fmt.Sprintf("\tmsub %s, %s, w1, w0\n", wReg(scratch), wReg(scratch)) +
+		fmt.Sprintf("\tsxtw %s, %s", a64.EmitRegister(m.Dest), wReg(scratch))
+}
+
+func (a64 Emitter) EmitXor(x ins.Xor) string {
+	return a64.logical("eor", x.Dest, x.Left, x.Right)
+}
+
+func (a64 Emitter) EmitStringLiteral(sl ins.StringLiteral) string {
+	alignLine := "\t.balign 4\n"
+	msgLine := fmt.Sprintf("%s:\n", sl.ID)
+	wordLine := fmt.Sprintf("\t.word %d\n", sl.Size)
+	asciiLine := fmt.Sprintf("\t.ascii %s", sl.String)
+
+	return alignLine + msgLine + wordLine + asciiLine
+}
+
+func (a64 Emitter) EmitAddressTable(at ins.AddressTable) string {
+	lines := []string{"\t.balign 8", at.ID + ":"}
+	for _, label := range at.Labels {
+		lines = append(lines, "\t.quad "+label)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (a64 Emitter) EmitBoolExpr(be ins.BoolExpr) string {
+	setup, left := a64.inRegister(be.Left, scratch)
+	cmpLine := setup + a64.compare(left, be.Right) + "\n"
+	return cmpLine + fmt.Sprintf("\tcset %s, %s", a64.EmitRegister(be.Dest), condStrings[be.True])
+}
+
+func (a64 Emitter) EmitAnd(a ins.And) string {
+	return a64.logical("and", a.Dest, a.Left, a.Right)
+}
+
+func (a64 Emitter) EmitOr(o ins.Or) string {
+	return a64.logical("orr", o.Dest, o.Left, o.Right)
+}
+
+func (a64 Emitter) EmitNeg(n ins.Neg) string {
+	r := a64.EmitRegister(n.Reg)
+	return fmt.Sprintf("\tneg %s, %s\n", r, r) + overflowCheck(n.Reg)
+}
+
+func (a64 Emitter) EmitSize(types.Size) string {
+	return ""
+}
+
+func (a64 Emitter) EmitLoad(ld ins.Load) string {
+	return a64.loadOperand(ld.Dest, ld.Src, ld.Size)
+}
+
+func (a64 Emitter) EmitStackInstr(st ins.StackInstr) string {
+	strs := make([]string, len(st.Regs))
+	for i, reg := range st.Regs {
+		if st.IsPush() {
+			//push {a, b} stores b first, so that a ends up at the lowest address
+			strs[len(strs)-1-i] = a64.push(reg)
+		} else {
+			strs[i] = a64.pop(reg)
+		}
+	}
+	return strings.Join(strs, "\n")
+}
+
+func (a64 Emitter) push(reg ins.Register) string {
+	if reg == linkRegister {
+		return "\tstp x29, x30, [sp, #-16]!\n\tmov x29, sp"
+	}
+	return fmt.Sprintf("\tstr %s, [sp, #-16]!", a64.EmitRegister(reg))
+}
+
+func (a64 Emitter) pop(reg ins.Register) string {
+	switch reg {
+	case linkRegister:
+		return "\tldp x29, x30, [sp], #16"
+	case pc:
+		return "\tldp x29, x30, [sp], #16\n\tret"
+	}
+	return fmt.Sprintf("\tldr %s, [sp], #16", a64.EmitRegister(reg))
+}
+
+func (a64 Emitter) EmitStore(st ins.Store) string {
+	src := a64.EmitRegister(st.Src)
+	instr := "str"
+	switch st.Size {
+	case types.Byte:
+		instr, src = "strb", wReg(st.Src)
+	case types.HalfWord:
+		instr, src = "strh", wReg(st.Src)
+	case types.Word:
+		src = wReg(st.Src)
+	}
+	addr, ok := st.Dest.(ins.Address)
+	if !ok {
+		return ""
+	}
+	return a64.memory(instr, src, addr, st.Size)
+}
+
+//EmitStoreHeap mallocs size bytes and stores the low word of op at the start
+func (a64 Emitter) EmitStoreHeap(st ins.StoreHeap) string {
+	malloc := a64.loadOperand(returnRegister, st.Size, types.Word) + "\n\tbl malloc\n"
+	setup, op := a64.inRegister(st.Op, scratch)
+	addr := ins.Address{Reg: returnRegister, Offset: ins.Immediate(st.Offset)}
+	return malloc + setup + a64.memory("str", wReg(op), addr, types.Word)
+}
+
+func (a64 Emitter) EmitFreeHeap(fh ins.FreeHeap) string {
+	return fmt.Sprintf("\tmov x0, %s\n\tbl free", a64.EmitRegister(fh.Reg))
+}
+
+//frameHeader is saved by stp x29, x30
+var frameHeader = []architecture.SavedRegister{{Name: "x29", Offset: -16}, {Name: "x30", Offset: -8}}
+
+func (a64 Emitter) EmitSourceFile(f ins.SourceFile) string {
+	return architecture.EmitSourceFile(f)
+}
+
+func (a64 Emitter) EmitLoc(l ins.Loc) string {
+	return architecture.EmitLoc(l)
+}
+
+func (a64 Emitter) EmitFrameInfo(fi ins.FrameInfo) string {
+	return architecture.EmitFrameInfo(fi, frameHeader)
+}
+
+//EmitDebugInfo numbers registers the same way as DWARF
+func (a64 Emitter) EmitDebugInfo(d ins.DebugInfo) string {
+	return architecture.EmitDebugInfo(d, types.DoubleWord, func(r ins.Register) int { return int(r) })
+}
+
+func (a64 Emitter) EmitOperand(op ins.Operand) string {
+	switch operand := op.(type) {
+	case ins.Address:
+		return a64.EmitAddress(operand)
+	case ins.PseudoImmediate:
+		return a64.EmitPseudoImmediate(operand)
+	case ins.Register:
+		return a64.EmitRegister(operand)
+	case ins.Immediate:
+		return a64.EmitImmediate(operand)
+	case ins.Variable:
+		return a64.EmitVariable(operand)
+	case ins.FunctionPointer:
+		return string(operand)
+	case ins.Directive:
+		return string(operand)
+	}
+	return ""
+}
+
+func (a64 Emitter) EmitImmediate(i ins.Immediate) string {
+	return "#" + strconv.Itoa(int(i))
+}
+
+func (a64 Emitter) EmitPseudoImmediate(pi ins.PseudoImmediate) string {
+	return "=" + strconv.Itoa(int(pi))
+}
+
+func (a64 Emitter) EmitAddress(a ins.Address) string {
+	str := "[" + a64.EmitRegister(a.Reg)
+	if a.Offset != 0 {
+		str += ", " + a64.EmitImmediate(a.Offset)
+	}
+	return str + "]"
+}
+
+func (a64 Emitter) EmitVariable(v ins.Variable) string {
+	return string(v)
+}
+
+func (a64 Emitter) EmitRegister(r ins.Register) string {
+	switch r {
+	case stackPointer:
+		return "sp"
+	case pc:
+		return "pc"
+	}
+	return "x" + strconv.Itoa(int(r))
+}
+
+//wReg returns the name of the bottom 32 bits of a register
+func wReg(r ins.Register) string {
+	if r == stackPointer {
+		return "wsp"
+	}
+	return "w" + strconv.Itoa(int(r))
+}
+
+//overflowCheck sets NE if reg no longer holds a sign extended 4-byte int
+func overflowCheck(reg ins.Register) string {
+	return fmt.Sprintf("\tcmp x%d, w%d, sxtw", reg, reg)
+}
+
+//loadOperand puts the value of op into dest, memory is read with the given size
+func (a64 Emitter) loadOperand(dest ins.Register, op ins.Operand, size types.Size) string {
+	d := a64.EmitRegister(dest)
+	switch src := op.(type) {
+	case ins.Immediate:
+		return a64.loadImmediate(d, int(src))
+	case ins.PseudoImmediate:
+		return a64.loadImmediate(d, int(src))
+	case ins.Register:
+		if src == dest {
+			return ""
+		}
+		return fmt.Sprintf("\tmov %s, %s", d, a64.EmitRegister(src))
+	case ins.Address:
+		switch size {
+		case types.Byte:
+			return a64.memory("ldrb", wReg(dest), src, size)
+		case types.HalfWord:
+			return a64.memory("ldrsh", d, src, size)
+		case types.Word:
+			return a64.memory("ldrsw", d, src, size)
+		}
+		return a64.memory("ldr", d, src, size)
+	case ins.Variable:
+		return loadAddress(d, string(src))
+	case ins.FunctionPointer:
+		return loadAddress(d, string(src))
+	case ins.Directive:
+		//Directives refer to libc globals, so go through the GOT
+		sym := directives[src]
+		return fmt.Sprintf("\tadrp %s, :got:%s\n\tldr %s, [%s, :got_lo12:%s]", d, sym, d, d, sym)
+	}
+	return ""
+}
+
+//loadAddress puts the address of a label into reg, this is position independent
+func loadAddress(reg, label string) string {
+	return fmt.Sprintf("\tadrp %s, %s\n\tadd %s, %s, :lo12:%s", reg, label, reg, reg, label)
+}
+
+//loadImmediate uses a mov when the value fits in 16 bits, otherwise the literal pool
+func (a64 Emitter) loadImmediate(reg string, val int) string {
+	if -movImmSize <= val && val < movImmSize {
+		return fmt.Sprintf("\tmov %s, #%d", reg, val)
+	}
+	return fmt.Sprintf("\tldr %s, =%d", reg, val)
+}
+
+//inRegister loads op into tmp if it is not already a register
+func (a64 Emitter) inRegister(op ins.Operand, tmp ins.Register) (string, ins.Register) {
+	if reg, ok := op.(ins.Register); ok {
+		return "", reg
+	}
+	return a64.loadOperand(tmp, op, types.DoubleWord) + "\n", tmp
+}
+
+//memory emits a load or store, using the address scratch register when the
+//offset cannot be encoded in the instruction
+func (a64 Emitter) memory(instr, reg string, addr ins.Address, size types.Size) string {
+	offset := int(addr.Offset)
+	scaled := offset >= 0 && offset%int(size) == 0 && offset/int(size) < addImmSize
+	if scaled || (-ldurImmSize <= offset && offset < ldurImmSize) {
+		return fmt.Sprintf("\t%s %s, %s", instr, reg, a64.EmitAddress(addr))
+	}
+	return a64.loadImmediate(a64.EmitRegister(addrScratch), offset) +
+		fmt.Sprintf("\n\t%s %s, [%s, %s]", instr, reg, a64.EmitRegister(addr.Reg), a64.EmitRegister(addrScratch))
+}
+
+//compare emits a cmp, turning negative immediates into a cmn
+func (a64 Emitter) compare(left ins.Register, right ins.Operand) string {
+	l := a64.EmitRegister(left)
+	if imm, ok := right.(ins.Immediate); ok {
+		switch {
+		case 0 <= imm && imm < addImmSize:
+			return fmt.Sprintf("\tcmp %s, #%d", l, imm)
+		case -addImmSize < imm && imm < 0:
+			return fmt.Sprintf("\tcmn %s, #%d", l, -imm)
+		}
+	}
+	setup, r := a64.inRegister(right, addrScratch)
+	return setup + fmt.Sprintf("\tcmp %s, %s", l, a64.EmitRegister(r))
+}
+
+//arithmetic emits an add or sub, checking for overflow unless the stack pointer is involved
+func (a64 Emitter) arithmetic(instr, inverse string, dest ins.Register, left, right ins.Operand) string {
+	lSetup, l := a64.inRegister(left, scratch)
+	d := a64.EmitRegister(dest)
+	var op string
+	imm, isImm := right.(ins.Immediate)
+	switch {
+	case isImm && 0 <= imm && imm < addImmSize:
+		op = fmt.Sprintf("\t%s %s, %s, #%d", instr, d, a64.EmitRegister(l), imm)
+	case isImm && -addImmSize < imm && imm < 0:
+		op = fmt.Sprintf("\t%s %s, %s, #%d", inverse, d, a64.EmitRegister(l), -imm)
+	default:
+		rSetup, r := a64.inRegister(right, addrScratch)
+		op = rSetup + fmt.Sprintf("\t%s %s, %s, %s", instr, d, a64.EmitRegister(l), a64.EmitRegister(r))
+	}
+	if dest == stackPointer || l == stackPointer {
+		return lSetup + op
+	}
+	return lSetup + op + "\n" + overflowCheck(dest)
+}
+
+//logical emits a bitwise instruction, only #1 is used as an immediate operand
+func (a64 Emitter) logical(instr string, dest ins.Register, left, right ins.Operand) string {
+	lSetup, l := a64.inRegister(left, scratch)
+	var r string
+	if imm, ok := right.(ins.Immediate); ok && imm == 1 {
+		r = a64.EmitImmediate(imm)
+	} else {
+		rSetup, reg := a64.inRegister(right, addrScratch)
+		lSetup += rSetup
+		r = a64.EmitRegister(reg)
+	}
+	return lSetup + fmt.Sprintf("\t%s %s, %s, %s", instr, a64.EmitRegister(dest), a64.EmitRegister(l), r)
+}
@@ -1,6 +1,10 @@
 package arm11
 
-import architecture "wacc_32/assembly/architectures"
+import (
+	architecture "wacc_32/assembly/architectures"
+	ins "wacc_32/assembly/instructions"
+	"wacc_32/types"
+)
 
 func Config() architecture.Config {
 	return architecture.Config{
@@ -12,6 +16,13 @@ func Config() architecture.Config {
 		ReturnRegister:  0,
 		CalleeSavedRegs: 0xF,
 		CallerSavedRegs: 0x7F0,
-		NRegs:           11,
+		PointerSize:     types.Word,
+		FrameHeaderSize: types.Word,
+		FrameAlignment:  1,
+		OverflowCond:    ins.VS,
+		MutexSize:       6 * types.Word,
+		MutexAttrSize:   types.Word,
+		SemaphoreSize:   16,
+		CondSize:        48,
 	}
 }
@@ -68,6 +68,8 @@ func (arm Emitter) EmitInstruction(instruction ins.Instruction) string {
 		return arm.EmitLabel(instr)
 	case ins.FunctionCall:
 		return arm.EmitFunctionCall(instr)
+	case ins.IndirectCall:
+		return arm.EmitIndirectCall(instr)
 	case ins.Branch:
 		return arm.EmitBranch(instr)
 	case ins.Move:
@@ -88,6 +90,8 @@ func (arm Emitter) EmitInstruction(instruction ins.Instruction) string {
 		return arm.EmitXor(instr)
 	case ins.StringLiteral:
 		return arm.EmitStringLiteral(instr)
+	case ins.AddressTable:
+		return arm.EmitAddressTable(instr)
 	case ins.BoolExpr:
 		return arm.EmitBoolExpr(instr)
 	case ins.And:
@@ -108,6 +112,16 @@ func (arm Emitter) EmitInstruction(instruction ins.Instruction) string {
 		return arm.EmitStoreHeap(instr)
 	case ins.FreeHeap:
 		return arm.EmitFreeHeap(instr)
+	case ins.SourceFile:
+		return arm.EmitSourceFile(instr)
+	case ins.Loc:
+		return arm.EmitLoc(instr)
+	case ins.FrameInfo:
+		return arm.EmitFrameInfo(instr)
+	case ins.DebugLabel:
+		return arm.EmitLabel(ins.Label(instr))
+	case ins.DebugInfo:
+		return arm.EmitDebugInfo(instr)
 	case ins.Operand:
 		return arm.EmitOperand(instr)
 	case ins.Immediate:
@@ -154,6 +168,10 @@ func (arm Emitter) EmitFunctionCall(fc ins.FunctionCall) string {
 	return "\tbl " + fc.Name
 }
 
+func (arm Emitter) EmitIndirectCall(ic ins.IndirectCall) string {
+	return "\tblx " + arm.EmitRegister(ic.Reg)
+}
+
 func (arm Emitter) EmitBranch(b ins.Branch) string {
 	return fmt.Sprintf("\tb%s %s", b.Condition, b.Label)
 }
@@ -247,6 +265,14 @@ func (arm Emitter) EmitStringLiteral(sl ins.StringLiteral) string {
 
 	return msgLine + wordLine + asciiLine
 }
+
+func (arm Emitter) EmitAddressTable(at ins.AddressTable) string {
+	lines := []string{"\t.balign 4", at.ID + ":"}
+	for _, label := range at.Labels {
+		lines = append(lines, "\t.word "+label)
+	}
+	return strings.Join(lines, "\n")
+}
 func (arm Emitter) EmitBoolExpr(be ins.BoolExpr) string {
 	cmpLine := fmt.Sprintf("\tcmp %s, %s\n", arm.EmitOperand(be.Left), arm.EmitOperand((be.Right)))
 	movTrue := fmt.Sprintf("\tmov%s %s, #1\n", be.True.String(), arm.EmitRegister(be.Dest))
@@ -305,6 +331,26 @@ func (arm Emitter) EmitFreeHeap(fh ins.FreeHeap) string {
 
 }
 
+//frameHeader is saved by push {lr}
+var frameHeader = []architecture.SavedRegister{{Name: "lr", Offset: -4}}
+
+func (arm Emitter) EmitSourceFile(f ins.SourceFile) string {
+	return architecture.EmitSourceFile(f)
+}
+
+func (arm Emitter) EmitLoc(l ins.Loc) string {
+	return architecture.EmitLoc(l)
+}
+
+func (arm Emitter) EmitFrameInfo(fi ins.FrameInfo) string {
+	return architecture.EmitFrameInfo(fi, frameHeader)
+}
+
+//EmitDebugInfo numbers registers the same way as DWARF
+func (arm Emitter) EmitDebugInfo(d ins.DebugInfo) string {
+	return architecture.EmitDebugInfo(d, types.Word, func(r ins.Register) int { return int(r) })
+}
+
 func (arm Emitter) EmitOperand(op ins.Operand) string {
 	switch operand := op.(type) {
 	case ins.Address:
@@ -2,6 +2,7 @@ package architecture
 
 import (
 	ins "wacc_32/assembly/instructions"
+	"wacc_32/types"
 )
 
 //Config contains all information about an architecture that the wacc compiler needs
@@ -14,5 +15,19 @@ type Config struct {
 	ProgramCounter  ins.Register
 	CalleeSavedRegs uint64
 	CallerSavedRegs uint64
-	NRegs           int
+
+	//PointerSize is the size of a reference (string, array, pair, lock...)
+	PointerSize types.Size
+	//FrameHeaderSize is the number of bytes pushed by a function prologue
+	FrameHeaderSize int
+	//FrameAlignment is what stack frames are rounded up to, 1 means no rounding
+	FrameAlignment int
+	//OverflowCond is set after an arithmetic instruction overflows a 4-byte int
+	OverflowCond ins.Cond
+
+	//The sizes of the pthread and semaphore objects in the target's libc
+	MutexSize     int
+	MutexAttrSize int
+	SemaphoreSize int
+	CondSize      int
 }
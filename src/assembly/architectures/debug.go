@@ -0,0 +1,51 @@
+package architecture
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	ins "wacc_32/assembly/instructions"
+)
+
+//EmitSourceFile returns the directive declaring a source file, the same on every target
+func EmitSourceFile(f ins.SourceFile) string {
+	return fmt.Sprintf("\t.file %d %s", f.Index, strconv.Quote(f.Name))
+}
+
+//EmitLoc returns the directive starting the code for a line, the same on every target
+func EmitLoc(l ins.Loc) string {
+	return fmt.Sprintf("\t.loc %d %d %d", l.File, l.Line, l.Col)
+}
+
+//EmitFrameInfo returns the call frame directives of a frame change. saved are the
+//registers a target's frame header saves, with their offsets from the stack pointer
+//of the caller
+func EmitFrameInfo(fi ins.FrameInfo, saved []SavedRegister) string {
+	switch fi.Kind {
+	case ins.FrameTable:
+		return "\t.cfi_sections .debug_frame"
+	case ins.FrameStart:
+		return "\t.cfi_startproc"
+	case ins.FrameEnd:
+		return "\t.cfi_endproc"
+	case ins.FrameHeader:
+		strs := []string{fmt.Sprintf("\t.cfi_def_cfa_offset %d", fi.Offset)}
+		for _, reg := range saved {
+			strs = append(strs, fmt.Sprintf("\t.cfi_offset %s, %d", reg.Name, reg.Offset))
+		}
+		return strings.Join(strs, "\n")
+	case ins.FrameSize:
+		return fmt.Sprintf("\t.cfi_def_cfa_offset %d", fi.Offset)
+	case ins.FrameRemember:
+		return "\t.cfi_remember_state"
+	case ins.FrameRestore:
+		return "\t.cfi_restore_state"
+	}
+	return ""
+}
+
+//SavedRegister is a register saved at Offset from the stack pointer of the caller
+type SavedRegister struct {
+	Name   string
+	Offset int
+}
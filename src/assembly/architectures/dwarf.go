@@ -0,0 +1,482 @@
+package architecture
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	ins "wacc_32/assembly/instructions"
+	"wacc_32/types"
+)
+
+//DWARF tags, attributes, forms and operations used to describe a program
+const (
+	dwTagArrayType      = 0x01
+	dwTagFormalParam    = 0x05
+	dwTagLexicalBlock   = 0x0b
+	dwTagMember         = 0x0d
+	dwTagPointerType    = 0x0f
+	dwTagCompileUnit    = 0x11
+	dwTagStructType     = 0x13
+	dwTagTypedef        = 0x16
+	dwTagSubrangeType   = 0x21
+	dwTagBaseType       = 0x24
+	dwTagSubprogram     = 0x2e
+	dwTagVariable       = 0x34
+	dwAtLocation        = 0x02
+	dwAtName            = 0x03
+	dwAtByteSize        = 0x0b
+	dwAtStmtList        = 0x10
+	dwAtLowPc           = 0x11
+	dwAtHighPc          = 0x12
+	dwAtLanguage        = 0x13
+	dwAtCompDir         = 0x1b
+	dwAtProducer        = 0x25
+	dwAtDataMemberLoc   = 0x38
+	dwAtDeclFile        = 0x3a
+	dwAtDeclLine        = 0x3b
+	dwAtEncoding        = 0x3e
+	dwAtFrameBase       = 0x40
+	dwAtType            = 0x49
+	dwFormAddr          = 0x01
+	dwFormData2         = 0x05
+	dwFormData4         = 0x06
+	dwFormString        = 0x08
+	dwFormData1         = 0x0b
+	dwFormUdata         = 0x0f
+	dwFormRef4          = 0x13
+	dwFormSecOffset     = 0x17
+	dwFormExprloc       = 0x18
+	dwAteBoolean        = 0x02
+	dwAteSigned         = 0x05
+	dwAteSignedChar     = 0x06
+	dwLangC99           = 0x0c
+	dwOpReg0            = 0x50
+	dwOpRegx            = 0x90
+	dwOpFbreg           = 0x91
+	dwOpCallFrameCfa    = 0x9c
+	dwarfVersion        = 4
+	dwarfProducer       = "wacc_32"
+	dwarfInfoLabel      = ".Ldebug_info0"
+	dwarfInfoEndLabel   = ".Ldebug_info_end0"
+	dwarfAbbrevLabel    = ".Ldebug_abbrev0"
+	dwarfLineLabel      = ".Ldebug_line0"
+	dwarfTypeLabelStart = ".Ldbg_type"
+)
+
+//Abbreviation codes of the kinds of entries
+const (
+	abbrevCompileUnit = iota + 1
+	abbrevSubprogram
+	abbrevLexicalBlock
+	abbrevVariable
+	abbrevFormalParam
+	abbrevBaseType
+	abbrevPointerType
+	abbrevVoidPointerType
+	abbrevStructType
+	abbrevMember
+	abbrevArrayType
+	abbrevSubrangeType
+	abbrevTypedef
+)
+
+//abbrev declares the attributes and forms of a kind of entry
+type abbrev struct {
+	tag      int
+	children bool
+	attrs    [][2]int
+}
+
+var abbrevs = map[int]abbrev{
+	abbrevCompileUnit: {dwTagCompileUnit, true, [][2]int{
+		{dwAtProducer, dwFormString}, {dwAtLanguage, dwFormData2}, {dwAtName, dwFormString},
+		{dwAtCompDir, dwFormString}, {dwAtLowPc, dwFormAddr}, {dwAtHighPc, dwFormData4},
+		{dwAtStmtList, dwFormSecOffset},
+	}},
+	abbrevSubprogram: {dwTagSubprogram, true, [][2]int{
+		{dwAtName, dwFormString}, {dwAtDeclFile, dwFormUdata}, {dwAtDeclLine, dwFormUdata},
+		{dwAtLowPc, dwFormAddr}, {dwAtHighPc, dwFormData4}, {dwAtFrameBase, dwFormExprloc},
+	}},
+	abbrevLexicalBlock: {dwTagLexicalBlock, true, [][2]int{
+		{dwAtLowPc, dwFormAddr}, {dwAtHighPc, dwFormData4},
+	}},
+	abbrevVariable: {dwTagVariable, false, [][2]int{
+		{dwAtName, dwFormString}, {dwAtDeclFile, dwFormUdata}, {dwAtDeclLine, dwFormUdata},
+		{dwAtType, dwFormRef4}, {dwAtLocation, dwFormExprloc},
+	}},
+	abbrevFormalParam: {dwTagFormalParam, false, [][2]int{
+		{dwAtName, dwFormString}, {dwAtDeclFile, dwFormUdata}, {dwAtDeclLine, dwFormUdata},
+		{dwAtType, dwFormRef4}, {dwAtLocation, dwFormExprloc},
+	}},
+	abbrevBaseType: {dwTagBaseType, false, [][2]int{
+		{dwAtName, dwFormString}, {dwAtEncoding, dwFormData1}, {dwAtByteSize, dwFormData1},
+	}},
+	abbrevPointerType: {dwTagPointerType, false, [][2]int{
+		{dwAtByteSize, dwFormData1}, {dwAtType, dwFormRef4},
+	}},
+	abbrevVoidPointerType: {dwTagPointerType, false, [][2]int{
+		{dwAtByteSize, dwFormData1},
+	}},
+	abbrevStructType: {dwTagStructType, true, [][2]int{
+		{dwAtName, dwFormString}, {dwAtByteSize, dwFormUdata},
+	}},
+	abbrevMember: {dwTagMember, false, [][2]int{
+		{dwAtName, dwFormString}, {dwAtType, dwFormRef4}, {dwAtDataMemberLoc, dwFormUdata},
+	}},
+	abbrevArrayType: {dwTagArrayType, true, [][2]int{
+		{dwAtType, dwFormRef4},
+	}},
+	abbrevSubrangeType: {dwTagSubrangeType, false, nil},
+	abbrevTypedef: {dwTagTypedef, false, [][2]int{
+		{dwAtName, dwFormString}, {dwAtType, dwFormRef4},
+	}},
+}
+
+//DwarfRegister returns the DWARF number of a register of a target
+type DwarfRegister func(ins.Register) int
+
+//EmitDebugInfo returns the DWARF sections describing the functions of a program, the
+//variables in each of their scopes and the types of the variables, the same on every
+//target apart from the size of addresses and the numbering of registers.
+//Wacc types are described as the C types with the same layout, so debuggers can print
+//them as C:
+//
+//	string       struct string { int length; char data[]; } *
+//	T[]          struct T[] { int length; T data[]; } *
+//	pair(T, U)   struct pair(T,U) { T fst; U snd; } *
+//	struct S     struct S { <fields without padding> } *
+//
+//Bare pairs, locks and semaphores are opaque pointers
+func EmitDebugInfo(d ins.DebugInfo, pointerSize types.Size, reg DwarfRegister) string {
+	w := &dwarfWriter{
+		pointerSize: pointerSize,
+		reg:         reg,
+		userTypes:   d.UserTypes,
+		types:       make(map[string]string),
+	}
+	w.section(".debug_info")
+	w.label(dwarfInfoLabel)
+	w.data(types.Word, dwarfInfoEndLabel+"-"+dwarfInfoLabel+"-4")
+	w.data(types.HalfWord, strconv.Itoa(dwarfVersion))
+	w.data(types.Word, dwarfAbbrevLabel)
+	w.data(types.Byte, strconv.Itoa(int(pointerSize)))
+
+	w.entry(abbrevCompileUnit)
+	w.string(dwarfProducer)
+	w.data(types.HalfWord, strconv.Itoa(dwLangC99))
+	w.string(d.File)
+	w.string(filepath.Dir(d.File))
+	w.data(pointerSize, d.Start)
+	w.data(types.Word, d.End+"-"+d.Start)
+	w.data(types.Word, dwarfLineLabel)
+	for _, fn := range d.Funcs {
+		w.function(fn)
+	}
+	for len(w.pending) > 0 {
+		wt := w.pending[0]
+		w.pending = w.pending[1:]
+		w.typeEntry(wt)
+	}
+	w.end()
+	w.label(dwarfInfoEndLabel)
+
+	w.section(".debug_abbrev")
+	w.label(dwarfAbbrevLabel)
+	for code := abbrevCompileUnit; code <= abbrevTypedef; code++ {
+		a := abbrevs[code]
+		children := 0
+		if a.children {
+			children = 1
+		}
+		w.uleb(code)
+		w.uleb(a.tag)
+		w.data(types.Byte, strconv.Itoa(children))
+		for _, attr := range a.attrs {
+			w.uleb(attr[0])
+			w.uleb(attr[1])
+		}
+		w.uleb(0)
+		w.uleb(0)
+	}
+	w.uleb(0)
+
+	//The assembler adds the line table after the label
+	w.section(".debug_line")
+	w.label(dwarfLineLabel)
+	return strings.Join(w.lines, "\n")
+}
+
+//dwarfWriter builds the lines of the DWARF sections, types are written after the
+//functions once every type a variable refers to is known
+type dwarfWriter struct {
+	lines       []string
+	pointerSize types.Size
+	reg         DwarfRegister
+	userTypes   map[string]types.UserType
+	types       map[string]string
+	pending     []types.WaccType
+}
+
+func (w *dwarfWriter) line(format string, args ...interface{}) {
+	w.lines = append(w.lines, fmt.Sprintf(format, args...))
+}
+
+func (w *dwarfWriter) section(name string) {
+	w.line("\t.section %s,\"\",%%progbits", name)
+}
+
+func (w *dwarfWriter) label(name string) {
+	w.line("%s:", name)
+}
+
+//data writes a value of the given size
+func (w *dwarfWriter) data(size types.Size, value string) {
+	if size == types.Byte {
+		w.line("\t.byte %s", value)
+	} else {
+		w.line("\t.%dbyte %s", size, value)
+	}
+}
+
+func (w *dwarfWriter) uleb(value int) {
+	w.line("\t.uleb128 %#x", value)
+}
+
+func (w *dwarfWriter) string(value string) {
+	w.line("\t.string %s", strconv.Quote(value))
+}
+
+//entry starts an entry of the given kind, its attributes follow in the order of its abbreviation
+func (w *dwarfWriter) entry(code int) {
+	w.uleb(code)
+}
+
+//end ends the children of an entry
+func (w *dwarfWriter) end() {
+	w.uleb(0)
+}
+
+//expr writes a DWARF expression with its length
+func (w *dwarfWriter) expr(ops ...byte) {
+	w.uleb(len(ops))
+	strs := make([]string, len(ops))
+	for i, op := range ops {
+		strs[i] = fmt.Sprintf("%#x", op)
+	}
+	w.line("\t.byte %s", strings.Join(strs, ", "))
+}
+
+//ref refers to the entry of a type relative to the start of the compile unit
+func (w *dwarfWriter) ref(wt types.WaccType) {
+	w.data(types.Word, w.typeLabel(wt)+"-"+dwarfInfoLabel)
+}
+
+func (w *dwarfWriter) function(fn ins.DebugFunc) {
+	w.entry(abbrevSubprogram)
+	w.string(fn.Name)
+	w.uleb(fn.File)
+	w.uleb(fn.Line)
+	w.data(w.pointerSize, fn.Start)
+	w.data(types.Word, fn.End+"-"+fn.Start)
+	w.expr(dwOpCallFrameCfa)
+	w.scope(fn.Scope)
+	w.end()
+}
+
+//scope writes the variables of a scope and the scopes nested in it
+func (w *dwarfWriter) scope(s ins.DebugScope) {
+	for _, v := range s.Vars {
+		w.variable(v)
+	}
+	for _, child := range s.Scopes {
+		w.entry(abbrevLexicalBlock)
+		w.data(w.pointerSize, child.Start)
+		w.data(types.Word, child.End+"-"+child.Start)
+		w.scope(child)
+		w.end()
+	}
+}
+
+func (w *dwarfWriter) variable(v ins.DebugVar) {
+	if v.Param {
+		w.entry(abbrevFormalParam)
+	} else {
+		w.entry(abbrevVariable)
+	}
+	w.string(v.Name)
+	w.uleb(v.File)
+	w.uleb(v.Line)
+	w.ref(v.Type)
+	switch {
+	case !v.InReg:
+		w.expr(append([]byte{dwOpFbreg}, sleb128(v.Offset)...)...)
+	case w.reg(v.Reg) < 32:
+		w.expr(byte(dwOpReg0 + w.reg(v.Reg)))
+	default:
+		w.expr(append([]byte{dwOpRegx}, uleb128(w.reg(v.Reg))...)...)
+	}
+}
+
+//typeName returns the name of a wacc type, struct and class types are named after their declaration
+//and the types given to its type parameters
+func typeName(wt types.WaccType) string {
+	switch {
+	case isUserType(wt):
+		return wt.(types.UserType).FullName()
+	case wt.Is(types.Array):
+		return typeName(wt.GetChildren()[0]) + "[]"
+	case wt.Is(types.Pair) && len(wt.GetChildren()) == 2:
+		children := wt.GetChildren()
+		return fmt.Sprintf("pair(%s,%s)", typeName(children[0]), typeName(children[1]))
+	}
+	return wt.String()
+}
+
+func isUserType(wt types.WaccType) bool {
+	_, ok := wt.(types.UserType)
+	return ok
+}
+
+//typeLabel returns the label of the entry of a type, writing it later if it is new
+func (w *dwarfWriter) typeLabel(wt types.WaccType) string {
+	name := typeName(wt)
+	if label, ok := w.types[name]; ok {
+		return label
+	}
+	label := dwarfTypeLabelStart + strconv.Itoa(len(w.types))
+	w.types[name] = label
+	w.pending = append(w.pending, wt)
+	return label
+}
+
+//typeEntry writes the entries of a type and the struct a reference type points to
+func (w *dwarfWriter) typeEntry(wt types.WaccType) {
+	name := typeName(wt)
+	w.label(w.types[name])
+	switch {
+	case wt == types.Integer:
+		w.baseType(name, dwAteSigned, types.Word)
+	case wt == types.Boolean:
+		w.baseType(name, dwAteBoolean, types.Byte)
+	case wt == types.Char:
+		w.baseType(name, dwAteSignedChar, types.Byte)
+	case wt == types.Str:
+		w.arrayPointer(name, types.Char)
+	case wt.Is(types.Array):
+		w.arrayPointer(name, wt.GetChildren()[0])
+	case wt.Is(types.Pair) && len(wt.GetChildren()) == 2:
+		children := wt.GetChildren()
+		w.structPointer(name, 0, []string{"fst", "snd"}, children)
+	case isUserType(wt):
+		ut := wt.(types.UserType)
+		if declared, ok := w.userTypes[ut.GetName()]; ok && len(declared.GetTypeParams()) == 0 {
+			ut = declared
+		} else if ok && len(declared.GetTypeParams()) == len(ut.GetTypeArgs()) {
+			ut = declared.Instantiate(ut.GetTypeArgs())
+		}
+		//Objects of a class in a class hierarchy start with the address of its vtable,
+		//interface values point to the object and its class's method table
+		header := 0
+		if ut.IsPolymorphic() {
+			header = int(w.pointerSize)
+		} else if ut.IsInterface() {
+			header = 2 * int(w.pointerSize)
+		}
+		w.structPointer(name, header, ut.GetFieldNames(), ut.GetFieldTypes())
+	default:
+		w.entry(abbrevTypedef)
+		w.string(name)
+		w.data(types.Word, w.types[name]+"_ptr-"+dwarfInfoLabel)
+		w.label(w.types[name] + "_ptr")
+		w.entry(abbrevVoidPointerType)
+		w.data(types.Byte, strconv.Itoa(int(w.pointerSize)))
+	}
+}
+
+func (w *dwarfWriter) baseType(name string, encoding int, size types.Size) {
+	w.entry(abbrevBaseType)
+	w.string(name)
+	w.data(types.Byte, strconv.Itoa(encoding))
+	w.data(types.Byte, strconv.Itoa(int(size)))
+}
+
+//structPointer writes a pointer to a struct of fields without padding between them,
+//which start header bytes into it. The struct comes right after the pointer
+func (w *dwarfWriter) structPointer(name string, header int, fields []string, fieldTypes []types.WaccType) {
+	label := w.types[name]
+	w.entry(abbrevPointerType)
+	w.data(types.Byte, strconv.Itoa(int(w.pointerSize)))
+	w.data(types.Word, label+"_struct-"+dwarfInfoLabel)
+
+	size := header
+	for _, t := range fieldTypes {
+		size += int(types.TypeSize(t))
+	}
+	w.label(label + "_struct")
+	w.entry(abbrevStructType)
+	w.string(name)
+	w.uleb(size)
+	offset := header
+	for i, t := range fieldTypes {
+		w.entry(abbrevMember)
+		w.string(fields[i])
+		w.ref(t)
+		w.uleb(offset)
+		offset += int(types.TypeSize(t))
+	}
+	w.end()
+}
+
+//arrayPointer writes a pointer to a struct holding the length of an array followed by its elements
+func (w *dwarfWriter) arrayPointer(name string, elem types.WaccType) {
+	label := w.types[name]
+	w.entry(abbrevPointerType)
+	w.data(types.Byte, strconv.Itoa(int(w.pointerSize)))
+	w.data(types.Word, label+"_struct-"+dwarfInfoLabel)
+
+	w.label(label + "_struct")
+	w.entry(abbrevStructType)
+	w.string(name)
+	w.uleb(types.Word)
+	w.entry(abbrevMember)
+	w.string("length")
+	w.ref(types.Integer)
+	w.uleb(0)
+	w.entry(abbrevMember)
+	w.string("data")
+	w.data(types.Word, label+"_data-"+dwarfInfoLabel)
+	w.uleb(types.Word)
+	w.end()
+
+	//An array without a bound, its length is only known at run time
+	w.label(label + "_data")
+	w.entry(abbrevArrayType)
+	w.ref(elem)
+	w.entry(abbrevSubrangeType)
+	w.end()
+}
+
+func uleb128(value int) []byte {
+	var bytes []byte
+	for {
+		b := byte(value & 0x7f)
+		value >>= 7
+		if value == 0 {
+			return append(bytes, b)
+		}
+		bytes = append(bytes, b|0x80)
+	}
+}
+
+func sleb128(value int) []byte {
+	var bytes []byte
+	for {
+		b := byte(value & 0x7f)
+		value >>= 7
+		if (value == 0 && b&0x40 == 0) || (value == -1 && b&0x40 != 0) {
+			return append(bytes, b)
+		}
+		bytes = append(bytes, b|0x80)
+	}
+}
@@ -14,6 +14,7 @@ type Emitter interface {
 	EmitExit(ins.Exit) string
 	EmitLabel(ins.Label) string
 	EmitFunctionCall(ins.FunctionCall) string
+	EmitIndirectCall(ins.IndirectCall) string
 	EmitBranch(ins.Branch) string
 	EmitMove(ins.Move) string
 	EmitCompare(ins.Compare) string
@@ -24,6 +25,7 @@ type Emitter interface {
 	EmitMod(ins.Mod) string
 	EmitXor(ins.Xor) string
 	EmitStringLiteral(ins.StringLiteral) string
+	EmitAddressTable(ins.AddressTable) string
 	EmitBoolExpr(ins.BoolExpr) string
 	EmitAnd(ins.And) string
 	EmitOr(ins.Or) string
@@ -34,6 +36,10 @@ type Emitter interface {
 	EmitStore(ins.Store) string
 	EmitStoreHeap(ins.StoreHeap) string
 	EmitFreeHeap(ins.FreeHeap) string
+	EmitSourceFile(ins.SourceFile) string
+	EmitLoc(ins.Loc) string
+	EmitFrameInfo(ins.FrameInfo) string
+	EmitDebugInfo(ins.DebugInfo) string
 
 	EmitOperand(ins.Operand) string
 	EmitImmediate(ins.Immediate) string
@@ -0,0 +1,43 @@
+package x86_64
+
+import (
+	architecture "wacc_32/assembly/architectures"
+	ins "wacc_32/assembly/instructions"
+	"wacc_32/types"
+)
+
+//Internal register numbers, registers 0-3 line up with the System V argument registers
+const (
+	returnRegister = 0  //%rdi, wacc return values are moved here from %rax after every call
+	accumulator    = 9  //%r11
+	scratch        = 10 //%r10
+	stackPointer   = 11 //%rsp
+	linkRegister   = 12 //pseudo register, pushing it sets up a frame
+	pc             = 13 //pseudo register, popping it returns from the function
+	rax            = 14 //%rax, only used inside the emitter
+)
+
+//calleeSaved are the registers every frame preserves for its caller
+var calleeSaved = []string{"%rbp", "%rbx", "%r12", "%r13", "%r14", "%r15"}
+
+func Config() architecture.Config {
+	return architecture.Config{
+		ProgramCounter:  pc,
+		LinkRegister:    linkRegister,
+		StackPointer:    stackPointer,
+		ScratchRegister: scratch,
+		Accumulator:     accumulator,
+		ReturnRegister:  returnRegister,
+		CalleeSavedRegs: 0xF,   //%rdi, %rsi, %rdx, %rcx
+		CallerSavedRegs: 0x1F0, //%rbx, %r12-%r15, preserved across libc calls
+		PointerSize:     types.DoubleWord,
+		//return address, the callee saved registers and padding to keep %rsp 16-byte aligned
+		FrameHeaderSize: 8 + 8*len(calleeSaved) + 8,
+		FrameAlignment:  16,
+		OverflowCond:    ins.NE,
+		MutexSize:       40,
+		MutexAttrSize:   4,
+		SemaphoreSize:   32,
+		CondSize:        48,
+	}
+}
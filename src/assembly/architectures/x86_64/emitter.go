@@ -0,0 +1,554 @@
+package x86_64
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	architecture "wacc_32/assembly/architectures"
+	ins "wacc_32/assembly/instructions"
+	"wacc_32/types"
+)
+
+var _ architecture.Emitter = Emitter{}
+
+//Emitter converts our internal assembly into x86-64 GNU assembly (AT&T syntax)
+//Integers are kept sign extended in the 64 bit registers, so every checked
+//arithmetic instruction is followed by a compare which sets NE on overflow
+type Emitter struct{}
+
+//regNames holds the 64, 32, 16 and 8 bit names of each internal register
+var regNames = map[ins.Register][4]string{
+	0:            {"%rdi", "%edi", "%di", "%dil"},
+	1:            {"%rsi", "%esi", "%si", "%sil"},
+	2:            {"%rdx", "%edx", "%dx", "%dl"},
+	3:            {"%rcx", "%ecx", "%cx", "%cl"},
+	4:            {"%rbx", "%ebx", "%bx", "%bl"},
+	5:            {"%r12", "%r12d", "%r12w", "%r12b"},
+	6:            {"%r13", "%r13d", "%r13w", "%r13b"},
+	7:            {"%r14", "%r14d", "%r14w", "%r14b"},
+	8:            {"%r15", "%r15d", "%r15w", "%r15b"},
+	accumulator:  {"%r11", "%r11d", "%r11w", "%r11b"},
+	scratch:      {"%r10", "%r10d", "%r10w", "%r10b"},
+	stackPointer: {"%rsp", "%esp", "%sp", "%spl"},
+	rax:          {"%rax", "%eax", "%ax", "%al"},
+}
+
+//dwarfRegs holds the DWARF number of each internal register
+var dwarfRegs = map[ins.Register]int{
+	0:            5,
+	1:            4,
+	2:            1,
+	3:            2,
+	4:            3,
+	5:            12,
+	6:            13,
+	7:            14,
+	8:            15,
+	accumulator:  11,
+	scratch:      10,
+	stackPointer: 7,
+	rax:          0,
+}
+
+//directives maps the directives used by the builtins to the symbols they refer to
+var directives = map[ins.Directive]string{
+	".streams": "stdout",
+}
+
+var condStrings = map[ins.Cond]string{
+	ins.EQ: "e",
+	ins.NE: "ne",
+	ins.GT: "g",
+	ins.LE: "le",
+	ins.GE: "ge",
+	ins.LT: "l",
+	ins.VS: "o",
+}
+
+func (x86 Emitter) Emit(bss, instr ins.Instruction) string {
+	codeString := ".data\n"
+	codeString += x86.EmitInstruction(bss)
+	codeString += "\n.text\n.global main\n"
+	codeString += x86.EmitInstruction(instr)
+	return codeString + "\n.section .note.GNU-stack,\"\",@progbits\n"
+}
+
+func (x86 Emitter) EmitInstruction(instruction ins.Instruction) string {
+	switch instr := instruction.(type) {
+	case ins.Instructions:
+		return x86.EmitInstructions(instr)
+	case ins.NOOP:
+		return x86.EmitNOOP(instr)
+	case ins.Pool:
+		return x86.EmitPool(instr)
+	case ins.Exit:
+		return x86.EmitExit(instr)
+	case ins.Label:
+		return x86.EmitLabel(instr)
+	case ins.FunctionCall:
+		return x86.EmitFunctionCall(instr)
+	case ins.IndirectCall:
+		return x86.EmitIndirectCall(instr)
+	case ins.Branch:
+		return x86.EmitBranch(instr)
+	case ins.Move:
+		return x86.EmitMove(instr)
+	case ins.Compare:
+		return x86.EmitCompare(instr)
+	case ins.Add:
+		return x86.EmitAdd(instr)
+	case ins.Sub:
+		return x86.EmitSub(instr)
+	case ins.Mult:
+		return x86.EmitMult(instr)
+	case ins.Div:
+		return x86.EmitDiv(instr)
+	case ins.Mod:
+		return x86.EmitMod(instr)
+	case ins.Xor:
+		return x86.EmitXor(instr)
+	case ins.StringLiteral:
+		return x86.EmitStringLiteral(instr)
+	case ins.AddressTable:
+		return x86.EmitAddressTable(instr)
+	case ins.BoolExpr:
+		return x86.EmitBoolExpr(instr)
+	case ins.And:
+		return x86.EmitAnd(instr)
+	case ins.Or:
+		return x86.EmitOr(instr)
+	case ins.Neg:
+		return x86.EmitNeg(instr)
+	case types.Size:
+		return x86.EmitSize(instr)
+	case ins.Load:
+		return x86.EmitLoad(instr)
+	case ins.StackInstr:
+		return x86.EmitStackInstr(instr)
+	case ins.Store:
+		return x86.EmitStore(instr)
+	case ins.StoreHeap:
+		return x86.EmitStoreHeap(instr)
+	case ins.FreeHeap:
+		return x86.EmitFreeHeap(instr)
+	case ins.SourceFile:
+		return x86.EmitSourceFile(instr)
+	case ins.Loc:
+		return x86.EmitLoc(instr)
+	case ins.FrameInfo:
+		return x86.EmitFrameInfo(instr)
+	case ins.DebugLabel:
+		return x86.EmitLabel(ins.Label(instr))
+	case ins.DebugInfo:
+		return x86.EmitDebugInfo(instr)
+	case ins.Operand:
+		return x86.EmitOperand(instr)
+	}
+	return ""
+}
+
+func (x86 Emitter) EmitInstructions(is ins.Instructions) string {
+	strs := make([]string, 0, len(is))
+	for _, instr := range is {
+		if str := x86.EmitInstruction(instr); str != "" {
+			strs = append(strs, str)
+		}
+	}
+	return strings.Join(strs, "\n")
+}
+
+func (x86 Emitter) EmitNOOP(_ ins.NOOP) string {
+	return ""
+}
+
+//EmitPool emits nothing, immediates are encoded in the instructions
+func (x86 Emitter) EmitPool(_ ins.Pool) string {
+	return ""
+}
+
+func (x86 Emitter) EmitExit(e ins.Exit) string {
+	return x86.loadOperand(returnRegister, e.Code, types.Word) + "\n" + call("exit")
+}
+
+func (x86 Emitter) EmitLabel(l ins.Label) string {
+	return l.Name + ":"
+}
+
+//EmitFunctionCall calls a function and moves its result to the wacc return register
+func (x86 Emitter) EmitFunctionCall(fc ins.FunctionCall) string {
+	return call(fc.Name) + "\n\tmovq %rax, %rdi"
+}
+
+//EmitIndirectCall calls the function at the address in a register, like EmitFunctionCall
+func (x86 Emitter) EmitIndirectCall(ic ins.IndirectCall) string {
+	return "\tmovl $0, %eax\n\tcall *" + x86.EmitRegister(ic.Reg) + "\n\tmovq %rax, %rdi"
+}
+
+func (x86 Emitter) EmitBranch(b ins.Branch) string {
+	if b.Condition == ins.AL {
+		return "\tjmp " + b.Label
+	}
+	return fmt.Sprintf("\tj%s %s", condStrings[b.Condition], b.Label)
+}
+
+func (x86 Emitter) EmitMove(m ins.Move) string {
+	return x86.loadOperand(m.Dest, m.Src, types.DoubleWord)
+}
+
+func (x86 Emitter) EmitCompare(c ins.Compare) string {
+	setup, left := x86.inRegister(c.Left, scratch)
+	return setup + x86.compare(left, c.Right)
+}
+
+func (x86 Emitter) EmitAdd(a ins.Add) string {
+	return x86.arithmetic("addq", a.Dest, a.Left, a.Right)
+}
+
+func (x86 Emitter) EmitSub(s ins.Sub) string {
+	return x86.arithmetic("subq", s.Dest, s.Left, s.Right)
+}
+
+func (x86 Emitter) EmitMult(m ins.Mult) string {
+	return x86.binary("imulq", m.Dest, m.Left, m.Right) + "\n" + overflowCheck(m.Dest)
+}
+
+//divide moves the operands into the argument registers, checks for a zero
+//divisor then divides them sign extended to 64 bits, leaving the quotient in %eax
+//and the remainder in %edx. A 32 bit idivl would trap on INT_MIN / -1, in 64 bits
+//the quotient fits and its low half wraps to INT_MIN like on the other targets
+func (x86 Emitter) divide(left, right ins.Operand) string {
+	return x86.loadOperand(0, left, types.Word) + "\n" +
+		x86.loadOperand(1, right, types.Word) + "\n" +
+		x86.EmitFunctionCall(ins.FunctionCall{Name: "p_check_divide_by_zero"}) + "\n" +
+		"\tmovslq %edi, %rax\n\tmovslq %esi, %rsi\n\tcqto\n\tidivq %rsi\n"
+}
+
+func (x86 Emitter) EmitDiv(d ins.Div) string {
+	return x86.divide(d.Left, d.Right) + "\tmovslq %eax, " + x86.EmitRegister(d.Dest)
+}
+
+func (x86 Emitter) EmitMod(m ins.Mod) string {
+	return x86.divide(m.Left, m.Right) + "\tmovslq %edx, " + x86.EmitRegister(m.Dest)
+}
+
+func (x86 Emitter) EmitXor(x ins.Xor) string {
+	return x86.binary("xorq", x.Dest, x.Left, x.Right)
+}
+
+func (x86 Emitter) EmitStringLiteral(sl ins.StringLiteral) string {
+	msgLine := fmt.Sprintf("%s:\n", sl.ID)
+	wordLine := fmt.Sprintf("\t.long %d\n", sl.Size)
+	asciiLine := fmt.Sprintf("\t.ascii %s", sl.String)
+
+	return msgLine + wordLine + asciiLine
+}
+
+func (x86 Emitter) EmitAddressTable(at ins.AddressTable) string {
+	lines := []string{"\t.balign 8", at.ID + ":"}
+	for _, label := range at.Labels {
+		lines = append(lines, "\t.quad "+label)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (x86 Emitter) EmitBoolExpr(be ins.BoolExpr) string {
+	setup, left := x86.inRegister(be.Left, scratch)
+	cmpLine := setup + x86.compare(left, be.Right) + "\n"
+	setLine := fmt.Sprintf("\tset%s %s\n", condStrings[be.True], regName(be.Dest, types.Byte))
+	return cmpLine + setLine + fmt.Sprintf("\tmovzbq %s, %s", regName(be.Dest, types.Byte), x86.EmitRegister(be.Dest))
+}
+
+func (x86 Emitter) EmitAnd(a ins.And) string {
+	return x86.binary("andq", a.Dest, a.Left, a.Right)
+}
+
+func (x86 Emitter) EmitOr(o ins.Or) string {
+	return x86.binary("orq", o.Dest, o.Left, o.Right)
+}
+
+func (x86 Emitter) EmitNeg(n ins.Neg) string {
+	return "\tnegq " + x86.EmitRegister(n.Reg) + "\n" + overflowCheck(n.Reg)
+}
+
+func (x86 Emitter) EmitSize(types.Size) string {
+	return ""
+}
+
+func (x86 Emitter) EmitLoad(ld ins.Load) string {
+	return x86.loadOperand(ld.Dest, ld.Src, ld.Size)
+}
+
+func (x86 Emitter) EmitStackInstr(st ins.StackInstr) string {
+	strs := make([]string, len(st.Regs))
+	for i, reg := range st.Regs {
+		if st.IsPush() {
+			//push {a, b} stores b first, so that a ends up at the lowest address
+			strs[len(strs)-1-i] = x86.push(reg)
+		} else {
+			strs[i] = x86.pop(reg)
+		}
+	}
+	return strings.Join(strs, "\n")
+}
+
+//push stores reg in a 16 byte slot so that %rsp stays aligned for calls.
+//Pushing the link register saves the callee saved registers instead
+func (x86 Emitter) push(reg ins.Register) string {
+	if reg == linkRegister {
+		strs := make([]string, 0, len(calleeSaved)+2)
+		for _, r := range calleeSaved {
+			strs = append(strs, "\tpushq "+r)
+		}
+		return strings.Join(append(strs, "\tleaq -8(%rsp), %rsp"), "\n")
+	}
+	return fmt.Sprintf("\tleaq -16(%%rsp), %%rsp\n\tmovq %s, (%%rsp)", x86.EmitRegister(reg))
+}
+
+func (x86 Emitter) pop(reg ins.Register) string {
+	switch reg {
+	case linkRegister:
+		return restoreCalleeSaved()
+	case pc:
+		return "\tmovq %rdi, %rax\n" + restoreCalleeSaved() + "\n\tret"
+	}
+	return fmt.Sprintf("\tmovq (%%rsp), %s\n\tleaq 16(%%rsp), %%rsp", x86.EmitRegister(reg))
+}
+
+func restoreCalleeSaved() string {
+	strs := []string{"\tleaq 8(%rsp), %rsp"}
+	for i := len(calleeSaved) - 1; i >= 0; i-- {
+		strs = append(strs, "\tpopq "+calleeSaved[i])
+	}
+	return strings.Join(strs, "\n")
+}
+
+func (x86 Emitter) EmitStore(st ins.Store) string {
+	addr, ok := st.Dest.(ins.Address)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("\tmov%s %s, %s", suffix(st.Size), regName(st.Src, st.Size), x86.EmitAddress(addr))
+}
+
+//EmitStoreHeap mallocs size bytes and stores the low word of op at the start
+func (x86 Emitter) EmitStoreHeap(st ins.StoreHeap) string {
+	malloc := x86.loadOperand(returnRegister, st.Size, types.Word) + "\n" +
+		x86.EmitFunctionCall(ins.FunctionCall{Name: "malloc"}) + "\n"
+	setup, op := x86.inRegister(st.Op, scratch)
+	addr := ins.Address{Reg: returnRegister, Offset: ins.Immediate(st.Offset)}
+	return malloc + setup + fmt.Sprintf("\tmovl %s, %s", regName(op, types.Word), x86.EmitAddress(addr))
+}
+
+func (x86 Emitter) EmitFreeHeap(fh ins.FreeHeap) string {
+	return x86.loadOperand(returnRegister, fh.Reg, types.DoubleWord) + "\n" + call("free")
+}
+
+//frameHeader is saved by pushing the link register, the return address is above the
+//callee saved registers
+var frameHeader = func() []architecture.SavedRegister {
+	saved := make([]architecture.SavedRegister, len(calleeSaved))
+	for i, r := range calleeSaved {
+		saved[i] = architecture.SavedRegister{Name: r, Offset: -16 - 8*i}
+	}
+	return saved
+}()
+
+func (x86 Emitter) EmitSourceFile(f ins.SourceFile) string {
+	return architecture.EmitSourceFile(f)
+}
+
+func (x86 Emitter) EmitLoc(l ins.Loc) string {
+	return architecture.EmitLoc(l)
+}
+
+func (x86 Emitter) EmitFrameInfo(fi ins.FrameInfo) string {
+	return architecture.EmitFrameInfo(fi, frameHeader)
+}
+
+func (x86 Emitter) EmitDebugInfo(d ins.DebugInfo) string {
+	return architecture.EmitDebugInfo(d, types.DoubleWord, dwarfRegister)
+}
+
+//dwarfRegister returns the DWARF number of an internal register
+func dwarfRegister(r ins.Register) int {
+	return dwarfRegs[r]
+}
+
+func (x86 Emitter) EmitOperand(op ins.Operand) string {
+	switch operand := op.(type) {
+	case ins.Address:
+		return x86.EmitAddress(operand)
+	case ins.PseudoImmediate:
+		return x86.EmitPseudoImmediate(operand)
+	case ins.Register:
+		return x86.EmitRegister(operand)
+	case ins.Immediate:
+		return x86.EmitImmediate(operand)
+	case ins.Variable:
+		return x86.EmitVariable(operand)
+	case ins.FunctionPointer:
+		return string(operand)
+	case ins.Directive:
+		return string(operand)
+	}
+	return ""
+}
+
+func (x86 Emitter) EmitImmediate(i ins.Immediate) string {
+	return "$" + strconv.Itoa(int(i))
+}
+
+func (x86 Emitter) EmitPseudoImmediate(pi ins.PseudoImmediate) string {
+	return "$" + strconv.Itoa(int(pi))
+}
+
+func (x86 Emitter) EmitAddress(a ins.Address) string {
+	str := "(" + x86.EmitRegister(a.Reg) + ")"
+	if a.Offset != 0 {
+		str = strconv.Itoa(int(a.Offset)) + str
+	}
+	return str
+}
+
+func (x86 Emitter) EmitVariable(v ins.Variable) string {
+	return string(v) + "(%rip)"
+}
+
+func (x86 Emitter) EmitRegister(r ins.Register) string {
+	return regName(r, types.DoubleWord)
+}
+
+//regName returns the name of the bottom size bytes of a register
+func regName(r ins.Register, size types.Size) string {
+	names := regNames[r]
+	switch size {
+	case types.Byte:
+		return names[3]
+	case types.HalfWord:
+		return names[2]
+	case types.Word:
+		return names[1]
+	}
+	return names[0]
+}
+
+//suffix returns the AT&T size suffix for a memory access
+func suffix(size types.Size) string {
+	switch size {
+	case types.Byte:
+		return "b"
+	case types.HalfWord:
+		return "w"
+	case types.Word:
+		return "l"
+	}
+	return "q"
+}
+
+//call zeroes %al, which holds the number of vector arguments to a variadic function
+func call(name string) string {
+	return "\tmovl $0, %eax\n\tcall " + name + "@PLT"
+}
+
+//overflowCheck sets NE if reg no longer holds a sign extended 4-byte int
+func overflowCheck(reg ins.Register) string {
+	return fmt.Sprintf("\tmovslq %s, %%rax\n\tcmpq %%rax, %s", regName(reg, types.Word), regName(reg, types.DoubleWord))
+}
+
+func fitsImm32(v int) bool {
+	return math.MinInt32 <= v && v <= math.MaxInt32
+}
+
+//loadOperand puts the value of op into dest, memory is read with the given size
+func (x86 Emitter) loadOperand(dest ins.Register, op ins.Operand, size types.Size) string {
+	d := x86.EmitRegister(dest)
+	switch src := op.(type) {
+	case ins.Immediate:
+		return loadImmediate(d, int(src))
+	case ins.PseudoImmediate:
+		return loadImmediate(d, int(src))
+	case ins.Register:
+		if src == dest {
+			return ""
+		}
+		return fmt.Sprintf("\tmovq %s, %s", x86.EmitRegister(src), d)
+	case ins.Address:
+		instr := "movq"
+		switch size {
+		case types.Byte:
+			instr = "movzbq"
+		case types.HalfWord:
+			instr = "movswq"
+		case types.Word:
+			instr = "movslq"
+		}
+		return fmt.Sprintf("\t%s %s, %s", instr, x86.EmitAddress(src), d)
+	case ins.Variable:
+		return fmt.Sprintf("\tleaq %s, %s", x86.EmitVariable(src), d)
+	case ins.FunctionPointer:
+		return fmt.Sprintf("\tleaq %s(%%rip), %s", string(src), d)
+	case ins.Directive:
+		//Directives refer to libc globals, so go through the GOT
+		return fmt.Sprintf("\tmovq %s@GOTPCREL(%%rip), %s", directives[src], d)
+	}
+	return ""
+}
+
+func loadImmediate(reg string, val int) string {
+	if fitsImm32(val) {
+		return fmt.Sprintf("\tmovq $%d, %s", val, reg)
+	}
+	return fmt.Sprintf("\tmovabsq $%d, %s", val, reg)
+}
+
+//inRegister loads op into tmp if it is not already a register
+func (x86 Emitter) inRegister(op ins.Operand, tmp ins.Register) (string, ins.Register) {
+	if reg, ok := op.(ins.Register); ok {
+		return "", reg
+	}
+	return x86.loadOperand(tmp, op, types.DoubleWord) + "\n", tmp
+}
+
+//source returns an operand usable as the source of an instruction, immediates
+//which don't fit in 32 bits are loaded into %rax
+func (x86 Emitter) source(op ins.Operand) (string, string) {
+	if imm, ok := op.(ins.Immediate); ok && fitsImm32(int(imm)) {
+		return "", x86.EmitImmediate(imm)
+	}
+	setup, reg := x86.inRegister(op, rax)
+	return setup, x86.EmitRegister(reg)
+}
+
+func (x86 Emitter) compare(left ins.Register, right ins.Operand) string {
+	setup, r := x86.source(right)
+	return setup + fmt.Sprintf("\tcmpq %s, %s", r, x86.EmitRegister(left))
+}
+
+//binary emits dest = left <instr> right. x86 instructions overwrite their
+//left operand, so the scratch register is used when dest is also right
+func (x86 Emitter) binary(instr string, dest ins.Register, left, right ins.Operand) string {
+	rSetup, r := x86.source(right)
+	target := dest
+	if reg, ok := right.(ins.Register); ok && reg == dest && left != right {
+		target = scratch
+	}
+	strs := []string{}
+	if setup := x86.loadOperand(target, left, types.DoubleWord); setup != "" {
+		strs = append(strs, setup)
+	}
+	strs = append(strs, rSetup+fmt.Sprintf("\t%s %s, %s", instr, r, x86.EmitRegister(target)))
+	if target != dest {
+		strs = append(strs, fmt.Sprintf("\tmovq %s, %s", x86.EmitRegister(target), x86.EmitRegister(dest)))
+	}
+	return strings.Join(strs, "\n")
+}
+
+//arithmetic emits an add or sub, checking for overflow unless the stack pointer is involved
+func (x86 Emitter) arithmetic(instr string, dest ins.Register, left, right ins.Operand) string {
+	op := x86.binary(instr, dest, left, right)
+	if dest == stackPointer || left == ins.Operand(ins.Register(stackPointer)) {
+		return op
+	}
+	return op + "\n" + overflowCheck(dest)
+}
@@ -16,6 +16,9 @@ var (
 	arg0      ins.Register
 	arg1      ins.Register
 	arg2      ins.Register
+
+	ptrSize      types.Size
+	overflowCond ins.Cond
 )
 
 //Use this to initialise the builtin registers
@@ -27,15 +30,17 @@ func Init(conf architecture.Config) {
 	sp = conf.StackPointer
 	lr = conf.LinkRegister
 	pc = conf.ProgramCounter
+	ptrSize = conf.PointerSize
+	overflowCond = conf.OverflowCond
 
 	PrintLine = ins.Instructions{
 		ins.NewLabel(PrintLineLabel),
 		ins.NewPush(lr),
-		ins.NewLoad(ins.Variable(LineBSSLabel), arg0, types.Word),
+		ins.NewLoad(ins.Variable(LineBSSLabel), arg0, ptrSize),
 		ins.NewAdd(returnReg, returnReg, ins.Immediate(types.Word)),
 		ins.NewFunctionCall("puts"),
-		ins.NewLoad(ins.NewDirective("streams"), returnReg, types.Word),
-		ins.NewLoad(ins.NewAddress(returnReg), returnReg, types.Word),
+		ins.NewLoad(ins.NewDirective("streams"), returnReg, ptrSize),
+		ins.NewLoad(ins.NewAddress(returnReg), returnReg, ptrSize),
 		ins.NewFunctionCall("fflush"),
 		ins.NewPop(pc),
 	}
@@ -44,11 +49,11 @@ func Init(conf architecture.Config) {
 		ins.NewPush(lr),
 		ins.NewLoad(ins.NewAddress(returnReg), arg1, types.Word),
 		ins.NewAdd(arg2, returnReg, ins.Immediate(types.Word)),
-		ins.NewLoad(ins.Variable("print_string"), arg0, types.Word),
+		ins.NewLoad(ins.Variable("print_string"), arg0, ptrSize),
 		ins.NewAdd(arg0, arg0, ins.Immediate(types.Word)),
 		ins.NewFunctionCall("printf"),
-		ins.NewLoad(ins.NewDirective("streams"), returnReg, types.Word),
-		ins.NewLoad(ins.NewAddress(returnReg), returnReg, types.Word),
+		ins.NewLoad(ins.NewDirective("streams"), returnReg, ptrSize),
+		ins.NewLoad(ins.NewAddress(returnReg), returnReg, ptrSize),
 		ins.NewFunctionCall("fflush"),
 		ins.NewPop(pc),
 	}
@@ -56,15 +61,15 @@ func Init(conf architecture.Config) {
 		ins.NewLabel("p_print_bool"),
 		ins.NewPush(lr),
 		ins.NewMove(arg0, arg1),
-		ins.NewLoad(ins.Variable("print_bool"), arg0, types.Word),
+		ins.NewLoad(ins.Variable("print_bool"), arg0, ptrSize),
 		ins.NewAdd(arg0, arg0, ins.Immediate(types.Word)),
 		ins.NewCompare(arg1, ins.Immediate(1)),
 		ins.NewBranch("p_true", ins.EQ),
 		ins.NewAdd(returnReg, returnReg, ins.Immediate(falseOffset)),
 		ins.NewLabel("p_true"),
 		ins.NewFunctionCall("printf"),
-		ins.NewLoad(ins.NewDirective("streams"), returnReg, types.Word),
-		ins.NewLoad(ins.NewAddress(returnReg), returnReg, types.Word),
+		ins.NewLoad(ins.NewDirective("streams"), returnReg, ptrSize),
+		ins.NewLoad(ins.NewAddress(returnReg), returnReg, ptrSize),
 		ins.NewFunctionCall("fflush"),
 		ins.NewPop(pc),
 	}
@@ -115,11 +120,11 @@ func printNumericalFunction(typeString string) ins.Instructions {
 		ins.NewLabel("p_print_" + typeString),
 		ins.NewPush(lr),
 		ins.NewMove(arg0, arg1),
-		ins.NewLoad(ins.Variable("print_"+typeString), returnReg, types.Word),
+		ins.NewLoad(ins.Variable("print_"+typeString), returnReg, ptrSize),
 		ins.NewAdd(returnReg, returnReg, ins.Immediate(types.Word)),
 		ins.NewFunctionCall("printf"),
-		ins.NewLoad(ins.NewDirective("streams"), returnReg, types.Word),
-		ins.NewLoad(ins.NewAddress(returnReg), returnReg, types.Word),
+		ins.NewLoad(ins.NewDirective("streams"), returnReg, ptrSize),
+		ins.NewLoad(ins.NewAddress(returnReg), returnReg, ptrSize),
 		ins.NewFunctionCall("fflush"),
 		ins.NewPop(pc),
 	}
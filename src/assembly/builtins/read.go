@@ -31,7 +31,7 @@ func readNumericalFunction(typeString string) ins.Instructions {
 		ins.NewLabel("p_read_" + typeString),
 		ins.NewPush(lr),
 		ins.NewMove(returnReg, arg1),
-		ins.NewLoad(ins.Variable("read_"+typeString), returnReg, types.Word),
+		ins.NewLoad(ins.Variable("read_"+typeString), returnReg, ptrSize),
 		ins.NewAdd(returnReg, returnReg, ins.Immediate(types.Word)),
 		ins.NewFunctionCall("scanf"),
 		ins.NewPop(pc),
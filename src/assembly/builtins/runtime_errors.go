@@ -16,17 +16,21 @@ const (
 	NullPointerReferenceError
 	InvalidThreadUnlockError
 	SameThreadLockError
+	ClosedChannelError
+	NegativeCapacityError
 )
 
 // Aliases for each of the runtime errors output string
 const (
-	arrayIndexTooLargeMsg   = `"ArrayIndexOutOfBoundsError: index too large\0"`
-	arrayIndexNegativeMsg   = `"ArrayIndexOutOfBoundsError: negative index\0"`
-	integerOverFlowMsg      = `"OverflowError: the result is too small/large to store in a 4-byte signed-integer.\0"`
-	divideByZeroMsg         = `"DivideByZeroError: divide or modulo by zero\0"`
-	nullPointerReferenceMsg = `"NullReferenceError: dereference a null reference\0"`
-	invalidThreadUnlockMsg  = `"InvalidThreadUnlockError: can't release lock\0"`
-	sameThreadLockMsg       = `"Deadlock: attempted to acquire an acquired lock in the same thread\0"`
+	arrayIndexTooLargeMsg   = "ArrayIndexOutOfBoundsError: index too large"
+	arrayIndexNegativeMsg   = "ArrayIndexOutOfBoundsError: negative index"
+	integerOverFlowMsg      = "OverflowError: the result is too small/large to store in a 4-byte signed-integer."
+	divideByZeroMsg         = "DivideByZeroError: divide or modulo by zero"
+	nullPointerReferenceMsg = "NullReferenceError: dereference a null reference"
+	invalidThreadUnlockMsg  = "InvalidThreadUnlockError: can't release lock"
+	sameThreadLockMsg       = "Deadlock: attempted to acquire an acquired lock in the same thread"
+	closedChannelMsg        = "ClosedChannelError: send on or close of a closed channel"
+	negativeCapacityMsg     = "NegativeCapacityError: make_chan with a negative capacity"
 )
 
 var errorMsgs = []string{
@@ -37,6 +41,23 @@ var errorMsgs = []string{
 	nullPointerReferenceMsg,
 	invalidThreadUnlockMsg,
 	sameThreadLockMsg,
+	closedChannelMsg,
+	negativeCapacityMsg,
+}
+
+//LockOrderError is raised with -lockdep when a thread acquires a lock while holding
+//one which another acquisition made while holding the first
+type LockOrderError struct {
+	Acquiring, Held string
+}
+
+//LockOrderMsg are the pieces of the message of a LockOrderError, around the names of
+//the lock being acquired, the lock held and the lock being acquired again
+var LockOrderMsg = [...]string{"Deadlock: acquiring ", " while holding ", ", which was acquired while holding ", " before"}
+
+//Error returns the message printed when the locks are acquired in opposite orders
+func (err LockOrderError) Error() string {
+	return LockOrderMsg[0] + err.Acquiring + LockOrderMsg[1] + err.Held + LockOrderMsg[2] + err.Acquiring + LockOrderMsg[3]
 }
 
 // Aliases for the labels of the runtime error code
@@ -48,9 +69,17 @@ const (
 	NullPointerReferenceCheckLabel = "p_check_null_pointer"
 	InvalidThreadUnlockCheckLabel  = "p_check_invalid_thread_unlock"
 	SameThreadLockCheckLabel       = "p_check_same_thread_lock"
+	ClosedChannelCheckLabel        = "p_check_closed_channel"
+	ChannelCapacityCheckLabel      = "p_check_channel_capacity"
 )
 
+//GetMsg returns the error message as a string literal for the data section
 func (err RuntimeErrType) GetMsg() string {
+	return `"` + err.Error() + `\0"`
+}
+
+//Error returns the message printed when the runtime error occurs
+func (err RuntimeErrType) Error() string {
 	return errorMsgs[err-1]
 }
 
@@ -66,8 +95,8 @@ func PrintError() ins.Instructions {
 		ins.NewLoad(ins.NewAddress(returnReg), arg1, types.Word),
 		ins.NewAdd(returnReg, returnReg, ins.Immediate(types.Word)),
 		ins.NewFunctionCall("printf"),
-		ins.NewLoad(ins.NewDirective("streams"), returnReg, types.Word),
-		ins.NewLoad(ins.NewAddress(returnReg), returnReg, types.Word),
+		ins.NewLoad(ins.NewDirective("streams"), returnReg, ptrSize),
+		ins.NewLoad(ins.NewAddress(returnReg), returnReg, ptrSize),
 		ins.NewFunctionCall("fflush"),
 		ins.NewExit(ins.Immediate(-1)),
 		ins.NewPop(pc),
@@ -82,7 +111,7 @@ func CheckDivideByZeroError() ins.Instructions {
 		ins.NewPush(lr),
 		ins.NewCompare(arg1, ins.Immediate(0)),
 		ins.NewBranch("ok_divide", ins.NE),
-		ins.NewLoad(DivideByZeroError.GetErrMsgLabel(), returnReg, types.Word),
+		ins.NewLoad(DivideByZeroError.GetErrMsgLabel(), returnReg, ptrSize),
 		ins.NewFunctionCall("p_print_error"),
 		ins.NewLabel("ok_divide"),
 		ins.NewPop(pc),
@@ -95,10 +124,10 @@ func CheckIntegerOverflowError() ins.Instructions {
 	return ins.Instructions{
 		ins.NewLabel(IntegerOverflowCheckLabel),
 		ins.NewPush(lr),
-		ins.NewBranch("not_ok", ins.VS),
+		ins.NewBranch("not_ok", overflowCond),
 		ins.NewPop(pc),
 		ins.NewLabel("not_ok"),
-		ins.NewLoad(IntegerOverflowError.GetErrMsgLabel(), returnReg, types.Word),
+		ins.NewLoad(IntegerOverflowError.GetErrMsgLabel(), returnReg, ptrSize),
 		ins.NewFunctionCall("p_print_error"),
 	}
 }
@@ -111,7 +140,7 @@ func CheckNullPointerReferenceError() ins.Instructions {
 		ins.NewPush(lr),
 		ins.NewCompare(returnReg, ins.Immediate(0)),
 		ins.NewBranch("ok_null", ins.NE),
-		ins.NewLoad(NullPointerReferenceError.GetErrMsgLabel(), returnReg, types.Word),
+		ins.NewLoad(NullPointerReferenceError.GetErrMsgLabel(), returnReg, ptrSize),
 		ins.NewFunctionCall("p_print_error"),
 		ins.NewLabel("ok_null"),
 		ins.NewPop(pc),
@@ -130,10 +159,10 @@ func CheckArrayIndexOutOfBounds() ins.Instructions {
 		ins.NewBranch("index_too_large_error", ins.GE),
 		ins.NewPop(pc),
 		ins.NewLabel("negative_index_error"),
-		ins.NewLoad(ArrayIndexNegativeError.GetErrMsgLabel(), returnReg, types.Word),
+		ins.NewLoad(ArrayIndexNegativeError.GetErrMsgLabel(), returnReg, ptrSize),
 		ins.NewFunctionCall("p_print_error"),
 		ins.NewLabel("index_too_large_error"),
-		ins.NewLoad(ArrayIndexTooLargeError.GetErrMsgLabel(), returnReg, types.Word),
+		ins.NewLoad(ArrayIndexTooLargeError.GetErrMsgLabel(), returnReg, ptrSize),
 		ins.NewFunctionCall("p_print_error"),
 		ins.NewPop(pc),
 	}
@@ -146,7 +175,7 @@ func CheckInvalidThreadUnlock() ins.Instructions {
 		ins.NewPush(lr),
 		ins.NewCompare(returnReg, ins.Immediate(1)), //EPERM
 		ins.NewBranch(InvalidThreadUnlockCheckLabel+"_done", ins.NE),
-		ins.NewLoad(InvalidThreadUnlockError.GetErrMsgLabel(), returnReg, types.Word),
+		ins.NewLoad(InvalidThreadUnlockError.GetErrMsgLabel(), returnReg, ptrSize),
 		ins.NewFunctionCall("p_print_error"),
 		ins.NewLabel(InvalidThreadUnlockCheckLabel + "_done"),
 		ins.NewPop(pc),
@@ -160,9 +189,37 @@ func CheckSameThreadLock() ins.Instructions {
 		ins.NewPush(lr),
 		ins.NewCompare(returnReg, ins.Immediate(35)), //EDEADLK
 		ins.NewBranch(SameThreadLockCheckLabel+"_done", ins.NE),
-		ins.NewLoad(SameThreadLockError.GetErrMsgLabel(), returnReg, types.Word),
+		ins.NewLoad(SameThreadLockError.GetErrMsgLabel(), returnReg, ptrSize),
 		ins.NewFunctionCall("p_print_error"),
 		ins.NewLabel(SameThreadLockCheckLabel + "_done"),
 		ins.NewPop(pc),
 	}
 }
+
+//CheckClosedChannel checks if a channel is closed when it is sent on or closed
+func CheckClosedChannel() ins.Instructions {
+	return ins.Instructions{
+		ins.NewLabel(ClosedChannelCheckLabel),
+		ins.NewPush(lr),
+		ins.NewCompare(returnReg, ins.Immediate(0)),
+		ins.NewBranch(ClosedChannelCheckLabel+"_done", ins.EQ),
+		ins.NewLoad(ClosedChannelError.GetErrMsgLabel(), returnReg, ptrSize),
+		ins.NewFunctionCall("p_print_error"),
+		ins.NewLabel(ClosedChannelCheckLabel + "_done"),
+		ins.NewPop(pc),
+	}
+}
+
+//CheckChannelCapacity checks if a channel is made with a negative capacity
+func CheckChannelCapacity() ins.Instructions {
+	return ins.Instructions{
+		ins.NewLabel(ChannelCapacityCheckLabel),
+		ins.NewPush(lr),
+		ins.NewCompare(returnReg, ins.Immediate(0)),
+		ins.NewBranch(ChannelCapacityCheckLabel+"_done", ins.GE),
+		ins.NewLoad(NegativeCapacityError.GetErrMsgLabel(), returnReg, ptrSize),
+		ins.NewFunctionCall("p_print_error"),
+		ins.NewLabel(ChannelCapacityCheckLabel + "_done"),
+		ins.NewPop(pc),
+	}
+}
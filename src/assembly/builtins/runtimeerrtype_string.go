@@ -15,11 +15,13 @@ func _() {
 	_ = x[NullPointerReferenceError-5]
 	_ = x[InvalidThreadUnlockError-6]
 	_ = x[SameThreadLockError-7]
+	_ = x[ClosedChannelError-8]
+	_ = x[NegativeCapacityError-9]
 }
 
-const _RuntimeErrType_name = "ArrayIndexTooLargeErrorArrayIndexNegativeErrorIntegerOverflowErrorDivideByZeroErrorNullPointerReferenceErrorInvalidThreadUnlockErrorSameThreadLockError"
+const _RuntimeErrType_name = "ArrayIndexTooLargeErrorArrayIndexNegativeErrorIntegerOverflowErrorDivideByZeroErrorNullPointerReferenceErrorInvalidThreadUnlockErrorSameThreadLockErrorClosedChannelErrorNegativeCapacityError"
 
-var _RuntimeErrType_index = [...]uint8{0, 23, 46, 66, 83, 108, 132, 151}
+var _RuntimeErrType_index = [...]uint8{0, 23, 46, 66, 83, 108, 132, 151, 169, 190}
 
 func (i RuntimeErrType) String() string {
 	i -= 1
@@ -1,86 +1,183 @@
 package assembly
 
 import (
+	"fmt"
+	"sort"
 	architecture "wacc_32/assembly/architectures"
+	"wacc_32/assembly/architectures/aarch64"
 	"wacc_32/assembly/architectures/arm11"
+	"wacc_32/assembly/architectures/x86_64"
 	"wacc_32/assembly/builtins"
 	ins "wacc_32/assembly/instructions"
+	"wacc_32/assembly/peephole"
 	"wacc_32/ast"
+	"wacc_32/ir"
+	"wacc_32/ir/tac"
 )
 
-//go:generate ./../visitor_generator/visitor_generator.sh instructions.Instruction instructions.ExprInstr *instructions.Context
-
-var _ ast.InstructionExprInstrVisitor = &CodeGenerator{}
-
-//CodeGenerator converts an AST into our internal assembly representation
+//CodeGenerator lowers the three address code of a program into our internal assembly representation
 type CodeGenerator struct {
 	architecture.Config
 	architecture.Emitter
-	bssVars   map[string]ins.Instruction
-	funcs     map[string]ins.Instruction
-	regs      *ins.RegisterManager
-	nIfs      int
-	numWhiles int
-	numDoWhiles int
-	numFors   int
+	bssVars  map[string]ins.Instruction
+	funcs    map[string]ins.Instruction
+	prog     map[string]*tac.Func
+	spawned  map[string]bool
+	frame    *frame
+	stats    []AllocStats
+	peephole []peephole.Rule
+	debug    *debugInfo
+	lockdep  bool
+}
+
+//AllocStats records how many of the temps of a function were spilled to the stack
+type AllocStats struct {
+	Func    string
+	Temps   int
+	Spilled int
 }
 
-//newCodeGenerator creates a code generator with a specific number of registers
+//Stats returns the register allocation statistics of every function lowered so far
+func (cg *CodeGenerator) Stats() []AllocStats {
+	return cg.stats
+}
+
+//newCodeGenerator creates a code generator for an architecture
 func newCodeGenerator(conf architecture.Config, emitter architecture.Emitter) *CodeGenerator {
 	return &CodeGenerator{
-		Config:  conf,
-		Emitter: emitter,
-		bssVars: make(map[string]ins.Instruction),
-		funcs:   make(map[string]ins.Instruction),
-		regs:    ins.NewRegisterManager(conf.CalleeSavedRegs, conf.CallerSavedRegs, conf.NRegs),
+		Config:   conf,
+		Emitter:  emitter,
+		bssVars:  make(map[string]ins.Instruction),
+		funcs:    make(map[string]ins.Instruction),
+		prog:     make(map[string]*tac.Func),
+		spawned:  make(map[string]bool),
+		peephole: peephole.All(conf),
 	}
 }
 
+//SetPeephole sets the peephole rules run over the generated code, none disables the pass
+func (cg *CodeGenerator) SetPeephole(rules []peephole.Rule) {
+	cg.peephole = rules
+}
+
+//SetLockdep makes the generated program check the order locks are acquired in, stopping
+//with a runtime error when two locks are acquired in opposite orders
+func (cg *CodeGenerator) SetLockdep() {
+	cg.lockdep = true
+}
+
 //NewArm11CodeGenerator creates a CodeGenerator which emits arm11 code
 func NewArm11CodeGenerator() *CodeGenerator {
 	return newCodeGenerator(arm11.Config(), arm11.Emitter{})
 }
 
+//NewAArch64CodeGenerator creates a CodeGenerator which emits aarch64 code
+func NewAArch64CodeGenerator() *CodeGenerator {
+	return newCodeGenerator(aarch64.Config(), aarch64.Emitter{})
+}
+
+//NewX86_64CodeGenerator creates a CodeGenerator which emits x86-64 code
+func NewX86_64CodeGenerator() *CodeGenerator {
+	return newCodeGenerator(x86_64.Config(), x86_64.Emitter{})
+}
+
+//targets maps the names accepted by the -target flag to a CodeGenerator
+var targets = map[string]func() *CodeGenerator{
+	"arm11":   NewArm11CodeGenerator,
+	"aarch64": NewAArch64CodeGenerator,
+	"x86_64":  NewX86_64CodeGenerator,
+}
+
+//Targets returns the names of all supported target architectures
+func Targets() []string {
+	names := make([]string, 0, len(targets))
+	for name := range targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//NewCodeGenerator creates a CodeGenerator for the named target architecture
+func NewCodeGenerator(target string) (*CodeGenerator, error) {
+	newTarget, ok := targets[target]
+	if !ok {
+		return nil, fmt.Errorf("unknown target %q, expected one of %v", target, Targets())
+	}
+	return newTarget(), nil
+}
+
+//GenerateCode converts a semantically checked AST into assembly
 func (cg *CodeGenerator) GenerateCode(tree ast.AST) string {
 	builtins.Init(cg.Config)
-	bss, instrs := cg.generateInternalCode(tree)
+	gen := ir.NewGenerator()
+	if cg.lockdep {
+		gen.SetLockdep()
+	}
+	prog := gen.Generate(tree)
+	bss, instrs := cg.generateInternalCode(prog)
+	instrs = append(append(cg.debugHeader(), instrs...), cg.debugTrailer(prog.UserTypes))
+	if len(cg.peephole) > 0 {
+		instrs = peephole.Optimise(peephole.Flatten(instrs), cg.peephole)
+	}
 	return cg.Emit(bss, instrs)
 }
 
-//generateInternalCode visits the tree and returns an internal representation of the assembly code
-func (cg *CodeGenerator) generateInternalCode(tree ast.AST) (bssVars, instrs ins.Instructions) {
-	ctx := &ins.Context{}
-	mainInstrs := ins.Instructions{cg.VisitAST(tree, ctx)}
-	instrs = make([]ins.Instruction, len(cg.funcs)+len(mainInstrs))
-	bssVars = make([]ins.Instruction, len(cg.bssVars))
-	i := 0
-	for _, v := range cg.bssVars {
-		bssVars[i] = v
-		i++
+//generateInternalCode lowers the program and returns an internal representation of the assembly code.
+//The thread headers and builtins the program uses, sorted by label, come first so the
+//code of the source comes after all the code which isn't from any line of it.
+//Functions follow in program order with main last
+func (cg *CodeGenerator) generateInternalCode(prog *tac.Program) (bssVars, instrs ins.Instructions) {
+	for _, fn := range prog.Funcs {
+		cg.prog[fn.Name] = fn
 	}
-	i = 0
-	for _, v := range cg.funcs {
-		instrs[i] = v
-		i++
+	for _, str := range prog.Strings {
+		cg.bssVars[str.Label] = ins.NewStringLiteral(str.Label, str.Value)
 	}
-	for _, v := range mainInstrs {
-		instrs[i] = v
-		i++
+	for _, vt := range prog.VTables {
+		cg.bssVars[vt.Label] = ins.NewAddressTable(vt.Label, vt.Funcs)
+	}
+	for _, label := range prog.Globals {
+		cg.bssVars[label] = ins.NewAddressTable(label, []string{"0"})
 	}
-	return bssVars, instrs
-}
 
-//VisitProgram visits AST node ast.Program
-func (cg *CodeGenerator) VisitProgram(node ast.Program, ctx *ins.Context) ins.Instruction {
-	prog := ins.Instructions(make([]ins.Instruction, 0))
+	var mainInstrs ins.Instruction = ins.NOOP{}
+	funcs := ins.Instructions{}
+	for _, fn := range prog.Funcs {
+		if fn.IsMain() {
+			mainInstrs = cg.lowerFunc(fn)
+		} else {
+			funcs = append(funcs, cg.lowerFunc(fn))
+		}
+	}
+	for _, fn := range prog.Funcs {
+		if cg.spawned[fn.Name] {
+			instrs = append(instrs, cg.concurrentHeader(fn))
+		}
+	}
 
-	for _, st := range node.GetStructs() {
-		prog = append(prog, cg.VisitUserType(*st, ctx))
+	for _, label := range sortedKeys(cg.funcs) {
+		instrs = append(instrs, cg.funcs[label])
 	}
+	for _, label := range sortedKeys(cg.bssVars) {
+		bssVars = append(bssVars, cg.bssVars[label])
+	}
+	return bssVars, append(append(instrs, funcs...), mainInstrs)
+}
 
-	for _, fn := range node.GetFuncs() {
-		prog = append(prog, cg.VisitFunction(*fn, ctx))
+func sortedKeys(m map[string]ins.Instruction) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
 	}
+	sort.Strings(keys)
+	return keys
+}
 
-	return prog
+//alignFrame rounds a stack frame size up to the target's frame alignment
+func (cg *CodeGenerator) alignFrame(size int) int {
+	if cg.FrameAlignment <= 1 {
+		return size
+	}
+	return (size + cg.FrameAlignment - 1) / cg.FrameAlignment * cg.FrameAlignment
 }
@@ -1,146 +1,180 @@
 package assembly
 
 import (
-	"wacc_32/assembly/builtins"
+	"fmt"
 	ins "wacc_32/assembly/instructions"
-	"wacc_32/ast"
+	"wacc_32/ir/tac"
 	"wacc_32/types"
 )
 
-//VisitWaccRoutine returns instructions to create and detach a pthread
+//lowerSpawn copies the arguments to the heap and runs the function in a pthread, which
+//is detached unless it is created in a handle to be joined
 //mov r0, <size>
 //bl malloc
-//mov r3, r0
-//str arg1, [r3] ...
-//sub sp, sp, #4
-//mov r0, sp
-//ldr r2, <functon>
+//mov r5, r0
+//str arg1, [r5] ...
+//add r0, sp, <thread>
 //mov r1, #0
+//ldr r2, <function>
+//mov r3, r5
 //bl pthread_create
-//ldr r0, [sp]
+//ldr r0, [sp, <thread>]
 //bl pthread_detach
-//add sp, sp, #4
-func (cg *CodeGenerator) VisitWaccRoutine(node ast.WaccRoutine, ctx *ins.Context) ins.Instruction {
-	r0, _ := cg.regs.AcquireCalleeSaved()
-	r1, _ := cg.regs.AcquireCalleeSaved()
-	r2, _ := cg.regs.AcquireCalleeSaved()
-	r3, _ := cg.regs.AcquireCalleeSaved()
-	defer cg.regs.ReleaseCalleeSaved(r0)
-	defer cg.regs.ReleaseCalleeSaved(r1)
-	defer cg.regs.ReleaseCalleeSaved(r2)
-	defer cg.regs.ReleaseCalleeSaved(r3)
+//A function started from its address is passed it after the arguments and run by a
+//routine header for arguments of that size
+func (cg *CodeGenerator) lowerSpawn(spawn tac.Spawn) ins.Instructions {
+	work, args := cg.workRegs(), cg.argRegs()
+	val, argPtr := work[0], work[1]
 
 	//1. Allocate memory for the arguments
-	argInstrs := ins.Instructions{}
-	var argSize types.Size
-	for _, arg := range node.GetArgs() {
-		exprInstrs := cg.VisitExpression(arg, ctx)
-		size := exprSize(arg)
-		loadInstrs, reg, _ := cg.loadIfNotRegister(exprInstrs.Result, size)
-		cg.regs.ReleaseCallerSaved(reg)
-		argInstrs = append(argInstrs, exprInstrs.Instrs...)
-		argInstrs = append(argInstrs, loadInstrs)
-		argInstrs = append(argInstrs, ins.NewStore(size, reg, ins.NewAddress(r3, ins.Immediate(argSize))))
-		argSize += size
+	var sizes []types.Size
+	var offsets []int
+	var total int
+	header := concHeader(spawn.Func)
+	if spawn.Code != nil {
+		sizes = spawn.Sizes
+		offsets, total = layout(sizes)
+		code := align(total, int(cg.PointerSize))
+		sizes = append(append([]types.Size{}, sizes...), cg.PointerSize)
+		offsets = append(offsets, code)
+		total = code + int(cg.PointerSize)
+		header = cg.routineHeader(code)
+	} else {
+		cg.spawned[spawn.Func] = true
+		sizes, offsets, total = cg.argLayout(spawn.Func, spawn.Args)
 	}
-	if len(argInstrs) > 0 {
-		argInstrs = append(
-			ins.Instructions{
-				ins.NewStoreHeap(r0, ins.Immediate(argSize), 0, 0),
-				ins.NewMove(r0, r3),
-			}, argInstrs...)
+	instrs := ins.Instructions{ins.NewMove(ins.Immediate(0), argPtr)}
+	if total > 0 {
+		instrs = append(cg.callC("malloc", ins.Immediate(total)), ins.NewMove(cg.ReturnRegister, argPtr))
+		for i, arg := range spawnOperands(spawn) {
+			instrs = append(instrs,
+				cg.load(arg, val),
+				ins.NewStore(sizes[i], val, ins.NewAddress(argPtr, ins.Immediate(offsets[i]))),
+			)
+		}
 	}
 
 	//2. Create thread
-	name, _ := node.FormatName()
-	threadCreateInstrs := ins.Instructions{
-		ins.NewSub(cg.StackPointer, cg.StackPointer, ins.Immediate(types.Word)),
-		ins.NewMove(cg.StackPointer, r0),
-		ins.NewLoad(ins.FunctionPointer(concHeader(name[1:])), r2, types.Word),
-		ins.NewMove(ins.Immediate(0), r1),
-		ins.NewFunctionCall("pthread_create"),
+	thread := ins.Immediate(cg.frame.thread)
+	if spawn.Handle != nil {
+		instrs = append(instrs, cg.load(spawn.Handle, args[0]))
+	} else {
+		instrs = append(instrs, ins.NewAdd(args[0], cg.StackPointer, thread))
 	}
+	instrs = append(instrs,
+		ins.NewMove(ins.Immediate(0), args[1]),
+		ins.NewLoad(ins.FunctionPointer(header), args[2], cg.PointerSize),
+		ins.NewMove(argPtr, args[3]),
+		ins.NewFunctionCall("pthread_create"),
+	)
 
 	//3. Detach thread
-	threadDetachInstrs := ins.Instructions{
-		ins.NewLoad(ins.NewAddress(cg.StackPointer), r0, types.Word),
-		ins.NewFunctionCall("pthread_detach"),
-		ins.NewAdd(cg.StackPointer, cg.StackPointer, ins.Immediate(types.Word)),
-	}
-	return append(append(argInstrs, threadCreateInstrs...), threadDetachInstrs...)
-}
-
-func (cg *CodeGenerator) VisitStatLock(node ast.StatLock, ctx *ins.Context) ins.Instruction {
-	r0, _ := cg.regs.AcquireCalleeSaved()
-	defer cg.regs.ReleaseCalleeSaved(r0)
-
-	offset := node.GetSymbolTable().GetOffset(node.GetName())
-	loadLock := ins.Instructions{
-		ins.NewLoad(ins.NewAddress(cg.StackPointer, ins.Immediate(offset)), r0, types.Word),
-	}
-	var function, runtimeCheck string
-	switch node.GetType() {
-	case ast.Acquire:
-		function = "pthread_mutex_lock"
-		cg.addCheckSameThreadLockCode()
-		runtimeCheck = builtins.SameThreadLockCheckLabel
-	case ast.Release:
-		function = "pthread_mutex_unlock"
-		cg.addInvalidThreadUnlockCode()
-		runtimeCheck = builtins.InvalidThreadUnlockCheckLabel
+	if spawn.Handle != nil {
+		return instrs
 	}
-	return append(loadLock, ins.NewFunctionCall(function), ins.NewFunctionCall(runtimeCheck))
+	return append(instrs,
+		ins.NewLoad(ins.NewAddress(cg.StackPointer, thread), args[0], cg.PointerSize),
+		ins.NewFunctionCall("pthread_detach"),
+	)
 }
 
 func concHeader(name string) string {
 	return ".." + name + "_conc"
 }
 
-func (cg *CodeGenerator) generateConcurrentHeader(name string, offset, nArgs int) ins.Instructions {
-	r0, _ := cg.regs.AcquireCalleeSaved()
-	r1, _ := cg.regs.AcquireCalleeSaved()
-	r2, _ := cg.regs.AcquireCalleeSaved()
-	r3, _ := cg.regs.AcquireCalleeSaved()
-	defer cg.regs.ReleaseCalleeSaved(r0)
-	defer cg.regs.ReleaseCalleeSaved(r1)
-	defer cg.regs.ReleaseCalleeSaved(r2)
-	defer cg.regs.ReleaseCalleeSaved(r3)
+//spawnOperands returns the values copied to the heap for a thread, the address of the
+//function follows the arguments when it is given
+func spawnOperands(spawn tac.Spawn) []tac.Operand {
+	if spawn.Code == nil {
+		return spawn.Args
+	}
+	return append(append([]tac.Operand{}, spawn.Args...), spawn.Code)
+}
 
-	headerName := concHeader(name)
-	label := ins.Instructions{
-		ins.NewLabel(headerName),
+//routineHeader returns the entry point of threads running the function at the address
+//stored at offset code of the heap pointer in r0, after its arguments. Like a
+//concurrentHeader it copies the arguments to where the function expects them
+func (cg *CodeGenerator) routineHeader(code int) string {
+	label := fmt.Sprintf("..routine%d_conc", code)
+	if _, ok := cg.funcs[label]; ok {
+		return label
 	}
-	if nArgs > 0 {
-		label = append(label,
-			ins.Instructions{
-				ins.NewPush(cg.LinkRegister),
-				ins.NewSub(cg.StackPointer, cg.StackPointer, ins.Immediate(offset)),
-				ins.NewMove(r0, r1),
-				ins.NewMove(r0, r3),
-				ins.NewMove(cg.StackPointer, r0),
-				ins.NewMove(ins.Immediate(offset), r2),
-				ins.NewFunctionCall("memmove"),
-				ins.NewAdd(cg.StackPointer, cg.StackPointer, ins.Immediate(offset)),
-				ins.NewPop(cg.LinkRegister),
-			}...,
-		)
+	args := cg.argRegs()
+	total := code + int(cg.PointerSize)
+	size := cg.alignFrame(total)
+	cg.funcs[label] = ins.Instructions{
+		ins.NewLabel(label),
+		ins.NewPush(cg.LinkRegister),
+		ins.NewDecrementStack(size, cg.StackPointer),
+		ins.NewMove(args[0], args[1]),
+		ins.NewMove(cg.StackPointer, args[0]),
+		ins.NewMove(ins.Immediate(total), args[2]),
+		ins.NewFunctionCall("memmove"),
+		ins.NewLoad(ins.NewAddress(cg.StackPointer, ins.Immediate(code)), args[0], cg.PointerSize),
+		ins.NewIndirectCall(args[0]),
+		ins.NewIncrementStack(size, cg.StackPointer),
+		ins.NewPop(cg.ProgramCounter),
+		ins.Pool{},
 	}
 	return label
 }
 
-func (cg *CodeGenerator) VisitStatSema(node ast.StatSema, ctx *ins.Context) ins.Instruction {
-	expr := cg.VisitIdent(*node.GetIdent(), ctx)
-	loadInstrs, reg, _ := cg.loadIfNotRegister(expr.Result, types.Word)
-	cg.regs.ReleaseCallerSaved(reg)
-
-	fName := "sem_wait"
-	if node.IsUp() {
-		fName = "sem_post"
+//concurrentHeader is the entry point of a thread running fn, it copies the
+//arguments from the heap pointer in r0 to where fn expects them and calls fn
+func (cg *CodeGenerator) concurrentHeader(fn *tac.Func) ins.Instructions {
+	args := cg.argRegs()
+	_, _, total := paramLayout(fn)
+	size := cg.alignFrame(total)
+	instrs := ins.Instructions{
+		ins.NewLabel(concHeader(fn.Name)),
+		ins.NewPush(cg.LinkRegister),
+		ins.NewDecrementStack(size, cg.StackPointer),
 	}
-	return ins.Instructions{
-		expr.Instrs,
-		loadInstrs,
-		ins.NewMove(reg, cg.ReturnRegister),
-		ins.NewFunctionCall(fName),
+	if total > 0 {
+		instrs = append(instrs,
+			ins.NewMove(args[0], args[1]),
+			ins.NewMove(cg.StackPointer, args[0]),
+			ins.NewMove(ins.Immediate(total), args[2]),
+			ins.NewFunctionCall("memmove"),
+		)
 	}
+	return append(instrs,
+		ins.NewFunctionCall(fn.Name),
+		ins.NewIncrementStack(size, cg.StackPointer),
+		ins.NewPop(cg.ProgramCounter),
+		ins.Pool{},
+	)
+}
+
+//lowerNewLock creates an error checking mutex
+func (cg *CodeGenerator) lowerNewLock(lock tac.NewLock) ins.Instructions {
+	work := cg.workRegs()
+	attr, mutex := work[0], work[1]
+	instrs := cg.callC("malloc", ins.Immediate(cg.MutexSize))
+	instrs = append(instrs, ins.NewMove(cg.ReturnRegister, mutex))
+	instrs = append(instrs, cg.callC("malloc", ins.Immediate(cg.MutexAttrSize))...)
+	instrs = append(instrs, ins.NewMove(cg.ReturnRegister, attr))
+	instrs = append(instrs, cg.callC("pthread_mutexattr_init", attr)...)
+	//2 is PTHREAD_MUTEX_ERRORCHECK_NP
+	instrs = append(instrs, cg.callC("pthread_mutexattr_settype", attr, ins.Immediate(2))...)
+	instrs = append(instrs, cg.callC("pthread_mutex_init", mutex, attr)...)
+	return append(instrs, cg.assign(lock.Dst, mutex))
+}
+
+//lowerNewSema creates a semaphore shared between the threads of the program
+func (cg *CodeGenerator) lowerNewSema(sema tac.NewSema) ins.Instructions {
+	reg := cg.workRegs()[1]
+	instrs := cg.callC("malloc", ins.Immediate(cg.SemaphoreSize))
+	instrs = append(instrs, ins.NewMove(cg.ReturnRegister, reg))
+	instrs = append(instrs, cg.callC("sem_init", reg, ins.Immediate(0), ins.Immediate(sema.Value))...)
+	return append(instrs, cg.assign(sema.Dst, reg))
+}
+
+//lowerNewCond creates a condition variable with the default attributes
+func (cg *CodeGenerator) lowerNewCond(cond tac.NewCond) ins.Instructions {
+	reg := cg.workRegs()[1]
+	instrs := cg.callC("malloc", ins.Immediate(cg.CondSize))
+	instrs = append(instrs, ins.NewMove(cg.ReturnRegister, reg))
+	instrs = append(instrs, cg.callC("pthread_cond_init", reg, ins.Immediate(0))...)
+	return append(instrs, cg.assign(cond.Dst, reg))
 }
@@ -0,0 +1,201 @@
+package assembly
+
+import (
+	"fmt"
+	"path/filepath"
+	ins "wacc_32/assembly/instructions"
+	"wacc_32/ir/tac"
+	"wacc_32/types"
+)
+
+//Labels around the code of the program
+const (
+	textStart = ".Ltext0"
+	textEnd   = ".Letext0"
+)
+
+//debugInfo numbers the source files of a program compiled with debug information and
+//collects the functions lowered so far
+type debugInfo struct {
+	main   string
+	files  map[string]int
+	names  []string
+	labels int
+	funcs  []ins.DebugFunc
+}
+
+//scopeLabel marks the start of the code for a line in a scope of the function being lowered
+type scopeLabel struct {
+	scope int
+	label string
+}
+
+//SetDebug marks the code of every line of the source and describes every stack frame,
+//so debuggers can set breakpoints on lines and show the call stack. main is the path
+//of the file being compiled
+func (cg *CodeGenerator) SetDebug(main string) {
+	cg.debug = &debugInfo{main: main, files: make(map[string]int)}
+	cg.debug.file("")
+}
+
+//file returns the index of a source file, empty for the file being compiled.
+//Libraries are found relative to the working directory like they were when parsing
+func (d *debugInfo) file(name string) int {
+	if index, ok := d.files[name]; ok {
+		return index
+	}
+	path := d.main
+	if name != "" {
+		path = name
+		if abs, err := filepath.Abs(name); err == nil {
+			path = abs
+		}
+	}
+	d.names = append(d.names, path)
+	d.files[name] = len(d.names)
+	return len(d.names)
+}
+
+//label returns a new label for the debug information
+func (d *debugInfo) label() string {
+	d.labels++
+	return fmt.Sprintf(".Ldbg%d", d.labels-1)
+}
+
+//loc marks the start of the code for a line of the source when debugging, the label
+//before it records where the scope of the line is
+func (cg *CodeGenerator) loc(l tac.Loc) ins.Instruction {
+	if cg.debug == nil || l.Line == 0 {
+		return ins.NOOP{}
+	}
+	label := cg.debug.label()
+	cg.frame.locs = append(cg.frame.locs, scopeLabel{l.Scope, label})
+	return ins.Instructions{
+		ins.NewDebugLabel(label),
+		ins.NewLoc(cg.debug.file(l.File), l.Line, l.Col+1),
+	}
+}
+
+//funcEnd ends the code of the function being lowered when debugging, recording where
+//its variables are
+func (cg *CodeGenerator) funcEnd() ins.Instruction {
+	if cg.debug == nil {
+		return ins.NOOP{}
+	}
+	end := cg.debug.label()
+	fn := cg.frame.fn
+	cg.debug.funcs = append(cg.debug.funcs, ins.DebugFunc{
+		Name:  fn.Name,
+		File:  cg.debug.file(fn.Loc.File),
+		Line:  fn.Loc.Line,
+		Start: fn.Name,
+		End:   end,
+		Scope: cg.debugScopes(end)[0],
+	})
+	return ins.NewDebugLabel(end)
+}
+
+//debugScopes describes every scope of the function being lowered, the code of a scope
+//runs from the first line in it or the scopes nested in it up to the line after the last.
+//Scopes without variables, or whose code was never reached, are left out of their parent
+func (cg *CodeGenerator) debugScopes(end string) []ins.DebugScope {
+	f := cg.frame
+	scopes := make([]ins.DebugScope, len(f.fn.Scopes))
+	first := make([]int, len(scopes))
+	last := make([]int, len(scopes))
+	for s := range scopes {
+		first[s] = -1
+	}
+	for i, l := range f.locs {
+		for s := l.scope; s != tac.NoScope; s = f.fn.Scopes[s] {
+			if first[s] == -1 {
+				first[s] = i
+			}
+			last[s] = i
+		}
+	}
+
+	isParam := make(map[tac.Temp]bool, len(f.fn.Params))
+	for _, p := range f.fn.Params {
+		isParam[p] = true
+	}
+	for t, info := range f.fn.Temps {
+		if info.Type != nil {
+			s := info.Decl.Scope
+			scopes[s].Vars = append(scopes[s].Vars, cg.debugVar(tac.Temp(t), isParam[tac.Temp(t)]))
+		}
+	}
+
+	//Scopes are created after the scopes around them, so children come before parents
+	for s := len(scopes) - 1; s > 0; s-- {
+		if first[s] == -1 || len(scopes[s].Vars)+len(scopes[s].Scopes) == 0 {
+			continue
+		}
+		scopes[s].Start = f.locs[first[s]].label
+		scopes[s].End = end
+		if last[s]+1 < len(f.locs) {
+			scopes[s].End = f.locs[last[s]+1].label
+		}
+		parent := &scopes[f.fn.Scopes[s]]
+		parent.Scopes = append([]ins.DebugScope{scopes[s]}, parent.Scopes...)
+	}
+	return scopes
+}
+
+//debugVar describes where the variable held in t is, stack slots are relative to the
+//stack pointer of the caller
+func (cg *CodeGenerator) debugVar(t tac.Temp, param bool) ins.DebugVar {
+	info := cg.frame.fn.Temps[t]
+	v := ins.DebugVar{
+		Name:  info.Name,
+		Type:  info.Type,
+		File:  cg.debug.file(info.Decl.File),
+		Line:  info.Decl.Line,
+		Param: param,
+	}
+	if r, ok := cg.frame.reg(t); ok {
+		v.InReg, v.Reg = true, r
+	} else {
+		v.Offset = cg.frame.slots[t] - cg.frame.size - cg.FrameHeaderSize
+	}
+	return v
+}
+
+//frameInfo describes a change to the stack frame of the function being lowered when debugging
+func (cg *CodeGenerator) frameInfo(kind ins.FrameInfoKind, offset int) ins.Instruction {
+	if cg.debug == nil {
+		return ins.NOOP{}
+	}
+	return ins.NewFrameInfo(kind, offset)
+}
+
+//debugHeader declares the source files the code refers to, it must come before any
+//other code
+func (cg *CodeGenerator) debugHeader() ins.Instructions {
+	if cg.debug == nil {
+		return nil
+	}
+	instrs := ins.Instructions{ins.NewFrameInfo(ins.FrameTable, 0)}
+	for i, name := range cg.debug.names {
+		instrs = append(instrs, ins.NewSourceFile(i+1, name))
+	}
+	return append(instrs, ins.NewDebugLabel(textStart))
+}
+
+//debugTrailer describes the functions of the program and the types of their variables,
+//it must come after all other code
+func (cg *CodeGenerator) debugTrailer(userTypes map[string]types.UserType) ins.Instruction {
+	if cg.debug == nil {
+		return ins.NOOP{}
+	}
+	return ins.Instructions{
+		ins.NewDebugLabel(textEnd),
+		ins.DebugInfo{
+			File:      cg.debug.main,
+			Start:     textStart,
+			End:       textEnd,
+			Funcs:     cg.debug.funcs,
+			UserTypes: userTypes,
+		},
+	}
+}
@@ -3,75 +3,350 @@ package assembly
 import (
 	"wacc_32/assembly/builtins"
 	ins "wacc_32/assembly/instructions"
-	"wacc_32/ast"
+	"wacc_32/ir/tac"
+	"wacc_32/types"
 )
 
-//VisitFunction visits AST node ast.Function
-func (cg *CodeGenerator) VisitFunction(node ast.Function, ctx *ins.Context) ins.Instruction {
-	subCtx := &ins.Context{}
+//frame is the stack frame of the function being lowered, from the stack pointer up:
+//
+//	the arguments of the wacc functions it calls
+//	a slot for every spilled temp
+//	the callee saved registers it writes
+//	the frame header pushed by the prologue
+//	its own parameters, stored by its caller
+type frame struct {
+	fn     *tac.Func
+	size   int
+	slots  []int
+	regs   map[tac.Temp]ins.Register
+	saved  []ins.Register
+	saveAt int
+	thread int
+	locs   []scopeLabel
+}
 
-	name := node.GetName()
+//reg returns the register holding t, false if t is spilled
+func (f *frame) reg(t tac.Temp) (ins.Register, bool) {
+	r, ok := f.regs[t]
+	return r, ok
+}
 
-	statInstrs, early := cg.visitStatsTillEnd(node.GetStats(), subCtx)
-	sym := node.GetSymbolTable()
-	offset := sym.GetTotalOffset()
-	withStack := wrapInstructions(
-		ins.NewDecrementStack(offset, cg.StackPointer),
-		statInstrs,
-		ins.NewIncrementStack(offset, cg.StackPointer),
-	)
-	withPushPop := addHeader(
-		ins.NewPush(cg.LinkRegister),
-		withStack,
-	)
+//layout places values of the given sizes one after another, each aligned to its size
+func layout(sizes []types.Size) (offsets []int, total int) {
+	offsets = make([]int, len(sizes))
+	for i, size := range sizes {
+		total = align(total, int(size))
+		offsets[i] = total
+		total += int(size)
+	}
+	return offsets, total
+}
+
+func align(offset, size int) int {
+	return (offset + size - 1) / size * size
+}
 
-	withLabel := addHeader(ins.NewLabel(name), withPushPop)
-	if name == "main" && !early {
-		withLabel = addTail(withLabel, ins.NewExit(ins.Immediate(0)))
-		return addTail(withLabel, ins.NewPop(cg.ProgramCounter))
+//paramLayout returns where the parameters of fn are stored relative to the caller's stack pointer
+func paramLayout(fn *tac.Func) (sizes []types.Size, offsets []int, total int) {
+	sizes = make([]types.Size, len(fn.Params))
+	for i, p := range fn.Params {
+		sizes[i] = fn.Size(p)
 	}
+	offsets, total = layout(sizes)
+	return sizes, offsets, total
+}
+
+//argLayout returns where the arguments of a call to name are stored, using the size of the
+//argument itself when name isn't part of the program
+func (cg *CodeGenerator) argLayout(name string, args []tac.Operand) (sizes []types.Size, offsets []int, total int) {
+	if callee, ok := cg.prog[name]; ok {
+		return paramLayout(callee)
+	}
+	sizes = make([]types.Size, len(args))
+	for i, arg := range args {
+		sizes[i] = cg.operandSize(arg)
+	}
+	offsets, total = layout(sizes)
+	return sizes, offsets, total
+}
 
-	//Add concurrent header if the function is concurrent
-	//The concurrent header sets up the stack of the function, from the pointer in r0
-	concurrentHeader := cg.generateConcurrentHeader(name, offset, len(node.GetParams()))
-	withLabel = append(concurrentHeader, withLabel...)
+//closureArgLayout returns where the arguments of a call through a function value are
+//stored, the closure is passed after them
+func (cg *CodeGenerator) closureArgLayout(call tac.CallClosure) (sizes []types.Size, offsets []int, total int) {
+	sizes = append(append([]types.Size{}, call.Sizes...), cg.PointerSize)
+	offsets, total = layout(sizes)
+	return sizes, offsets, total
+}
 
-	cg.funcs[name] = append(withLabel, ins.NewPop(cg.ProgramCounter), ins.Pool{})
-	return ins.NOOP{}
+//indirectArgLayout returns where the arguments of a call to the address of a function
+//are stored
+func indirectArgLayout(call tac.CallIndirect) (offsets []int, total int) {
+	return layout(call.Sizes)
 }
 
-//VisitParamList visits AST node ast.ParamList
-func (cg *CodeGenerator) VisitParamList(node ast.ParamList, ctx *ins.Context) ins.Instruction {
-	return ins.NOOP{}
+//operandSize returns the size of the value of op in the function being lowered
+func (cg *CodeGenerator) operandSize(op tac.Operand) types.Size {
+	switch o := op.(type) {
+	case tac.Temp:
+		return cg.frame.fn.Size(o)
+	case tac.Global:
+		return cg.PointerSize
+	}
+	return types.Word
+}
+
+//newFrame allocates registers to the temps of fn and lays out its stack frame
+func (cg *CodeGenerator) newFrame(fn *tac.Func) *frame {
+	f := &frame{
+		fn:    fn,
+		slots: make([]int, len(fn.Temps)),
+		regs:  allocate(fn, cg.colours()),
+	}
+	cg.frame = f
+
+	//The outgoing argument area is shared by every call
+	offset := 0
+	spawns := false
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			switch i := instr.(type) {
+			case tac.Call:
+				if !i.C {
+					if _, _, size := cg.argLayout(i.Func, i.Args); size > offset {
+						offset = size
+					}
+				}
+			case tac.CallClosure:
+				if _, _, size := cg.closureArgLayout(i); size > offset {
+					offset = size
+				}
+			case tac.CallIndirect:
+				if _, size := indirectArgLayout(i); size > offset {
+					offset = size
+				}
+			case tac.Spawn:
+				spawns = true
+			}
+		}
+	}
+
+	isParam := make(map[tac.Temp]bool, len(fn.Params))
+	for _, p := range fn.Params {
+		isParam[p] = true
+	}
+	stats := AllocStats{Func: fn.Name, Temps: len(fn.Temps)}
+	for t, info := range fn.Temps {
+		if _, ok := f.reg(tac.Temp(t)); ok {
+			continue
+		}
+		stats.Spilled++
+		if isParam[tac.Temp(t)] {
+			continue
+		}
+		offset = align(offset, int(info.Size))
+		f.slots[t] = offset
+		offset += int(info.Size)
+	}
+	cg.stats = append(cg.stats, stats)
+
+	//main never returns so it doesn't need to preserve anything
+	if !fn.IsMain() {
+		f.saved = append(f.saved, cg.workRegs()...)
+		for _, r := range cg.colours() {
+			for _, used := range f.regs {
+				if r == used {
+					f.saved = append(f.saved, r)
+					break
+				}
+			}
+		}
+	}
+	offset = align(offset, int(cg.PointerSize))
+	f.saveAt = offset
+	offset += len(f.saved) * int(cg.PointerSize)
+
+	if spawns {
+		f.thread = offset
+		offset += int(cg.PointerSize)
+	}
+	f.size = cg.alignFrame(offset)
+
+	_, paramOffsets, _ := paramLayout(fn)
+	for i, p := range fn.Params {
+		f.slots[p] = f.size + cg.FrameHeaderSize + paramOffsets[i]
+	}
+	return f
+}
+
+//saveRegs stores the callee saved registers the function writes, or restores them
+func (cg *CodeGenerator) saveRegs(restore bool) ins.Instructions {
+	instrs := make(ins.Instructions, len(cg.frame.saved))
+	for i, r := range cg.frame.saved {
+		addr := ins.NewAddress(cg.StackPointer, ins.Immediate(cg.frame.saveAt+i*int(cg.PointerSize)))
+		if restore {
+			instrs[i] = ins.NewLoad(addr, r, cg.PointerSize)
+		} else {
+			instrs[i] = ins.NewStore(cg.PointerSize, r, addr)
+		}
+	}
+	return instrs
+}
+
+//lowerFunc lowers a function, blocks are emitted in order so jumps to the next block are left out
+func (cg *CodeGenerator) lowerFunc(fn *tac.Func) ins.Instructions {
+	f := cg.newFrame(fn)
+	instrs := ins.Instructions{
+		ins.NewLabel(fn.Name),
+		cg.loc(fn.Loc),
+		cg.frameInfo(ins.FrameStart, 0),
+		ins.NewPush(cg.LinkRegister),
+		cg.frameInfo(ins.FrameHeader, cg.FrameHeaderSize),
+		ins.NewDecrementStack(f.size, cg.StackPointer),
+		cg.frameInfo(ins.FrameSize, cg.FrameHeaderSize+f.size),
+		cg.saveRegs(false),
+	}
+	for _, p := range fn.Params {
+		if r, ok := f.reg(p); ok {
+			instrs = append(instrs, ins.NewLoad(cg.slot(p), r, fn.Size(p)))
+		}
+	}
+	for i, b := range fn.Blocks {
+		var next *tac.Block
+		if i+1 < len(fn.Blocks) {
+			next = fn.Blocks[i+1]
+		}
+		instrs = append(instrs, ins.NewLabel(b.Label))
+		for _, instr := range b.Instrs {
+			instrs = append(instrs, cg.lowerInstr(instr))
+		}
+		instrs = append(instrs, cg.lowerTerminator(b.Term, next))
+	}
+	return append(instrs, cg.funcEnd(), cg.frameInfo(ins.FrameEnd, 0), ins.Pool{})
 }
 
-//VisitParam visits AST node ast.Param
-func (cg *CodeGenerator) VisitParam(node ast.Param, ctx *ins.Context) ins.Instruction {
+//lowerTerminator ends a block, main exits instead of returning
+func (cg *CodeGenerator) lowerTerminator(term tac.Terminator, next *tac.Block) ins.Instruction {
+	scratch := cg.workRegs()[0]
+	switch t := term.(type) {
+	case tac.Jump:
+		if t.Target == next {
+			return ins.NOOP{}
+		}
+		return ins.NewBranch(t.Target.Label, ins.AL)
+	case tac.Branch:
+		load, cond := cg.operand(t.Cond, scratch)
+		instrs := ins.Instructions{load, ins.NewCompare(cond, ins.Immediate(0))}
+		switch {
+		case t.Then == next:
+			return append(instrs, ins.NewBranch(t.Else.Label, ins.EQ))
+		case t.Else == next:
+			return append(instrs, ins.NewBranch(t.Then.Label, ins.NE))
+		}
+		return append(instrs, ins.NewBranch(t.Else.Label, ins.EQ), ins.NewBranch(t.Then.Label, ins.AL))
+	case tac.Return:
+		if cg.frame.fn.IsMain() {
+			load, code := cg.operand(t.Value, scratch)
+			return ins.Instructions{load, ins.NewExit(code)}
+		}
+		return ins.Instructions{
+			cg.load(t.Value, cg.ReturnRegister),
+			cg.saveRegs(true),
+			cg.frameInfo(ins.FrameRemember, 0),
+			ins.NewIncrementStack(cg.frame.size, cg.StackPointer),
+			cg.frameInfo(ins.FrameSize, cg.FrameHeaderSize),
+			ins.NewPop(cg.ProgramCounter),
+			cg.frameInfo(ins.FrameRestore, 0),
+		}
+	case tac.Exit:
+		load, code := cg.operand(t.Code, scratch)
+		return ins.Instructions{load, ins.NewExit(code)}
+	}
 	return ins.NOOP{}
 }
 
-//VisitStatReturn visits AST node ast.StatReturn
-func (cg *CodeGenerator) VisitStatReturn(node ast.StatReturn, ctx *ins.Context) ins.Instruction {
-	exprInstrs := cg.VisitExpression(node.GetReturnExpr(), ctx)
+//lowerCall calls a wacc function with its arguments in the outgoing argument area,
+//or a C function with its arguments in registers
+func (cg *CodeGenerator) lowerCall(call tac.Call) ins.Instructions {
+	var instrs ins.Instructions
+	if call.C {
+		args := cg.argRegs()
+		for i, arg := range call.Args {
+			instrs = append(instrs, cg.load(arg, args[i]))
+		}
+	} else {
+		scratch := cg.workRegs()[0]
+		sizes, offsets, _ := cg.argLayout(call.Func, call.Args)
+		for i, arg := range call.Args {
+			load, reg := cg.operand(arg, scratch)
+			instrs = append(instrs,
+				load,
+				ins.NewStore(sizes[i], reg, ins.NewAddress(cg.StackPointer, ins.Immediate(offsets[i]))),
+			)
+		}
+	}
+	instrs = append(instrs, ins.NewFunctionCall(call.Func))
+	if call.Dst != tac.NoTemp {
+		instrs = append(instrs, cg.assign(call.Dst, cg.ReturnRegister))
+	}
+	return instrs
+}
+
+//lowerCallClosure calls a function value with its arguments and then the closure in the
+//outgoing argument area, jumping to the address in the first word of the closure
+func (cg *CodeGenerator) lowerCallClosure(call tac.CallClosure) ins.Instructions {
 	var instrs ins.Instructions
-	switch op := exprInstrs.Result.(type) {
-	case ins.Address:
-		instrs = append(exprInstrs.Instrs,
-			ins.NewLoad(exprInstrs.Result, cg.ReturnRegister, exprSize(node.GetReturnExpr())))
-	case ins.Register:
-		cg.regs.ReleaseCallerSaved(op)
-		instrs = append(exprInstrs.Instrs, ins.NewMove(exprInstrs.Result, cg.ReturnRegister))
-	default:
-		instrs = append(exprInstrs.Instrs, ins.NewMove(exprInstrs.Result, cg.ReturnRegister))
-	}
-	offset := node.GetSymbolTable().GetOffset(node.GetFunctionName())
+	scratch := cg.workRegs()[0]
+	sizes, offsets, _ := cg.closureArgLayout(call)
+	for i, arg := range append(append([]tac.Operand{}, call.Args...), call.Closure) {
+		load, reg := cg.operand(arg, scratch)
+		instrs = append(instrs,
+			load,
+			ins.NewStore(sizes[i], reg, ins.NewAddress(cg.StackPointer, ins.Immediate(offsets[i]))),
+		)
+	}
+	load, closure := cg.operand(call.Closure, scratch)
 	instrs = append(instrs,
-		ins.NewAdd(cg.StackPointer, cg.StackPointer, ins.Immediate(offset)),
-		ins.NewPop(cg.ProgramCounter),
+		load,
+		ins.NewLoad(ins.NewAddress(closure, ins.Immediate(0)), scratch, cg.PointerSize),
+		ins.NewIndirectCall(scratch),
 	)
+	if call.Dst != tac.NoTemp {
+		instrs = append(instrs, cg.assign(call.Dst, cg.ReturnRegister))
+	}
+	return instrs
+}
+
+//lowerCallIndirect calls the function at an address with its arguments in the outgoing
+//argument area
+func (cg *CodeGenerator) lowerCallIndirect(call tac.CallIndirect) ins.Instructions {
+	var instrs ins.Instructions
+	scratch := cg.workRegs()[0]
+	offsets, _ := indirectArgLayout(call)
+	for i, arg := range call.Args {
+		load, reg := cg.operand(arg, scratch)
+		instrs = append(instrs,
+			load,
+			ins.NewStore(call.Sizes[i], reg, ins.NewAddress(cg.StackPointer, ins.Immediate(offsets[i]))),
+		)
+	}
+	load, fn := cg.operand(call.Func, scratch)
+	instrs = append(instrs, load, ins.NewIndirectCall(fn))
+	if call.Dst != tac.NoTemp {
+		instrs = append(instrs, cg.assign(call.Dst, cg.ReturnRegister))
+	}
 	return instrs
 }
 
+//callC calls a C function, args must not be in the argument registers
+func (cg *CodeGenerator) callC(name string, args ...ins.Operand) ins.Instructions {
+	regs := cg.argRegs()
+	instrs := make(ins.Instructions, 0, len(args)+1)
+	for i, arg := range args {
+		instrs = append(instrs, ins.NewMove(arg, regs[i]))
+	}
+	return append(instrs, ins.NewFunctionCall(name))
+}
+
 func (cg *CodeGenerator) addOutOfBoundsCode() {
 	cg.funcs[builtins.ArrayOutOfBoundsCheckLabel] = builtins.CheckArrayIndexOutOfBounds()
 	cg.funcs[builtins.PrintErrorCheckLabel] = builtins.PrintError()
@@ -107,3 +382,15 @@ func (cg *CodeGenerator) addCheckSameThreadLockCode() {
 	cg.funcs[builtins.PrintErrorCheckLabel] = builtins.PrintError()
 	cg.addErrMsgBSS(builtins.SameThreadLockError)
 }
+
+func (cg *CodeGenerator) addClosedChannelCode() {
+	cg.funcs[builtins.ClosedChannelCheckLabel] = builtins.CheckClosedChannel()
+	cg.funcs[builtins.PrintErrorCheckLabel] = builtins.PrintError()
+	cg.addErrMsgBSS(builtins.ClosedChannelError)
+}
+
+func (cg *CodeGenerator) addChannelCapacityCode() {
+	cg.funcs[builtins.ChannelCapacityCheckLabel] = builtins.CheckChannelCapacity()
+	cg.funcs[builtins.PrintErrorCheckLabel] = builtins.PrintError()
+	cg.addErrMsgBSS(builtins.NegativeCapacityError)
+}
@@ -2,6 +2,7 @@ package instructions
 
 var (
 	_ Instruction = StringLiteral{}
+	_ Instruction = AddressTable{}
 )
 
 // Structure for the Block Starting Symbol
@@ -50,3 +51,18 @@ func NewStringLiteral(ID string, value string) Instruction {
 	strLit.Size = lenUnescaped(value)
 	return strLit
 }
+
+//AddressTable is a table in the data section holding the addresses of Labels, one
+//pointer each
+type AddressTable struct {
+	ID     string
+	Labels []string
+}
+
+//NewAddressTable creates a table of the addresses of labels
+func NewAddressTable(ID string, labels []string) Instruction {
+	return AddressTable{
+		ID:     ID,
+		Labels: labels,
+	}
+}
@@ -0,0 +1,115 @@
+package instructions
+
+import "wacc_32/types"
+
+//SourceFile declares a source file of the program, which Locs refer to by its index
+type SourceFile struct {
+	Index int
+	Name  string
+}
+
+//NewSourceFile declares the source file name as index
+func NewSourceFile(index int, name string) Instruction {
+	return SourceFile{
+		Index: index,
+		Name:  name,
+	}
+}
+
+//Loc marks the start of the code for a line of a source file, Col counts from 1
+type Loc struct {
+	File      int
+	Line, Col int
+}
+
+//NewLoc marks the start of the code for line:col of a source file
+func NewLoc(file, line, col int) Instruction {
+	return Loc{
+		File: file,
+		Line: line,
+		Col:  col,
+	}
+}
+
+//FrameInfoKind is a change to the stack frame of a function which debuggers need to
+//know about to unwind it
+type FrameInfoKind int
+
+//The frame changes
+const (
+	//FrameTable puts the frame information of every function in the debug sections
+	FrameTable FrameInfoKind = iota + 1
+	//FrameStart and FrameEnd surround the code of a function
+	FrameStart
+	FrameEnd
+	//FrameHeader follows the push of the frame header, which is Offset bytes
+	FrameHeader
+	//FrameSize follows a change to the stack pointer, which is Offset bytes below
+	//the stack pointer of the caller
+	FrameSize
+	//FrameRemember and FrameRestore save and restore the frame around a return,
+	//so the code after it has the frame from before it
+	FrameRemember
+	FrameRestore
+)
+
+//FrameInfo describes a change to the stack frame of the function being run
+type FrameInfo struct {
+	Kind   FrameInfoKind
+	Offset int
+}
+
+//NewFrameInfo creates a description of a change to the stack frame
+func NewFrameInfo(kind FrameInfoKind, offset int) Instruction {
+	return FrameInfo{
+		Kind:   kind,
+		Offset: offset,
+	}
+}
+
+//DebugLabel names an address the debug information refers to, it isn't a jump target
+type DebugLabel Label
+
+//NewDebugLabel creates a label for the debug information
+func NewDebugLabel(name string) Instruction {
+	return DebugLabel{
+		Name: name,
+	}
+}
+
+//DebugInfo describes the functions of a program, their variables and the wacc types
+//of the variables to debuggers. The code is between the labels Start and End
+type DebugInfo struct {
+	File       string
+	Start, End string
+	Funcs      []DebugFunc
+	UserTypes  map[string]types.UserType
+}
+
+//DebugFunc is a function between the labels Start and End, declared at Line of File
+type DebugFunc struct {
+	Name       string
+	File, Line int
+	Start, End string
+	Scope      DebugScope
+}
+
+//DebugScope is a block of a function between the labels Start and End, which declares
+//variables and holds the scopes nested in it. A function's own scope has no labels
+type DebugScope struct {
+	Start, End string
+	Vars       []DebugVar
+	Scopes     []DebugScope
+}
+
+//DebugVar is a variable declared at Line of File. Its value is in Reg if InReg, otherwise
+//it is Offset bytes from the stack pointer of the caller
+type DebugVar struct {
+	Name       string
+	Type       types.WaccType
+	File, Line int
+	Param      bool
+	InReg      bool
+	Reg        Register
+	Offset     int
+}
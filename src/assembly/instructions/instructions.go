@@ -49,6 +49,18 @@ func NewFunctionCall(name string) Instruction {
 	}
 }
 
+//IndirectCall calls the function whose address is in Reg
+type IndirectCall struct {
+	Reg Register
+}
+
+//NewIndirectCall branches to the function at the address in reg
+func NewIndirectCall(reg Register) Instruction {
+	return IndirectCall{
+		Reg: reg,
+	}
+}
+
 type Branch struct {
 	Label     string
 	Condition Cond
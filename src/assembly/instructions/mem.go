@@ -68,6 +68,11 @@ func NewPush(regs ...Register) Instruction {
 	}
 }
 
+//IsPush returns true for a push and false for a pop
+func (st StackInstr) IsPush() bool {
+	return st.T == push
+}
+
 //NewPop creates a pop
 func NewPop(reg ...Register) Instruction {
 	return StackInstr{
@@ -0,0 +1,271 @@
+package assembly
+
+import (
+	"math/bits"
+	"wacc_32/assembly/builtins"
+	ins "wacc_32/assembly/instructions"
+	"wacc_32/ir/tac"
+)
+
+//regsIn returns the first n registers of a register mask
+func regsIn(mask uint64, n int) []ins.Register {
+	regs := make([]ins.Register, 0, n)
+	for mask != 0 && len(regs) < n {
+		reg := bits.TrailingZeros64(mask)
+		regs = append(regs, ins.Register(reg))
+		mask &^= 1 << reg
+	}
+	return regs
+}
+
+//workRegs returns the registers spilled temps are loaded into, they are preserved across calls
+func (cg *CodeGenerator) workRegs() []ins.Register {
+	return regsIn(cg.CallerSavedRegs, 2)
+}
+
+//colours returns the registers temps can be allocated to, they are preserved across calls
+func (cg *CodeGenerator) colours() []ins.Register {
+	regs := regsIn(cg.CallerSavedRegs, bits.OnesCount64(cg.CallerSavedRegs))
+	return regs[len(cg.workRegs()):]
+}
+
+//argRegs returns the registers arguments to C functions are passed in
+func (cg *CodeGenerator) argRegs() []ins.Register {
+	return regsIn(cg.CalleeSavedRegs, 4)
+}
+
+//slot returns the address of the stack slot holding a spilled temp
+func (cg *CodeGenerator) slot(t tac.Temp) ins.Operand {
+	return ins.NewAddress(cg.StackPointer, ins.Immediate(cg.frame.slots[t]))
+}
+
+//load puts the value of op into reg
+func (cg *CodeGenerator) load(op tac.Operand, reg ins.Register) ins.Instruction {
+	switch o := op.(type) {
+	case tac.Temp:
+		if r, ok := cg.frame.reg(o); ok {
+			if r == reg {
+				return ins.NOOP{}
+			}
+			return ins.NewMove(r, reg)
+		}
+		return ins.NewLoad(cg.slot(o), reg, cg.frame.fn.Size(o))
+	case tac.Global:
+		return ins.NewLoad(ins.Variable(o), reg, cg.PointerSize)
+	case tac.Imm:
+		return ins.NewMove(ins.Immediate(o), reg)
+	}
+	return ins.NOOP{}
+}
+
+//operand returns the register holding op, loading it into scratch if it isn't in one
+func (cg *CodeGenerator) operand(op tac.Operand, scratch ins.Register) (ins.Instruction, ins.Register) {
+	if t, ok := op.(tac.Temp); ok {
+		if r, ok := cg.frame.reg(t); ok {
+			return ins.NOOP{}, r
+		}
+	}
+	return cg.load(op, scratch), scratch
+}
+
+//dest returns the register t should be computed into, scratch if t is spilled
+func (cg *CodeGenerator) dest(t tac.Temp, scratch ins.Register) ins.Register {
+	if r, ok := cg.frame.reg(t); ok {
+		return r
+	}
+	return scratch
+}
+
+//writeBack stores reg to the slot of t if t is spilled, reg must be dest(t, reg)
+func (cg *CodeGenerator) writeBack(reg ins.Register, t tac.Temp) ins.Instruction {
+	if _, ok := cg.frame.reg(t); ok {
+		return ins.NOOP{}
+	}
+	return ins.NewStore(cg.frame.fn.Size(t), reg, cg.slot(t))
+}
+
+//assign copies the value of reg to t
+func (cg *CodeGenerator) assign(t tac.Temp, reg ins.Register) ins.Instruction {
+	if r, ok := cg.frame.reg(t); ok {
+		if r == reg {
+			return ins.NOOP{}
+		}
+		return ins.NewMove(reg, r)
+	}
+	return ins.NewStore(cg.frame.fn.Size(t), reg, cg.slot(t))
+}
+
+var conds = map[tac.Op]ins.Cond{
+	tac.Lt: ins.LT,
+	tac.Le: ins.LE,
+	tac.Gt: ins.GT,
+	tac.Ge: ins.GE,
+	tac.Eq: ins.EQ,
+	tac.Ne: ins.NE,
+}
+
+//lowerInstr lowers a single three address instruction, spilled operands are
+//loaded into the work registers and spilled results are stored straight back
+func (cg *CodeGenerator) lowerInstr(instr tac.Instr) ins.Instruction {
+	work := cg.workRegs()
+	a, b := work[0], work[1]
+	switch i := instr.(type) {
+	case tac.Move:
+		if r, ok := cg.frame.reg(i.Dst); ok {
+			return cg.load(i.Src, r)
+		}
+		load, src := cg.operand(i.Src, a)
+		return ins.Instructions{load, cg.writeBack(src, i.Dst)}
+	case tac.BinOp:
+		loadLeft, left := cg.operand(i.Left, a)
+		loadRight, right := cg.operand(i.Right, b)
+		dst := cg.dest(i.Dst, a)
+		return ins.Instructions{
+			loadLeft,
+			loadRight,
+			cg.lowerBinOp(i.Op, dst, left, right),
+			cg.writeBack(dst, i.Dst),
+		}
+	case tac.UnOp:
+		dst := cg.dest(i.Dst, a)
+		switch i.Op {
+		case tac.Neg:
+			cg.addOverflowCode()
+			return ins.Instructions{
+				cg.load(i.Src, dst),
+				ins.NewNeg(dst),
+				ins.NewFunctionCall(builtins.IntegerOverflowCheckLabel),
+				cg.writeBack(dst, i.Dst),
+			}
+		case tac.Not:
+			load, src := cg.operand(i.Src, a)
+			return ins.Instructions{load, ins.NewXor(dst, src, ins.Immediate(1)), cg.writeBack(dst, i.Dst)}
+		}
+	case tac.Load:
+		load, addr := cg.operand(i.Addr, a)
+		dst := cg.dest(i.Dst, b)
+		return ins.Instructions{
+			load,
+			ins.NewLoad(ins.NewAddress(addr, ins.Immediate(i.Offset)), dst, i.Size),
+			cg.writeBack(dst, i.Dst),
+		}
+	case tac.Store:
+		loadAddr, addr := cg.operand(i.Addr, a)
+		loadSrc, src := cg.operand(i.Src, b)
+		return ins.Instructions{
+			loadAddr,
+			loadSrc,
+			ins.NewStore(i.Size, src, ins.NewAddress(addr, ins.Immediate(i.Offset))),
+		}
+	case tac.Index:
+		//The scaled index is always computed in b
+		instrs := ins.Instructions{cg.load(i.Index, b)}
+		if i.Scale != 1 {
+			instrs = append(instrs, ins.NewMove(ins.Immediate(i.Scale), a), ins.NewMult(b, b, a))
+		}
+		load, base := cg.operand(i.Base, a)
+		dst := cg.dest(i.Dst, a)
+		instrs = append(instrs, load, ins.NewAdd(dst, base, b))
+		if i.Offset != 0 {
+			instrs = append(instrs, ins.NewAdd(dst, dst, ins.Immediate(i.Offset)))
+		}
+		return append(instrs, cg.writeBack(dst, i.Dst))
+	case tac.Call:
+		return cg.lowerCall(i)
+	case tac.CallClosure:
+		return cg.lowerCallClosure(i)
+	case tac.CallIndirect:
+		return cg.lowerCallIndirect(i)
+	case tac.Spawn:
+		return cg.lowerSpawn(i)
+	case tac.NewLock:
+		return cg.lowerNewLock(i)
+	case tac.NewSema:
+		return cg.lowerNewSema(i)
+	case tac.NewCond:
+		return cg.lowerNewCond(i)
+	case tac.Print:
+		printIns, bss, printLabel, bssLabel := builtins.PrintType(i.Type)
+		if bssLabel != "" {
+			cg.bssVars[bssLabel] = bss
+			cg.funcs[printLabel] = printIns
+		}
+		return ins.Instructions{cg.load(i.Src, cg.argRegs()[0]), ins.NewFunctionCall(printLabel)}
+	case tac.PrintLine:
+		cg.bssVars[builtins.LineBSSLabel] = builtins.LineBSS
+		cg.funcs[builtins.PrintLineLabel] = builtins.PrintLine
+		return ins.NewFunctionCall(builtins.PrintLineLabel)
+	case tac.Read:
+		readIns, bss, readLabel, bssLabel := builtins.ReadType(i.Type)
+		cg.bssVars[bssLabel] = bss
+		cg.funcs[readLabel] = readIns
+		return ins.Instructions{
+			ins.NewAdd(cg.argRegs()[0], cg.StackPointer, ins.Immediate(cg.frame.slots[i.Dst])),
+			ins.NewFunctionCall(readLabel),
+		}
+	case tac.Check:
+		return cg.lowerCheck(i)
+	case tac.Loc:
+		return cg.loc(i)
+	}
+	return ins.NOOP{}
+}
+
+//lowerBinOp stores left <op> right in dst
+func (cg *CodeGenerator) lowerBinOp(op tac.Op, dst, left, right ins.Register) ins.Instruction {
+	switch op {
+	case tac.Add:
+		cg.addOverflowCode()
+		return ins.Instructions{ins.NewAdd(dst, left, right), ins.NewFunctionCall(builtins.IntegerOverflowCheckLabel)}
+	case tac.Sub:
+		cg.addOverflowCode()
+		return ins.Instructions{ins.NewSub(dst, left, right), ins.NewFunctionCall(builtins.IntegerOverflowCheckLabel)}
+	case tac.Mul:
+		cg.addOverflowCode()
+		return ins.Instructions{ins.NewMult(dst, left, right), ins.NewFunctionCall(builtins.IntegerOverflowCheckLabel)}
+	case tac.Div:
+		cg.addDivideByZeroCode()
+		return ins.NewDiv(dst, left, right)
+	case tac.Mod:
+		cg.addDivideByZeroCode()
+		return ins.NewMod(dst, left, right)
+	case tac.And:
+		return ins.NewAnd(dst, left, right)
+	case tac.Or:
+		return ins.NewOr(dst, left, right)
+	case tac.Xor:
+		return ins.NewXor(dst, left, right)
+	}
+	return ins.NewBoolean(dst, left, right, conds[op])
+}
+
+//lowerCheck passes the checked values to the runtime error builtins
+func (cg *CodeGenerator) lowerCheck(check tac.Check) ins.Instruction {
+	args := cg.argRegs()
+	instrs := ins.Instructions{}
+	for i, arg := range check.Args {
+		instrs = append(instrs, cg.load(arg, args[i]))
+	}
+	var label string
+	switch check.Kind {
+	case tac.NullCheck:
+		cg.addNullPointerReferenceCode()
+		label = builtins.NullPointerReferenceCheckLabel
+	case tac.BoundsCheck:
+		cg.addOutOfBoundsCode()
+		label = builtins.ArrayOutOfBoundsCheckLabel
+	case tac.LockCheck:
+		cg.addCheckSameThreadLockCode()
+		label = builtins.SameThreadLockCheckLabel
+	case tac.UnlockCheck:
+		cg.addInvalidThreadUnlockCode()
+		label = builtins.InvalidThreadUnlockCheckLabel
+	case tac.ClosedCheck:
+		cg.addClosedChannelCode()
+		label = builtins.ClosedChannelCheckLabel
+	case tac.CapacityCheck:
+		cg.addChannelCapacityCode()
+		label = builtins.ChannelCapacityCheckLabel
+	}
+	return append(instrs, ins.NewFunctionCall(label))
+}
@@ -0,0 +1,148 @@
+package peephole
+
+import (
+	"fmt"
+	architecture "wacc_32/assembly/architectures"
+	ins "wacc_32/assembly/instructions"
+)
+
+//Rule looks at the instructions at the start of window and, if it matches,
+//returns what the first n of them should be replaced with
+type Rule struct {
+	Name  string
+	Match func(window ins.Instructions) (replacement ins.Instructions, n int, ok bool)
+}
+
+//windowSize is the largest number of instructions a rule looks at
+const windowSize = 2
+
+//All returns every rule supported for an architecture, in the order they are tried
+func All(conf architecture.Config) []Rule {
+	return []Rule{
+		{"self-move", selfMove},
+		{"move-back", moveBack},
+		{"dead-move", deadMove},
+		{"store-reload", storeReload},
+		{"zero-stack", zeroStack(conf.StackPointer)},
+		{"branch-next", branchNext},
+	}
+}
+
+//Names returns the names of a list of rules
+func Names(rules []Rule) []string {
+	names := make([]string, len(rules))
+	for i, rule := range rules {
+		names[i] = rule.Name
+	}
+	return names
+}
+
+//Select returns the named rules of an architecture, in the order given
+func Select(conf architecture.Config, names []string) ([]Rule, error) {
+	all := All(conf)
+	rules := make([]Rule, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, rule := range all {
+			if rule.Name == name {
+				rules = append(rules, rule)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown peephole rule %q, expected one of %v", name, Names(all))
+		}
+	}
+	return rules, nil
+}
+
+//Flatten returns the instructions nested in instr as a flat list without NOOPs
+func Flatten(instr ins.Instruction) ins.Instructions {
+	return flatten(instr, ins.Instructions{})
+}
+
+func flatten(instr ins.Instruction, flat ins.Instructions) ins.Instructions {
+	switch i := instr.(type) {
+	case ins.Instructions:
+		for _, child := range i {
+			flat = flatten(child, flat)
+		}
+	case ins.NOOP:
+	default:
+		flat = append(flat, instr)
+	}
+	return flat
+}
+
+//Optimise applies the rules to a flat list of instructions until none of them match.
+//Rules look past debug information, which is kept after the instructions they replace
+func Optimise(instrs ins.Instructions, rules []Rule) ins.Instructions {
+	for changed := true; changed; {
+		changed = false
+		out := make(ins.Instructions, 0, len(instrs))
+		for i := 0; i < len(instrs); {
+			if isDebugInfo(instrs[i]) {
+				out = append(out, instrs[i])
+				i++
+				continue
+			}
+			window, at := codeWindow(instrs, i)
+			matched := false
+			for _, rule := range rules {
+				if replacement, n, ok := rule.Match(window); ok {
+					out = append(out, replacement...)
+					end := at[n-1] + 1
+					for _, instr := range instrs[i:end] {
+						if isDebugInfo(instr) {
+							out = append(out, instr)
+						}
+					}
+					i = end
+					matched, changed = true, true
+					break
+				}
+			}
+			if !matched {
+				out = append(out, instrs[i])
+				i++
+			}
+		}
+		instrs = out
+	}
+	return instrs
+}
+
+//codeWindow returns the instructions rules look at from start, skipping debug
+//information, along with their indexes
+func codeWindow(instrs ins.Instructions, start int) (window ins.Instructions, at []int) {
+	for i := start; i < len(instrs) && len(window) < windowSize; i++ {
+		if !isDebugInfo(instrs[i]) {
+			window = append(window, instrs[i])
+			at = append(at, i)
+		}
+	}
+	return window, at
+}
+
+//isDebugInfo returns whether instr only describes the code to debuggers
+func isDebugInfo(instr ins.Instruction) bool {
+	switch instr.(type) {
+	case ins.SourceFile, ins.Loc, ins.FrameInfo, ins.DebugLabel, ins.DebugInfo:
+		return true
+	}
+	return false
+}
+
+//Count returns the number of instructions which are executed, labels and debug
+//information don't count
+func Count(instrs ins.Instructions) int {
+	n := 0
+	for _, instr := range instrs {
+		switch instr.(type) {
+		case ins.Label, ins.Pool, ins.StringLiteral, ins.AddressTable, ins.SourceFile, ins.Loc, ins.FrameInfo, ins.DebugLabel, ins.DebugInfo:
+		default:
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,112 @@
+package peephole
+
+import (
+	ins "wacc_32/assembly/instructions"
+	"wacc_32/types"
+)
+
+//selfMove removes
+//	mov r4, r4
+func selfMove(window ins.Instructions) (ins.Instructions, int, bool) {
+	if mov, ok := window[0].(ins.Move); ok && mov.Src == ins.Operand(mov.Dest) {
+		return nil, 1, true
+	}
+	return nil, 0, false
+}
+
+//moveBack removes the second move of
+//	mov r5, r4
+//	mov r4, r5
+func moveBack(window ins.Instructions) (ins.Instructions, int, bool) {
+	if len(window) < 2 {
+		return nil, 0, false
+	}
+	first, ok1 := window[0].(ins.Move)
+	second, ok2 := window[1].(ins.Move)
+	if ok1 && ok2 && first.Src == ins.Operand(second.Dest) && second.Src == ins.Operand(first.Dest) {
+		return window[:1], 2, true
+	}
+	return nil, 0, false
+}
+
+//deadMove removes the first move of
+//	mov r4, #1
+//	mov r4, r5
+//as its value is overwritten before it is read
+func deadMove(window ins.Instructions) (ins.Instructions, int, bool) {
+	if len(window) < 2 {
+		return nil, 0, false
+	}
+	first, ok := window[0].(ins.Move)
+	if !ok {
+		return nil, 0, false
+	}
+	switch second := window[1].(type) {
+	case ins.Move:
+		if second.Dest == first.Dest && second.Src != ins.Operand(first.Dest) {
+			return nil, 1, true
+		}
+	case ins.Load:
+		if addr, ok := second.Src.(ins.Address); second.Dest == first.Dest && (!ok || addr.Reg != first.Dest) {
+			return nil, 1, true
+		}
+	}
+	return nil, 0, false
+}
+
+//storeReload replaces the load of
+//	str r4, [sp, #4]
+//	ldr r5, [sp, #4]
+//with a move, or nothing if the value is already in the right register.
+//Narrower values are left alone as the store may truncate them
+func storeReload(window ins.Instructions) (ins.Instructions, int, bool) {
+	if len(window) < 2 {
+		return nil, 0, false
+	}
+	store, ok1 := window[0].(ins.Store)
+	load, ok2 := window[1].(ins.Load)
+	if !ok1 || !ok2 || store.Dest != load.Src || store.Size != load.Size || store.Size < types.Word {
+		return nil, 0, false
+	}
+	if load.Dest == store.Src {
+		return window[:1], 2, true
+	}
+	return ins.Instructions{store, ins.NewMove(store.Src, load.Dest)}, 2, true
+}
+
+//zeroStack removes
+//	add sp, sp, #0
+//	sub sp, sp, #0
+func zeroStack(sp ins.Register) func(ins.Instructions) (ins.Instructions, int, bool) {
+	isZero := func(dest ins.Register, left, right ins.Operand) bool {
+		return dest == sp && left == ins.Operand(sp) && right == ins.Operand(ins.Immediate(0))
+	}
+	return func(window ins.Instructions) (ins.Instructions, int, bool) {
+		switch i := window[0].(type) {
+		case ins.Add:
+			if isZero(i.Dest, i.Left, i.Right) {
+				return nil, 1, true
+			}
+		case ins.Sub:
+			if isZero(i.Dest, i.Left, i.Right) {
+				return nil, 1, true
+			}
+		}
+		return nil, 0, false
+	}
+}
+
+//branchNext removes the branch of
+//	b .L1
+//.L1:
+func branchNext(window ins.Instructions) (ins.Instructions, int, bool) {
+	if len(window) < 2 {
+		return nil, 0, false
+	}
+	branch, ok1 := window[0].(ins.Branch)
+	label, ok2 := window[1].(ins.Label)
+	if ok1 && ok2 && branch.Label == label.Name {
+		return window[1:2], 2, true
+	}
+	return nil, 0, false
+}
@@ -0,0 +1,157 @@
+package assembly
+
+import (
+	ins "wacc_32/assembly/instructions"
+	"wacc_32/ir/tac"
+)
+
+//interference is the interference graph of the temps of a function, temps
+//interfere if one is written while the other is live
+type interference struct {
+	adj   []map[tac.Temp]bool
+	moves [][]tac.Temp
+}
+
+func (g *interference) addEdge(t1, t2 tac.Temp) {
+	if t1 != t2 {
+		g.adj[t1][t2] = true
+		g.adj[t2][t1] = true
+	}
+}
+
+//newInterference builds the interference graph of fn, the source and destination
+//of a move don't interfere so they can share a register
+func newInterference(fn *tac.Func) *interference {
+	g := &interference{
+		adj:   make([]map[tac.Temp]bool, len(fn.Temps)),
+		moves: make([][]tac.Temp, len(fn.Temps)),
+	}
+	for t := range g.adj {
+		g.adj[t] = make(map[tac.Temp]bool)
+	}
+
+	liveOut := fn.LiveOut()
+	for _, b := range fn.Blocks {
+		live := liveOut[b].Copy()
+		for _, t := range tac.TermUses(b.Term) {
+			live[t] = true
+		}
+		for i := len(b.Instrs) - 1; i >= 0; i-- {
+			instr := b.Instrs[i]
+			if d := tac.Def(instr); d != tac.NoTemp {
+				src := tac.NoTemp
+				if m, ok := instr.(tac.Move); ok {
+					if s, ok := m.Src.(tac.Temp); ok {
+						src = s
+						g.moves[d] = append(g.moves[d], s)
+						g.moves[s] = append(g.moves[s], d)
+					}
+				}
+				for t := range live {
+					if t != src {
+						g.addEdge(d, t)
+					}
+				}
+			}
+			tac.StepBack(instr, live)
+		}
+	}
+
+	//Parameters are all written on entry
+	entry := fn.Blocks[0].LiveIn(liveOut[fn.Blocks[0]])
+	for _, p := range fn.Params {
+		entry[p] = true
+	}
+	for t1 := range entry {
+		for t2 := range entry {
+			g.addEdge(t1, t2)
+		}
+	}
+	return g
+}
+
+//colour assigns registers to temps by simplifying the graph: temps with fewer
+//neighbours than registers are removed first, otherwise the temp with the most
+//neighbours is removed optimistically. Temps are then coloured in reverse order
+//of removal, those left without a register are spilled
+func (g *interference) colour(colours []ins.Register, spilled tac.TempSet) map[tac.Temp]ins.Register {
+	n := len(g.adj)
+	removed := make([]bool, n)
+	degree := make([]int, n)
+	remaining := n
+	for t := range spilled {
+		removed[t] = true
+		remaining--
+	}
+	for t := range g.adj {
+		for nb := range g.adj[t] {
+			if !removed[nb] {
+				degree[t]++
+			}
+		}
+	}
+
+	stack := make([]tac.Temp, 0, remaining)
+	for len(stack) < remaining {
+		pick := -1
+		for t := 0; t < n; t++ {
+			if !removed[t] && degree[t] < len(colours) {
+				pick = t
+				break
+			}
+		}
+		if pick == -1 {
+			for t := 0; t < n; t++ {
+				if !removed[t] && (pick == -1 || degree[t] > degree[pick]) {
+					pick = t
+				}
+			}
+		}
+		removed[pick] = true
+		stack = append(stack, tac.Temp(pick))
+		for nb := range g.adj[pick] {
+			degree[nb]--
+		}
+	}
+
+	regs := make(map[tac.Temp]ins.Register, n)
+	for i := len(stack) - 1; i >= 0; i-- {
+		t := stack[i]
+		taken := make(map[ins.Register]bool)
+		for nb := range g.adj[t] {
+			if r, ok := regs[nb]; ok {
+				taken[r] = true
+			}
+		}
+		//Prefer the register of a move partner so the move disappears
+		for _, partner := range g.moves[t] {
+			if r, ok := regs[partner]; ok && !taken[r] {
+				regs[t] = r
+				break
+			}
+		}
+		if _, ok := regs[t]; ok {
+			continue
+		}
+		for _, r := range colours {
+			if !taken[r] {
+				regs[t] = r
+				break
+			}
+		}
+	}
+	return regs
+}
+
+//allocate assigns registers to the temps of fn, temps whose address is needed are always spilled
+func allocate(fn *tac.Func, colours []ins.Register) map[tac.Temp]ins.Register {
+	spilled := tac.TempSet{}
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			if read, ok := instr.(tac.Read); ok {
+				spilled[read.Dst] = true
+			}
+		}
+	}
+	return newInterference(fn).colour(colours, spilled)
+}
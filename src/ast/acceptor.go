@@ -34,6 +34,26 @@ func (b BinOp) AcceptAnother(v SomethingAnotherVisitor, ctx Ctx) Another {
 	return v.VisitBinOp(b, ctx)
 }
 
+//Accept calls v.VisitMakeChan(m)
+func (m MakeChan) AcceptAnother(v SomethingAnotherVisitor, ctx Ctx) Another {
+	return v.VisitMakeChan(m, ctx)
+}
+
+//Accept calls v.VisitRecv(r)
+func (r Recv) AcceptAnother(v SomethingAnotherVisitor, ctx Ctx) Another {
+	return v.VisitRecv(r, ctx)
+}
+
+//Accept calls v.VisitStatSend(s)
+func (s StatSend) AcceptSomething(v SomethingAnotherVisitor, ctx Ctx) Something {
+	return v.VisitStatSend(s, ctx)
+}
+
+//Accept calls v.VisitStatClose(s)
+func (s StatClose) AcceptSomething(v SomethingAnotherVisitor, ctx Ctx) Something {
+	return v.VisitStatClose(s, ctx)
+}
+
 //Accept calls v.VisitStatLock(s)
 func (s StatLock) AcceptSomething(v SomethingAnotherVisitor, ctx Ctx) Something {
 	return v.VisitStatLock(s, ctx)
@@ -44,6 +64,21 @@ func (s StatSema) AcceptSomething(v SomethingAnotherVisitor, ctx Ctx) Something
 	return v.VisitStatSema(s, ctx)
 }
 
+//Accept calls v.VisitStatCond(s)
+func (s StatCond) AcceptSomething(v SomethingAnotherVisitor, ctx Ctx) Something {
+	return v.VisitStatCond(s, ctx)
+}
+
+//Accept calls v.VisitStatWith(s)
+func (s StatWith) AcceptSomething(v SomethingAnotherVisitor, ctx Ctx) Something {
+	return v.VisitStatWith(s, ctx)
+}
+
+//Accept calls v.VisitWaccFuture(w)
+func (w WaccFuture) AcceptAnother(v SomethingAnotherVisitor, ctx Ctx) Another {
+	return v.VisitWaccFuture(w, ctx)
+}
+
 //Accept calls v.VisitArrayElem(a)
 func (a ArrayElem) AcceptAnother(v SomethingAnotherVisitor, ctx Ctx) Another {
 	return v.VisitArrayElem(a, ctx)
@@ -69,6 +104,11 @@ func (p Param) AcceptSomething(v SomethingAnotherVisitor, ctx Ctx) Something {
 	return v.VisitParam(p, ctx)
 }
 
+//Accept calls v.VisitLambda(l)
+func (l Lambda) AcceptAnother(v SomethingAnotherVisitor, ctx Ctx) Another {
+	return v.VisitLambda(l, ctx)
+}
+
 //Accept calls v.VisitProgram(p)
 func (p Program) AcceptSomething(v SomethingAnotherVisitor, ctx Ctx) Something {
 	return v.VisitProgram(p, ctx)
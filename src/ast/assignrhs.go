@@ -94,6 +94,8 @@ type RHSFunctionCall struct {
 	pos        errors.Position
 	concurrent bool
 	isMethod   bool
+	closure    *Ident
+	typeArgs   types.Substitution
 }
 
 //GetName returns the name of the function being called
@@ -111,6 +113,18 @@ func (fnc RHSFunctionCall) GetArgs() []Expression {
 	return fnc.args
 }
 
+//GetClosure returns the variable holding the function value being called, nil if the
+//call is to a named function
+func (fnc RHSFunctionCall) GetClosure() *Ident {
+	return fnc.closure
+}
+
+//GetTypeArgs returns the types the type parameters of a generic function are called
+//with, nil if the function isn't generic
+func (fnc RHSFunctionCall) GetTypeArgs() types.Substitution {
+	return fnc.typeArgs
+}
+
 //NewRHSFunctionCall creates a new FunctionCall
 func NewRHSFunctionCall(fName *Ident, args []Expression, isMethod bool, pos errors.Position) *RHSFunctionCall {
 	return &RHSFunctionCall{
@@ -157,30 +171,69 @@ func (fnc *RHSFunctionCall) FormatName() (string, error) {
 	toLookUp := fnc.fName.GetName()
 
 	if fnc.isMethod {
-		components := fnc.fName.GetNameComponents()
-		classType, err := sym.GetType(components[0][1:])
+		t, method, err := fnc.receiver()
 		if err != nil {
-			return "", errors.NewUndefinedIdentifierError(fnc.pos, err)
+			return "", err
 		}
-		t, err := LookupUserType(classType.(types.UserType), *fnc.table)
-		if err != nil {
-			return "", errors.NewUndefinedIdentifierError(fnc.pos, err)
+
+		//A class runs the methods it inherits unless it overrides them
+		toLookUp = method + "_" + t.GetName()
+		for _, super := range t.GetSupers() {
+			if _, err := sym.GetType(toLookUp); err == nil {
+				break
+			}
+			toLookUp = method + "_" + super
 		}
-		for i := 1; i < len(components)-1; i++ {
-			fieldName := components[i]
-			for j, field := range t.GetFieldNames() {
-				if field == fieldName {
-					t = t.GetFieldTypes()[j].(types.UserType)
-					break
-				}
+	}
+
+	return toLookUp, nil
+}
+
+//receiver returns the class of the object a method is called on and the name of the method
+func (fnc *RHSFunctionCall) receiver() (types.UserType, string, error) {
+	sym := *fnc.table
+	components := fnc.fName.GetNameComponents()
+	classType, err := sym.GetType(components[0][1:])
+	if err != nil {
+		return types.UserType{}, "", errors.NewUndefinedIdentifierError(fnc.pos, err)
+	}
+	t, err := LookupUserType(classType.(types.UserType), *fnc.table)
+	if err != nil {
+		return types.UserType{}, "", errors.NewUndefinedIdentifierError(fnc.pos, err)
+	}
+	for i := 1; i < len(components)-1; i++ {
+		fieldName := components[i]
+		for j, field := range t.GetFieldNames() {
+			if field == fieldName {
+				t, _ = LookupUserType(t.GetFieldTypes()[j].(types.UserType), *fnc.table)
+				break
 			}
 		}
+	}
+	return t, "0" + components[len(components)-1], nil
+}
 
-		className := t.GetName()
-		toLookUp = "0" + components[len(components)-1] + "_" + className
+//GetMethod returns the class a method is called on and the name the method was declared
+//with, false if the call isn't to a method
+func (fnc RHSFunctionCall) GetMethod() (types.UserType, string, bool) {
+	if !fnc.isMethod {
+		return types.UserType{}, "", false
 	}
+	t, method, err := fnc.receiver()
+	return t, method, err == nil
+}
 
-	return toLookUp, nil
+//IsVirtual checks whether the call is to a method which subclasses of the class it is
+//called on can override, or to a method of an interface, so the method to run depends
+//on the class of the object
+func (fnc RHSFunctionCall) IsVirtual() bool {
+	t, _, ok := fnc.GetMethod()
+	if !ok || !t.HasSubclasses() && !t.IsInterface() {
+		return false
+	}
+	name, _ := fnc.FormatName()
+	fType, err := fnc.table.GetType(name)
+	return err == nil && !types.IsGeneric(fType)
 }
 
 //Check ensures that the function exists, and its arguments are valid
@@ -188,12 +241,31 @@ func (fnc *RHSFunctionCall) Check(ctx Context) bool {
 	ok := true
 	fnc.table = ctx.table
 
-	toLookup, err := fnc.FormatName()
-	if err != nil {
-		ctx.SemanticErrChan <- err
-		return false
+	var fType types.WaccType
+	//A variable holding a function value hides a function with the same name,
+	//wacc routines only start named functions
+	variable := strings.TrimPrefix(fnc.fName.name[1:], fnc.fName.library)
+	t, err := ctx.table.GetType(variable)
+	if !fnc.isMethod && !fnc.concurrent && err == nil && t.Is(types.Function) {
+		fnc.closure = NewIdent(variable, fnc.fName.pos)
+		fnc.closure.Check(ctx)
+		fType = t
+	} else {
+		toLookup, err := fnc.FormatName()
+		if err != nil {
+			ctx.SemanticErrChan <- err
+			return false
+		}
+		fType, err = ctx.table.GetType(toLookup)
+		if err != nil {
+			err = fmt.Errorf("function %s has not been declared", displayName(fnc.fName.name))
+			if t, method, ok := fnc.GetMethod(); ok {
+				err = fmt.Errorf("%s has no method %s", typeName(t), displayName(method))
+			}
+			ctx.SemanticErrChan <- errors.NewUndefinedIdentifierError(fnc.pos, err)
+			return false
+		}
 	}
-	fType, _ := ctx.table.GetType(toLookup)
 	paramTypes := fType.GetChildren()
 	expArgLength := len(paramTypes) - 1
 	actArgLength := len(fnc.args)
@@ -202,27 +274,64 @@ func (fnc *RHSFunctionCall) Check(ctx Context) bool {
 		ok = false
 	}
 
+	//The type arguments of a generic function are inferred from its arguments
+	generic := fnc.closure == nil && types.IsGeneric(fType)
+	sub := types.Substitution{}
 	for i, arg := range fnc.args {
 		if arg.Check(ctx) {
 			argType := arg.EvalType(*ctx.table)
-			if !paramTypes[i].Is(argType) {
+			if generic && !types.Unify(paramTypes[i], argType, sub) || !generic && !paramTypes[i].Is(argType) {
 				nodeName := fmt.Sprintf("function %s", fnc.fName.name)
-				ctx.SemanticErrChan <- errors.NewTypeError(fnc.pos, nodeName, paramTypes[i], argType)
+				ctx.SemanticErrChan <- errors.NewTypeError(fnc.pos, nodeName, types.Substitute(paramTypes[i], sub), argType)
 				ok = false
 			}
+		} else if generic {
+			ok = false
 		}
 	}
 
+	if generic && ok {
+		for _, param := range types.TypeVars(fType) {
+			if _, bound := sub[param]; !bound {
+				ctx.SemanticErrChan <- errors.NewInferenceError(fnc.pos, fnc.fName.String(), param)
+				return false
+			}
+		}
+		fnc.typeArgs = sub
+	}
 	return ok
 }
 
+//GetParamTypes returns the types of the parameters of the function being called, with
+//the types a generic function is called with in place of its type parameters
+func (fnc RHSFunctionCall) GetParamTypes() []types.WaccType {
+	var fType types.WaccType
+	if fnc.closure != nil {
+		fType = fnc.closure.EvalType(*fnc.table)
+	} else {
+		toLookup, _ := fnc.FormatName()
+		fType, _ = fnc.table.GetType(toLookup)
+	}
+	children := fType.GetChildren()
+	params := make([]types.WaccType, len(children)-1)
+	for i, param := range children[:len(params)] {
+		params[i] = types.Substitute(param, fnc.typeArgs)
+	}
+	return params
+}
+
 //EvalType returns the type of a function call
 //Assumes the function call has already passed semantic checks
 func (fnc RHSFunctionCall) EvalType(s symboltable.SymbolTable) types.WaccType {
-	toLookup, _ := fnc.FormatName()
-	returnType, _ := s.GetType(toLookup)
-	types := returnType.GetChildren()
-	return types[len(types)-1]
+	var returnType types.WaccType
+	if fnc.closure != nil {
+		returnType = fnc.closure.EvalType(s)
+	} else {
+		toLookup, _ := fnc.FormatName()
+		returnType, _ = s.GetType(toLookup)
+	}
+	children := returnType.GetChildren()
+	return types.Substitute(children[len(children)-1], fnc.typeArgs)
 }
 
 //PairElemPos enum
@@ -2,6 +2,7 @@ package ast
 
 import (
 	"fmt"
+	"reflect"
 	"wacc_32/symboltable"
 	"wacc_32/types"
 )
@@ -24,7 +25,40 @@ func (a ast) GetSymbolTable() *symboltable.SymbolTable {
 //Context is a struct to pass information between nodes during semantic analysis
 type Context struct {
 	SemanticErrChan chan<- error
+	Analysis        bool //Undo folding and pruning once reported so the program still matches its source
 	returnType      types.WaccType
 	table           *symboltable.SymbolTable
 	functionName    string
 }
+
+//journal records the changes folding and pruning make to a program so they can be
+//undone, a nil journal doesn't record them
+type journal struct {
+	undo []func()
+}
+
+//set assigns value to the field or element dest points to
+func (j *journal) set(dest, value interface{}) {
+	d := reflect.ValueOf(dest).Elem()
+	if j != nil {
+		old := reflect.New(d.Type()).Elem()
+		old.Set(d)
+		j.undo = append(j.undo, func() { d.Set(old) })
+	}
+	v := reflect.ValueOf(value)
+	if !v.IsValid() {
+		v = reflect.Zero(d.Type())
+	}
+	d.Set(v)
+}
+
+//rollback undoes the changes recorded, latest first
+func (j *journal) rollback() {
+	if j == nil {
+		return
+	}
+	for i := len(j.undo) - 1; i >= 0; i-- {
+		j.undo[i]()
+	}
+	j.undo = nil
+}
@@ -119,7 +119,9 @@ func (b *BinOp) Check(ctx Context) bool {
 		fallthrough
 	case NotEq:
 		for _, t := range ts {
-			if t.Is(types.Array) {
+			//Function values have no identity, a function's name creates a new one each time,
+			//and neither have interface values, converting an object creates a new one
+			if t.Is(types.Array) || t.Is(types.Function) || isInterface(t, *ctx.table) {
 				ctx.SemanticErrChan <- errors.NewMultiTypeError(b.pos, "equality operators", t, intType, boolType, charType, pairType)
 				ok = false
 			}
@@ -159,3 +161,13 @@ func (b BinOp) EvalType(_ symboltable.SymbolTable) types.WaccType {
 	}
 	return types.Boolean
 }
+
+//isInterface checks whether t is an interface
+func isInterface(t types.WaccType, table symboltable.SymbolTable) bool {
+	ut, ok := t.(types.UserType)
+	if !ok {
+		return false
+	}
+	declared, err := lookupDeclaration(ut.GetName(), table)
+	return err == nil && declared.IsInterface()
+}
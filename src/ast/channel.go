@@ -0,0 +1,201 @@
+package ast
+
+import (
+	"wacc_32/errors"
+	"wacc_32/symboltable"
+	"wacc_32/types"
+)
+
+var (
+	_ RHS       = &MakeChan{}
+	_ RHS       = &Recv{}
+	_ Statement = &StatSend{}
+	_ Statement = &StatClose{}
+)
+
+//MakeChan creates a channel buffering up to capacity values of t, an unbuffered
+//channel has capacity 0
+type MakeChan struct {
+	ast
+	t        types.WaccType
+	capacity Expression
+	pos      errors.Position
+}
+
+//NewMakeChan creates a channel of t with a capacity
+func NewMakeChan(t types.WaccType, capacity Expression, pos errors.Position) *MakeChan {
+	return &MakeChan{
+		t:        t,
+		capacity: capacity,
+		pos:      pos,
+	}
+}
+
+//GetCapacity returns the expression of the capacity of the channel
+func (m MakeChan) GetCapacity() Expression {
+	return m.capacity
+}
+
+//Check makes sure the capacity is an integer
+func (m *MakeChan) Check(ctx Context) bool {
+	m.table = ctx.table
+	if !m.capacity.Check(ctx) || !checkTypeArgs(m.t, ctx, m.pos) {
+		return false
+	}
+	if t := m.capacity.EvalType(*ctx.table); !t.Is(types.Integer) {
+		ctx.SemanticErrChan <- errors.NewTypeError(m.pos, "make_chan capacity", types.Integer, t)
+		return false
+	}
+	return true
+}
+
+//EvalType returns a channel of m.t
+func (m MakeChan) EvalType(_ symboltable.SymbolTable) types.WaccType {
+	return types.NewChan(m.t)
+}
+
+//String returns
+// MAKE_CHAN
+//   - type
+//   - capacity
+func (m MakeChan) String() string {
+	return format("MAKE_CHAN", m.t.String(), m.capacity.String())
+}
+
+//Recv takes the next value sent on a channel, waiting for one to be sent. A closed
+//channel with no values left gives the default value of its element type
+type Recv struct {
+	ast
+	channel Expression
+	pos     errors.Position
+}
+
+//NewRecv creates a receive from channel
+func NewRecv(channel Expression, pos errors.Position) *Recv {
+	return &Recv{
+		channel: channel,
+		pos:     pos,
+	}
+}
+
+//GetChannel returns the channel received from
+func (r Recv) GetChannel() Expression {
+	return r.channel
+}
+
+//Check makes sure a channel is received from
+func (r *Recv) Check(ctx Context) bool {
+	r.table = ctx.table
+	if !r.channel.Check(ctx) {
+		return false
+	}
+	if t := r.channel.EvalType(*ctx.table); !t.Is(types.Chan) {
+		ctx.SemanticErrChan <- errors.NewTypeError(r.pos, "recv", types.Chan, t)
+		return false
+	}
+	return true
+}
+
+//EvalType returns the type of the elements of the channel
+func (r Recv) EvalType(s symboltable.SymbolTable) types.WaccType {
+	return r.channel.EvalType(s).GetChildren()[0]
+}
+
+//String returns
+// RECV
+//   - channel
+func (r Recv) String() string {
+	return format("RECV", r.channel.String())
+}
+
+//StatSend sends a value on a channel, waiting until there is room in its buffer or, for
+//an unbuffered channel, until the value is received
+type StatSend struct {
+	ast
+	channel Expression
+	value   Expression
+	pos     errors.Position
+}
+
+//NewStatSend creates a send of value on channel
+func NewStatSend(channel, value Expression, pos errors.Position) *StatSend {
+	return &StatSend{
+		channel: channel,
+		value:   value,
+		pos:     pos,
+	}
+}
+
+//GetChannel returns the channel sent on
+func (s StatSend) GetChannel() Expression {
+	return s.channel
+}
+
+//GetValue returns the value sent
+func (s StatSend) GetValue() Expression {
+	return s.value
+}
+
+//String returns
+// SEND
+//   - channel
+//   - value
+func (s StatSend) String() string {
+	return format("SEND", s.channel.String(), s.value.String())
+}
+
+//Check makes sure the value sent is of the type of the elements of the channel
+func (s *StatSend) Check(ctx Context) {
+	s.table = ctx.table
+	chanOk, valueOk := s.channel.Check(ctx), s.value.Check(ctx)
+	if !chanOk || !valueOk {
+		return
+	}
+	t := s.channel.EvalType(*ctx.table)
+	if !t.Is(types.Chan) {
+		ctx.SemanticErrChan <- errors.NewTypeError(s.pos, "send", types.Chan, t)
+		return
+	}
+	elem := t.GetChildren()[0]
+	if v := s.value.EvalType(*ctx.table); !elem.Is(v) {
+		ctx.SemanticErrChan <- errors.NewTypeError(s.pos, "send", elem, v)
+	}
+}
+
+//StatClose closes a channel, so no more values can be sent on it
+type StatClose struct {
+	ast
+	channel Expression
+	pos     errors.Position
+}
+
+//NewStatClose creates a close of channel
+func NewStatClose(channel Expression, pos errors.Position) *StatClose {
+	return &StatClose{
+		channel: channel,
+		pos:     pos,
+	}
+}
+
+//GetChannel returns the channel closed
+func (s StatClose) GetChannel() Expression {
+	return s.channel
+}
+
+//String returns
+// CLOSE
+//   - channel
+func (s StatClose) String() string {
+	return format("CLOSE", s.channel.String())
+}
+
+//Check makes sure a channel is closed
+func (s *StatClose) Check(ctx Context) {
+	s.table = ctx.table
+	if !s.channel.Check(ctx) {
+		return
+	}
+	if t := s.channel.EvalType(*ctx.table); !t.Is(types.Chan) {
+		ctx.SemanticErrChan <- errors.NewTypeError(s.pos, "close", types.Chan, t)
+	}
+}
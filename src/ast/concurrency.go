@@ -2,12 +2,14 @@ package ast
 
 import (
 	"wacc_32/errors"
+	"wacc_32/symboltable"
 	"wacc_32/types"
 )
 
 var (
 	_ Statement = &StatLock{}
 	_ Statement = &StatSema{}
+	_ RHS       = &WaccFuture{}
 )
 
 type LockStatType int
@@ -54,6 +56,11 @@ func (s StatLock) GetName() string {
 	return s.lock.name
 }
 
+//GetIdent returns the lock being acquired or released
+func (s StatLock) GetIdent() *Ident {
+	return s.lock
+}
+
 func (s StatLock) GetType() LockStatType {
 	return s.sType
 }
@@ -120,3 +127,26 @@ func (s StatSema) Check(ctx Context) {
 		ctx.SemanticErrChan <- errors.NewTypeError(s.pos, s.getName(), types.Sema, t)
 	}
 }
+
+//WaccFuture is a function call executed in a new thread, whose result is read by
+//joining the future it evaluates to
+type WaccFuture struct {
+	*RHSFunctionCall
+}
+
+//NewWaccFuture creates a FunctionCall which will be executed in a new thread
+func NewWaccFuture(fName *Ident, isMethod bool, args []Expression, pos errors.Position) *WaccFuture {
+	call := NewRHSFunctionCall(fName, args, isMethod, pos)
+	call.concurrent = true
+	return &WaccFuture{call}
+}
+
+//Check makes sure the call can be run in a new thread
+func (wf *WaccFuture) Check(ctx Context) bool {
+	return wf.RHSFunctionCall.Check(ctx)
+}
+
+//EvalType returns a future of the type the function returns
+func (wf WaccFuture) EvalType(s symboltable.SymbolTable) types.WaccType {
+	return types.NewFuture(wf.RHSFunctionCall.EvalType(s))
+}
@@ -0,0 +1,171 @@
+package ast
+
+import (
+	"strings"
+	"wacc_32/errors"
+	"wacc_32/symboltable"
+	"wacc_32/types"
+)
+
+var (
+	_ Statement = &StatCond{}
+	_ Statement = &StatWith{}
+)
+
+type CondStatType int
+
+const (
+	Wait CondStatType = iota + 1
+	Signal
+	Broadcast
+)
+
+var condStatStrings = []string{"WAIT", "SIGNAL", "BROADCAST"}
+
+func (cst CondStatType) String() string {
+	return condStatStrings[cst-1]
+}
+
+//StatCond waits on a condition variable, or wakes up one or all of the threads waiting
+//on it. Waiting releases the lock until the thread is woken up and reacquires it
+type StatCond struct {
+	ast
+	sType CondStatType
+	cond  *Ident
+	lock  *Ident //The lock released while waiting, nil unless sType is Wait
+	pos   errors.Position
+}
+
+//NewWait creates a wait on cond, releasing lock while waiting
+func NewWait(cond, lock *Ident, pos errors.Position) *StatCond {
+	return &StatCond{
+		sType: Wait,
+		cond:  cond,
+		lock:  lock,
+		pos:   pos,
+	}
+}
+
+//NewSignal creates a statement waking up one thread waiting on cond
+func NewSignal(cond *Ident, pos errors.Position) *StatCond {
+	return &StatCond{
+		sType: Signal,
+		cond:  cond,
+		pos:   pos,
+	}
+}
+
+//NewBroadcast creates a statement waking up every thread waiting on cond
+func NewBroadcast(cond *Ident, pos errors.Position) *StatCond {
+	return &StatCond{
+		sType: Broadcast,
+		cond:  cond,
+		pos:   pos,
+	}
+}
+
+//GetType returns whether the statement waits, signals or broadcasts
+func (s StatCond) GetType() CondStatType {
+	return s.sType
+}
+
+//GetCond returns the condition variable
+func (s StatCond) GetCond() *Ident {
+	return s.cond
+}
+
+//GetLock returns the lock released while waiting
+func (s StatCond) GetLock() *Ident {
+	return s.lock
+}
+
+func (s StatCond) getName() string {
+	return strings.ToLower(s.sType.String())
+}
+
+//String returns
+// WAIT
+//   - cond
+//   - lock
+func (s StatCond) String() string {
+	if s.lock == nil {
+		return format(s.sType.String(), s.cond.String())
+	}
+	return format(s.sType.String(), s.cond.String(), s.lock.String())
+}
+
+//Check makes sure a condition variable is used, and a lock is released while waiting
+func (s *StatCond) Check(ctx Context) {
+	s.table = ctx.table
+	s.cond.table = ctx.table
+	if s.cond.Check(ctx) {
+		if t := s.cond.EvalType(*s.table); !t.Is(types.Cond) {
+			ctx.SemanticErrChan <- errors.NewTypeError(s.pos, s.getName(), types.Cond, t)
+		}
+	}
+	if s.lock == nil {
+		return
+	}
+	s.lock.table = ctx.table
+	if s.lock.Check(ctx) {
+		if t := s.lock.EvalType(*s.table); !t.Is(types.Lock) {
+			ctx.SemanticErrChan <- errors.NewTypeError(s.pos, s.getName(), types.Lock, t)
+		}
+	}
+}
+
+//StatWith acquires a lock, runs its body and releases the lock however the body is
+//left, including by returning
+type StatWith struct {
+	ast
+	lock *Ident
+	stat Statement
+	pos  errors.Position
+}
+
+//NewStatWith creates a block holding lock while stat runs
+func NewStatWith(lock *Ident, stat Statement, pos errors.Position) *StatWith {
+	return &StatWith{
+		lock: lock,
+		stat: stat,
+		pos:  pos,
+	}
+}
+
+//GetIdent returns the lock held by the block
+func (s StatWith) GetIdent() *Ident {
+	return s.lock
+}
+
+//GetStat returns the body of the block
+func (s StatWith) GetStat() Statement {
+	return s.stat
+}
+
+//String returns
+// WITH
+//   - lock
+//   - stat
+func (s StatWith) String() string {
+	return format("WITH", s.lock.String(), s.stat.String())
+}
+
+//Check makes sure a lock is held, the body has a scope of its own
+func (s *StatWith) Check(ctx Context) {
+	s.table = ctx.table
+	s.lock.table = ctx.table
+	if s.lock.Check(ctx) {
+		if t := s.lock.EvalType(*s.table); !t.Is(types.Lock) {
+			ctx.SemanticErrChan <- errors.NewTypeError(s.pos, "with", types.Lock, t)
+		}
+	}
+
+	withCtx := Context{
+		SemanticErrChan: ctx.SemanticErrChan,
+		functionName:    ctx.functionName,
+		table:           symboltable.NewSymbolTable(ctx.table),
+		returnType:      ctx.returnType,
+	}
+	s.stat.Check(withCtx)
+	s.table.SetTotalOffset(withCtx.table.GetTotalOffset())
+}
@@ -0,0 +1,338 @@
+package ast
+
+import "wacc_32/errors"
+
+//acquisition is a lock acquired at pos
+type acquisition struct {
+	lock place
+	pos  errors.Position
+}
+
+//orderEdge is a lock acquired while another is held
+type orderEdge struct {
+	held, acquired acquisition
+}
+
+//lockOrder is what a function does with locks, in terms of its own variables
+type lockOrder struct {
+	edges    []orderEdge   //Locks acquired while holding others, by fn or what it calls or starts
+	acquires []acquisition //Locks acquired by fn or what it calls, in its own thread
+	seen     map[interface{}]bool
+}
+
+//orderFacts is what the lock order checker knows about one point of a function
+type orderFacts struct {
+	held []acquisition //Locks acquired on some path to this point and not released since
+}
+
+//orderWalker walks one function, recording the order it acquires locks in
+type orderWalker struct {
+	funcs     map[string]*Function
+	summaries map[*Function]*lockOrder
+	order     *lockOrder
+	flow      *flowWalker
+}
+
+//lockCycles reports locks which may be acquired in a cycle, each held while acquiring
+//the next, as threads acquiring them in that order can deadlock. Locks are followed
+//through the arguments of calls and wacc routines, a call acquires the locks its
+//function does while holding those of the caller
+func (prog *Program) lockCycles(errChan chan<- error) {
+	funcs := make(map[string]*Function, len(prog.funcs))
+	for _, fn := range prog.funcs {
+		funcs[fn.ident.name] = fn
+	}
+	summaries := make(map[*Function]*lockOrder)
+	graph := &lockOrder{seen: make(map[interface{}]bool)}
+	for _, fn := range prog.funcs {
+		if fn.table == nil {
+			continue
+		}
+		params := paramIndices(fn)
+		for _, e := range lockSummary(funcs, summaries, fn).edges {
+			_, heldParam := params[e.held.lock.root]
+			_, acquiredParam := params[e.acquired.lock.root]
+			//Locks passed in are ordered by the callers, for what they pass
+			if !heldParam && !acquiredParam {
+				graph.edge(e)
+			}
+		}
+	}
+	for _, cycle := range cycles(graph.edges) {
+		positions := make([]errors.Position, len(cycle))
+		locks := make([]string, len(cycle))
+		for i, e := range cycle {
+			positions[i] = e.acquired.pos
+			locks[i] = e.held.lock.root.name + e.held.lock.path
+		}
+		errChan <- errors.NewLockCycleWarning(positions, locks)
+	}
+}
+
+//lockSummary returns the locks fn acquires and the order it acquires them in. A
+//recursive call acquires nothing
+func lockSummary(funcs map[string]*Function, summaries map[*Function]*lockOrder, fn *Function) *lockOrder {
+	if order, ok := summaries[fn]; ok {
+		return order
+	}
+	order := &lockOrder{seen: make(map[interface{}]bool)}
+	summaries[fn] = &lockOrder{}
+	w := &orderWalker{funcs: funcs, summaries: summaries, order: order}
+	//Loop bodies are walked twice, so locks still held at the end of an iteration are
+	//held by the start of the next
+	w.flow = &flowWalker{pass: w, loops: 2}
+	w.flow.stat(fn.stats, &flowState{facts: &orderFacts{}})
+	summaries[fn] = order
+	return order
+}
+
+func (o *lockOrder) edge(e orderEdge) {
+	if !o.seen[e] {
+		o.seen[e] = true
+		o.edges = append(o.edges, e)
+	}
+}
+
+func (o *lockOrder) acquired(a acquisition) {
+	if !o.seen[a] {
+		o.seen[a] = true
+		o.acquires = append(o.acquires, a)
+	}
+}
+
+func (f *orderFacts) copy() flowFacts {
+	return &orderFacts{append([]acquisition{}, f.held...)}
+}
+
+//merge adds what is true on another path, a lock may be held if it is held on either path
+func (f *orderFacts) merge(other flowFacts) {
+	for _, acq := range other.(*orderFacts).held {
+		if !f.holds(acq.lock) {
+			f.held = append(f.held, acq)
+		}
+	}
+}
+
+func (f *orderFacts) holds(lock place) bool {
+	for _, acq := range f.held {
+		if acq.lock == lock {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *orderWalker) stat(stat Statement, st *flowState) bool {
+	if s, ok := stat.(*WaccRoutine); ok {
+		w.flow.exprs(s.args, st)
+		w.start(s.RHSFunctionCall)
+		return true
+	}
+	return false
+}
+
+//expr follows the calls and routines started by expr, arguments first
+func (w *orderWalker) expr(expr Expression, st *flowState) {
+	walk(expr, func(node interface{}) bool {
+		switch n := node.(type) {
+		case *Lambda:
+			//The body runs when the closure is called
+			return false
+		case *RHSFunctionCall:
+			w.flow.exprs(n.args, st)
+			w.call(n, st)
+			return false
+		case *WaccFuture:
+			w.flow.exprs(n.args, st)
+			w.start(n.RHSFunctionCall)
+			return false
+		}
+		return true
+	})
+}
+
+//acquire orders the lock acquired after each lock held
+func (w *orderWalker) acquire(lock *Ident, pos errors.Position, st *flowState) bool {
+	p, ok := placeOf(lock)
+	facts := st.facts.(*orderFacts)
+	if !ok || st.done || facts.holds(p) {
+		return false
+	}
+	acq := acquisition{p, pos}
+	for _, held := range facts.held {
+		w.order.edge(orderEdge{held, acq})
+	}
+	w.order.acquired(acq)
+	facts.held = append(facts.held, acq)
+	return true
+}
+
+func (w *orderWalker) release(lock *Ident, st *flowState) {
+	p, ok := placeOf(lock)
+	if !ok {
+		return
+	}
+	facts := st.facts.(*orderFacts)
+	held := make([]acquisition, 0, len(facts.held))
+	for _, acq := range facts.held {
+		if acq.lock != p {
+			held = append(held, acq)
+		}
+	}
+	facts.held = held
+}
+
+//call adds the order the function called acquires locks in, and orders the locks it
+//acquires after those held by the caller
+func (w *orderWalker) call(call *RHSFunctionCall, st *flowState) {
+	fn, args := callee(w.funcs, call)
+	if fn == nil || st.done {
+		return
+	}
+	order, params := lockSummary(w.funcs, w.summaries, fn), paramIndices(fn)
+	facts := st.facts.(*orderFacts)
+	for _, acq := range order.acquires {
+		if acq, ok := callerAcquisition(acq, call.pos, params, args); ok && !facts.holds(acq.lock) {
+			for _, held := range facts.held {
+				w.order.edge(orderEdge{held, acq})
+			}
+			w.order.acquired(acq)
+		}
+	}
+	w.edges(order, call.pos, params, args)
+}
+
+//start adds the order a routine acquires locks in, the routine holds none of the locks
+//of the function starting it
+func (w *orderWalker) start(call *RHSFunctionCall) {
+	if fn, args := callee(w.funcs, call); fn != nil {
+		w.edges(lockSummary(w.funcs, w.summaries, fn), call.pos, paramIndices(fn), args)
+	}
+}
+
+func (w *orderWalker) edges(order *lockOrder, pos errors.Position, params map[variable]int, args []*place) {
+	for _, e := range order.edges {
+		held, ok := callerAcquisition(e.held, pos, params, args)
+		acquired, ok2 := callerAcquisition(e.acquired, pos, params, args)
+		if ok && ok2 && held.lock != acquired.lock {
+			w.order.edge(orderEdge{held, acquired})
+		}
+	}
+}
+
+//callerAcquisition returns acq, of a function called at pos, in terms of the caller
+//and acquired at the call. Locks passed in are the arguments, other locks are the
+//callee's own, unless the argument isn't a variable or field, when it is unknown
+func callerAcquisition(acq acquisition, pos errors.Position, params map[variable]int, args []*place) (acquisition, bool) {
+	if _, ok := params[acq.lock.root]; !ok {
+		return acquisition{acq.lock, pos}, true
+	}
+	lock, ok := argPlace(acq.lock, params, args)
+	return acquisition{lock, pos}, ok
+}
+
+//cycles returns a shortest cycle through the first lock of each group of locks which
+//can all reach each other in the graph of edges
+func cycles(edges []orderEdge) [][]orderEdge {
+	index := make(map[place]int)
+	var nodes []place
+	for _, e := range edges {
+		for _, p := range []place{e.held.lock, e.acquired.lock} {
+			if _, ok := index[p]; !ok {
+				index[p] = len(nodes)
+				nodes = append(nodes, p)
+			}
+		}
+	}
+	out := make([][]orderEdge, len(nodes))
+	for _, e := range edges {
+		out[index[e.held.lock]] = append(out[index[e.held.lock]], e)
+	}
+
+	//Tarjan's strongly connected components
+	order, low := make([]int, len(nodes)), make([]int, len(nodes))
+	component := make([]int, len(nodes))
+	onStack := make([]bool, len(nodes))
+	var stack []int
+	var components [][]int
+	next := 1
+	var visit func(n int)
+	visit = func(n int) {
+		order[n], low[n] = next, next
+		next++
+		stack = append(stack, n)
+		onStack[n] = true
+		for _, e := range out[n] {
+			m := index[e.acquired.lock]
+			if order[m] == 0 {
+				visit(m)
+				if low[m] < low[n] {
+					low[n] = low[m]
+				}
+			} else if onStack[m] && order[m] < low[n] {
+				low[n] = order[m]
+			}
+		}
+		if low[n] == order[n] {
+			var c []int
+			for {
+				m := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[m] = false
+				component[m] = len(components)
+				c = append(c, m)
+				if m == n {
+					break
+				}
+			}
+			components = append(components, c)
+		}
+	}
+	for n := range nodes {
+		if order[n] == 0 {
+			visit(n)
+		}
+	}
+
+	var found [][]orderEdge
+	reported := make(map[int]bool)
+	for n := range nodes {
+		c := component[n]
+		if reported[c] || len(components[c]) < 2 {
+			continue
+		}
+		reported[c] = true
+		found = append(found, shortestCycle(n, out, index, component))
+	}
+	return found
+}
+
+//shortestCycle returns a shortest cycle through start, following only the edges within
+//its component
+func shortestCycle(start int, out [][]orderEdge, index map[place]int, component []int) []orderEdge {
+	via := make(map[int]orderEdge)
+	queue := []int{start}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, e := range out[n] {
+			m := index[e.acquired.lock]
+			if component[m] != component[start] {
+				continue
+			}
+			if m == start {
+				cycle := []orderEdge{e}
+				for n != start {
+					cycle = append([]orderEdge{via[n]}, cycle...)
+					n = index[via[n].held.lock]
+				}
+				return cycle
+			}
+			if _, ok := via[m]; !ok {
+				via[m] = e
+				queue = append(queue, m)
+			}
+		}
+	}
+	return nil
+}
@@ -103,6 +103,7 @@ type Ident struct {
 	name       string
 	namespaced bool
 	imported   bool
+	library    string //Prefix of the function names of the library the ident is written in
 	pos        errors.Position
 }
 
@@ -118,10 +119,20 @@ func NewIdent(name string, pos errors.Position) *Ident {
 	}
 }
 
+//SetLibrary records the library the ident is written in, by the prefix of its function names
+func (i *Ident) SetLibrary(prefix string) {
+	i.library = prefix
+}
+
 func (i Ident) IsNamespaced() bool {
 	return i.namespaced
 }
 
+//IsFunction checks whether the identifier names a function, used as a function value
+func (i Ident) IsFunction() bool {
+	return !i.namespaced && !i.imported && i.name[0] == '0'
+}
+
 //String returns the ident name
 //functions and structs/classes = 0name
 //imported stuff				= dir$dir$dir$dir$file$0name
@@ -177,7 +188,12 @@ func (i *Ident) Check(ctx Context) bool {
 		}
 		for i := 1; i < len(components); i++ {
 			fieldName := components[i]
-			uType, err1 := LookupUserType(t.(types.UserType), *ctx.table)
+			ut, isUserType := t.(types.UserType)
+			if !isUserType {
+				err = fmt.Errorf("%s has no field %s", components[i-1], fieldName)
+				goto Error
+			}
+			uType, err1 := LookupUserType(ut, *ctx.table)
 			if err1 != nil {
 				err = err1
 				goto Error
@@ -190,6 +206,18 @@ func (i *Ident) Check(ctx Context) bool {
 		scope = ctx.table
 	} else {
 		scope, err = ctx.table.Find(i.name)
+		//A function's name is a value of its function type when no variable hides it
+		if err != nil && !i.imported {
+			if t, err1 := ctx.table.GetType("0" + i.library + i.name); err1 == nil && t.Is(types.Function) {
+				//Generic functions only have code for the types they are called with
+				if types.IsGeneric(t) {
+					ctx.SemanticErrChan <- errors.NewGenericValueError(i.pos, i.name)
+					return false
+				}
+				i.name = "0" + i.library + i.name
+				scope, err = ctx.table.Find(i.name)
+			}
+		}
 	}
 
 Error:
@@ -0,0 +1,159 @@
+package ast
+
+import "wacc_32/errors"
+
+//flowFacts is what a pass knows about one point of a function
+type flowFacts interface {
+	copy() flowFacts
+	//merge adds what is known on another path joining this one
+	merge(other flowFacts)
+}
+
+//flowState is what a pass knows about one point of a function, and whether it is reached
+type flowState struct {
+	facts flowFacts
+	done  bool //Every path to this point has returned or exited
+}
+
+func (st flowState) copy() flowState {
+	return flowState{st.facts.copy(), st.done}
+}
+
+//joinFlows returns the state after two paths join, a path which has returned adds nothing
+func joinFlows(a, b flowState) flowState {
+	if a.done {
+		return b
+	}
+	if b.done {
+		return a
+	}
+	a.facts.merge(b.facts)
+	return a
+}
+
+//flowPass is an analysis of the statements of a function, whose control flow a
+//flowWalker follows
+type flowPass interface {
+	//stat analyses a statement, returning false to leave it to the walker
+	stat(stat Statement, st *flowState) bool
+	expr(expr Expression, st *flowState)
+	//acquire records lock acquired at pos, returning false if it was already held
+	acquire(lock *Ident, pos errors.Position, st *flowState) bool
+	release(lock *Ident, st *flowState)
+}
+
+//flowWalker follows the control flow of a function for a pass. Branches are walked from
+//copies of the state before them, which are joined after them
+type flowWalker struct {
+	pass  flowPass
+	loops int //Times loop bodies are walked, more than once carries the end of an iteration into the next
+}
+
+func (w *flowWalker) stat(stat Statement, st *flowState) {
+	if w.pass.stat(stat, st) {
+		return
+	}
+	switch s := stat.(type) {
+	case *StatNewassign:
+		w.pass.expr(s.rhs, st)
+	case *StatAssign:
+		w.pass.expr(s.rhs, st)
+		w.pass.expr(s.lhs, st)
+	case *StatEnhancedAssign:
+		w.pass.expr(s.lhs, st)
+		w.pass.expr(s.rhs, st)
+	case *StatRead:
+		w.pass.expr(s.toRead, st)
+	case *StatFree:
+		w.pass.expr(s.expr, st)
+	case *StatPrint:
+		w.pass.expr(s.exprToPrint, st)
+	case *StatPrintln:
+		w.pass.expr(s.exprToPrint, st)
+	case *StatExit:
+		w.pass.expr(s.exitCode, st)
+		st.done = true
+	case *StatReturn:
+		w.pass.expr(s.retValue, st)
+		st.done = true
+	case *StatLock:
+		w.pass.expr(s.lock, st)
+		if s.sType == Acquire {
+			w.pass.acquire(s.lock, s.pos, st)
+		} else {
+			w.pass.release(s.lock, st)
+		}
+	case *StatSema:
+		w.pass.expr(s.sema, st)
+	case *StatSend:
+		w.pass.expr(s.channel, st)
+		w.pass.expr(s.value, st)
+	case *StatClose:
+		w.pass.expr(s.channel, st)
+	case *StatCond:
+		w.pass.expr(s.cond, st)
+		if s.lock == nil {
+			break
+		}
+		w.pass.expr(s.lock, st)
+		//Waiting releases the lock and reacquires it while the other locks are still held
+		if s.sType == Wait {
+			w.pass.release(s.lock, st)
+			w.pass.acquire(s.lock, s.pos, st)
+		}
+	case *StatWith:
+		w.pass.expr(s.lock, st)
+		acquired := w.pass.acquire(s.lock, s.pos, st)
+		w.stat(s.stat, st)
+		if acquired {
+			w.pass.release(s.lock, st)
+		}
+	case *WaccRoutine:
+		w.exprs(s.args, st)
+	case *StatBegin:
+		w.stat(s.stat, st)
+	case *StatIf:
+		w.pass.expr(s.cond, st)
+		ifState, elseState := st.copy(), st.copy()
+		w.stat(s.ifStat, &ifState)
+		w.stat(s.elseStat, &elseState)
+		*st = joinFlows(ifState, elseState)
+	case *StatWhile:
+		w.pass.expr(s.cond, st)
+		body := st.copy()
+		for i := 0; i < w.loops; i++ {
+			w.stat(s.bodyStat, &body)
+			w.pass.expr(s.cond, &body)
+		}
+		*st = joinFlows(*st, body)
+	case *StatDoWhile:
+		w.stat(s.bodyStat, st)
+		w.pass.expr(s.cond, st)
+		again := st.copy()
+		for i := 1; i < w.loops; i++ {
+			w.stat(s.bodyStat, &again)
+			w.pass.expr(s.cond, &again)
+		}
+		*st = joinFlows(*st, again)
+	case *StatFor:
+		w.stat(&s.initial, st)
+		w.pass.expr(s.cond, st)
+		body := st.copy()
+		for i := 0; i < w.loops; i++ {
+			w.stat(s.bodyStat, &body)
+			w.stat(&s.change, &body)
+			w.pass.expr(s.cond, &body)
+		}
+		*st = joinFlows(*st, body)
+	case StatMultiple:
+		for _, child := range s {
+			w.stat(child, st)
+		}
+	}
+}
+
+func (w *flowWalker) exprs(exprs []Expression, st *flowState) {
+	for _, expr := range exprs {
+		w.pass.expr(expr, st)
+	}
+}
@@ -0,0 +1,440 @@
+package ast
+
+import (
+	"fmt"
+	"math"
+	"wacc_32/errors"
+	"wacc_32/symboltable"
+	"wacc_32/types"
+)
+
+//variable identifies a local by the scope it is declared in
+type variable struct {
+	scope *symboltable.SymbolTable
+	name  string
+}
+
+//folder replaces constant expressions with literals once the program has been checked
+type folder struct {
+	errChan  chan<- error
+	edits    *journal
+	assigned map[variable]bool
+	consts   map[variable]*Literal
+}
+
+var charEscapes = map[byte]byte{
+	'\\': '\\',
+	't':  '\t',
+	'n':  '\n',
+	'b':  '\b',
+	'f':  '\f',
+	'r':  '\r',
+	'"':  '"',
+	'\'': '\'',
+	'0':  byte(0),
+}
+
+//fold folds the constant expressions of every function and propagates the
+//value of locals which are declared with a constant and never reassigned.
+//Arithmetic errors in constant expressions are reported as semantic errors
+func (prog *Program) fold(errChan chan<- error, edits *journal) {
+	f := &folder{
+		errChan:  errChan,
+		edits:    edits,
+		assigned: make(map[variable]bool),
+		consts:   make(map[variable]*Literal),
+	}
+	//Methods are in prog.funcs too
+	for _, ut := range prog.userTypes {
+		for _, field := range ut.fields {
+			f.edits.set(&field.rhs, f.fold(field.rhs))
+		}
+	}
+	for _, fn := range prog.funcs {
+		for _, stat := range fn.stats {
+			f.findAssigned(stat)
+		}
+		walk(fn.stats, func(node interface{}) bool {
+			if l, ok := node.(*Lambda); ok {
+				f.findAssigned(l.stats)
+			}
+			return true
+		})
+	}
+	for _, fn := range prog.funcs {
+		for _, stat := range fn.stats {
+			f.foldStat(stat)
+		}
+	}
+}
+
+//identKey returns the variable an identifier refers to, false for field accesses
+func identKey(expr Expression) (variable, bool) {
+	ident, ok := expr.(*Ident)
+	if !ok || ident.namespaced {
+		return variable{}, false
+	}
+	return variable{ident.table, ident.name}, true
+}
+
+//findAssigned records every variable which is written after its declaration
+func (f *folder) findAssigned(stat Statement) {
+	var lhs Expression
+	switch s := stat.(type) {
+	case *StatRead:
+		lhs = s.toRead
+	case *StatAssign:
+		lhs = s.lhs
+	case *StatEnhancedAssign:
+		lhs = s.lhs
+	case *StatFor:
+		f.findAssigned(&s.change)
+		f.findAssigned(s.bodyStat)
+	case *StatWhile:
+		f.findAssigned(s.bodyStat)
+	case *StatDoWhile:
+		f.findAssigned(s.bodyStat)
+	case *StatBegin:
+		f.findAssigned(s.stat)
+	case *StatWith:
+		f.findAssigned(s.stat)
+	case *StatIf:
+		f.findAssigned(s.ifStat)
+		f.findAssigned(s.elseStat)
+	case StatMultiple:
+		for _, child := range s {
+			f.findAssigned(child)
+		}
+	}
+	if key, ok := identKey(lhs); ok {
+		f.assigned[key] = true
+	}
+}
+
+//foldStat folds the expressions of a statement
+func (f *folder) foldStat(stat Statement) {
+	switch s := stat.(type) {
+	case *StatRead:
+		f.edits.set(&s.toRead, f.fold(s.toRead))
+	case *StatFree:
+		f.edits.set(&s.expr, f.fold(s.expr))
+	case *StatNewassign:
+		f.foldNewassign(s)
+	case *StatPrint:
+		f.edits.set(&s.exprToPrint, f.fold(s.exprToPrint))
+	case *StatPrintln:
+		f.edits.set(&s.exprToPrint, f.fold(s.exprToPrint))
+	case *StatExit:
+		f.edits.set(&s.exitCode, f.fold(s.exitCode))
+	case *StatFor:
+		f.foldNewassign(&s.initial)
+		f.edits.set(&s.cond, f.fold(s.cond))
+		f.foldStat(&s.change)
+		f.foldStat(s.bodyStat)
+	case *StatWhile:
+		f.edits.set(&s.cond, f.fold(s.cond))
+		f.foldStat(s.bodyStat)
+	case *StatDoWhile:
+		f.foldStat(s.bodyStat)
+		f.edits.set(&s.cond, f.fold(s.cond))
+	case *StatBegin:
+		f.foldStat(s.stat)
+	case *StatWith:
+		f.foldStat(s.stat)
+	case *StatAssign:
+		f.edits.set(&s.lhs, f.fold(s.lhs))
+		f.edits.set(&s.rhs, f.fold(s.rhs))
+	case *StatEnhancedAssign:
+		f.edits.set(&s.lhs, f.fold(s.lhs))
+		f.edits.set(&s.rhs, f.fold(s.rhs))
+	case *StatReturn:
+		f.edits.set(&s.retValue, f.fold(s.retValue))
+	case *StatIf:
+		f.edits.set(&s.cond, f.fold(s.cond))
+		f.foldStat(s.ifStat)
+		f.foldStat(s.elseStat)
+	case *WaccRoutine:
+		f.foldExprs(s.args)
+	case *StatSend:
+		f.edits.set(&s.channel, f.fold(s.channel))
+		f.edits.set(&s.value, f.fold(s.value))
+	case *StatClose:
+		f.edits.set(&s.channel, f.fold(s.channel))
+	case StatMultiple:
+		for _, child := range s {
+			f.foldStat(child)
+		}
+	}
+}
+
+//foldNewassign folds the right hand side of a declaration and remembers its value
+//if the variable is never reassigned
+func (f *folder) foldNewassign(s *StatNewassign) {
+	f.edits.set(&s.rhs, f.fold(s.rhs))
+	key := variable{s.ident.table, s.ident.name}
+	if lit, ok := s.rhs.(*Literal); ok && isScalar(lit) && lit.t == s.t && !f.assigned[key] {
+		f.consts[key] = lit
+	}
+}
+
+//isScalar checks whether a literal is an int, bool or char constant
+func isScalar(lit *Literal) bool {
+	switch lit.t {
+	case types.Integer:
+		_, ok := lit.value.(int)
+		return ok
+	case types.Boolean:
+		_, ok := lit.value.(bool)
+		return ok
+	case types.Char:
+		_, ok := lit.value.(string)
+		return ok
+	}
+	return false
+}
+
+func (f *folder) foldExprs(exprs []Expression) {
+	for i, expr := range exprs {
+		f.edits.set(&exprs[i], f.fold(expr))
+	}
+}
+
+//fold returns expr with its constant sub-expressions replaced by literals
+func (f *folder) fold(expr Expression) Expression {
+	switch e := expr.(type) {
+	case *Ident:
+		if key, ok := identKey(e); ok {
+			if lit, ok := f.consts[key]; ok {
+				return &Literal{ast: e.ast, t: lit.t, value: lit.value, pos: e.pos}
+			}
+		}
+	case *ArrayElem:
+		f.foldExprs(e.indices)
+	case *Literal:
+		if exprs, ok := e.value.([]Expression); ok {
+			f.foldExprs(exprs)
+		}
+	case *RHSNewPair:
+		f.edits.set(&e.fst, f.fold(e.fst))
+		f.edits.set(&e.snd, f.fold(e.snd))
+	case *RHSFunctionCall:
+		f.foldExprs(e.args)
+	case *WaccFuture:
+		f.foldExprs(e.args)
+	case *Lambda:
+		f.foldStat(e.stats)
+	case *PairElem:
+		f.edits.set(&e.value, f.fold(e.value))
+	case *Make:
+		f.edits.set(&e.length, f.fold(e.length))
+	case *MakeChan:
+		f.edits.set(&e.capacity, f.fold(e.capacity))
+	case *Recv:
+		f.edits.set(&e.channel, f.fold(e.channel))
+	case *UnOp:
+		f.edits.set(&e.expr, f.fold(e.expr))
+		return f.foldUnOp(e)
+	case *BinOp:
+		f.edits.set(&e.left, f.fold(e.left))
+		//Short circuiting only needs the left operand, the right one is never evaluated
+		if l, ok := boolValue(e.left); ok && (e.op == And || e.op == Or) {
+			if l == (e.op == Or) {
+				return NewLiteral(types.Boolean, l, e.pos)
+			}
+			return f.fold(e.right)
+		}
+		f.edits.set(&e.right, f.fold(e.right))
+		return f.foldBinOp(e)
+	case *TernaryOp:
+		f.edits.set(&e.cond, f.fold(e.cond))
+		if cond, ok := boolValue(e.cond); ok {
+			if cond {
+				return f.fold(e.ifExpr)
+			}
+			return f.fold(e.elseExpr)
+		}
+		f.edits.set(&e.ifExpr, f.fold(e.ifExpr))
+		f.edits.set(&e.elseExpr, f.fold(e.elseExpr))
+	}
+	return expr
+}
+
+func intValue(expr Expression) (int, bool) {
+	if lit, ok := expr.(*Literal); ok && lit.t == types.Integer {
+		n, ok := lit.value.(int)
+		return n, ok
+	}
+	return 0, false
+}
+
+func boolValue(expr Expression) (bool, bool) {
+	if lit, ok := expr.(*Literal); ok && lit.t == types.Boolean {
+		b, ok := lit.value.(bool)
+		return b, ok
+	}
+	return false, false
+}
+
+//charValue decodes a char literal of the form '\?x'
+func charValue(expr Expression) (int, bool) {
+	lit, ok := expr.(*Literal)
+	if !ok || lit.t != types.Char {
+		return 0, false
+	}
+	char, ok := lit.value.(string)
+	if !ok || len(char) < 3 {
+		return 0, false
+	}
+	if char[1] == '\\' {
+		return int(charEscapes[char[2]]), true
+	}
+	return int(char[1]), true
+}
+
+//charLiteral encodes a char as a literal, only printable characters are supported
+func charLiteral(c int) (string, bool) {
+	for esc, code := range charEscapes {
+		if int(code) == c {
+			return `'\` + string(esc) + `'`, true
+		}
+	}
+	if c < ' ' || c > '~' {
+		return "", false
+	}
+	return "'" + string(rune(c)) + "'", true
+}
+
+//strLen returns the length of a string literal once its escape sequences are replaced
+func strLen(expr Expression) (int, bool) {
+	lit, ok := expr.(*Literal)
+	if !ok || lit.t != types.Str {
+		return 0, false
+	}
+	str, ok := lit.value.(string)
+	if !ok || len(str) < 2 {
+		return 0, false
+	}
+	n := 0
+	for i := 1; i < len(str)-1; i++ {
+		if str[i] == '\\' {
+			i++
+		}
+		n++
+	}
+	return n, true
+}
+
+//checkInt reports an overflow if n, the value of expr, doesn't fit in a wacc int
+func (f *folder) checkInt(n int64, expr string, pos errors.Position) bool {
+	if n < math.MinInt32 || n > math.MaxInt32 {
+		f.errChan <- errors.NewOverflowError(pos, expr)
+		return false
+	}
+	return true
+}
+
+func (f *folder) foldUnOp(u *UnOp) Expression {
+	switch u.op {
+	case Not:
+		if b, ok := boolValue(u.expr); ok {
+			return NewLiteral(types.Boolean, !b, u.pos)
+		}
+	case Neg:
+		if n, ok := intValue(u.expr); ok && f.checkInt(-int64(n), fmt.Sprintf("-(%d)", n), u.pos) {
+			return NewLiteral(types.Integer, -n, u.pos)
+		}
+	case Ord:
+		if c, ok := charValue(u.expr); ok {
+			return NewLiteral(types.Integer, c, u.pos)
+		}
+	case Chr:
+		if n, ok := intValue(u.expr); ok {
+			if char, ok := charLiteral(n); ok {
+				return NewLiteral(types.Char, char, u.pos)
+			}
+		}
+	case Len:
+		if n, ok := strLen(u.expr); ok {
+			return NewLiteral(types.Integer, n, u.pos)
+		}
+		if lit, ok := u.expr.(*Literal); ok && lit.t.Is(types.Array) {
+			if elems, ok := lit.value.([]Expression); ok {
+				return NewLiteral(types.Integer, len(elems), u.pos)
+			}
+		}
+	}
+	return u
+}
+
+func (f *folder) foldBinOp(b *BinOp) Expression {
+	if l, ok := intValue(b.left); ok {
+		if r, ok := intValue(b.right); ok {
+			return f.foldIntBinOp(b, int64(l), int64(r))
+		}
+	}
+	if l, ok := charValue(b.left); ok {
+		if r, ok := charValue(b.right); ok {
+			return foldComparison(b, int64(l), int64(r))
+		}
+	}
+	if l, ok := boolValue(b.left); ok {
+		if r, ok := boolValue(b.right); ok {
+			switch b.op {
+			case Equal:
+				return NewLiteral(types.Boolean, l == r, b.pos)
+			case NotEq:
+				return NewLiteral(types.Boolean, l != r, b.pos)
+			}
+		}
+	}
+	return b
+}
+
+func (f *folder) foldIntBinOp(b *BinOp, l, r int64) Expression {
+	expr := fmt.Sprintf("%d %s %d", l, b.op, r)
+	var n int64
+	switch b.op {
+	case Star:
+		n = l * r
+	case Plus:
+		n = l + r
+	case Minus:
+		n = l - r
+	case Div, Mod:
+		if r == 0 {
+			f.errChan <- errors.NewDivideByZeroError(b.pos, expr)
+			return b
+		}
+		n = l / r
+		if b.op == Mod {
+			n = l % r
+		}
+	default:
+		return foldComparison(b, l, r)
+	}
+	if !f.checkInt(n, expr, b.pos) {
+		return b
+	}
+	return NewLiteral(types.Integer, int(n), b.pos)
+}
+
+func foldComparison(b *BinOp, l, r int64) Expression {
+	var res bool
+	switch b.op {
+	case Greater:
+		res = l > r
+	case GreaterEq:
+		res = l >= r
+	case Less:
+		res = l < r
+	case LessEq:
+		res = l <= r
+	case Equal:
+		res = l == r
+	case NotEq:
+		res = l != r
+	default:
+		return b
+	}
+	return NewLiteral(types.Boolean, res, b.pos)
+}
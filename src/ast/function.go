@@ -2,6 +2,7 @@ package ast
 
 import (
 	"fmt"
+	"strings"
 	"wacc_32/errors"
 	"wacc_32/symboltable"
 	"wacc_32/types"
@@ -23,6 +24,8 @@ type Function struct {
 	pos          errors.Position
 	isConcurrent bool
 	isMethod     bool
+	methodName   string //The name a method was declared with, before its class is added
+	typeParams   []string
 }
 
 //NewFunction returns a function
@@ -53,6 +56,17 @@ func (f *Function) SetConcurrent() {
 	f.isConcurrent = true
 }
 
+//SetTypeParams makes the function generic over params
+func (f *Function) SetTypeParams(params []string) {
+	f.typeParams = params
+}
+
+//GetTypeParams returns the type parameters of a generic function, a method's start
+//with those of its class
+func (f Function) GetTypeParams() []string {
+	return f.typeParams
+}
+
 func (f *Function) IsConcurrent() bool {
 	return f.isConcurrent
 }
@@ -62,6 +76,16 @@ func (f Function) GetName() string {
 	return f.ident.String()
 }
 
+//GetInternalName returns the name the function is called by
+func (f Function) GetInternalName() string {
+	return f.ident.GetName()
+}
+
+//GetPos returns the position of the function's declaration
+func (f Function) GetPos() errors.Position {
+	return f.pos
+}
+
 //GetParams returns the parameters the function takes
 func (f Function) GetParams() ParamList {
 	return f.params
@@ -87,6 +111,7 @@ func (f Function) String() string {
 
 func (f *Function) MakeMethod(ut types.UserType) {
 	this := NewIdent("this", f.pos)
+	f.methodName = f.ident.name
 	f.ident = NewIdent(f.ident.GetName()+"_"+ut.GetName(), f.pos)
 	f.params = append(f.params, NewParam(ut, this, f.pos))
 	f.isMethod = true
@@ -96,6 +121,21 @@ func (f Function) IsMethod() bool {
 	return f.isMethod
 }
 
+//isLibrary returns whether the function was imported, the names of imported functions
+//are prefixed with the path of their library
+func (f *Function) isLibrary() bool {
+	return strings.Contains(f.ident.name, "$")
+}
+
+//methodType returns the type of a method without this, which overriding methods share
+func (f Function) methodType() types.WaccType {
+	params := make([]types.WaccType, len(f.params)-1)
+	for i, p := range f.params[:len(params)] {
+		params[i] = p.t
+	}
+	return types.NewFunction(f.retType, params)
+}
+
 func (f *Function) Check(ctx Context) {
 	var fCtx Context
 	if f.GetName() != "main" {
@@ -112,6 +152,7 @@ func (f *Function) Check(ctx Context) {
 		}
 	}
 
+	checkTypeArgs(f.retType, ctx, f.pos)
 	f.params.Check(fCtx)
 
 	//Check all statements with new context
@@ -184,6 +225,11 @@ func (param Param) GetType() types.WaccType {
 	return param.t
 }
 
+//GetPos returns the position of the parameter
+func (param Param) GetPos() errors.Position {
+	return param.pos
+}
+
 //String returns
 // <type> <ident>
 func (param Param) String() string {
@@ -195,7 +241,7 @@ func (param *Param) Check(ctx Context) {
 	param.table = ctx.table
 	name := param.ident.name
 
-	if param.t.Is(types.UserDefinedType) {
+	if checkTypeArgs(param.t, ctx, param.pos) && param.t.Is(types.UserDefinedType) {
 		paramUT := param.t.(types.UserType)
 		_, err := LookupUserType(paramUT, *ctx.table)
 
@@ -0,0 +1,172 @@
+package ast
+
+import (
+	"fmt"
+	"wacc_32/errors"
+	"wacc_32/symboltable"
+	"wacc_32/types"
+)
+
+//Signature is a method an interface lists, without a body
+type Signature struct {
+	ast
+	retType    types.WaccType
+	ident      *Ident
+	params     ParamList
+	pos        errors.Position
+	methodName string //The name the method was declared with, before its interface is added
+}
+
+//NewSignature creates the method ident of an interface
+func NewSignature(retType types.WaccType, ident *Ident, params ParamList, pos errors.Position) *Signature {
+	return &Signature{
+		retType: retType,
+		ident:   ident,
+		params:  params,
+		pos:     pos,
+	}
+}
+
+//MakeMethod makes the signature a method of the interface ut, called on a this of ut
+func (sig *Signature) MakeMethod(ut types.UserType) {
+	sig.methodName = sig.ident.name
+	sig.ident = NewIdent(sig.ident.name+"_"+ut.GetName(), sig.pos)
+	sig.params = append(sig.params, NewParam(ut, NewIdent("this", sig.pos), sig.pos))
+}
+
+//GetName returns the name the method was declared with
+func (sig Signature) GetName() string {
+	return sig.methodName[1:]
+}
+
+//GetPos returns the position of the signature
+func (sig Signature) GetPos() errors.Position {
+	return sig.pos
+}
+
+//String returns
+// <return_type> <name>(<params>)
+func (sig Signature) String() string {
+	return fmt.Sprintf("%s %s(%s)", sig.retType, sig.GetName(), sig.params[:len(sig.params)-1].String())
+}
+
+//EvalType returns the type of the method with this, like the functions implementing it
+func (sig Signature) EvalType() types.WaccType {
+	params := make([]types.WaccType, len(sig.params))
+	for i, p := range sig.params {
+		params[i] = p.t
+	}
+	return types.NewFunction(sig.retType, params)
+}
+
+//methodType returns the type of the method without this, which implementations share
+func (sig Signature) methodType() types.WaccType {
+	params := make([]types.WaccType, len(sig.params)-1)
+	for i, p := range sig.params[:len(params)] {
+		params[i] = p.t
+	}
+	return types.NewFunction(sig.retType, params)
+}
+
+//NewInterface creates the interface ident listing the methods signatures
+func NewInterface(ident *Ident, signatures []*Signature) *UserType {
+	return &UserType{
+		ident:       ident,
+		IsInterface: true,
+		signatures:  signatures,
+	}
+}
+
+//SetInterfaces makes the class implement the interfaces called names
+func (ut *UserType) SetInterfaces(names []*Ident) {
+	ut.implements = names
+}
+
+//GetSignatures returns the methods an interface lists
+func (ut UserType) GetSignatures() []*Signature {
+	return ut.signatures
+}
+
+//GetInterfaces returns the declarations of the interfaces a class implements, those of
+//the classes it extends first
+func (ut UserType) GetInterfaces() []*UserType {
+	if ut.base == nil {
+		return ut.interfaces
+	}
+	return append(ut.base.GetInterfaces(), ut.interfaces...)
+}
+
+//interfaceNames returns the names of the interfaces a class implements
+func (ut UserType) interfaceNames() []string {
+	interfaces := ut.GetInterfaces()
+	names := make([]string, len(interfaces))
+	for i, iface := range interfaces {
+		names[i] = iface.GetName()
+	}
+	return names
+}
+
+//methodNames returns the names the methods of an interface were declared with
+func (ut UserType) methodNames() []string {
+	names := make([]string, len(ut.signatures))
+	for i, sig := range ut.signatures {
+		names[i] = sig.methodName
+	}
+	return names
+}
+
+//declareSignatures adds the methods of an interface to table, so calls to them are
+//checked like calls to the methods of a class
+func (ut UserType) declareSignatures(table *symboltable.SymbolTable, errChan chan<- error) {
+	for _, sig := range ut.signatures {
+		if err := table.AddDefinition(sig.ident.name, sig.EvalType(), sig.pos); err != nil {
+			errChan <- err
+		}
+	}
+}
+
+//checkSignatures checks the types the methods of an interface take and return
+func (ut UserType) checkSignatures(ctx Context) {
+	for _, sig := range ut.signatures {
+		checkTypeArgs(sig.retType, ctx, sig.pos)
+		for _, param := range sig.params {
+			checkTypeArgs(param.t, ctx, param.pos)
+		}
+	}
+}
+
+//checkImplements reports the methods of the interfaces a class implements which it has
+//no method for, or has one of a different type. Methods it inherits count
+func (ut UserType) checkImplements(ctx Context) {
+	for i, iface := range ut.interfaces {
+		for _, sig := range iface.signatures {
+			fn := ut.method(sig.methodName)
+			if fn == nil {
+				ctx.SemanticErrChan <- errors.NewMissingMethodError(ut.implements[i].pos, ut.GetName(), iface.GetName(), sig.GetName())
+				continue
+			}
+			expected, actual := sig.methodType(), fn.methodType()
+			if len(fn.typeParams) > 0 || !expected.Is(actual) {
+				ctx.SemanticErrChan <- errors.NewImplementTypeError(fn.pos, sig.GetName(), ut.GetName(), iface.GetName(), expected, actual)
+			}
+		}
+	}
+}
+
+//linkInterfaces connects each class to the interfaces it implements, reporting those
+//which aren't interfaces
+func linkInterfaces(ut *UserType, byName map[string]*UserType, errChan chan<- error) {
+	for _, name := range ut.implements {
+		iface, ok := byName[name.name]
+		switch {
+		case !ok:
+			errChan <- errors.NewUndefinedIdentifierError(name.pos, fmt.Errorf("interface %s is not defined", name.name))
+		case iface.IsClass:
+			errChan <- errors.NewImplementError(name.pos, ut.GetName(), iface.GetName(), "it is a class")
+		case !iface.IsInterface:
+			errChan <- errors.NewImplementError(name.pos, ut.GetName(), iface.GetName(), "it is a struct")
+		default:
+			ut.interfaces = append(ut.interfaces, iface)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package ast
+
+import (
+	"fmt"
+	"wacc_32/errors"
+	"wacc_32/symboltable"
+	"wacc_32/types"
+)
+
+var _ Expression = &Lambda{}
+
+//Lambda is an anonymous function, which captures the local variables it uses from the
+//scopes around it
+type Lambda struct {
+	ast
+	retType types.WaccType
+	params  ParamList
+	stats   StatMultiple
+	scope   *symboltable.SymbolTable
+	pos     errors.Position
+}
+
+//NewLambda creates an anonymous function
+func NewLambda(retType types.WaccType, params ParamList, stats Statement, pos errors.Position) *Lambda {
+	l := &Lambda{
+		retType: retType,
+		params:  params,
+		pos:     pos,
+	}
+	switch st := stats.(type) {
+	case StatMultiple:
+		l.stats = st
+	default:
+		l.stats = StatMultiple{st}
+	}
+	return l
+}
+
+//GetParams returns the parameters the lambda takes
+func (l Lambda) GetParams() ParamList {
+	return l.params
+}
+
+//GetStats returns the lambda's statements
+func (l Lambda) GetStats() []Statement {
+	return l.stats
+}
+
+//GetReturnType returns the type the lambda returns
+func (l Lambda) GetReturnType() types.WaccType {
+	return l.retType
+}
+
+//GetPos returns the position of the lambda
+func (l Lambda) GetPos() errors.Position {
+	return l.pos
+}
+
+//GetScope returns the scope of the lambda's parameters
+func (l Lambda) GetScope() *symboltable.SymbolTable {
+	return l.scope
+}
+
+//String returns
+// fn(<params>) -> <return_type>
+//   - stat0
+//   ...
+//   - statn
+func (l Lambda) String() string {
+	s := make([]string, len(l.stats))
+	for i, st := range l.stats {
+		s[i] = st.String()
+	}
+	return format(fmt.Sprintf("fn(%s) -> %s", l.params.String(), l.retType), s...)
+}
+
+//Check checks the body of the lambda in a new scope, its returns return from the lambda
+func (l *Lambda) Check(ctx Context) bool {
+	l.table = ctx.table
+	lCtx := Context{
+		SemanticErrChan: ctx.SemanticErrChan,
+		Analysis:        ctx.Analysis,
+		functionName:    "lambda",
+		returnType:      l.retType,
+		table:           symboltable.NewSymbolTable(ctx.table),
+	}
+	l.scope = lCtx.table
+	l.params.Check(lCtx)
+	for _, stat := range l.stats {
+		stat.Check(lCtx)
+	}
+	return true
+}
+
+//EvalType returns the function type of the lambda
+func (l Lambda) EvalType(s symboltable.SymbolTable) types.WaccType {
+	params := make([]types.WaccType, len(l.params))
+	for i, p := range l.params {
+		params[i] = p.t
+	}
+	return types.NewFunction(l.retType, params)
+}
+
+//Capture is a local variable of an enclosing scope which a lambda uses
+type Capture struct {
+	Scope *symboltable.SymbolTable
+	Name  string
+	Type  types.WaccType
+}
+
+//GetCaptures returns the variables the lambda uses from the scopes around it, in the
+//order they are first used. Functions and user types aren't captured
+func (l Lambda) GetCaptures() []Capture {
+	var captures []Capture
+	seen := make(map[Capture]bool)
+	walk(l.stats, func(node interface{}) bool {
+		i, ok := node.(*Ident)
+		if !ok || i == nil || i.table == nil || i.IsFunction() {
+			return true
+		}
+		name, scope := i.name, i.table
+		if i.namespaced {
+			name = i.GetNameComponents()[0]
+			if scope, ok = findScope(i.table, name); !ok {
+				return true
+			}
+		}
+		if scope.GetParentScope() == nil || l.declares(scope) {
+			return true
+		}
+		c := Capture{Scope: scope, Name: name}
+		if !seen[c] {
+			seen[c] = true
+			c.Type, _ = scope.GetType(name)
+			captures = append(captures, c)
+		}
+		return true
+	})
+	return captures
+}
+
+//declares checks whether scope is the lambda's scope or nested in it
+func (l Lambda) declares(scope *symboltable.SymbolTable) bool {
+	for ; scope != nil; scope = scope.GetParentScope() {
+		if scope == l.scope {
+			return true
+		}
+	}
+	return false
+}
+
+//findScope returns the scope name is declared in, looking outwards from table
+func findScope(table *symboltable.SymbolTable, name string) (*symboltable.SymbolTable, bool) {
+	scope, err := table.Find(name)
+	return scope, err == nil
+}
@@ -0,0 +1,283 @@
+package ast
+
+import (
+	"strings"
+	"wacc_32/errors"
+	"wacc_32/types"
+)
+
+//declaration is a local variable or parameter which should be read at least once
+type declaration struct {
+	key   variable
+	pos   errors.Position
+	param bool
+}
+
+//linter looks for suspicious but valid code in a checked function
+type linter struct {
+	errChan       chan<- error
+	fn            *Function
+	decls         []declaration
+	read          map[variable]bool
+	uninitialised map[variable]bool
+	unreleased    map[errors.Position]bool
+	flow          *flowWalker
+}
+
+//lintFacts is what the linter knows about one point of a function
+type lintFacts struct {
+	unset map[variable]bool     //Variables declared without a value and not assigned since
+	held  map[variable]heldLock //Locks acquired and not released since
+}
+
+//heldLock is a lock acquired at pos
+type heldLock struct {
+	lock *Ident
+	pos  errors.Position
+}
+
+//lint reports unused locals and parameters, locals which may be read before they
+//are assigned and locks which may still be held when a function returns.
+//It runs before folding as constant propagation removes reads
+func (prog *Program) lint(errChan chan<- error) {
+	for _, fn := range prog.funcs {
+		//Warnings in libraries aren't actionable
+		if fn.table == nil || fn.isLibrary() {
+			continue
+		}
+		l := &linter{
+			errChan:       errChan,
+			fn:            fn,
+			read:          make(map[variable]bool),
+			uninitialised: make(map[variable]bool),
+			unreleased:    make(map[errors.Position]bool),
+		}
+		l.flow = &flowWalker{pass: l, loops: 1}
+		l.lintFunction()
+	}
+}
+
+func (l *linter) lintFunction() {
+	for _, param := range l.fn.params {
+		if param.ident.name != "this" {
+			l.decls = append(l.decls, declaration{variable{l.fn.table, param.ident.name}, param.pos, true})
+		}
+	}
+	st := newLintState()
+	l.flow.stat(l.fn.stats, &st)
+	//Only main can fall off the end of its body
+	if !st.done {
+		l.returns(st)
+	}
+	for _, decl := range l.decls {
+		//Names starting with _ are deliberately unused
+		if l.read[decl.key] || strings.HasPrefix(decl.key.name, "_") {
+			continue
+		}
+		if decl.param {
+			l.errChan <- errors.NewUnusedParameterWarning(decl.pos, decl.key.name, l.fn.GetName())
+		} else {
+			l.errChan <- errors.NewUnusedVariableWarning(decl.pos, decl.key.name)
+		}
+	}
+}
+
+//identVariable returns the local an identifier reads, the object for field accesses
+func identVariable(i *Ident) (variable, bool) {
+	if i.table == nil {
+		return variable{}, false
+	}
+	if !i.namespaced {
+		return variable{i.table, i.name}, true
+	}
+	name := i.GetNameComponents()[0]
+	scope, err := i.table.Find(name)
+	if err != nil {
+		return variable{}, false
+	}
+	return variable{scope, name}, true
+}
+
+func newLintState() flowState {
+	return flowState{facts: &lintFacts{
+		unset: make(map[variable]bool),
+		held:  make(map[variable]heldLock),
+	}}
+}
+
+func (f *lintFacts) copy() flowFacts {
+	cp := &lintFacts{
+		unset: make(map[variable]bool, len(f.unset)),
+		held:  make(map[variable]heldLock, len(f.held)),
+	}
+	for key := range f.unset {
+		cp.unset[key] = true
+	}
+	for key, lock := range f.held {
+		cp.held[key] = lock
+	}
+	return cp
+}
+
+//merge adds what is true on another path, anything true on either path may be true
+func (f *lintFacts) merge(other flowFacts) {
+	b := other.(*lintFacts)
+	for key := range b.unset {
+		f.unset[key] = true
+	}
+	for key, lock := range b.held {
+		if _, ok := f.held[key]; !ok {
+			f.held[key] = lock
+		}
+	}
+}
+
+//returns reports the locks held when the function returns
+func (l *linter) returns(st flowState) {
+	for _, lock := range st.facts.(*lintFacts).held {
+		if !l.unreleased[lock.pos] {
+			l.unreleased[lock.pos] = true
+			l.errChan <- errors.NewUnreleasedLockWarning(lock.pos, lock.lock.String(), l.fn.GetName())
+		}
+	}
+}
+
+func (l *linter) stat(stat Statement, st *flowState) bool {
+	switch s := stat.(type) {
+	case *StatNewassign:
+		l.newassign(s, st)
+	case *StatRead:
+		l.assign(s.toRead, st)
+	case *StatReturn:
+		l.expr(s.retValue, st)
+		if !st.done {
+			l.returns(*st)
+		}
+		st.done = true
+	case *StatAssign:
+		l.expr(s.rhs, st)
+		l.assign(s.lhs, st)
+	//Waiting holds the lock again before going on, so it is held from where it was acquired
+	case *StatCond:
+		l.expr(s.cond, st)
+		if s.lock != nil {
+			l.expr(s.lock, st)
+		}
+	//The lock of a with block is released however the block is left
+	case *StatWith:
+		l.expr(s.lock, st)
+		l.flow.stat(s.stat, st)
+	default:
+		return false
+	}
+	return true
+}
+
+func (l *linter) acquire(lock *Ident, pos errors.Position, st *flowState) bool {
+	key, ok := identVariable(lock)
+	if !ok {
+		return false
+	}
+	held := st.facts.(*lintFacts).held
+	if _, ok := held[key]; ok {
+		return false
+	}
+	held[key] = heldLock{lock, pos}
+	return true
+}
+
+func (l *linter) release(lock *Ident, st *flowState) {
+	if key, ok := identVariable(lock); ok {
+		delete(st.facts.(*lintFacts).held, key)
+	}
+}
+
+func (l *linter) newassign(s *StatNewassign, st *flowState) {
+	l.expr(s.rhs, st)
+	if s.ident.table == nil {
+		return
+	}
+	key := variable{s.ident.table, s.ident.name}
+	l.decls = append(l.decls, declaration{key, s.pos, false})
+	//Locks, semaphores, condition variables and objects are usable as soon as they are declared
+	if s.uninitialised && !s.t.Is(types.Lock) && !s.t.Is(types.Sema) && !s.t.Is(types.Cond) && !s.t.Is(types.UserDefinedType) {
+		st.facts.(*lintFacts).unset[key] = true
+	}
+}
+
+//assign writes to lhs, only assigning a whole variable doesn't read it
+func (l *linter) assign(lhs Expression, st *flowState) {
+	if ident, ok := lhs.(*Ident); ok && !ident.namespaced {
+		if key, ok := identVariable(ident); ok {
+			delete(st.facts.(*lintFacts).unset, key)
+		}
+		return
+	}
+	l.expr(lhs, st)
+}
+
+func (l *linter) use(i *Ident, st *flowState) {
+	key, ok := identVariable(i)
+	if !ok {
+		return
+	}
+	l.read[key] = true
+	if st.facts.(*lintFacts).unset[key] && !st.done && !l.uninitialised[key] {
+		l.uninitialised[key] = true
+		l.errChan <- errors.NewUninitialisedWarning(i.pos, key.name)
+	}
+}
+
+func (l *linter) exprs(exprs []Expression, st *flowState) {
+	for _, expr := range exprs {
+		l.expr(expr, st)
+	}
+}
+
+func (l *linter) expr(expr Expression, st *flowState) {
+	switch e := expr.(type) {
+	case *Ident:
+		l.use(e, st)
+	case *ArrayElem:
+		l.use(e.ident, st)
+		l.exprs(e.indices, st)
+	case *Literal:
+		if exprs, ok := e.value.([]Expression); ok {
+			l.exprs(exprs, st)
+		}
+	case *RHSNewPair:
+		l.expr(e.fst, st)
+		l.expr(e.snd, st)
+	case *RHSFunctionCall:
+		if e.closure != nil {
+			l.use(e.closure, st)
+		}
+		l.exprs(e.args, st)
+	case *WaccFuture:
+		l.exprs(e.args, st)
+	case *Lambda:
+		//The body runs later but captures the values of variables as they are now
+		body := flowState{facts: &lintFacts{
+			unset: st.facts.copy().(*lintFacts).unset,
+			held:  make(map[variable]heldLock),
+		}}
+		l.flow.stat(e.stats, &body)
+	case *PairElem:
+		l.expr(e.value, st)
+	case *Make:
+		l.expr(e.length, st)
+	case *MakeChan:
+		l.expr(e.capacity, st)
+	case *Recv:
+		l.expr(e.channel, st)
+	case *UnOp:
+		l.expr(e.expr, st)
+	case *BinOp:
+		l.expr(e.left, st)
+		l.expr(e.right, st)
+	case *TernaryOp:
+		l.expr(e.cond, st)
+		l.expr(e.ifExpr, st)
+		l.expr(e.elseExpr, st)
+	}
+}
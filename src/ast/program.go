@@ -1,6 +1,7 @@
 package ast
 
 import (
+	"fmt"
 	"strings"
 	"sync"
 	"wacc_32/errors"
@@ -14,6 +15,7 @@ type Program struct {
 	userTypes []*UserType
 	funcs     []*Function
 	pos       errors.Position
+	warnings  []error
 }
 
 const (
@@ -50,6 +52,11 @@ func (prog Program) GetFuncs() []*Function {
 	return prog.funcs
 }
 
+//AddWarning adds a warning found while building the AST, it is reported during the semantic check
+func (prog *Program) AddWarning(warning error) {
+	prog.warnings = append(prog.warnings, warning)
+}
+
 //GetStructs returns the program's structures
 func (prog Program) GetStructs() []*UserType {
 	return prog.userTypes
@@ -84,6 +91,7 @@ func (prog *Program) Check(ctx Context) {
 		SemanticErrChan: ctx.SemanticErrChan,
 	}
 
+	link(prog.userTypes, ctx.SemanticErrChan)
 	for _, ut := range prog.userTypes {
 		//Declare Class/Struct
 		utName := ut.GetName()
@@ -91,6 +99,7 @@ func (prog *Program) Check(ctx Context) {
 		if err != nil {
 			ctx.SemanticErrChan <- err
 		}
+		ut.declareSignatures(utCtx.table, ctx.SemanticErrChan)
 	}
 
 	fCtx := Context{
@@ -134,17 +143,71 @@ func (prog *Program) Check(ctx Context) {
 		}(fn)
 	}
 	wg.Wait()
+	prog.growingRecursion(ctx.SemanticErrChan)
 
+	for _, warning := range prog.warnings {
+		ctx.SemanticErrChan <- warning
+	}
+	prog.lint(ctx.SemanticErrChan)
+	prog.races(ctx.SemanticErrChan)
+	prog.lockCycles(ctx.SemanticErrChan)
+	var edits *journal
+	if ctx.Analysis {
+		edits = &journal{}
+	}
+	prog.fold(ctx.SemanticErrChan, edits)
+	prog.prune(ctx.SemanticErrChan, edits)
+	edits.rollback()
 	close(ctx.SemanticErrChan)
 }
 
+//LookupUserType returns the declaration of a struct or class, with the type arguments of
+//uType in place of its type parameters
 func LookupUserType(uType types.UserType, table symboltable.SymbolTable) (types.UserType, error) {
 	if uType.GetFieldTypes() != nil {
 		return uType, nil
 	}
-	wt, err := table.GetType("2" + uType.GetName())
+	declared, err := lookupDeclaration(uType.GetName(), table)
+	if err != nil {
+		return types.UserType{}, err
+	}
+	params, args := declared.GetTypeParams(), uType.GetTypeArgs()
+	if len(params) == 0 && len(args) == 0 {
+		return declared, nil
+	}
+	if len(params) != len(args) {
+		return types.UserType{}, fmt.Errorf("%s expected %d type arguments not %d", uType.GetName(), len(params), len(args))
+	}
+	return declared.Instantiate(args), nil
+}
+
+//lookupDeclaration returns the struct or class called name as it was declared
+func lookupDeclaration(name string, table symboltable.SymbolTable) (types.UserType, error) {
+	wt, err := table.GetType("2" + name)
 	if err != nil {
 		return types.UserType{}, err
 	}
 	return wt.(types.UserType), nil
 }
+
+//checkTypeArgs reports the references to generic structs and classes in t which have
+//the wrong number of type arguments
+func checkTypeArgs(t types.WaccType, ctx Context, pos errors.Position) bool {
+	ok := true
+	if ut, isUserType := t.(types.UserType); isUserType {
+		declared, err := lookupDeclaration(ut.GetName(), *ctx.table)
+		params, args := declared.GetTypeParams(), ut.GetTypeArgs()
+		if err == nil && len(params) != len(args) {
+			ctx.SemanticErrChan <- errors.NewTypeArgCountError(pos, ut.GetName(), len(params), len(args))
+			ok = false
+		}
+		for _, arg := range args {
+			ok = checkTypeArgs(arg, ctx, pos) && ok
+		}
+		return ok
+	}
+	for _, child := range t.GetChildren() {
+		ok = checkTypeArgs(child, ctx, pos) && ok
+	}
+	return ok
+}
@@ -0,0 +1,206 @@
+package ast
+
+import (
+	"wacc_32/errors"
+)
+
+//pruner removes code which can never run once constants have been folded
+type pruner struct {
+	errChan chan<- error
+	edits   *journal
+}
+
+//prune removes statements after a return or exit, branches and loops whose
+//condition is constant and functions which can't be reached from main.
+//Everything removed is reported as a warning
+func (prog *Program) prune(errChan chan<- error, edits *journal) {
+	p := pruner{errChan, edits}
+	for _, fn := range prog.funcs {
+		p.edits.set(&fn.stats, p.pruneStats(fn.stats))
+		walk(fn.stats, func(node interface{}) bool {
+			if l, ok := node.(*Lambda); ok {
+				p.edits.set(&l.stats, p.pruneStats(l.stats))
+			}
+			return true
+		})
+	}
+
+	reachable := prog.reachable()
+	funcs := make([]*Function, 0, len(prog.funcs))
+	for _, fn := range prog.funcs {
+		if reachable[fn.ident.name] {
+			funcs = append(funcs, fn)
+		} else if !fn.isMethod && !fn.isLibrary() {
+			//Unused library functions are expected
+			errChan <- errors.NewUnusedFunctionWarning(fn.pos, fn.GetName())
+		}
+	}
+	p.edits.set(&prog.funcs, funcs)
+	for _, ut := range prog.userTypes {
+		methods := make([]*Function, 0, len(ut.functions))
+		for _, fn := range ut.functions {
+			if reachable[fn.ident.name] {
+				methods = append(methods, fn)
+			}
+		}
+		p.edits.set(&ut.functions, methods)
+	}
+}
+
+//terminator returns the position and name of the statement which stops stat from
+//falling through to the next statement, false if it can fall through
+func terminator(stat Statement) (errors.Position, string, bool) {
+	switch s := stat.(type) {
+	case *StatReturn:
+		return s.pos, "return", true
+	case *StatExit:
+		return s.pos, "exit", true
+	case *StatIf:
+		_, _, thenOk := terminator(s.ifStat)
+		_, _, elseOk := terminator(s.elseStat)
+		return s.pos, "if statement", thenOk && elseOk
+	case *StatBegin:
+		return terminator(s.stat)
+	case *StatWith:
+		return terminator(s.stat)
+	case StatMultiple:
+		if len(s) > 0 {
+			return terminator(s[len(s)-1])
+		}
+	}
+	return errors.Position{}, "", false
+}
+
+//flatten splices nested statement lists into one, they don't have scopes of their own
+func flatten(stats StatMultiple, flat StatMultiple) StatMultiple {
+	for _, stat := range stats {
+		if multiple, ok := stat.(StatMultiple); ok {
+			flat = flatten(multiple, flat)
+		} else {
+			flat = append(flat, stat)
+		}
+	}
+	return flat
+}
+
+//pruneStats prunes each statement and drops those after one which never falls through
+func (p pruner) pruneStats(stats StatMultiple) StatMultiple {
+	stats = flatten(stats, make(StatMultiple, 0, len(stats)))
+	pruned := make(StatMultiple, 0, len(stats))
+	for i, stat := range stats {
+		stat = p.pruneStat(stat)
+		pruned = append(pruned, stat)
+		if pos, name, ok := terminator(stat); ok {
+			if i < len(stats)-1 {
+				p.errChan <- errors.NewUnreachableCodeWarning(pos, name)
+			}
+			break
+		}
+	}
+	return pruned
+}
+
+//pruneStat returns stat without its unreachable parts
+func (p pruner) pruneStat(stat Statement) Statement {
+	switch s := stat.(type) {
+	case *StatIf:
+		if cond, ok := boolValue(s.cond); ok {
+			branch, dead := s.ifStat, "else branch"
+			if !cond {
+				branch, dead = s.elseStat, "then branch"
+			}
+			p.errChan <- errors.NewDeadBranchWarning(s.pos, dead)
+			//The branch keeps its own scope
+			return &StatBegin{ast: s.ast, stat: p.pruneStat(branch)}
+		}
+		p.edits.set(&s.ifStat, p.pruneStat(s.ifStat))
+		p.edits.set(&s.elseStat, p.pruneStat(s.elseStat))
+	case *StatWhile:
+		if cond, ok := boolValue(s.cond); ok && !cond {
+			p.errChan <- errors.NewDeadBranchWarning(s.pos, "loop body")
+			return &StatSkip{}
+		}
+		p.edits.set(&s.bodyStat, p.pruneStat(s.bodyStat))
+	case *StatFor:
+		p.edits.set(&s.bodyStat, p.pruneStat(s.bodyStat))
+	case *StatDoWhile:
+		p.edits.set(&s.bodyStat, p.pruneStat(s.bodyStat))
+	case *StatBegin:
+		p.edits.set(&s.stat, p.pruneStat(s.stat))
+	case *StatWith:
+		p.edits.set(&s.stat, p.pruneStat(s.stat))
+	case StatMultiple:
+		return p.pruneStats(s)
+	}
+	return stat
+}
+
+//reachable returns the internal names of the functions main can call, directly or not
+func (prog *Program) reachable() map[string]bool {
+	calls := make(map[string][]string, len(prog.funcs))
+	for _, fn := range prog.funcs {
+		calls[fn.ident.name] = calledFunctions(fn.stats, nil)
+	}
+	//A call to a method can run any method overriding it
+	for _, ut := range prog.userTypes {
+		if ut.base == nil {
+			continue
+		}
+		for _, fn := range ut.functions {
+			if overridden := ut.base.Implementation(fn.methodName); overridden != "" {
+				calls[overridden] = append(calls[overridden], fn.ident.name)
+			}
+		}
+	}
+	//The method tables of a class for its interfaces hold the methods implementing them
+	for _, ut := range prog.userTypes {
+		for _, iface := range ut.GetInterfaces() {
+			for _, sig := range iface.signatures {
+				if implementation := ut.Implementation(sig.methodName); implementation != "" {
+					calls["0main"] = append(calls["0main"], implementation)
+				}
+			}
+		}
+	}
+	reachable := map[string]bool{"0main": true}
+	queue := []string{"0main"}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, callee := range calls[name] {
+			if !reachable[callee] {
+				reachable[callee] = true
+				queue = append(queue, callee)
+			}
+		}
+	}
+	return reachable
+}
+
+//calledFunctions appends the names of the functions called by stat, or used by it as
+//function values, to names
+func calledFunctions(stat Statement, names []string) []string {
+	walk(stat, func(node interface{}) bool {
+		var call *RHSFunctionCall
+		switch n := node.(type) {
+		case *RHSFunctionCall:
+			call = n
+		case *WaccRoutine:
+			call = n.RHSFunctionCall
+		case *WaccFuture:
+			call = n.RHSFunctionCall
+		case *Ident:
+			if n.IsFunction() {
+				names = append(names, n.name)
+			}
+		}
+		//Calls which failed semantic analysis have no symbol table to look their name up in
+		if call != nil && call.table != nil && call.closure == nil {
+			if name, err := call.FormatName(); err == nil {
+				names = append(names, name)
+			}
+		}
+		return true
+	})
+	return names
+}
@@ -0,0 +1,614 @@
+package ast
+
+import (
+	"sort"
+	"strings"
+	"wacc_32/errors"
+	"wacc_32/symboltable"
+	"wacc_32/types"
+)
+
+//SymbolKind is the kind of thing a Symbol is
+type SymbolKind int
+
+const (
+	FunctionSymbol SymbolKind = iota + 1
+	MethodSymbol
+	StructSymbol
+	ClassSymbol
+	FieldSymbol
+	VariableSymbol
+	InterfaceSymbol
+)
+
+//Symbol is something declared in a program which editors can list or complete
+type Symbol struct {
+	Name     string
+	Kind     SymbolKind
+	Type     string
+	Pos      errors.Position
+	Children []Symbol //Fields and methods of user types
+}
+
+//Reference is the code at a position in a program, with its type and where the
+//identifier it refers to was declared
+type Reference struct {
+	Name       string //Empty if the code isn't an identifier
+	Kind       SymbolKind
+	Type       string //The signature of functions
+	Pos        errors.Position
+	Definition errors.Position
+	Defined    bool //Whether Definition has been found
+}
+
+//The queries below are for editors, they are run on programs checked with
+//Context.Analysis so the tree still matches the source. Positions in the file
+//being compiled have an empty File(), lines count from 1 and columns from 0
+
+//Symbols returns the functions and user types declared in the file being compiled
+func (prog *Program) Symbols() []Symbol {
+	symbols := make([]Symbol, 0)
+	for _, ut := range prog.userTypes {
+		if ut.ident.pos.File() != "" {
+			continue
+		}
+		s := Symbol{Name: displayName(ut.ident.name), Kind: StructSymbol, Type: "struct", Pos: ut.ident.pos}
+		if ut.IsClass {
+			s.Kind, s.Type = ClassSymbol, "class"
+		} else if ut.IsInterface {
+			s.Kind, s.Type = InterfaceSymbol, "interface"
+		}
+		for _, sig := range ut.signatures {
+			s.Children = append(s.Children, Symbol{
+				Name: sig.GetName(),
+				Kind: MethodSymbol,
+				Type: sig.String(),
+				Pos:  sig.pos,
+			})
+		}
+		for _, field := range ut.fields {
+			s.Children = append(s.Children, Symbol{
+				Name: field.ident.name,
+				Kind: FieldSymbol,
+				Type: typeName(field.t),
+				Pos:  field.pos,
+			})
+		}
+		for _, fn := range ut.functions {
+			method := fn.symbol()
+			method.Name = strings.TrimSuffix(method.Name, "_"+ut.ident.name)
+			s.Children = append(s.Children, method)
+		}
+		symbols = append(symbols, s)
+	}
+	for _, fn := range prog.funcs {
+		if fn.pos.File() == "" && !fn.isMethod && fn.GetName() != "main" {
+			symbols = append(symbols, fn.symbol())
+		}
+	}
+	sort.SliceStable(symbols, func(i, j int) bool {
+		return symbols[i].Pos.StartsBefore(symbols[j].Pos.StartLine(), symbols[j].Pos.StartCol())
+	})
+	return symbols
+}
+
+func (f *Function) symbol() Symbol {
+	s := Symbol{Name: displayName(f.ident.name), Kind: FunctionSymbol, Type: f.signature(""), Pos: f.pos}
+	if f.isMethod {
+		s.Kind = MethodSymbol
+	}
+	return s
+}
+
+//signature returns the declaration of the function called name without its body or the
+//this parameter of methods
+func (f *Function) signature(name string) string {
+	params := f.params
+	if f.isMethod {
+		params = params[:len(params)-1]
+	}
+	strs := make([]string, len(params))
+	for i, param := range params {
+		strs[i] = typeName(param.t) + " " + param.ident.name
+	}
+	if len(f.typeParams) > 0 && !f.isMethod {
+		name += "<" + strings.Join(f.typeParams, ", ") + ">"
+	}
+	return typeName(f.retType) + " " + name + "(" + strings.Join(strs, ", ") + ")"
+}
+
+//Functions returns the functions of the library whose functions are prefixed with lib
+func (prog *Program) Functions(lib string) []Symbol {
+	symbols := make([]Symbol, 0)
+	for _, fn := range prog.funcs {
+		name := fn.ident.name[1:]
+		if strings.HasPrefix(name, lib) && !strings.Contains(name[len(lib):], "$") && !fn.isMethod {
+			symbols = append(symbols, fn.symbol())
+		}
+	}
+	return symbols
+}
+
+//Lookup returns the innermost expression at line:col
+func (prog *Program) Lookup(line, col int) (ref Reference, ok bool) {
+	//Code which failed its checks may not have a type
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	var found interface{}
+	for _, fn := range prog.funcs {
+		if fn.pos.File() != "" || !fn.pos.Contains(line, col) {
+			continue
+		}
+		for _, param := range fn.params {
+			if param.pos.Contains(line, col) {
+				found = param
+			}
+		}
+		walk(fn.stats, func(node interface{}) bool {
+			pos, hasPos := Pos(node)
+			if !hasPos {
+				return true
+			}
+			if !pos.Contains(line, col) {
+				return false
+			}
+			found = node
+			if l, ok := node.(*Lambda); ok {
+				for _, param := range l.params {
+					if param.pos.Contains(line, col) {
+						found = param
+					}
+				}
+			}
+			return true
+		})
+	}
+	for _, ut := range prog.userTypes {
+		for _, field := range ut.fields {
+			if field.pos.File() == "" && field.pos.Contains(line, col) {
+				return Reference{field.ident.name, FieldSymbol, typeName(field.t), field.pos, field.pos, true}, true
+			}
+		}
+	}
+	if found == nil {
+		return Reference{}, false
+	}
+	return prog.reference(found, line, col)
+}
+
+func (prog *Program) reference(node interface{}, line, col int) (Reference, bool) {
+	switch n := node.(type) {
+	case *Param:
+		return Reference{n.ident.name, VariableSymbol, typeName(n.t), n.pos, n.pos, true}, true
+	case *StatNewassign:
+		return Reference{n.ident.name, VariableSymbol, typeName(n.t), n.pos, n.pos, true}, true
+	case *Ident:
+		if n.table == nil {
+			return Reference{}, false
+		}
+		if n.namespaced {
+			return prog.fieldReference(n, col)
+		}
+		if n.IsFunction() {
+			return prog.functionReference(n.name, n.pos)
+		}
+		t, err := n.table.GetType(n.name)
+		if err != nil {
+			return Reference{}, false
+		}
+		def, _ := n.table.GetPosition(n.name)
+		return Reference{n.name, VariableSymbol, typeName(t), n.pos, def, true}, true
+	case *RHSFunctionCall:
+		return prog.callReference(n, line, col)
+	case *WaccRoutine:
+		return prog.callReference(n.RHSFunctionCall, line, col)
+	case *WaccFuture:
+		return prog.callReference(n.RHSFunctionCall, line, col)
+	case Expression:
+		if n.GetSymbolTable() == nil {
+			return Reference{}, false
+		}
+		pos, _ := Pos(n)
+		return Reference{Type: typeName(n.EvalType(*n.GetSymbolTable())), Pos: pos}, true
+	}
+	return Reference{}, false
+}
+
+//fieldReference returns the variable or field of a field access at col
+func (prog *Program) fieldReference(i *Ident, col int) (Reference, bool) {
+	components := i.GetNameComponents()
+	t, err := i.table.GetType(components[0])
+	if err != nil {
+		return Reference{}, false
+	}
+	def, _ := i.table.GetPosition(components[0])
+	ref := Reference{components[0], VariableSymbol, typeName(t), i.pos, def, true}
+	//Fields are separated by a single dot
+	offset := i.pos.StartCol() + len(components[0]) + 1
+	for _, name := range components[1:] {
+		if i.pos.StartLine() == i.pos.EndLine() && col < offset {
+			break
+		}
+		field, ok := prog.field(t, name)
+		if !ok {
+			return Reference{}, false
+		}
+		t = field.t
+		ref = Reference{name, FieldSymbol, typeName(t), i.pos, field.pos, true}
+		offset += len(name) + 1
+	}
+	return ref, true
+}
+
+//callReference returns the function called, or the value returned if col is after its name
+func (prog *Program) callReference(call *RHSFunctionCall, line, col int) (Reference, bool) {
+	if call.table == nil {
+		return Reference{}, false
+	}
+	name, err := call.FormatName()
+	if err != nil {
+		return Reference{}, false
+	}
+	if !call.fName.pos.Contains(line, col) {
+		return Reference{Type: typeName(call.EvalType(*call.table)), Pos: call.pos}, true
+	}
+	return prog.functionReference(name, call.fName.pos)
+}
+
+//functionReference returns the function called name, named at pos
+func (prog *Program) functionReference(name string, pos errors.Position) (Reference, bool) {
+	for _, fn := range prog.funcs {
+		if fn.ident.name == name {
+			ref := Reference{displayName(name), FunctionSymbol, "", pos, fn.pos, true}
+			if fn.isMethod {
+				ref.Kind = MethodSymbol
+				ref.Name = strings.TrimSuffix(ref.Name, "_"+fn.params[len(fn.params)-1].t.(types.UserType).GetName())
+			}
+			ref.Type = fn.signature(ref.Name)
+			return ref, true
+		}
+	}
+	return Reference{}, false
+}
+
+//field returns the declaration of a field of the user type t
+func (prog *Program) field(t types.WaccType, name string) (*StatNewassign, bool) {
+	ut := prog.userType(t)
+	if ut == nil {
+		return nil, false
+	}
+	for _, field := range ut.fields {
+		if field.ident.name == name {
+			return field, true
+		}
+	}
+	return nil, false
+}
+
+//userType returns the declaration of t if it is a user type
+func (prog *Program) userType(t types.WaccType) *UserType {
+	uType, ok := t.(types.UserType)
+	if !ok {
+		return nil
+	}
+	for _, ut := range prog.userTypes {
+		if ut.ident.name == uType.GetName() {
+			return ut
+		}
+	}
+	return nil
+}
+
+//Completions returns the variables, functions and user types which can be used at line:col
+func (prog *Program) Completions(line, col int) []Symbol {
+	symbols := make([]Symbol, 0)
+	seen := make(map[string]bool)
+	for table := prog.scopeAt(line, col); table != nil; table = table.GetParentScope() {
+		for _, name := range table.GetNames() {
+			pos, _ := table.GetPosition(name)
+			//Only locals declared before line:col are in scope
+			if seen[name] || strings.ContainsAny(name[:1], "012") ||
+				pos.File() == "" && pos.StartLine() > 0 && !pos.StartsBefore(line, col) {
+				continue
+			}
+			seen[name] = true
+			t, _ := table.GetType(name)
+			symbols = append(symbols, Symbol{Name: name, Kind: VariableSymbol, Type: typeName(t), Pos: pos})
+		}
+	}
+	for _, s := range prog.Symbols() {
+		s.Children = nil
+		symbols = append(symbols, s)
+	}
+	return symbols
+}
+
+//FieldCompletions returns the fields and methods of the variable accessed by path at
+//line:col, e.g. a.b for a.b.
+func (prog *Program) FieldCompletions(line, col int, path []string) (symbols []Symbol) {
+	defer func() {
+		if recover() != nil {
+			symbols = nil
+		}
+	}()
+	table := prog.scopeAt(line, col)
+	if table == nil || len(path) == 0 {
+		return nil
+	}
+	t, err := table.GetType(path[0])
+	if err != nil {
+		return nil
+	}
+	for _, name := range path[1:] {
+		field, ok := prog.field(t, name)
+		if !ok {
+			return nil
+		}
+		t = field.t
+	}
+	ut := prog.userType(t)
+	if ut == nil {
+		return nil
+	}
+	for _, field := range ut.fields {
+		symbols = append(symbols, Symbol{Name: field.ident.name, Kind: FieldSymbol, Type: typeName(field.t), Pos: field.pos})
+	}
+	for _, fn := range ut.functions {
+		method := fn.symbol()
+		method.Name = strings.TrimSuffix(method.Name, "_"+ut.ident.name)
+		symbols = append(symbols, method)
+	}
+	return symbols
+}
+
+//scopeAt returns the innermost scope at line:col, nil if it isn't in a function
+func (prog *Program) scopeAt(line, col int) *symboltable.SymbolTable {
+	for _, fn := range prog.funcs {
+		//Main is last so code typed after it, which may not have been parsed yet, is in it
+		inMain := fn.GetName() == "main" && fn.pos.StartsBefore(line, col)
+		if fn.pos.File() != "" || !fn.pos.Contains(line, col) && !inMain || fn.table == nil {
+			continue
+		}
+		scope := fn.table
+		//The last statement starting before line:col is in the innermost scope, as long
+		//as every statement enclosing it also encloses line:col
+		walk(fn.stats, func(node interface{}) bool {
+			pos, hasPos := Pos(node)
+			if !hasPos {
+				return true
+			}
+			if !pos.StartsBefore(line, col) {
+				return false
+			}
+			switch n := node.(type) {
+			case Statement:
+				if table := n.GetSymbolTable(); table != nil {
+					scope = table
+				}
+			case *Lambda:
+				//Expressions are only entered for the lambdas in them
+				if n.scope != nil && pos.Contains(line, col) {
+					scope = n.scope
+				}
+			}
+			return pos.Contains(line, col)
+		})
+		return scope
+	}
+	return nil
+}
+
+//Pos returns the position of a statement or expression, if it has one
+func Pos(node interface{}) (errors.Position, bool) {
+	switch n := node.(type) {
+	case *Ident:
+		return n.pos, true
+	case *ArrayElem:
+		return n.pos, true
+	case *Literal:
+		return n.pos, true
+	case *RHSFunctionCall:
+		return n.pos, true
+	case *WaccRoutine:
+		return n.pos, true
+	case *WaccFuture:
+		return n.pos, true
+	case *MakeChan:
+		return n.pos, true
+	case *Recv:
+		return n.pos, true
+	case *UnOp:
+		return n.pos, true
+	case *BinOp:
+		return n.pos, true
+	case *TernaryOp:
+		return n.pos, true
+	case *Lambda:
+		return n.pos, true
+	case *StatNewassign:
+		return n.pos, true
+	case *StatRead:
+		return n.pos, true
+	case *StatPrint:
+		return n.pos, true
+	case *StatPrintln:
+		return n.pos, true
+	case *StatFree:
+		return n.pos, true
+	case *StatExit:
+		return n.pos, true
+	case *StatReturn:
+		return n.pos, true
+	case *StatAssign:
+		return n.pos, true
+	case *StatEnhancedAssign:
+		return n.pos, true
+	case *StatLock:
+		return n.pos, true
+	case *StatSema:
+		return n.pos, true
+	case *StatSend:
+		return n.pos, true
+	case *StatClose:
+		return n.pos, true
+	case *StatCond:
+		return n.pos, true
+	case *StatWith:
+		return n.pos, true
+	case *StatBegin:
+		return n.pos, true
+	case *StatIf:
+		return n.pos, true
+	case *StatWhile:
+		return n.pos, true
+	case *StatDoWhile:
+		return n.pos, true
+	case *StatFor:
+		return n.pos, true
+	}
+	return errors.Position{}, false
+}
+
+//walk calls f on node and the statements and expressions in it, parents before
+//their children in the order they appear in the source.
+//The children of a node are skipped if f returns false
+func walk(node interface{}, f func(interface{}) bool) {
+	if node == nil || !f(node) {
+		return
+	}
+	switch n := node.(type) {
+	case StatMultiple:
+		for _, stat := range n {
+			walk(stat, f)
+		}
+	case []Expression:
+		for _, expr := range n {
+			walk(expr, f)
+		}
+	case *StatNewassign:
+		walk(n.ident, f)
+		walk(n.rhs, f)
+	case *StatRead:
+		walk(n.toRead, f)
+	case *StatFree:
+		walk(n.expr, f)
+	case *StatPrint:
+		walk(n.exprToPrint, f)
+	case *StatPrintln:
+		walk(n.exprToPrint, f)
+	case *StatExit:
+		walk(n.exitCode, f)
+	case *StatReturn:
+		walk(n.retValue, f)
+	case *StatAssign:
+		walk(n.lhs, f)
+		walk(n.rhs, f)
+	case *StatEnhancedAssign:
+		walk(n.lhs, f)
+		walk(n.rhs, f)
+	case *StatLock:
+		walk(n.lock, f)
+	case *StatSema:
+		walk(n.sema, f)
+	case *StatSend:
+		walk(n.channel, f)
+		walk(n.value, f)
+	case *StatClose:
+		walk(n.channel, f)
+	case *StatCond:
+		walk(n.cond, f)
+		if n.lock != nil {
+			walk(n.lock, f)
+		}
+	case *StatWith:
+		walk(n.lock, f)
+		walk(n.stat, f)
+	case *WaccRoutine:
+		walk(n.args, f)
+	case *WaccFuture:
+		walk(n.args, f)
+	case *StatBegin:
+		walk(n.stat, f)
+	case *StatIf:
+		walk(n.cond, f)
+		walk(n.ifStat, f)
+		walk(n.elseStat, f)
+	case *StatWhile:
+		walk(n.cond, f)
+		walk(n.bodyStat, f)
+	case *StatDoWhile:
+		walk(n.bodyStat, f)
+		walk(n.cond, f)
+	case *StatFor:
+		walk(&n.initial, f)
+		walk(n.cond, f)
+		walk(&n.change, f)
+		walk(n.bodyStat, f)
+	case *ArrayElem:
+		walk(n.ident, f)
+		walk(n.indices, f)
+	case *Literal:
+		if exprs, ok := n.value.([]Expression); ok {
+			walk(exprs, f)
+		}
+	case *RHSNewPair:
+		walk(n.fst, f)
+		walk(n.snd, f)
+	case *RHSFunctionCall:
+		if n.closure != nil {
+			walk(n.closure, f)
+		}
+		walk(n.args, f)
+	case *Lambda:
+		walk(n.stats, f)
+	case *PairElem:
+		walk(n.value, f)
+	case *Make:
+		walk(n.length, f)
+	case *MakeChan:
+		walk(n.capacity, f)
+	case *Recv:
+		walk(n.channel, f)
+	case *UnOp:
+		walk(n.expr, f)
+	case *BinOp:
+		walk(n.left, f)
+		walk(n.right, f)
+	case *TernaryOp:
+		walk(n.cond, f)
+		walk(n.ifExpr, f)
+		walk(n.elseExpr, f)
+	}
+}
+
+//displayName returns the name of a function or user type as it is written in the
+//source, without the prefixes added to tell them apart
+func displayName(name string) string {
+	name = strings.TrimPrefix(name, "0")
+	if i := strings.LastIndex(name, "$"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+//typeName returns the name of t as it is written in the source
+func typeName(t types.WaccType) string {
+	if t == nil {
+		return "?"
+	}
+	if ut, ok := t.(types.UserType); ok {
+		name := displayName(ut.GetName())
+		if args := ut.GetTypeArgs(); len(args) > 0 {
+			strs := make([]string, len(args))
+			for i, arg := range args {
+				strs[i] = typeName(arg)
+			}
+			name += "<" + strings.Join(strs, ", ") + ">"
+		}
+		return name
+	}
+	return t.String()
+}
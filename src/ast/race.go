@@ -0,0 +1,426 @@
+package ast
+
+import (
+	"wacc_32/errors"
+)
+
+//place is a variable, or a field of the object in a variable
+type place struct {
+	root variable
+	path string //The fields followed from root, each prefixed by a dot
+}
+
+//write is a write into the value of a variable, to an element, a pair element or a
+//field of it, with the locks held while it happens
+type write struct {
+	pos  errors.Position
+	held map[place]bool
+}
+
+//routine is a function started in a new thread which may still be running
+type routine struct {
+	fn     *Function
+	args   []*place  //What each parameter was passed, nil if it isn't a variable or a field
+	future *variable //The future the result is joined through, nil for a wacc statement
+}
+
+//raceFacts is what the race checker knows about one point of a function
+type raceFacts struct {
+	held     map[place]bool //Locks acquired on every path to this point and not released since
+	routines []*routine     //Routines started on some path to this point and not joined since
+}
+
+//raceChecker looks for heap values written both by a function and by a routine it
+//started, without a lock held by both
+type raceChecker struct {
+	errChan   chan<- error
+	funcs     map[string]*Function
+	summaries map[*Function]map[int][]write
+	reported  map[[2]errors.Position]bool
+}
+
+//raceWalker walks one function, recording the writes it makes into the values of its
+//parameters and, when reporting, checking its writes against the routines it started
+type raceWalker struct {
+	*raceChecker
+	fn     *Function
+	params map[variable]int
+	writes map[int][]write
+	report bool
+	flow   *flowWalker
+}
+
+//races reports heap values passed to wacc routines which are written by the routine
+//and by the function which started it while the routine may be running, unless both
+//writes hold a common lock. Values are followed through the arguments of calls but not
+//through copies into other variables, and the elements and fields of a value aren't
+//told apart
+func (prog *Program) races(errChan chan<- error) {
+	c := &raceChecker{
+		errChan:   errChan,
+		funcs:     make(map[string]*Function, len(prog.funcs)),
+		summaries: make(map[*Function]map[int][]write),
+		reported:  make(map[[2]errors.Position]bool),
+	}
+	for _, fn := range prog.funcs {
+		c.funcs[fn.ident.name] = fn
+	}
+	for _, fn := range prog.funcs {
+		//Warnings in libraries aren't actionable
+		if fn.table == nil || fn.isLibrary() {
+			continue
+		}
+		c.walker(fn, true).function()
+	}
+}
+
+func (c *raceChecker) walker(fn *Function, report bool) *raceWalker {
+	w := &raceWalker{
+		raceChecker: c,
+		fn:          fn,
+		params:      paramIndices(fn),
+		writes:      make(map[int][]write),
+		report:      report,
+	}
+	//Loop bodies are walked twice, so writes race with the routines started by the
+	//iteration before
+	w.flow = &flowWalker{pass: w, loops: 2}
+	return w
+}
+
+//summary returns the writes fn makes into the values of its parameters, by the index
+//of the parameter. A recursive call adds no writes
+func (c *raceChecker) summary(fn *Function) map[int][]write {
+	if writes, ok := c.summaries[fn]; ok {
+		return writes
+	}
+	c.summaries[fn] = nil
+	w := c.walker(fn, false)
+	w.function()
+	c.summaries[fn] = w.writes
+	return w.writes
+}
+
+//paramIndices returns the index of each parameter of fn
+func paramIndices(fn *Function) map[variable]int {
+	params := make(map[variable]int, len(fn.params))
+	for i, param := range fn.params {
+		params[variable{fn.table, param.ident.name}] = i
+	}
+	return params
+}
+
+func (w *raceWalker) function() {
+	st := flowState{facts: &raceFacts{held: make(map[place]bool)}}
+	w.flow.stat(w.fn.stats, &st)
+}
+
+func (f *raceFacts) copy() flowFacts {
+	return &raceFacts{
+		held:     copyPlaces(f.held),
+		routines: append([]*routine{}, f.routines...),
+	}
+}
+
+func copyPlaces(places map[place]bool) map[place]bool {
+	cp := make(map[place]bool, len(places))
+	for p := range places {
+		cp[p] = true
+	}
+	return cp
+}
+
+//merge adds what is true on another path, a lock is only held if it is held on both
+//paths but a routine may be running if it was started on either
+func (f *raceFacts) merge(other flowFacts) {
+	b := other.(*raceFacts)
+	for p := range f.held {
+		if !b.held[p] {
+			delete(f.held, p)
+		}
+	}
+	for _, r := range b.routines {
+		found := false
+		for _, other := range f.routines {
+			found = found || r == other
+		}
+		if !found {
+			f.routines = append(f.routines, r)
+		}
+	}
+}
+
+func (w *raceWalker) stat(stat Statement, st *flowState) bool {
+	switch s := stat.(type) {
+	case *StatNewassign:
+		w.assigned(s.rhs, s.ident, st)
+	case *StatAssign:
+		w.assigned(s.rhs, s.lhs, st)
+		w.assign(s.lhs, s.pos, st)
+	case *StatEnhancedAssign:
+		w.expr(s.rhs, st)
+		w.assign(s.lhs, s.pos, st)
+	case *StatRead:
+		w.assign(s.toRead, s.pos, st)
+	case *WaccRoutine:
+		w.flow.exprs(s.args, st)
+		w.start(s.RHSFunctionCall, nil, st)
+	default:
+		return false
+	}
+	return true
+}
+
+func (w *raceWalker) acquire(lock *Ident, pos errors.Position, st *flowState) bool {
+	p, ok := placeOf(lock)
+	held := st.facts.(*raceFacts).held
+	if !ok || held[p] {
+		return false
+	}
+	held[p] = true
+	return true
+}
+
+func (w *raceWalker) release(lock *Ident, st *flowState) {
+	if p, ok := placeOf(lock); ok {
+		delete(st.facts.(*raceFacts).held, p)
+	}
+}
+
+//assigned evaluates rhs, which is assigned to target. A routine started by rhs is
+//joined through target
+func (w *raceWalker) assigned(rhs Expression, target Expression, st *flowState) {
+	future, ok := rhs.(*WaccFuture)
+	if !ok {
+		w.expr(rhs, st)
+		return
+	}
+	w.flow.exprs(future.args, st)
+	var key *variable
+	if ident, ok := target.(*Ident); ok && !ident.namespaced {
+		if v, ok := identVariable(ident); ok {
+			key = &v
+		}
+	}
+	w.start(future.RHSFunctionCall, key, st)
+}
+
+//assign writes to lhs, assigning a whole variable doesn't write into its value
+func (w *raceWalker) assign(lhs Expression, pos errors.Position, st *flowState) {
+	w.expr(lhs, st)
+	if ident, ok := lhs.(*Ident); ok && !ident.namespaced {
+		return
+	}
+	if root, ok := rootVariable(lhs); ok {
+		w.written(root, pos, copyPlaces(st.facts.(*raceFacts).held), st)
+	}
+}
+
+//rootVariable returns the variable holding the value an element, pair element or field
+//is part of
+func rootVariable(expr Expression) (variable, bool) {
+	switch e := expr.(type) {
+	case *Ident:
+		return identVariable(e)
+	case *ArrayElem:
+		return identVariable(e.ident)
+	case *PairElem:
+		return rootVariable(e.value)
+	}
+	return variable{}, false
+}
+
+//placeOf returns the variable, or field of the object in a variable, an identifier names
+func placeOf(i *Ident) (place, bool) {
+	root, ok := identVariable(i)
+	if !ok {
+		return place{}, false
+	}
+	if !i.namespaced {
+		return place{root, ""}, true
+	}
+	return place{root, fieldPath(i.GetNameComponents()[1:])}, true
+}
+
+func fieldPath(fields []string) string {
+	var path string
+	for _, field := range fields {
+		path += "." + field
+	}
+	return path
+}
+
+//expr follows the calls, routines started and futures joined by expr, arguments first
+func (w *raceWalker) expr(expr Expression, st *flowState) {
+	walk(expr, func(node interface{}) bool {
+		switch n := node.(type) {
+		case *Lambda:
+			//The body runs when the closure is called
+			return false
+		case *RHSFunctionCall:
+			w.flow.exprs(n.args, st)
+			w.call(n, st)
+			return false
+		case *WaccFuture:
+			w.flow.exprs(n.args, st)
+			w.start(n.RHSFunctionCall, nil, st)
+			return false
+		case *UnOp:
+			if n.op == Join {
+				w.expr(n.expr, st)
+				w.join(n.expr, st)
+				return false
+			}
+		}
+		return true
+	})
+}
+
+//callee returns the function of funcs a call runs and what each of its parameters is
+//passed, the object a method is called on is passed as this. Calls through function
+//values have no known callee
+func callee(funcs map[string]*Function, call *RHSFunctionCall) (*Function, []*place) {
+	if call.closure != nil || call.table == nil {
+		return nil, nil
+	}
+	name, err := call.FormatName()
+	fn := funcs[name]
+	if err != nil || fn == nil {
+		return nil, nil
+	}
+	args := make([]*place, len(fn.params))
+	for i, arg := range call.args {
+		if ident, ok := arg.(*Ident); ok && i < len(args) {
+			if p, ok := placeOf(ident); ok {
+				args[i] = &p
+			}
+		}
+	}
+	if call.isMethod && len(args) > len(call.args) {
+		components := call.fName.GetNameComponents()
+		name := components[0][1:]
+		if scope, err := call.table.Find(name); err == nil {
+			args[len(args)-1] = &place{variable{scope, name}, fieldPath(components[1 : len(components)-1])}
+		}
+	}
+	return fn, args
+}
+
+//argPlace returns the place of a caller which is p, a place of the function it called
+//reached from a parameter, given the params of the function and what it passed them
+func argPlace(p place, params map[variable]int, args []*place) (place, bool) {
+	i, ok := params[p.root]
+	if !ok || args[i] == nil {
+		return place{}, false
+	}
+	return place{args[i].root, args[i].path + p.path}, true
+}
+
+//call records the writes a function makes into the values of its arguments as writes
+//at the call, holding the locks held by the caller and those passed to the function
+//which it acquires
+func (w *raceWalker) call(call *RHSFunctionCall, st *flowState) {
+	fn, args := callee(w.funcs, call)
+	if fn == nil {
+		return
+	}
+	params, writes := paramIndices(fn), w.summary(fn)
+	for i, arg := range args {
+		if arg == nil {
+			continue
+		}
+		for _, wr := range writes[i] {
+			held := translate(wr.held, params, args)
+			for p := range st.facts.(*raceFacts).held {
+				held[p] = true
+			}
+			w.written(arg.root, call.pos, held, st)
+		}
+	}
+}
+
+//start records a routine started by call, joined through future
+func (w *raceWalker) start(call *RHSFunctionCall, future *variable, st *flowState) {
+	if fn, args := callee(w.funcs, call); fn != nil {
+		facts := st.facts.(*raceFacts)
+		facts.routines = append(facts.routines, &routine{fn, args, future})
+	}
+}
+
+//join forgets the routines whose future is the variable expr, they have finished
+func (w *raceWalker) join(expr Expression, st *flowState) {
+	ident, ok := expr.(*Ident)
+	if !ok {
+		return
+	}
+	v, ok := identVariable(ident)
+	if !ok {
+		return
+	}
+	facts := st.facts.(*raceFacts)
+	running := make([]*routine, 0, len(facts.routines))
+	for _, r := range facts.routines {
+		if r.future == nil || *r.future != v {
+			running = append(running, r)
+		}
+	}
+	facts.routines = running
+}
+
+//translate returns the places of a caller which are the places held of the function
+//it called, given the params of the function and what it passed them
+func translate(held map[place]bool, params map[variable]int, args []*place) map[place]bool {
+	out := make(map[place]bool, len(held))
+	for p := range held {
+		if caller, ok := argPlace(p, params, args); ok {
+			out[caller] = true
+		}
+	}
+	return out
+}
+
+//written records a write at pos into the value of root, holding the locks held. A
+//routine which may be running and writes into the same value without a common lock
+//held is reported
+func (w *raceWalker) written(root variable, pos errors.Position, held map[place]bool, st *flowState) {
+	if st.done {
+		return
+	}
+	if i, ok := w.params[root]; ok {
+		w.writes[i] = append(w.writes[i], write{pos, held})
+	}
+	if !w.report {
+		return
+	}
+	for _, r := range st.facts.(*raceFacts).routines {
+		params := paramIndices(r.fn)
+		for i, arg := range r.args {
+			if arg == nil || arg.root != root {
+				continue
+			}
+			for _, other := range w.summary(r.fn)[i] {
+				if !shareLock(held, translate(other.held, params, r.args)) {
+					w.race(root.name, r.fn, pos, other.pos)
+				}
+			}
+		}
+	}
+}
+
+func shareLock(a, b map[place]bool) bool {
+	for p := range a {
+		if b[p] {
+			return true
+		}
+	}
+	return false
+}
+
+//race reports a write at pos racing with a write at other by the routine fn, once
+func (w *raceWalker) race(name string, fn *Function, pos, other errors.Position) {
+	key := [2]errors.Position{pos, other}
+	if !w.reported[key] {
+		w.reported[key] = true
+		w.errChan <- errors.NewRaceWarning(pos, name, fn.GetName(), other)
+	}
+}
@@ -0,0 +1,92 @@
+package ast
+
+import (
+	"wacc_32/errors"
+	"wacc_32/types"
+)
+
+//typeParam is a type parameter of a generic function
+type typeParam struct {
+	fn    string
+	param string
+}
+
+//typeArgFlow is a type parameter of a generic function passed into the type argument of
+//a function it calls
+type typeArgFlow struct {
+	to    typeParam
+	grows bool //The type argument is built from the type parameter, not the parameter itself
+	call  *RHSFunctionCall
+}
+
+//growingRecursion reports the calls through which a generic function calls itself with
+//a type argument built from its own type parameter, such as T[] for T. Generic functions
+//are generated once for each set of types they are called with, so these would need
+//instances for ever larger types
+func (prog Program) growingRecursion(errChan chan<- error) {
+	typeParams := make(map[string][]string)
+	for _, fn := range prog.funcs {
+		if len(fn.typeParams) > 0 {
+			typeParams[fn.ident.name] = fn.typeParams
+		}
+	}
+	var froms []typeParam
+	flows := make(map[typeParam][]typeArgFlow)
+	for _, fn := range prog.funcs {
+		if len(fn.typeParams) == 0 {
+			continue
+		}
+		walk(fn.stats, func(node interface{}) bool {
+			call, ok := node.(*RHSFunctionCall)
+			if !ok || call.closure != nil || len(call.typeArgs) == 0 {
+				return true
+			}
+			callee, err := call.FormatName()
+			if err != nil {
+				return true
+			}
+			for _, param := range typeParams[callee] {
+				arg := call.typeArgs[param]
+				for _, v := range types.TypeVars(arg) {
+					from := typeParam{fn.ident.name, v}
+					if flows[from] == nil {
+						froms = append(froms, from)
+					}
+					flows[from] = append(flows[from], typeArgFlow{typeParam{callee, param}, !types.NewTypeVar(v).Is(arg), call})
+				}
+			}
+			return true
+		})
+	}
+
+	//A type argument grows without bound when a flow which grows it is on a cycle
+	reported := make(map[*RHSFunctionCall]bool)
+	for _, from := range froms {
+		for _, flow := range flows[from] {
+			if flow.grows && !reported[flow.call] && reaches(flows, flow.to, from) {
+				reported[flow.call] = true
+				errChan <- errors.NewGrowingTypeArgError(flow.call.pos, displayName(from.fn), from.param)
+			}
+		}
+	}
+}
+
+//reaches checks whether the type parameter from is passed into to through calls
+func reaches(flows map[typeParam][]typeArgFlow, from, to typeParam) bool {
+	seen := map[typeParam]bool{from: true}
+	stack := []typeParam{from}
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if p == to {
+			return true
+		}
+		for _, flow := range flows[p] {
+			if !seen[flow.to] {
+				seen[flow.to] = true
+				stack = append(stack, flow.to)
+			}
+		}
+	}
+	return false
+}
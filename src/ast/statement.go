@@ -1,7 +1,6 @@
 package ast
 
 import (
-	"fmt"
 	"strings"
 	"sync"
 	"wacc_32/errors"
@@ -126,8 +125,8 @@ func (s *StatFree) Check(ctx Context) {
 	s.table = ctx.table
 	if s.expr.Check(ctx) {
 		freeType := s.expr.EvalType(*ctx.table)
-		if !freeType.Is(types.Pair) && !freeType.Is(types.Array) && !freeType.Is(types.Lock) {
-			ctx.SemanticErrChan <- errors.NewMultiTypeError(s.pos, "free", freeType, types.Pair, types.Array, types.Lock)
+		if !freeType.Is(types.Pair) && !freeType.Is(types.Array) && !freeType.Is(types.Lock) && !freeType.Is(types.Cond) {
+			ctx.SemanticErrChan <- errors.NewMultiTypeError(s.pos, "free", freeType, types.Pair, types.Array, types.Lock, types.Cond)
 		}
 	}
 }
@@ -135,10 +134,11 @@ func (s *StatFree) Check(ctx Context) {
 //StatNewassign represents a variable declaration
 type StatNewassign struct {
 	ast
-	t     types.WaccType
-	ident *Ident
-	rhs   Expression
-	pos   errors.Position
+	t             types.WaccType
+	ident         *Ident
+	rhs           Expression
+	pos           errors.Position
+	uninitialised bool
 }
 
 //NewStatNewassign creates a new StatNewassign
@@ -152,6 +152,14 @@ func NewStatNewassign(t types.WaccType, ident *Ident, rhs Expression,
 	}
 }
 
+//NewStatDeclaration creates a StatNewassign for a variable declared without a value
+//It holds the default value of its type
+func NewStatDeclaration(t types.WaccType, ident *Ident, pos errors.Position) *StatNewassign {
+	s := NewStatNewassign(t, ident, NewDefaultLiteral(t, pos), pos)
+	s.uninitialised = true
+	return s
+}
+
 //GetType returns the type of the variable being declared
 func (s StatNewassign) GetType() types.WaccType {
 	return s.t
@@ -187,8 +195,8 @@ func (s StatNewassign) String() string {
 func (s *StatNewassign) Check(ctx Context) {
 	s.table = ctx.table
 	s.ident.table = ctx.table
-
-	if s.rhs.Check(ctx) {
+	//A type with the wrong number of type arguments matches nothing
+	if s.rhs.Check(ctx) && checkTypeArgs(s.t, ctx, s.pos) {
 		rType := s.rhs.EvalType(*ctx.table)
 		if !s.t.Is(rType) {
 			ctx.SemanticErrChan <- errors.NewTypeError(s.pos, "variable "+s.ident.name, s.t, rType)
@@ -198,6 +206,12 @@ func (s *StatNewassign) Check(ctx Context) {
 	err := ctx.table.AddDeclaration(s.ident.name, s.t, s.pos)
 	if err != nil {
 		ctx.SemanticErrChan <- err
+		return
+	}
+	if parent := ctx.table.GetParentScope(); parent != nil {
+		if outer, err := parent.GetPosition(s.ident.name); err == nil {
+			ctx.SemanticErrChan <- errors.NewShadowWarning(s.pos, s.ident.name, outer)
+		}
 	}
 }
 
@@ -206,6 +220,7 @@ type StatPrint struct {
 	ast
 	exprToPrint Expression
 	newLine     bool
+	pos         errors.Position
 }
 
 //GetExprToPrint returns the expression that has to be printed
@@ -214,10 +229,11 @@ func (s StatPrint) GetExprToPrint() Expression {
 }
 
 //NewStatPrint creates a new print statement
-func NewStatPrint(exprToPrint Expression) *StatPrint {
+func NewStatPrint(exprToPrint Expression, pos errors.Position) *StatPrint {
 	return &StatPrint{
 		exprToPrint: exprToPrint,
 		newLine:     false,
+		pos:         pos,
 	}
 }
 
@@ -238,10 +254,11 @@ func (s *StatPrint) Check(ctx Context) {
 type StatPrintln StatPrint
 
 //NewStatPrintln creates a new println statement
-func NewStatPrintln(exprToPrint Expression) *StatPrintln {
+func NewStatPrintln(exprToPrint Expression, pos errors.Position) *StatPrintln {
 	return &StatPrintln{
 		exprToPrint: exprToPrint,
 		newLine:     true,
+		pos:         pos,
 	}
 }
 
@@ -308,6 +325,7 @@ type StatFor struct {
 	cond     Expression
 	change   StatAssign
 	bodyStat Statement
+	pos      errors.Position
 }
 
 //GetInitial returns the initial state of the iterator from the loop
@@ -331,12 +349,14 @@ func (s StatFor) GetBody() Statement {
 }
 
 //NewStatFor creates a new for statement
-func NewStatFor(initial StatNewassign, cond Expression, change StatAssign, bodyStat Statement) *StatFor {
+func NewStatFor(initial StatNewassign, cond Expression, change StatAssign, bodyStat Statement,
+	pos errors.Position) *StatFor {
 	return &StatFor{
 		initial:  initial,
 		cond:     cond,
 		change:   change,
 		bodyStat: bodyStat,
+		pos:      pos,
 	}
 }
 
@@ -369,8 +389,8 @@ func (s *StatFor) Check(ctx Context) {
 	//Check ensures that the condition a valid boolean expression
 	if s.cond.Check(ctx) {
 		boolean := types.Boolean
-		if s.cond.EvalType(*ctx.table) != boolean {
-			ctx.SemanticErrChan <- fmt.Errorf("Condition should be a boolean type")
+		if condType := s.cond.EvalType(*ctx.table); condType != boolean {
+			ctx.SemanticErrChan <- errors.NewTypeError(s.pos, "condition", boolean, condType)
 		}
 	}
 
@@ -455,6 +475,7 @@ type StatDoWhile struct {
 	ast
 	bodyStat Statement
 	cond     Expression
+	pos      errors.Position
 }
 
 //GetCond returns the condition expression of the do while loop
@@ -468,10 +489,11 @@ func (s StatDoWhile) GetBody() Statement {
 }
 
 //NewStatWhile creates a new while statement
-func NewStatDoWhile(bodyStat Statement, cond Expression) *StatDoWhile {
+func NewStatDoWhile(bodyStat Statement, cond Expression, pos errors.Position) *StatDoWhile {
 	return &StatDoWhile{
 		bodyStat: bodyStat,
 		cond:     cond,
+		pos:      pos,
 	}
 }
 
@@ -501,8 +523,8 @@ func (s *StatDoWhile) Check(ctx Context) {
 	s.bodyStat.Check(doWhileCtx)
 	if s.cond.Check(ctx) {
 		boolean := types.Boolean
-		if s.cond.EvalType(*ctx.table) != boolean {
-			ctx.SemanticErrChan <- fmt.Errorf("condition should be a boolean type")
+		if condType := s.cond.EvalType(*ctx.table); condType != boolean {
+			ctx.SemanticErrChan <- errors.NewTypeError(s.pos, "condition", boolean, condType)
 		}
 	}
 
@@ -513,6 +535,7 @@ func (s *StatDoWhile) Check(ctx Context) {
 type StatBegin struct {
 	ast
 	stat Statement
+	pos  errors.Position
 }
 
 //GetStat returns the statement in the local scope
@@ -521,9 +544,10 @@ func (s StatBegin) GetStat() Statement {
 }
 
 //NewStatBegin creates a new scope
-func NewStatBegin(stat Statement) *StatBegin {
+func NewStatBegin(stat Statement, pos errors.Position) *StatBegin {
 	return &StatBegin{
 		stat: stat,
+		pos:  pos,
 	}
 }
 
@@ -589,7 +613,7 @@ func (s StatAssign) String() string {
 //Check lhs and rhs compatibility
 func (s *StatAssign) Check(ctx Context) {
 	s.table = ctx.table
-	if s.lhs.Check(ctx) && s.rhs.Check(ctx) {
+	if checkAssignable(s.lhs, ctx) && s.rhs.Check(ctx) {
 		lType := s.lhs.EvalType(*ctx.table)
 		rType := s.rhs.EvalType(*ctx.table)
 		if !lType.Is(rType) {
@@ -598,11 +622,26 @@ func (s *StatAssign) Check(ctx Context) {
 	}
 }
 
+//checkAssignable checks the left hand side of an assignment, a function's name is a
+//value but not a variable
+func checkAssignable(lhs Expression, ctx Context) bool {
+	if !lhs.Check(ctx) {
+		return false
+	}
+	if ident, ok := lhs.(*Ident); ok && ident.IsFunction() {
+		_, err := ctx.table.Find(ident.String())
+		ctx.SemanticErrChan <- errors.NewUndefinedIdentifierError(ident.pos, err)
+		return false
+	}
+	return true
+}
+
 //StatReturn represents a return statement
 type StatReturn struct {
 	ast
 	caller   string
 	retValue Expression
+	retType  types.WaccType
 	pos      errors.Position
 }
 
@@ -623,6 +662,11 @@ func (s StatReturn) GetReturnExpr() Expression {
 	return s.retValue
 }
 
+//GetReturnType returns the type the function or lambda returned from returns
+func (s StatReturn) GetReturnType() types.WaccType {
+	return s.retType
+}
+
 //String returns
 // RETURN
 //   - retValue
@@ -635,6 +679,7 @@ func (s StatReturn) String() string {
 func (s *StatReturn) Check(ctx Context) {
 	s.table = ctx.table
 	s.caller = ctx.functionName
+	s.retType = ctx.returnType
 	if ctx.functionName == "" {
 		ctx.SemanticErrChan <- errors.NewReturnError(s.pos)
 		return
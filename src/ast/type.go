@@ -49,30 +49,54 @@ func (l Literal) GetType() types.WaccType {
 //Check returns nil as TypeNodes are always valid
 func (l *Literal) Check(ctx Context) bool {
 	if l.t == types.Array {
-		typeSet := make(map[types.WaccType]struct{})
+		var subType types.WaccType
 		for _, expr := range l.value.([]Expression) {
 			if !expr.Check(ctx) {
 				return false
 			}
-			typeSet[expr.EvalType(*ctx.table)] = struct{}{}
-			if len(typeSet) > 1 {
+			t := expr.EvalType(*ctx.table)
+			if subType == nil {
+				subType = t
+				continue
+			}
+			//Objects of different classes are stored as the nearest class they extend
+			var ok bool
+			if subType, ok = types.CommonSuper(subType, t); !ok {
 				ctx.SemanticErrChan <- errors.NewArrayTypeError(l.pos)
 				return false
 			}
 		}
 		//Get the subtype of the array
-		var subType types.WaccType = types.Integer
-		for k := range typeSet {
-			subType = k
-			break
+		if subType == nil {
+			subType = types.Integer
 		}
 		l.t = types.NewArray(subType, 1)
 	}
 	if l.t.Is(types.UserDefinedType) {
-		lt, err := LookupUserType(l.t.(types.UserType), *ctx.table)
+		ut := l.t.(types.UserType)
+		structName := ut.GetName()
+		lt, err := lookupDeclaration(structName, *ctx.table)
 		if err != nil {
 			ctx.SemanticErrChan <- errors.NewUndefinedIdentifierError(l.pos, err)
 		}
+		if lt.IsInterface() && l.value != 0 {
+			ctx.SemanticErrChan <- errors.NewInterfaceLiteralError(l.pos, structName)
+			return false
+		}
+		typeParams := lt.GetTypeParams()
+		//The type arguments of a generic constructor without any are inferred from its
+		//arguments, declarations report the wrong number of type arguments themselves
+		infer := l.value != 0 && len(ut.GetTypeArgs()) == 0 && len(typeParams) > 0
+		if err == nil && !infer {
+			if len(typeParams) != len(ut.GetTypeArgs()) {
+				if l.value != 0 {
+					ctx.SemanticErrChan <- errors.NewTypeArgCountError(l.pos, structName, len(typeParams), len(ut.GetTypeArgs()))
+				}
+				return false
+			}
+			lt, _ = LookupUserType(ut, *ctx.table)
+			l.t = lt
+		}
 		if l.value == 0 {
 			return true
 		}
@@ -84,25 +108,38 @@ func (l *Literal) Check(ctx Context) bool {
 
 		fieldTypes := lt.GetChildren()
 		fieldValues := l.value.([]Expression)
-		structName := l.t.(types.UserType).GetName()
 
 		if len(fieldValues) != len(fieldTypes) {
 			ctx.SemanticErrChan <- errors.NewArgCountError(l.pos, "constructor "+structName, len(fieldTypes), len(fieldValues))
 			return false
 		}
 
+		sub := types.Substitution{}
 		for i, expr := range fieldValues {
 			if !expr.Check(ctx) {
 				return false
 			}
-			// fmt.Println(expr.EvalType(*ctx.table))
-			if !expr.EvalType(*ctx.table).Is(fieldTypes[i]) {
+			argType := expr.EvalType(*ctx.table)
+			if infer && !types.Unify(fieldTypes[i], argType, sub) || !infer && !fieldTypes[i].Is(argType) {
 				nodeName := fmt.Sprintf("%s constructor argument number %d", structName, i+1)
-				ctx.SemanticErrChan <- errors.NewTypeError(l.pos, nodeName, fieldTypes[i], expr.EvalType(*ctx.table))
+				ctx.SemanticErrChan <- errors.NewTypeError(l.pos, nodeName, types.Substitute(fieldTypes[i], sub), argType)
 				return false
 			}
 		}
 
+		if infer {
+			typeArgs := make([]types.WaccType, len(typeParams))
+			for i, param := range typeParams {
+				arg, ok := sub[param]
+				if !ok {
+					ctx.SemanticErrChan <- errors.NewInferenceError(l.pos, structName, param)
+					return false
+				}
+				typeArgs[i] = arg
+			}
+			l.t = lt.Instantiate(typeArgs)
+		}
+
 	}
 
 	return true
@@ -17,11 +17,12 @@ const (
 	Chr
 	Neg
 	TryLock
+	Join
 )
 
 var _ Expression = &UnOp{}
 
-var unopStrings = []string{"!", "len", "ord", "chr", "-", "try_lock"}
+var unopStrings = []string{"!", "len", "ord", "chr", "-", "try_lock", "join"}
 
 //UnOp represents unary operators
 type UnOp struct {
@@ -51,7 +52,7 @@ func NewUnOp(op UnopType, expr Expression, pos errors.Position) *UnOp {
 }
 
 //String returns
-// (! | len | ord | chr | - | try_lock)
+// (! | len | ord | chr | - | try_lock | join)
 func (op UnopType) String() string {
 	return unopStrings[int(op)-1]
 }
@@ -60,9 +61,12 @@ func (op UnopType) String() string {
 // a bool for try_lock
 // a boolean for !
 // a character for Chr
+// the result of the routine for join
 // an integer otherwise
 func (u *UnOp) EvalType(s symboltable.SymbolTable) types.WaccType {
 	switch u.op {
+	case Join:
+		return u.expr.EvalType(s).GetChildren()[0]
 	case TryLock:
 		fallthrough
 	case Not:
@@ -109,6 +113,8 @@ func (u *UnOp) Check(ctx Context) bool {
 		expectedType = types.Integer
 	} else if op == TryLock && exprT != lock {
 		expectedType = types.Boolean
+	} else if op == Join && !exprT.Is(types.Future) {
+		expectedType = types.Future
 	} else {
 		return true
 	}
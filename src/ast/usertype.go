@@ -1,6 +1,8 @@
 package ast
 
 import (
+	"fmt"
+	"wacc_32/errors"
 	"wacc_32/symboltable"
 	"wacc_32/types"
 )
@@ -10,10 +12,18 @@ var _ AST = &UserType{}
 // UserType AST
 type UserType struct {
 	ast
-	ident     *Ident
-	fields    []*StatNewassign
-	IsClass   bool
-	functions []*Function
+	ident       *Ident
+	fields      []*StatNewassign
+	IsClass     bool
+	IsInterface bool
+	functions   []*Function
+	typeParams  []string
+	parent      *Ident    //The class the class extends, nil if it extends none
+	base        *UserType //The declaration of parent, once the program is checked
+	subclassed  bool
+	implements  []*Ident     //The interfaces the class implements
+	interfaces  []*UserType  //The declarations of implements, once the program is checked
+	signatures  []*Signature //The methods of an interface
 }
 
 func NewUserType(ident *Ident, fields []*StatNewassign, isClass bool, functions []*Function) *UserType {
@@ -25,15 +35,45 @@ func NewUserType(ident *Ident, fields []*StatNewassign, isClass bool, functions
 	}
 }
 
+//SetTypeParams makes the struct or class generic over params
+func (ut *UserType) SetTypeParams(params []string) {
+	ut.typeParams = params
+}
+
+//GetTypeParams returns the type parameters of a generic struct or class
+func (ut UserType) GetTypeParams() []string {
+	return ut.typeParams
+}
+
+//SetParent makes the class extend the class parent
+func (ut *UserType) SetParent(parent *Ident) {
+	ut.parent = parent
+}
+
+//GetParent returns the declaration of the class the class extends, nil if it extends none
+func (ut UserType) GetParent() *UserType {
+	return ut.base
+}
+
 //GetName returns the name of the userType
 func (ut UserType) GetName() string {
 	return ut.ident.GetName()
 }
 
+//allFields returns the declarations of the fields of the userType, those a class inherits
+//come first so its objects start like the objects of its parent
+func (ut UserType) allFields() []*StatNewassign {
+	if ut.base == nil {
+		return ut.fields
+	}
+	return append(ut.base.allFields(), ut.fields...)
+}
+
 //FieldNameList returns a ordered list of the field names to their respective WaccTypes
 func (ut UserType) FieldNameList() []string {
-	namesList := make([]string, len(ut.fields))
-	for i, field := range ut.fields {
+	fields := ut.allFields()
+	namesList := make([]string, len(fields))
+	for i, field := range fields {
 		namesList[i] = field.ident.name
 	}
 	return namesList
@@ -41,9 +81,10 @@ func (ut UserType) FieldNameList() []string {
 
 //FieldTypeList returns a ordered list of the field names to their respective WaccTypes
 func (ut UserType) FieldTypeList() []types.WaccType {
-	fieldsList := make([]types.WaccType, len(ut.fields))
+	fields := ut.allFields()
+	fieldsList := make([]types.WaccType, len(fields))
 
-	for i, field := range ut.fields {
+	for i, field := range fields {
 		fieldsList[i] = field.t
 	}
 	return fieldsList
@@ -53,12 +94,58 @@ func (ut UserType) FieldTypeList() []types.WaccType {
 func (ut UserType) FieldsMap() map[string]types.WaccType {
 	fieldsMap := make(map[string]types.WaccType)
 
-	for _, field := range ut.fields {
+	for _, field := range ut.allFields() {
 		fieldsMap[field.GetName()] = field.t
 	}
 	return fieldsMap
 }
 
+//Methods returns the names of the methods of a class, those it inherits first, each of
+//which has a slot in the vtable of the class. Generic methods have an instance for each
+//call instead, so they have no slot
+func (ut UserType) Methods() []string {
+	var names []string
+	if ut.base != nil {
+		names = ut.base.Methods()
+	}
+	for _, fn := range ut.functions {
+		if len(fn.typeParams) == 0 && !containsString(names, fn.methodName) {
+			names = append(names, fn.methodName)
+		}
+	}
+	return names
+}
+
+//Implementation returns the name of the function a class runs for method, its own or
+//the one it inherits, empty if it has none
+func (ut UserType) Implementation(method string) string {
+	if fn := ut.method(method); fn != nil {
+		return fn.ident.name
+	}
+	return ""
+}
+
+//method returns the declaration of method the class runs
+func (ut UserType) method(method string) *Function {
+	for c := &ut; c != nil; c = c.base {
+		for _, fn := range c.functions {
+			if fn.methodName == method {
+				return fn
+			}
+		}
+	}
+	return nil
+}
+
+func containsString(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
+
 func (ut UserType) String() string {
 	//String representations of fields
 	fieldStrs := make([]string, len(ut.fields))
@@ -74,15 +161,33 @@ func (ut UserType) String() string {
 
 	//String representation of class/Struct
 	var userType string
-	if ut.IsClass {
+	switch {
+	case ut.IsInterface:
+		userType = "INTERFACE "
+		for _, sig := range ut.signatures {
+			funcStrs = append(funcStrs, sig.String())
+		}
+	case ut.IsClass:
 		userType = "CLASS "
-	} else {
+	default:
 		userType = "STRUCT "
 	}
+	name := ut.ident.name
+	if ut.parent != nil {
+		name += " EXTENDS " + ut.parent.name
+	}
+	for i, iface := range ut.implements {
+		if i == 0 {
+			name += " IMPLEMENTS "
+		} else {
+			name += ", "
+		}
+		name += iface.name
+	}
 
 	children := append(fieldStrs, funcStrs...)
 
-	return format(userType+ut.ident.name, children...)
+	return format(userType+name, children...)
 }
 
 func (ut *UserType) Check(ctx Context) {
@@ -93,14 +198,108 @@ func (ut *UserType) Check(ctx Context) {
 
 	for _, field := range ut.fields {
 		field.Check(utCtx)
+		if ut.base == nil {
+			continue
+		}
+		for _, inherited := range ut.base.allFields() {
+			if inherited.GetName() == field.GetName() {
+				ctx.SemanticErrChan <- errors.NewIdentifierAlreadyInUseError(field.pos, field.GetName(), inherited.pos)
+			}
+		}
 	}
 
 	for _, fn := range ut.functions {
 		fn.Check(utCtx)
+		if ut.base != nil {
+			ut.checkOverride(fn, ctx)
+		}
 	}
+	ut.checkSignatures(utCtx)
+	ut.checkImplements(ctx)
 	ut.table = utCtx.table
 }
 
+//checkOverride reports a method which overrides an inherited method with a different type,
+//only the type of this may change
+func (ut UserType) checkOverride(fn *Function, ctx Context) {
+	inherited := ut.base.method(fn.methodName)
+	if inherited == nil {
+		return
+	}
+	name := fn.methodName[1:]
+	parent := inherited.params[len(inherited.params)-1].t.(types.UserType).GetName()
+	if len(inherited.typeParams) > 0 || len(fn.typeParams) > 0 {
+		ctx.SemanticErrChan <- errors.NewGenericOverrideError(fn.pos, name, parent)
+		return
+	}
+	expected, actual := inherited.methodType(), fn.methodType()
+	if !expected.Is(actual) {
+		ctx.SemanticErrChan <- errors.NewOverrideError(fn.pos, name, ut.GetName(), parent, expected, actual)
+	}
+}
+
 func (ut UserType) EvalType() types.WaccType {
-	return types.NewUserType(ut.ident.name, ut.FieldNameList(), ut.FieldTypeList(), ut.IsClass)
+	if ut.IsInterface {
+		return types.NewInterface(ut.ident.name, ut.methodNames())
+	}
+	t := types.NewUserType(ut.ident.name, ut.FieldNameList(), ut.FieldTypeList(), ut.IsClass).WithTypeParams(ut.typeParams)
+	if ut.subclassed {
+		t = t.WithSubclasses()
+	}
+	return t.WithSupers(ut.supers()).WithInterfaces(ut.interfaceNames())
+}
+
+//supers returns the classes the class extends, its parent first
+func (ut UserType) supers() []string {
+	var names []string
+	for c := ut.base; c != nil; c = c.base {
+		names = append(names, c.GetName())
+	}
+	return names
+}
+
+//link connects each class to the class it extends and the interfaces it implements,
+//reporting those which can't be extended or implemented
+func link(userTypes []*UserType, errChan chan<- error) {
+	byName := make(map[string]*UserType, len(userTypes))
+	for _, ut := range userTypes {
+		byName[ut.GetName()] = ut
+	}
+	for _, ut := range userTypes {
+		linkInterfaces(ut, byName, errChan)
+		if ut.parent == nil {
+			continue
+		}
+		parent, ok := byName[ut.parent.name]
+		switch {
+		case !ok:
+			errChan <- errors.NewUndefinedIdentifierError(ut.parent.pos, fmt.Errorf("class %s is not defined", ut.parent.name))
+		case parent.IsInterface:
+			errChan <- errors.NewExtendError(ut.parent.pos, ut.GetName(), parent.GetName(), "it is an interface, implement it instead")
+		case !parent.IsClass:
+			errChan <- errors.NewExtendError(ut.parent.pos, ut.GetName(), parent.GetName(), "it is a struct")
+		case len(parent.typeParams) > 0:
+			errChan <- errors.NewExtendError(ut.parent.pos, ut.GetName(), parent.GetName(), "it is generic")
+		case extends(parent, ut.GetName(), byName):
+			errChan <- errors.NewExtendError(ut.parent.pos, ut.GetName(), parent.GetName(), "it extends "+ut.GetName())
+		default:
+			ut.base = parent
+			parent.subclassed = true
+		}
+	}
+}
+
+//extends checks whether ut is the class name or extends it
+func extends(ut *UserType, name string, byName map[string]*UserType) bool {
+	for seen := make(map[*UserType]bool); ut != nil && !seen[ut]; {
+		if ut.GetName() == name {
+			return true
+		}
+		seen[ut] = true
+		if ut.parent == nil {
+			return false
+		}
+		ut = byName[ut.parent.name]
+	}
+	return false
 }
@@ -38,12 +38,33 @@ type SomethingAnotherVisitor interface {
 	//VisitBinOp visits AST node BinOp
 	VisitBinOp(node BinOp, ctx Ctx) Another
 
+	//VisitMakeChan visits AST node MakeChan
+	VisitMakeChan(node MakeChan, ctx Ctx) Another
+
+	//VisitRecv visits AST node Recv
+	VisitRecv(node Recv, ctx Ctx) Another
+
+	//VisitStatSend visits AST node StatSend
+	VisitStatSend(node StatSend, ctx Ctx) Something
+
+	//VisitStatClose visits AST node StatClose
+	VisitStatClose(node StatClose, ctx Ctx) Something
+
 	//VisitStatLock visits AST node StatLock
 	VisitStatLock(node StatLock, ctx Ctx) Something
 
 	//VisitStatSema visits AST node StatSema
 	VisitStatSema(node StatSema, ctx Ctx) Something
 
+	//VisitStatCond visits AST node StatCond
+	VisitStatCond(node StatCond, ctx Ctx) Something
+
+	//VisitStatWith visits AST node StatWith
+	VisitStatWith(node StatWith, ctx Ctx) Something
+
+	//VisitWaccFuture visits AST node WaccFuture
+	VisitWaccFuture(node WaccFuture, ctx Ctx) Another
+
 	//VisitArrayElem visits AST node ArrayElem
 	VisitArrayElem(node ArrayElem, ctx Ctx) Another
 
@@ -59,6 +80,9 @@ type SomethingAnotherVisitor interface {
 	//VisitParam visits AST node Param
 	VisitParam(node Param, ctx Ctx) Something
 
+	//VisitLambda visits AST node Lambda
+	VisitLambda(node Lambda, ctx Ctx) Another
+
 	//VisitProgram visits AST node Program
 	VisitProgram(node Program, ctx Ctx) Something
 
@@ -0,0 +1,15 @@
+//Command wacc-lsp is a language server for WACC which talks JSON-RPC over stdio
+package main
+
+import (
+	"fmt"
+	"os"
+	"wacc_32/lsp"
+)
+
+func main() {
+	if err := lsp.NewServer(os.Stdin, os.Stdout).Run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -7,8 +7,13 @@ import (
 	"strconv"
 	"strings"
 	"wacc_32/assembly"
+	"wacc_32/assembly/peephole"
 	"wacc_32/ast"
+	"wacc_32/errors"
+	"wacc_32/interpreter"
+	"wacc_32/ir"
 	"wacc_32/visitor"
+	"wacc_32/waccfmt"
 
 	"github.com/antlr/antlr4/runtime/Go/antlr"
 	"golang.org/x/exp/errors/fmt"
@@ -18,6 +23,11 @@ import (
  * THIS FILE IMPLEMENTS HELPER FUNCTIONS FOR THE FOLLOWING: *
  *  -p --parse_only                           				*
  *  -t --print_ast                           				*
+ *  -run --interpret                           				*
+ *  -ir --print_ir                           				*
+ *  -O0 -O1                                   				*
+ *  -diagnostics-format                         			*
+ *  -fmt --format                             				*
  ************************************************************/
 
 const (
@@ -41,40 +51,77 @@ func printAST(ast ast.AST) {
 	fmt.Println("===========================================================")
 }
 
-func semanticCheck(tree ast.AST) {
+func printIR(tree ast.AST, lockdep bool) {
+	gen := ir.NewGenerator()
+	if lockdep {
+		gen.SetLockdep()
+	}
+	fmt.Println(gen.Generate(tree).String())
+}
+
+//diagnosticOptions picks the warnings which are reported, whether they are errors
+//and how diagnostics are written
+type diagnosticOptions struct {
+	disabled map[string]bool
+	werror   bool
+	format   errors.Format
+	file     string
+}
+
+func semanticCheck(tree ast.AST, opts diagnosticOptions) {
 	errChan := make(chan error)
 	codeChan := make(chan int)
-	go semanticErrorListener(errChan, codeChan)
+	go semanticErrorListener(errChan, codeChan, opts)
 	tree.Check(ast.Context{SemanticErrChan: errChan})
 	if <-codeChan == semanticError {
 		os.Exit(semanticError)
 	}
 }
 
-func getLineNum(line string) int {
-	start := len("Line [")
-	end := strings.Index(line, ":")
-	num, _ := strconv.Atoi(line[start:end])
-	return num
+//sortByLine sorts diagnostics by the line they start on
+func sortByLine(diagnostics []errors.Diagnostic) {
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostics[i].Pos().StartLine() < diagnostics[j].Pos().StartLine()
+	})
 }
 
-func semanticErrorListener(errChan <-chan error, codeChan chan<- int) {
+func semanticErrorListener(errChan <-chan error, codeChan chan<- int, opts diagnosticOptions) {
 	code := ok
-	errs := make([]string, 0)
+	errs := make([]errors.Diagnostic, 0)
+	warnings := make([]errors.Diagnostic, 0)
 	for err := range errChan {
-		if err != nil {
-			code = semanticError
-			errs = append(errs, err.Error())
+		if err == nil {
+			continue
+		}
+		d := errors.AsDiagnostic(err)
+		if warning, isWarning := d.(errors.Warning); isWarning {
+			if opts.disabled[warning.Name()] {
+				continue
+			}
+			if !opts.werror {
+				warnings = append(warnings, d)
+				continue
+			}
 		}
+		code = semanticError
+		errs = append(errs, d)
 	}
-	sort.Slice(errs, func(i, j int) bool {
-		return getLineNum(errs[i]) < getLineNum(errs[j])
-	})
-	for _, err := range errs {
-		println(err)
+	if opts.format == errors.TextFormat {
+		sortByLine(warnings)
+		sortByLine(errs)
+		opts.format.Write(os.Stderr, opts.file, warnings)
+		opts.format.Write(os.Stderr, opts.file, errs)
+	} else {
+		//Structured formats are a single document
+		diagnostics := append(warnings, errs...)
+		sortByLine(diagnostics)
+		opts.format.Write(os.Stderr, opts.file, diagnostics)
 	}
 	codeChan <- code
 	close(codeChan)
+	if opts.format != errors.TextFormat {
+		return
+	}
 	switch n := len(errs); n {
 	case 0:
 	case 1:
@@ -84,11 +131,105 @@ func semanticErrorListener(errChan <-chan error, codeChan chan<- int) {
 	}
 }
 
-func generateCode(tree ast.AST, writeTo string) {
-	codeGen := assembly.NewArm11CodeGenerator()
-	arm11Code := codeGen.GenerateCode(tree)
-	err := ioutil.WriteFile(writeTo, []byte(arm11Code), 0644)
+func generateCode(tree ast.AST, codeGen *assembly.CodeGenerator, writeTo string) {
+	code := codeGen.GenerateCode(tree)
+	err := ioutil.WriteFile(writeTo, []byte(code), 0644)
 	if err != nil {
 		panic(err)
 	}
 }
+
+//optLevel is the flag for one optimisation level, -O0 and -O1 share a level so
+//whichever is given last wins
+type optLevel struct {
+	level *int
+	value int
+}
+
+func (o optLevel) String() string {
+	if o.level == nil {
+		return "false"
+	}
+	return strconv.FormatBool(*o.level == o.value)
+}
+
+func (o optLevel) Set(s string) error {
+	on, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	if on {
+		*o.level = o.value
+	} else if *o.level == o.value {
+		*o.level = 1 - o.value
+	}
+	return nil
+}
+
+func (o optLevel) IsBoolFlag() bool {
+	return true
+}
+
+//setOptimisation picks the peephole rules run by the code generator
+func setOptimisation(codeGen *assembly.CodeGenerator, level int, rules string) error {
+	if level == 0 {
+		codeGen.SetPeephole(nil)
+		return nil
+	}
+	if rules == "" {
+		return nil
+	}
+	selected, err := peephole.Select(codeGen.Config, strings.Split(rules, ","))
+	if err != nil {
+		return err
+	}
+	codeGen.SetPeephole(selected)
+	return nil
+}
+
+//printStats writes the number of temps spilled in each function to stderr
+func printStats(codeGen *assembly.CodeGenerator) {
+	temps, spilled := 0, 0
+	for _, s := range codeGen.Stats() {
+		fmt.Fprintf(os.Stderr, "%s: %d temps, %d spilled\n", s.Func, s.Temps, s.Spilled)
+		temps += s.Temps
+		spilled += s.Spilled
+	}
+	fmt.Fprintf(os.Stderr, "total: %d temps, %d spilled\n", temps, spilled)
+}
+
+//runProgram interprets the program using stdin and stdout and returns its exit code,
+//with lockdep the order locks are acquired in is checked
+func runProgram(tree ast.AST, lockdep bool) int {
+	it := interpreter.NewInterpreter(os.Stdin, os.Stdout)
+	if lockdep {
+		it.SetLockdep()
+	}
+	return it.Run(tree)
+}
+
+//formatFiles prints the canonical source of each file, or with check lists the files whose
+//source isn't canonical and returns 1 if there are any
+func formatFiles(files []string, check bool, format errors.Format) int {
+	code := ok
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		wp := visitor.NewWaccParser(string(data), "")
+		wp.SetDiagnostics(format, file)
+		tree := wp.GetParseTree()
+		wp.SyntaxCheck()
+
+		formatted := waccfmt.Format(tree, wp.Comments())
+		if !check {
+			fmt.Print(formatted)
+		} else if formatted != string(data) {
+			fmt.Println(file)
+			code = 1
+		}
+	}
+	return code
+}
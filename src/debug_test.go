@@ -0,0 +1,71 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"wacc_32/assembly"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var locRegexp = regexp.MustCompile(`\t\.loc (\d+) (\d+) (\d+)`)
+
+//TestDebugInfo checks that compiling every valid test program with debug information
+//marks its lines and frames without changing the instructions generated
+func TestDebugInfo(t *testing.T) {
+	files := validPrograms(t)
+	for _, target := range assembly.Targets() {
+		t.Run(target, func(t *testing.T) {
+			locs := 0
+			for _, file := range files {
+				plain, _ := assembly.NewCodeGenerator(target)
+				code, ok := compile(file, plain)
+				if !ok {
+					continue
+				}
+				debug, _ := assembly.NewCodeGenerator(target)
+				debug.SetDebug("/" + file)
+				debugCode, _ := compile(file, debug)
+
+				assert.Equal(t, countInstructions(code), countInstructions(debugCode), file)
+				assert.Contains(t, debugCode, "\t.file 1 \"/"+file+"\"", file)
+				files := strings.Count(debugCode, "\t.file ")
+				for _, loc := range locRegexp.FindAllStringSubmatch(debugCode, -1) {
+					index, _ := strconv.Atoi(loc[1])
+					line, _ := strconv.Atoi(loc[2])
+					assert.True(t, index >= 1 && index <= files && line > 0, loc[0])
+					locs++
+				}
+				assert.Equal(t, strings.Count(debugCode, ".cfi_startproc"), strings.Count(debugCode, ".cfi_endproc"), file)
+				assert.Equal(t, strings.Count(debugCode, ".cfi_remember_state"), strings.Count(debugCode, ".cfi_restore_state"), file)
+			}
+			assert.Greater(t, locs, len(files))
+		})
+	}
+}
+
+var debugLabelRegexp = regexp.MustCompile(`\.Ldbg\d+`)
+
+//TestDebugVariables checks that the variables of a program and the fields of its
+//structs are described, and that the code they are described in is labelled
+func TestDebugVariables(t *testing.T) {
+	file := "../tests/extensions/structs/valid/structInStruct.wacc"
+	for _, target := range assembly.Targets() {
+		t.Run(target, func(t *testing.T) {
+			codeGen, _ := assembly.NewCodeGenerator(target)
+			codeGen.SetDebug("/" + file)
+			code, ok := compile(file, codeGen)
+			assert.True(t, ok)
+
+			assert.Contains(t, code, "\t.section .debug_info")
+			for _, name := range []string{"main", "objA", "objB", "sa", "sb", "c", "int", "char"} {
+				assert.Contains(t, code, "\t.string \""+name+"\"")
+			}
+			for _, label := range debugLabelRegexp.FindAllString(code, -1) {
+				assert.Contains(t, code, "\n"+label+":")
+			}
+		})
+	}
+}
@@ -0,0 +1,353 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+//Severity says whether a diagnostic stops compilation
+type Severity int
+
+const (
+	SeverityError Severity = iota + 1
+	SeverityWarning
+)
+
+var severities = []string{"error", "warning"}
+
+func (s Severity) String() string {
+	return severities[s-1]
+}
+
+//Diagnostic is an error or warning found in a program
+type Diagnostic interface {
+	error
+	Pos() Position
+	Kind() string
+	Message() string
+	Severity() Severity
+}
+
+//AsDiagnostic returns err as a Diagnostic, errors without a position are given an empty one
+func AsDiagnostic(err error) Diagnostic {
+	if d, ok := err.(Diagnostic); ok {
+		return d
+	}
+	return otherError{err}
+}
+
+type otherError struct {
+	error
+}
+
+func (e otherError) Pos() Position {
+	return Position{}
+}
+
+func (e otherError) Kind() string {
+	return "Error"
+}
+
+func (e otherError) Message() string {
+	return e.Error()
+}
+
+func (e otherError) Severity() Severity {
+	return SeverityError
+}
+
+//SyntaxError is an error found while parsing a file
+type SyntaxError struct {
+	pos      Position
+	msg      string
+	expected []string //Tokens the parser could have accepted instead
+}
+
+//NewSyntaxError returns
+// Line [s:e-s:e] SyntaxError: <msg>, expecting <expected>
+func NewSyntaxError(p Position, msg string, expected []string) error {
+	return SyntaxError{p, msg, expected}
+}
+
+func (e SyntaxError) Error() string {
+	str := fmt.Sprintf("%s %s: %s", e.pos.String(), red(e.Kind()), e.msg)
+	if len(e.expected) > 0 && !strings.Contains(e.msg, "expecting") {
+		str += ", expecting " + strings.Join(e.expected, ", ")
+	}
+	return str
+}
+
+//Pos returns where the error was found
+func (e SyntaxError) Pos() Position {
+	return e.pos
+}
+
+//Kind returns SyntaxError
+func (e SyntaxError) Kind() string {
+	return "SyntaxError"
+}
+
+//Message returns the description of the error without its position
+func (e SyntaxError) Message() string {
+	return e.msg
+}
+
+//Severity returns SeverityError
+func (e SyntaxError) Severity() Severity {
+	return SeverityError
+}
+
+//Format is a way of writing diagnostics for -diagnostics-format
+type Format int
+
+const (
+	TextFormat Format = iota + 1
+	JSONFormat
+	SARIFFormat
+)
+
+var formats = []string{"text", "json", "sarif"}
+
+func (f Format) String() string {
+	return formats[f-1]
+}
+
+//ParseFormat returns the Format called name
+func ParseFormat(name string) (Format, error) {
+	for i, format := range formats {
+		if format == name {
+			return Format(i + 1), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown diagnostics format %q, expected one of %v", name, formats)
+}
+
+//Formats returns the name of every Format
+func Formats() []string {
+	return append([]string{}, formats...)
+}
+
+//Write writes the diagnostics found in file to w.
+//Text is written a diagnostic at a time with the source it refers to, the other
+//formats as a single document
+func (f Format) Write(w io.Writer, file string, diags []Diagnostic) error {
+	switch f {
+	case JSONFormat:
+		return writeJSON(w, newJSONDiagnostics(file, diags))
+	case SARIFFormat:
+		return writeJSON(w, newSARIFLog(file, diags))
+	}
+	for _, d := range diags {
+		if _, err := fmt.Fprint(w, Render(d, file)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+//option returns the flag which disables a warning, if it has one
+func option(d Diagnostic) string {
+	if w, ok := d.(Warning); ok {
+		return "-W" + w.Name()
+	}
+	return ""
+}
+
+//positionFile returns the file p is in, which may be an imported library
+func positionFile(p Position, file string) string {
+	if p.file != "" {
+		return p.file
+	}
+	return file
+}
+
+//expectedTokens returns the tokens a syntax error expected instead
+func expectedTokens(d Diagnostic) []string {
+	if s, ok := d.(SyntaxError); ok {
+		return s.expected
+	}
+	return nil
+}
+
+/* ********************************* JSON ********************************* */
+
+type jsonLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type jsonDiagnostic struct {
+	File     string       `json:"file"`
+	Severity string       `json:"severity"`
+	Kind     string       `json:"kind"`
+	Message  string       `json:"message"`
+	Option   string       `json:"option,omitempty"`
+	Expected []string     `json:"expected,omitempty"`
+	Start    jsonLocation `json:"start"`
+	End      jsonLocation `json:"end"`
+	Related  []jsonLabel  `json:"related,omitempty"`
+}
+
+//jsonLabel is other code a diagnostic refers to
+type jsonLabel struct {
+	File    string       `json:"file"`
+	Message string       `json:"message"`
+	Start   jsonLocation `json:"start"`
+	End     jsonLocation `json:"end"`
+}
+
+func newJSONDiagnostics(file string, diags []Diagnostic) []jsonDiagnostic {
+	out := make([]jsonDiagnostic, len(diags))
+	for i, d := range diags {
+		p := d.Pos()
+		out[i] = jsonDiagnostic{
+			File:     positionFile(d.Pos(), file),
+			Severity: d.Severity().String(),
+			Kind:     d.Kind(),
+			Message:  d.Message(),
+			Option:   option(d),
+			Expected: expectedTokens(d),
+			Start:    jsonLocation{p.startLine, p.startCol},
+			End:      jsonLocation{p.endLine, p.endCol},
+		}
+		for _, label := range labels(d) {
+			out[i].Related = append(out[i].Related, jsonLabel{
+				File:    positionFile(label.pos, file),
+				Message: label.msg,
+				Start:   jsonLocation{label.pos.startLine, label.pos.startCol},
+				End:     jsonLocation{label.pos.endLine, label.pos.endCol},
+			})
+		}
+	}
+	return out
+}
+
+/* ********************************* SARIF ********************************* */
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID           string          `json:"ruleId"`
+	Level            string          `json:"level"`
+	Message          sarifMessage    `json:"message"`
+	Locations        []sarifLocation `json:"locations"`
+	RelatedLocations []sarifLocation `json:"relatedLocations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	Message          *sarifMessage         `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+//sarifRegion counts columns from 1
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+//sarifURI returns the artifact location of a file, relative paths are left relative
+func sarifURI(file string) string {
+	uri := strings.TrimPrefix(file, "./")
+	if strings.HasPrefix(uri, "/") {
+		uri = "file://" + uri
+	}
+	return uri
+}
+
+func newSARIFPhysicalLocation(p Position, file string) sarifPhysicalLocation {
+	//Diagnostics without a position point at the start of the file
+	region := sarifRegion{1, 1, 1, 1}
+	if p.startLine > 0 {
+		region = sarifRegion{p.startLine, p.startCol + 1, p.endLine, p.endCol + 1}
+	}
+	return sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{sarifURI(positionFile(p, file))},
+		Region:           region,
+	}
+}
+
+func newSARIFLog(file string, diags []Diagnostic) sarifLog {
+	rules := make([]sarifRule, 0)
+	seen := make(map[string]bool)
+	results := make([]sarifResult, len(diags))
+	for i, d := range diags {
+		if !seen[d.Kind()] {
+			seen[d.Kind()] = true
+			rules = append(rules, sarifRule{d.Kind()})
+		}
+		msg := d.Message()
+		if opt := option(d); opt != "" {
+			msg += " [" + opt + "]"
+		}
+		results[i] = sarifResult{
+			RuleID:    d.Kind(),
+			Level:     d.Severity().String(),
+			Message:   sarifMessage{msg},
+			Locations: []sarifLocation{{PhysicalLocation: newSARIFPhysicalLocation(d.Pos(), file)}},
+		}
+		for _, label := range labels(d) {
+			results[i].RelatedLocations = append(results[i].RelatedLocations, sarifLocation{
+				PhysicalLocation: newSARIFPhysicalLocation(label.pos, file),
+				Message:          &sarifMessage{label.msg},
+			})
+		}
+	}
+	return sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []sarifRun{{
+			Tool:    sarifTool{sarifDriver{"wacc", rules}},
+			Results: results,
+		}},
+	}
+}
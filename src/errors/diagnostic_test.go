@@ -0,0 +1,83 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"wacc_32/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var diags = []Diagnostic{
+	NewTypeError(NewPosition(3, 4, 3, 10), "exit", types.Integer, types.Boolean).(Diagnostic),
+	NewUnusedVariableWarning(NewPosition(5, 2, 5, 9), "x").(Diagnostic),
+}
+
+func TestTextFormatMatchesErrorStrings(t *testing.T) {
+	var buf bytes.Buffer
+
+	assert.NoError(t, TextFormat.Write(&buf, "a.wacc", diags))
+	assert.Equal(t, diags[0].Error()+"\n"+diags[1].Error()+"\n", buf.String())
+}
+
+func TestJSONFormatHasStructuredFields(t *testing.T) {
+	var buf bytes.Buffer
+	var out []map[string]interface{}
+
+	assert.NoError(t, JSONFormat.Write(&buf, "a.wacc", diags))
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	assert.Len(t, out, 2)
+	assert.Equal(t, "TypeError", out[0]["kind"])
+	assert.Equal(t, "error", out[0]["severity"])
+	assert.Equal(t, "exit expected int not bool", out[0]["message"])
+	assert.Equal(t, map[string]interface{}{"line": 3.0, "column": 4.0}, out[0]["start"])
+	assert.Nil(t, out[0]["option"])
+	assert.Equal(t, "-Wunused", out[1]["option"])
+}
+
+func TestSARIFFormatCountsColumnsFromOne(t *testing.T) {
+	var buf bytes.Buffer
+	var out sarifLog
+
+	assert.NoError(t, SARIFFormat.Write(&buf, "/tmp/a.wacc", diags))
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	assert.Equal(t, sarifVersion, out.Version)
+	assert.Len(t, out.Runs[0].Tool.Driver.Rules, 2)
+	result := out.Runs[0].Results[1]
+	assert.Equal(t, "warning", result.Level)
+	assert.Equal(t, "file:///tmp/a.wacc", result.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, sarifRegion{5, 3, 5, 10}, result.Locations[0].PhysicalLocation.Region)
+}
+
+func TestParseFormatRejectsUnknownNames(t *testing.T) {
+	f, err := ParseFormat("sarif")
+	assert.NoError(t, err)
+	assert.Equal(t, SARIFFormat, f)
+
+	_, err = ParseFormat("xml")
+	assert.Error(t, err)
+}
+
+func TestSyntaxErrorListsExpectedTokens(t *testing.T) {
+	err := NewSyntaxError(NewPosition(2, 9, 2, 9), "no viable alternative at input 'x'", []string{"'('", "IDENT"})
+	var buf bytes.Buffer
+	var out []map[string]interface{}
+
+	assert.Contains(t, err.Error(), ": no viable alternative at input 'x', expecting '(', IDENT")
+	assert.NoError(t, JSONFormat.Write(&buf, "a.wacc", []Diagnostic{err.(Diagnostic)}))
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	assert.Equal(t, []interface{}{"'('", "IDENT"}, out[0]["expected"])
+}
+
+func TestJSONFormatReportsLibraryFiles(t *testing.T) {
+	first := NewSourcePosition("lib/b.wacc", 2, 2, 2, 6, 1)
+	err := NewIdentifierAlreadyInUseError(NewSourcePosition("lib/b.wacc", 4, 2, 4, 10, 1), "x", first)
+	var buf bytes.Buffer
+	var out []jsonDiagnostic
+
+	assert.NoError(t, JSONFormat.Write(&buf, "a.wacc", []Diagnostic{err.(Diagnostic)}))
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	assert.Equal(t, "lib/b.wacc", out[0].File)
+	assert.Equal(t, []jsonLabel{{"lib/b.wacc", "x first declared here", jsonLocation{2, 2}, jsonLocation{2, 6}}}, out[0].Related)
+}
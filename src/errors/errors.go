@@ -17,18 +17,56 @@ const (
 	importError
 	uninitialisedUserTypeError
 	invalidFieldAccessError
+	arithmeticError
+	inheritanceError
+	interfaceError
 )
 
-var semanticErrors = []string{"TypeError", "ParamError", "ReturnError", "UndefinedIdentifierError", "IdentifierAlreadyInUseError", "ArgCountError", "ImportError", "UninitialisedUserTypeError", "InvalidFieldAccess"}
+var semanticErrors = []string{"TypeError", "ParamError", "ReturnError", "UndefinedIdentifierError", "IdentifierAlreadyInUseError", "ArgCountError", "ImportError", "UninitialisedUserTypeError", "InvalidFieldAccess", "ArithmeticError", "InheritanceError", "InterfaceError"}
 
 func (s semanticError) String() string {
 	return red(semanticErrors[s-1])
 }
 
+//SemanticError is an error found while checking a program
+type SemanticError struct {
+	pos    Position
+	eType  semanticError
+	msg    string
+	labels []Label
+}
+
+func (e SemanticError) Error() string {
+	return fmt.Sprintf("%s %s: %s\t👎", e.pos.String(), e.eType, e.msg)
+}
+
+//Pos returns where the error was found
+func (e SemanticError) Pos() Position {
+	return e.pos
+}
+
+//Kind returns the name of the kind of error
+func (e SemanticError) Kind() string {
+	return semanticErrors[e.eType-1]
+}
+
+//Message returns the description of the error without its position
+func (e SemanticError) Message() string {
+	return e.msg
+}
+
+//Severity returns SeverityError
+func (e SemanticError) Severity() Severity {
+	return SeverityError
+}
+
+//Labels returns the other code the error refers to
+func (e SemanticError) Labels() []Label {
+	return e.labels
+}
+
 func newError(p Position, errType semanticError, template string, args ...interface{}) error {
-	msg := fmt.Sprintf(template, args...)
-	err := fmt.Errorf("%s %s: %s\t👎", p.String(), errType, msg)
-	return err
+	return SemanticError{p, errType, fmt.Sprintf(template, args...), nil}
 }
 
 func red(s string) string {
@@ -81,7 +119,9 @@ func NewUndefinedIdentifierError(p Position, err error) error {
 //NewIdentifierAlreadyInUseError returns
 // Line [s:e-s:e] IdentifierAlreadyInUseError: <name> already declared at <original_position>
 func NewIdentifierAlreadyInUseError(p Position, name string, firstPos Position) error {
-	return newError(p, identifierAlreadyInUseError, "%s already declared at %s", name, firstPos)
+	err := newError(p, identifierAlreadyInUseError, "%s already declared at %s", name, firstPos).(SemanticError)
+	err.labels = []Label{{firstPos, name + " first declared here"}}
+	return err
 }
 
 //NewArgCountError returns
@@ -98,6 +138,74 @@ func NewArgCountError(p Position, fname string, n, m int) error {
 	)
 }
 
+//NewTypeArgCountError returns
+// Line [s:e-s:e] ArgCountError: wrong number of type arguments for <name>, expected <n> got <m>
+func NewTypeArgCountError(p Position, name string, n, m int) error {
+	return newError(p, argCountError, "wrong number of type arguments for %s, expected %d got %d", name, n, m)
+}
+
+//NewInferenceError returns
+// Line [s:e-s:e] TypeError: could not infer type parameter <param> of <name>
+func NewInferenceError(p Position, name, param string) error {
+	return newError(p, typeError, "could not infer type parameter %s of %s", param, name)
+}
+
+//NewGrowingTypeArgError returns
+// Line [s:e-s:e] TypeError: type parameter <param> of <name> grows through recursive calls
+func NewGrowingTypeArgError(p Position, name, param string) error {
+	return newError(p, typeError, "type parameter %s of %s grows through recursive calls", param, name)
+}
+
+//NewGenericValueError returns
+// Line [s:e-s:e] TypeError: generic function <fname> can only be called
+func NewGenericValueError(p Position, fname string) error {
+	return newError(p, typeError, "generic function %s can only be called", fname)
+}
+
+//NewExtendError returns
+// Line [s:e-s:e] InheritanceError: class <class> can't extend <parent>, <reason>
+func NewExtendError(p Position, class, parent, reason string) error {
+	return newError(p, inheritanceError, "class %s can't extend %s, %s", class, parent, reason)
+}
+
+//NewOverrideError returns
+// Line [s:e-s:e] InheritanceError: <method> of <class> overrides <method> of <parent> with type <not>, expected <expected>
+func NewOverrideError(p Position, method, class, parent string, expected, not types.WaccType) error {
+	return newError(p, inheritanceError, "%s of %s overrides %s of %s with type %s, expected %s",
+		method, class, method, parent, not, expected)
+}
+
+//NewGenericOverrideError returns
+// Line [s:e-s:e] InheritanceError: generic method <method> of <class> can't be overridden
+func NewGenericOverrideError(p Position, method, class string) error {
+	return newError(p, inheritanceError, "generic method %s of %s can't be overridden", method, class)
+}
+
+//NewImplementError returns
+// Line [s:e-s:e] InterfaceError: class <class> can't implement <iface>, <reason>
+func NewImplementError(p Position, class, iface, reason string) error {
+	return newError(p, interfaceError, "class %s can't implement %s, %s", class, iface, reason)
+}
+
+//NewMissingMethodError returns
+// Line [s:e-s:e] InterfaceError: class <class> doesn't implement <iface>, it has no method <method>
+func NewMissingMethodError(p Position, class, iface, method string) error {
+	return newError(p, interfaceError, "class %s doesn't implement %s, it has no method %s", class, iface, method)
+}
+
+//NewImplementTypeError returns
+// Line [s:e-s:e] InterfaceError: <method> of <class> implements <method> of <iface> with type <not>, expected <expected>
+func NewImplementTypeError(p Position, method, class, iface string, expected, not types.WaccType) error {
+	return newError(p, interfaceError, "%s of %s implements %s of %s with type %s, expected %s",
+		method, class, method, iface, not, expected)
+}
+
+//NewInterfaceLiteralError returns
+// Line [s:e-s:e] InterfaceError: interface <iface> has no objects of its own, create one of a class implementing it
+func NewInterfaceLiteralError(p Position, iface string) error {
+	return newError(p, interfaceError, "interface %s has no objects of its own, create one of a class implementing it", iface)
+}
+
 //NewSameTypeError returns
 // Line [s:e-s:e] TypeError: <op> requires both arguments to have the same type
 func NewSameTypeError(p Position, op string) error {
@@ -144,3 +252,15 @@ func NewUninitialisedUsertypeError(p Position) error {
 func NewInvalidFieldAccessError(p Position, fieldName, structName string) error {
 	return newError(p, invalidFieldAccessError, "field %s is not present in struct %s", fieldName, structName)
 }
+
+//NewOverflowError returns
+// Line [s:e-s:e] ArithmeticError: <expr> overflows a 32 bit integer
+func NewOverflowError(p Position, expr string) error {
+	return newError(p, arithmeticError, "%s overflows a 32 bit integer", expr)
+}
+
+//NewDivideByZeroError returns
+// Line [s:e-s:e] ArithmeticError: <expr> divides by zero
+func NewDivideByZeroError(p Position, expr string) error {
+	return newError(p, arithmeticError, "%s divides by zero", expr)
+}
@@ -6,6 +6,8 @@ import "fmt"
 type Position struct {
 	startLine, startCol int
 	endLine, endCol     int
+	endLen              int    //Length of the last token, 0 if it isn't known
+	file                string //File the code is in, empty for the file being compiled
 }
 
 //NewPosition creates a new position
@@ -18,6 +20,66 @@ func NewPosition(startLine, startCol, endLine, endCol int) Position {
 	}
 }
 
+//NewSourcePosition creates a new position in file which knows the length of its last token,
+//so the whole of the code can be underlined
+func NewSourcePosition(file string, startLine, startCol, endLine, endCol, endLen int) Position {
+	return Position{
+		startLine: startLine,
+		startCol:  startCol,
+		endLine:   endLine,
+		endCol:    endCol,
+		endLen:    endLen,
+		file:      file,
+	}
+}
+
+//String returns where the position starts and ends, counting columns from 1 like the
+//location under a rendered diagnostic
 func (p Position) String() string {
-	return fmt.Sprintf("Line [%d:%d-%d:%d]", p.startLine, p.startCol, p.endLine, p.endCol)
+	return fmt.Sprintf("Line [%d:%d-%d:%d]", p.startLine, p.startCol+1, p.endLine, p.endCol+1)
+}
+
+//StartLine returns the line the position starts on, counting from 1
+func (p Position) StartLine() int {
+	return p.startLine
+}
+
+//StartCol returns the column the position starts on, counting from 0
+func (p Position) StartCol() int {
+	return p.startCol
+}
+
+//EndLine returns the line of the last token of the position
+func (p Position) EndLine() int {
+	return p.endLine
+}
+
+//EndCol returns the column the last token of the position starts on
+func (p Position) EndCol() int {
+	return p.endCol
+}
+
+//File returns the file the position is in, empty for the file being compiled
+func (p Position) File() string {
+	return p.file
+}
+
+//Contains returns whether the character at line:col is part of the code p covers.
+//Columns count from 0
+func (p Position) Contains(line, col int) bool {
+	endLen := p.endLen
+	if endLen < 1 {
+		endLen = 1
+	}
+	return !before(line, col, p.startLine, p.startCol) && before(line, col, p.endLine, p.endCol+endLen)
+}
+
+//StartsBefore returns whether p starts before the character at line:col
+func (p Position) StartsBefore(line, col int) bool {
+	return before(p.startLine, p.startCol, line, col)
+}
+
+//before returns whether line1:col1 comes before line2:col2
+func before(line1, col1, line2, col2 int) bool {
+	return line1 < line2 || line1 == line2 && col1 < col2
 }
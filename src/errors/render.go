@@ -0,0 +1,171 @@
+package errors
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//Label points at other code a diagnostic refers to, e.g. an earlier declaration
+type Label struct {
+	pos Position
+	msg string
+}
+
+//Pos returns the code the label points at
+func (l Label) Pos() Position {
+	return l.pos
+}
+
+//Message returns what the label says about the code
+func (l Label) Message() string {
+	return l.msg
+}
+
+//labelled is a diagnostic which points at more than one part of the source
+type labelled interface {
+	Labels() []Label
+}
+
+//labels returns the secondary labels of d
+func labels(d Diagnostic) []Label {
+	if l, ok := d.(labelled); ok {
+		return l.Labels()
+	}
+	return nil
+}
+
+//maxSpanLines is the most lines of a span shown before the middle is left out
+const maxSpanLines = 4
+
+//sources holds the lines of every file parsed, keyed by the file's path with the file
+//being compiled under ""
+var sources = struct {
+	sync.Mutex
+	files map[string][]string
+}{files: make(map[string][]string)}
+
+//AddSource records the source of file so diagnostics can show it.
+//file is empty for the file being compiled
+func AddSource(file, data string) {
+	sources.Lock()
+	defer sources.Unlock()
+	sources.files[file] = strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+}
+
+//sourceLine returns the line of file numbered from 1
+func sourceLine(file string, line int) (string, bool) {
+	sources.Lock()
+	defer sources.Unlock()
+	lines := sources.files[file]
+	if line < 1 || line > len(lines) {
+		return "", false
+	}
+	return lines[line-1], true
+}
+
+//Render returns d as text followed by the source it refers to with the code underlined,
+//^ for where d was found and - for its labels. file is the name of the file being compiled
+func Render(d Diagnostic, file string) string {
+	var b strings.Builder
+	b.WriteString(d.Error() + "\n")
+	p := d.Pos()
+	if _, ok := sourceLine(p.file, p.startLine); !ok {
+		return b.String()
+	}
+	r := snippetRenderer{b: &b, file: file, gutter: len(strconv.Itoa(p.endLine))}
+	for _, label := range labels(d) {
+		if n := len(strconv.Itoa(label.pos.endLine)); n > r.gutter {
+			r.gutter = n
+		}
+	}
+	r.location(p)
+	b.WriteString(r.margin() + "\n")
+	r.span(p, '^', "")
+	for _, label := range labels(d) {
+		if _, ok := sourceLine(label.pos.file, label.pos.startLine); !ok {
+			continue
+		}
+		if label.pos.file != p.file {
+			r.location(label.pos)
+		}
+		r.span(label.pos, '-', label.msg)
+	}
+	return b.String()
+}
+
+//snippetRenderer writes source lines with a gutter of line numbers
+type snippetRenderer struct {
+	b      *strings.Builder
+	file   string
+	gutter int //Width of the line numbers
+}
+
+//location writes where p is, counting columns from 1
+func (r snippetRenderer) location(p Position) {
+	file := r.file
+	if p.file != "" {
+		file = p.file
+	}
+	fmt.Fprintf(r.b, "%s--> %s:%d:%d\n", strings.Repeat(" ", r.gutter), file, p.startLine, p.startCol+1)
+}
+
+//margin returns the gutter of a line without a number
+func (r snippetRenderer) margin() string {
+	return strings.Repeat(" ", r.gutter) + " |"
+}
+
+//span writes the lines p covers, each underlined with mark, then msg after the last
+func (r snippetRenderer) span(p Position, mark rune, msg string) {
+	for line := p.startLine; line <= p.endLine; line++ {
+		//Long spans only show their first few lines and their last
+		if p.endLine-p.startLine >= maxSpanLines && line == p.startLine+maxSpanLines-2 {
+			fmt.Fprintf(r.b, "%s...\n", strings.Repeat(" ", r.gutter))
+			line = p.endLine
+		}
+		source, ok := sourceLine(p.file, line)
+		if !ok {
+			break
+		}
+		source = strings.TrimRight(source, " \t")
+		fmt.Fprintf(r.b, "%*d | %s\n", r.gutter, line, source)
+		r.b.WriteString(r.margin() + " " + underline([]rune(source), p, line, mark))
+		if line == p.endLine && msg != "" {
+			r.b.WriteString(" " + msg)
+		}
+		r.b.WriteString("\n")
+	}
+}
+
+//underline returns the marks under the part of source, line number line, that p covers.
+//Tabs before the marks are kept so they line up however the tabs are displayed
+func underline(source []rune, p Position, line int, mark rune) string {
+	from := 0
+	for from < len(source) && (source[from] == ' ' || source[from] == '\t') {
+		from++
+	}
+	to := len(source)
+	if line == p.startLine {
+		from = p.startCol
+	}
+	if line == p.endLine {
+		to = p.endCol + p.endLen
+	}
+	if to > len(source) {
+		to = len(source)
+	}
+	if to <= from {
+		to = from + 1
+	}
+	var b strings.Builder
+	for i := 0; i < from; i++ {
+		if i < len(source) && source[i] == '\t' {
+			b.WriteRune('\t')
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+	b.WriteString(strings.Repeat(string(mark), to-from))
+	return b.String()
+}
@@ -0,0 +1,39 @@
+package errors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderUnderlinesErrorAndLabels(t *testing.T) {
+	AddSource("render.wacc", "begin\n  int x = 1 ;\n\tint x = 2\nend\n")
+	first := NewSourcePosition("render.wacc", 2, 2, 2, 10, 1)
+	err := NewIdentifierAlreadyInUseError(NewSourcePosition("render.wacc", 3, 1, 3, 9, 1), "x", first)
+
+	assert.Equal(t, err.Error()+"\n"+
+		" --> render.wacc:3:2\n"+
+		"  |\n"+
+		"3 | \tint x = 2\n"+
+		"  | \t^^^^^^^^^\n"+
+		"2 |   int x = 1 ;\n"+
+		"  |   --------- x first declared here\n",
+		Render(err.(Diagnostic), "a.wacc"))
+}
+
+func TestRenderShortensLongSpans(t *testing.T) {
+	AddSource("long.wacc", "a\nb\nc\nd\ne\nf\n")
+	err := NewSyntaxError(NewSourcePosition("long.wacc", 1, 0, 6, 0, 1), "bad", nil)
+
+	assert.Equal(t, err.Error()+"\n"+
+		" --> long.wacc:1:1\n"+
+		"  |\n"+
+		"1 | a\n"+
+		"  | ^\n"+
+		"2 | b\n"+
+		"  | ^\n"+
+		" ...\n"+
+		"6 | f\n"+
+		"  | ^\n",
+		Render(err.(Diagnostic), "a.wacc"))
+}
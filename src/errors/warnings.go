@@ -0,0 +1,163 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+)
+
+type warningType int
+
+const (
+	unreachableCodeWarning = iota + 1
+	unusedFunctionWarning
+	unusedWarning
+	shadowWarning
+	unusedImportWarning
+	lockWarning
+	uninitialisedWarning
+	raceWarning
+	deadlockWarning
+)
+
+var warnings = []string{"UnreachableCodeWarning", "UnusedFunctionWarning", "UnusedWarning", "ShadowWarning", "UnusedImportWarning", "LockWarning", "UninitialisedWarning", "RaceWarning", "DeadlockWarning"}
+
+//warningNames are used to enable and disable warnings with -Wno-<name>
+var warningNames = []string{"unreachable", "unused-function", "unused", "shadow", "unused-import", "lock", "uninitialised", "race", "deadlock"}
+
+func (w warningType) String() string {
+	return yellow(warnings[w-1])
+}
+
+//WarningNames returns the name of every kind of warning
+func WarningNames() []string {
+	return append([]string{}, warningNames...)
+}
+
+//Warning is reported alongside semantic errors but doesn't stop compilation
+type Warning struct {
+	pos    Position
+	wType  warningType
+	msg    string
+	labels []Label
+}
+
+func (w Warning) Error() string {
+	return fmt.Sprintf("%s %s: %s [-W%s]", w.pos.String(), w.wType, w.msg, w.Name())
+}
+
+//Name returns the name used to disable the kind of warning
+func (w Warning) Name() string {
+	return warningNames[w.wType-1]
+}
+
+//Pos returns where the warning was found
+func (w Warning) Pos() Position {
+	return w.pos
+}
+
+//Kind returns the name of the kind of warning
+func (w Warning) Kind() string {
+	return warnings[w.wType-1]
+}
+
+//Message returns the description of the warning without its position
+func (w Warning) Message() string {
+	return w.msg
+}
+
+//Severity returns SeverityWarning
+func (w Warning) Severity() Severity {
+	return SeverityWarning
+}
+
+//Labels returns the other code the warning refers to
+func (w Warning) Labels() []Label {
+	return w.labels
+}
+
+func newWarning(p Position, wType warningType, template string, args ...interface{}) error {
+	return Warning{p, wType, fmt.Sprintf(template, args...), nil}
+}
+
+func yellow(s string) string {
+	return "\u001b[1m\u001b[33m" + s + "\u001b[0m"
+}
+
+/* **************************** FACTORY METHODS **************************** */
+
+//NewUnreachableCodeWarning returns
+// Line [s:e-s:e] UnreachableCodeWarning: statements after <stat> are never executed
+func NewUnreachableCodeWarning(p Position, stat string) error {
+	return newWarning(p, unreachableCodeWarning, "statements after %s are never executed", stat)
+}
+
+//NewDeadBranchWarning returns
+// Line [s:e-s:e] UnreachableCodeWarning: <branch> is never executed
+func NewDeadBranchWarning(p Position, branch string) error {
+	return newWarning(p, unreachableCodeWarning, "%s is never executed", branch)
+}
+
+//NewUnusedFunctionWarning returns
+// Line [s:e-s:e] UnusedFunctionWarning: function <name> is never called
+func NewUnusedFunctionWarning(p Position, name string) error {
+	return newWarning(p, unusedFunctionWarning, "function %s is never called", name)
+}
+
+//NewUnusedVariableWarning returns
+// Line [s:e-s:e] UnusedWarning: variable <name> is never read
+func NewUnusedVariableWarning(p Position, name string) error {
+	return newWarning(p, unusedWarning, "variable %s is never read", name)
+}
+
+//NewUnusedParameterWarning returns
+// Line [s:e-s:e] UnusedWarning: parameter <name> of <function> is never read
+func NewUnusedParameterWarning(p Position, name, function string) error {
+	return newWarning(p, unusedWarning, "parameter %s of %s is never read", name, function)
+}
+
+//NewShadowWarning returns
+// Line [s:e-s:e] ShadowWarning: <name> shadows the declaration at <original_position>
+func NewShadowWarning(p Position, name string, outer Position) error {
+	w := newWarning(p, shadowWarning, "%s shadows the declaration at %s", name, outer).(Warning)
+	w.labels = []Label{{outer, name + " declared here"}}
+	return w
+}
+
+//NewUnusedImportWarning returns
+// Line [s:e-s:e] UnusedImportWarning: library <lib> is imported but never used
+func NewUnusedImportWarning(p Position, lib string) error {
+	return newWarning(p, unusedImportWarning, "library %s is imported but never used", lib)
+}
+
+//NewUnreleasedLockWarning returns
+// Line [s:e-s:e] LockWarning: lock <lock> may still be held when <function> returns
+func NewUnreleasedLockWarning(p Position, lock, function string) error {
+	return newWarning(p, lockWarning, "lock %s may still be held when %s returns", lock, function)
+}
+
+//NewUninitialisedWarning returns
+// Line [s:e-s:e] UninitialisedWarning: <name> may be read before it is assigned
+func NewUninitialisedWarning(p Position, name string) error {
+	return newWarning(p, uninitialisedWarning, "%s may be read before it is assigned", name)
+}
+
+//NewRaceWarning returns
+// Line [s:e-s:e] RaceWarning: <name> is written here and by routine <routine> at <other_position> without a common lock held
+func NewRaceWarning(p Position, name, routine string, other Position) error {
+	w := newWarning(p, raceWarning, "%s is written here and by routine %s at %s without a common lock held", name, routine, other).(Warning)
+	w.labels = []Label{{other, name + " written by " + routine + " here"}}
+	return w
+}
+
+//NewLockCycleWarning returns
+// Line [s:e-s:e] DeadlockWarning: locks <l1> -> <l2> -> ... -> <l1> may be acquired in a cycle, a potential deadlock
+//acquired[i] is where locks[i+1] is acquired while holding locks[i], the warning is at
+//the first and labels the others
+func NewLockCycleWarning(acquired []Position, locks []string) error {
+	cycle := strings.Join(locks, " -> ") + " -> " + locks[0]
+	w := newWarning(acquired[0], deadlockWarning, "locks %s may be acquired in a cycle, a potential deadlock", cycle).(Warning)
+	for i := 1; i < len(acquired); i++ {
+		w.labels = append(w.labels, Label{acquired[i], fmt.Sprintf("%s acquired while holding %s here", locks[(i+1)%len(locks)], locks[i])})
+	}
+	return w
+}
@@ -0,0 +1,45 @@
+package interpreter
+
+import (
+	"wacc_32/assembly/builtins"
+	"wacc_32/ast"
+	"wacc_32/interpreter/values"
+	"wacc_32/types"
+)
+
+//VisitMakeChan creates an open channel
+func (it *Interpreter) VisitMakeChan(node ast.MakeChan, ctx *values.Frame) values.Value {
+	capacity := it.VisitExpression(node.GetCapacity(), ctx).(int32)
+	if capacity < 0 {
+		panic(builtins.NegativeCapacityError)
+	}
+	return values.NewChan(int(capacity))
+}
+
+//VisitRecv takes a value from a channel, a closed channel with no values left gives
+//the default value of its element type
+func (it *Interpreter) VisitRecv(node ast.Recv, ctx *values.Frame) values.Value {
+	channel := dereference(it.VisitExpression(node.GetChannel(), ctx)).(*values.Chan)
+	if value, ok := channel.Recv(); ok {
+		return value
+	}
+	return values.Zero(types.Substitute(node.EvalType(*node.GetSymbolTable()), ctx.Types))
+}
+
+//VisitStatSend sends a value on a channel, which must not be closed
+func (it *Interpreter) VisitStatSend(node ast.StatSend, ctx *values.Frame) values.Control {
+	channel := dereference(it.VisitExpression(node.GetChannel(), ctx)).(*values.Chan)
+	if !channel.Send(it.VisitExpression(node.GetValue(), ctx)) {
+		panic(builtins.ClosedChannelError)
+	}
+	return values.Next
+}
+
+//VisitStatClose closes a channel, which must not be closed already
+func (it *Interpreter) VisitStatClose(node ast.StatClose, ctx *values.Frame) values.Control {
+	channel := dereference(it.VisitExpression(node.GetChannel(), ctx)).(*values.Chan)
+	if !channel.Close() {
+		panic(builtins.ClosedChannelError)
+	}
+	return values.Next
+}
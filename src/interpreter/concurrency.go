@@ -0,0 +1,113 @@
+package interpreter
+
+import (
+	"wacc_32/assembly/builtins"
+	"wacc_32/ast"
+	"wacc_32/interpreter/values"
+)
+
+//VisitWaccRoutine evaluates the arguments and runs the function in a new goroutine
+func (it *Interpreter) VisitWaccRoutine(node ast.WaccRoutine, ctx *values.Frame) values.Control {
+	stats, frame := it.prepareCall(*node.RHSFunctionCall, ctx)
+	go it.thread(func(thread int64) {
+		frame.Thread = thread
+		it.run(stats, frame)
+	})
+	return values.Next
+}
+
+//VisitWaccFuture runs the function in a new goroutine, returning the future its result
+//is stored in
+func (it *Interpreter) VisitWaccFuture(node ast.WaccFuture, ctx *values.Frame) values.Value {
+	stats, frame := it.prepareCall(*node.RHSFunctionCall, ctx)
+	future := values.NewFuture()
+	go it.thread(func(thread int64) {
+		frame.Thread = thread
+		future.Complete(it.run(stats, frame).Value)
+	})
+	return future
+}
+
+//VisitStatLock acquires or releases a lock with the same checks as an error checking pthread mutex
+func (it *Interpreter) VisitStatLock(node ast.StatLock, ctx *values.Frame) values.Control {
+	lock := dereference(it.VisitIdent(*node.GetIdent(), ctx)).(*values.Lock)
+	switch node.GetType() {
+	case ast.Acquire:
+		it.acquire(lock, ctx.Thread)
+	case ast.Release:
+		it.release(lock, ctx.Thread)
+	}
+	return values.Next
+}
+
+func (it *Interpreter) acquire(lock *values.Lock, thread int64) {
+	it.checkOrder(lock, thread)
+	if !lock.Acquire(thread) {
+		panic(builtins.SameThreadLockError)
+	}
+	if it.lockdep != nil {
+		it.lockdep.Acquired(thread, lock)
+	}
+}
+
+func (it *Interpreter) release(lock *values.Lock, thread int64) {
+	if !lock.Release(thread) {
+		panic(builtins.InvalidThreadUnlockError)
+	}
+	if it.lockdep != nil {
+		it.lockdep.Released(thread, lock)
+	}
+}
+
+//checkOrder raises a LockOrderError if thread acquiring lock inverts the order of an
+//earlier acquisition, when lockdep is on
+func (it *Interpreter) checkOrder(lock *values.Lock, thread int64) {
+	if it.lockdep == nil {
+		return
+	}
+	if held := it.lockdep.Acquiring(thread, lock); held != nil {
+		panic(builtins.LockOrderError{Acquiring: lock.Name, Held: held.Name})
+	}
+}
+
+//VisitStatSema visits AST node ast.StatSema
+func (it *Interpreter) VisitStatSema(node ast.StatSema, ctx *values.Frame) values.Control {
+	sema := dereference(it.VisitIdent(*node.GetIdent(), ctx)).(*values.Sema)
+	if node.IsUp() {
+		sema.Up()
+	} else {
+		sema.Down()
+	}
+	return values.Next
+}
+
+//VisitStatCond waits on a condition variable, releasing the lock while waiting like
+//pthread_cond_wait does with an error checking mutex, or wakes up the threads waiting.
+//The lock is reacquired after the other locks the thread holds, so waiting checks that
+//order like acquiring it would
+func (it *Interpreter) VisitStatCond(node ast.StatCond, ctx *values.Frame) values.Control {
+	cond := dereference(it.VisitIdent(*node.GetCond(), ctx)).(*values.Cond)
+	switch node.GetType() {
+	case ast.Wait:
+		lock := dereference(it.VisitIdent(*node.GetLock(), ctx)).(*values.Lock)
+		it.checkOrder(lock, ctx.Thread)
+		if !cond.Wait(lock, ctx.Thread) {
+			panic(builtins.InvalidThreadUnlockError)
+		}
+	case ast.Signal:
+		cond.Signal()
+	case ast.Broadcast:
+		cond.Broadcast()
+	}
+	return values.Next
+}
+
+//VisitStatWith holds the lock while the body runs, releasing it when the body falls
+//through or returns
+func (it *Interpreter) VisitStatWith(node ast.StatWith, ctx *values.Frame) values.Control {
+	lock := dereference(it.VisitIdent(*node.GetIdent(), ctx)).(*values.Lock)
+	it.acquire(lock, ctx.Thread)
+	ctl := it.VisitStatement(node.GetStat(), ctx)
+	it.release(lock, ctx.Thread)
+	return ctl
+}
@@ -0,0 +1,227 @@
+package interpreter
+
+import (
+	"fmt"
+	"math"
+	"wacc_32/assembly/builtins"
+	"wacc_32/ast"
+	"wacc_32/interpreter/values"
+	"wacc_32/symboltable"
+	"wacc_32/types"
+)
+
+//VisitExpression visits AST node ast.Expression
+func (it *Interpreter) VisitExpression(node ast.Expression, ctx *values.Frame) values.Value {
+	return node.(ast.ValueAcceptor).AcceptValue(it, ctx)
+}
+
+//VisitRHS visits AST node ast.RHS
+func (it *Interpreter) VisitRHS(node ast.RHS, ctx *values.Frame) values.Value {
+	return it.VisitExpression(node, ctx)
+}
+
+//checkOverflow raises an overflow error if n doesn't fit in a 4-byte signed integer
+func checkOverflow(n int64) values.Value {
+	if n < math.MinInt32 || n > math.MaxInt32 {
+		panic(builtins.IntegerOverflowError)
+	}
+	return int32(n)
+}
+
+//VisitUnOp visits AST node ast.UnOp
+func (it *Interpreter) VisitUnOp(node ast.UnOp, ctx *values.Frame) values.Value {
+	v := it.VisitExpression(node.GetExpr(), ctx)
+	switch node.GetOpType() {
+	case ast.Not:
+		return !v.(bool)
+	case ast.Len:
+		return int32(len(dereference(v).(*values.Array).Elems))
+	case ast.Ord:
+		return int32(v.(byte))
+	case ast.Chr:
+		return byte(v.(int32))
+	case ast.Neg:
+		return checkOverflow(-int64(v.(int32)))
+	case ast.TryLock:
+		//A trylock can't deadlock so it doesn't order the locks held
+		lock := dereference(v).(*values.Lock)
+		ok := lock.TryAcquire(ctx.Thread)
+		if ok && it.lockdep != nil {
+			it.lockdep.Acquired(ctx.Thread, lock)
+		}
+		return ok
+	case ast.Join:
+		return dereference(v).(*values.Future).Join()
+	}
+	return v
+}
+
+//ordinal returns the value of an int or a char so they can be compared
+func ordinal(v values.Value) int64 {
+	if c, ok := v.(byte); ok {
+		return int64(c)
+	}
+	return int64(v.(int32))
+}
+
+//VisitBinOp evaluates both sides, the code generator doesn't short circuit && and ||
+func (it *Interpreter) VisitBinOp(node ast.BinOp, ctx *values.Frame) values.Value {
+	l := it.VisitExpression(node.GetLeftExpr(), ctx)
+	r := it.VisitExpression(node.GetRightExpr(), ctx)
+
+	switch node.GetOpType() {
+	case ast.Star:
+		return checkOverflow(ordinal(l) * ordinal(r))
+	case ast.Div:
+		if r.(int32) == 0 {
+			panic(builtins.DivideByZeroError)
+		}
+		return l.(int32) / r.(int32)
+	case ast.Mod:
+		if r.(int32) == 0 {
+			panic(builtins.DivideByZeroError)
+		}
+		return l.(int32) % r.(int32)
+	case ast.Plus:
+		return checkOverflow(ordinal(l) + ordinal(r))
+	case ast.Minus:
+		return checkOverflow(ordinal(l) - ordinal(r))
+	case ast.Greater:
+		return ordinal(l) > ordinal(r)
+	case ast.GreaterEq:
+		return ordinal(l) >= ordinal(r)
+	case ast.Less:
+		return ordinal(l) < ordinal(r)
+	case ast.LessEq:
+		return ordinal(l) <= ordinal(r)
+	case ast.Equal:
+		return l == r
+	case ast.NotEq:
+		return l != r
+	case ast.And:
+		return l.(bool) && r.(bool)
+	case ast.Or:
+		return l.(bool) || r.(bool)
+	}
+	panic(fmt.Sprintf("unknown binary operator %s", node.GetOpType()))
+}
+
+//VisitTernaryOp only evaluates the chosen branch
+func (it *Interpreter) VisitTernaryOp(node ast.TernaryOp, ctx *values.Frame) values.Value {
+	if it.VisitExpression(node.GetCondition(), ctx).(bool) {
+		return it.VisitExpression(node.GetIfExpr(), ctx)
+	}
+	return it.VisitExpression(node.GetElseExpr(), ctx)
+}
+
+//VisitRHSNewPair visits AST node ast.RHSNewPair
+func (it *Interpreter) VisitRHSNewPair(node ast.RHSNewPair, ctx *values.Frame) values.Value {
+	fst := it.VisitExpression(node.GetExpr(0), ctx)
+	snd := it.VisitExpression(node.GetExpr(1), ctx)
+	return values.NewPair(fst, snd)
+}
+
+//VisitMake creates an array of uninitialised elements
+func (it *Interpreter) VisitMake(node ast.Make, ctx *values.Frame) values.Value {
+	length := it.VisitExpression(node.GetLengthExpression(), ctx).(int32)
+	if length < 0 {
+		panic(builtins.ArrayIndexNegativeError)
+	}
+	elemType := types.Substitute(node.EvalType(symboltable.SymbolTable{}), ctx.Types).GetChildren()[0]
+	elems := make([]values.Value, length)
+	for i := range elems {
+		elems[i] = values.Zero(elemType)
+	}
+	return values.NewArray(elems)
+}
+
+//VisitPairElem visits AST node ast.PairElem
+func (it *Interpreter) VisitPairElem(node ast.PairElem, ctx *values.Frame) values.Value {
+	return *it.pairElemReference(node, ctx)
+}
+
+//VisitArrayElem visits AST node ast.ArrayElem
+func (it *Interpreter) VisitArrayElem(node ast.ArrayElem, ctx *values.Frame) values.Value {
+	return *it.arrayElemReference(node, ctx)
+}
+
+//VisitIdent visits AST node ast.Ident
+func (it *Interpreter) VisitIdent(node ast.Ident, ctx *values.Frame) values.Value {
+	return *it.identReference(node, ctx)
+}
+
+//dereference raises a null reference error if v is null
+func dereference(v values.Value) values.Value {
+	if v == nil {
+		panic(builtins.NullPointerReferenceError)
+	}
+	return v
+}
+
+//reference returns the memory an assignable expression refers to
+func (it *Interpreter) reference(lhs ast.Expression, ctx *values.Frame) *values.Value {
+	switch node := lhs.(type) {
+	case *ast.Ident:
+		return it.identReference(*node, ctx)
+	case *ast.ArrayElem:
+		return it.arrayElemReference(*node, ctx)
+	case *ast.PairElem:
+		return it.pairElemReference(*node, ctx)
+	}
+	panic(fmt.Sprintf("%s can't be assigned to", lhs))
+}
+
+//identReference returns a variable, or a field of a class or struct
+func (it *Interpreter) identReference(node ast.Ident, ctx *values.Frame) *values.Value {
+	table := node.GetSymbolTable()
+	if node.IsFunction() {
+		var v values.Value = values.NewClosure(it.funcs[node.GetName()], nil)
+		return &v
+	}
+	if !node.IsNamespaced() {
+		return ctx.Lookup(table, node.GetName())
+	}
+
+	components := node.GetNameComponents()
+	scope, _ := table.Find(components[0])
+	ref := ctx.Lookup(scope, components[0])
+	t, _ := table.GetType(components[0])
+	for _, fieldName := range components[1:] {
+		uType, _ := ast.LookupUserType(t.(types.UserType), *table)
+		fieldNames := uType.GetFieldNames()
+		for i := range fieldNames {
+			if fieldNames[i] == fieldName {
+				ref = &dereference(*ref).(*values.Struct).Fields[i]
+				t = uType.GetFieldTypes()[i]
+				break
+			}
+		}
+	}
+	return ref
+}
+
+//arrayElemReference checks every index against the length of its dimension
+func (it *Interpreter) arrayElemReference(node ast.ArrayElem, ctx *values.Frame) *values.Value {
+	ref := it.identReference(*node.GetIdent(), ctx)
+	for _, expr := range node.GetIndices() {
+		arr := dereference(*ref).(*values.Array)
+		index := it.VisitExpression(expr, ctx).(int32)
+		if index < 0 {
+			panic(builtins.ArrayIndexNegativeError)
+		}
+		if int(index) >= len(arr.Elems) {
+			panic(builtins.ArrayIndexTooLargeError)
+		}
+		ref = &arr.Elems[index]
+	}
+	return ref
+}
+
+//pairElemReference returns the fst or snd element of a pair
+func (it *Interpreter) pairElemReference(node ast.PairElem, ctx *values.Frame) *values.Value {
+	pair := dereference(it.VisitExpression(node.GetValue(), ctx)).(*values.Pair)
+	if node.GetPairElemPos() == ast.FST {
+		return &pair.Fst
+	}
+	return &pair.Snd
+}
@@ -0,0 +1,94 @@
+package interpreter
+
+import (
+	"wacc_32/ast"
+	"wacc_32/interpreter/values"
+	"wacc_32/symboltable"
+)
+
+//VisitFunction runs the body of a function in a frame which already holds its arguments
+func (it *Interpreter) VisitFunction(node ast.Function, ctx *values.Frame) values.Control {
+	return it.run(node.GetStats(), ctx)
+}
+
+//run runs the statements of a function body until one of them returns
+func (it *Interpreter) run(stats []ast.Statement, ctx *values.Frame) values.Control {
+	for _, stat := range stats {
+		if ctl := it.VisitStatement(stat, ctx); ctl.Return {
+			return ctl
+		}
+	}
+	return values.Next
+}
+
+//VisitLambda creates a closure holding the current values of the variables the lambda captures
+func (it *Interpreter) VisitLambda(node ast.Lambda, ctx *values.Frame) values.Value {
+	env := values.NewFrame(ctx.Thread)
+	env.Types = ctx.Types
+	for _, c := range node.GetCaptures() {
+		env.Declare(c.Scope, c.Name, *ctx.Lookup(c.Scope, c.Name))
+	}
+	return values.NewClosure(&node, env)
+}
+
+//VisitParamList visits AST node ast.ParamList
+func (it *Interpreter) VisitParamList(node ast.ParamList, ctx *values.Frame) values.Control {
+	return values.Next
+}
+
+//VisitParam visits AST node ast.Param
+func (it *Interpreter) VisitParam(node ast.Param, ctx *values.Frame) values.Control {
+	return values.Next
+}
+
+//VisitStatReturn visits AST node ast.StatReturn
+func (it *Interpreter) VisitStatReturn(node ast.StatReturn, ctx *values.Frame) values.Control {
+	return values.NewReturn(it.VisitExpression(node.GetReturnExpr(), ctx))
+}
+
+//VisitRHSFunctionCall calls a function and returns its result
+func (it *Interpreter) VisitRHSFunctionCall(node ast.RHSFunctionCall, ctx *values.Frame) values.Value {
+	stats, frame := it.prepareCall(node, ctx)
+	return it.run(stats, frame).Value
+}
+
+//prepareCall evaluates the arguments of a call and binds them to the parameters in a new
+//frame, returning the body to run in it. A call through a function value starts with the
+//variables its lambda captured
+func (it *Interpreter) prepareCall(node ast.RHSFunctionCall, ctx *values.Frame) ([]ast.Statement, *values.Frame) {
+	args := it.visitExpressions(node.GetArgs(), ctx)
+
+	var code interface{}
+	frame := values.NewFrame(ctx.Thread)
+	if ident := node.GetClosure(); ident != nil {
+		closure := dereference(it.VisitIdent(*ident, ctx)).(*values.Closure)
+		code = closure.Code
+		if closure.Env != nil {
+			frame = closure.Env.Copy(ctx.Thread)
+		}
+	} else {
+		name, _ := node.FormatName()
+		if node.IsVirtual() {
+			//The method runs as the class the object was created as implements it
+			_, method, _ := node.GetMethod()
+			this := dereference(args[len(args)-1]).(*values.Struct)
+			name = it.classes[this.Class].Implementation(method)
+		}
+		code = it.funcs[name]
+		frame.Types = node.GetTypeArgs().Compose(ctx.Types)
+	}
+
+	var params ast.ParamList
+	var scope *symboltable.SymbolTable
+	var stats []ast.Statement
+	switch fn := code.(type) {
+	case *ast.Function:
+		params, scope, stats = fn.GetParams(), fn.GetSymbolTable(), fn.GetStats()
+	case *ast.Lambda:
+		params, scope, stats = fn.GetParams(), fn.GetScope(), fn.GetStats()
+	}
+	for i, arg := range args {
+		frame.Declare(scope, params[i].GetName(), arg)
+	}
+	return stats, frame
+}
@@ -0,0 +1,88 @@
+package interpreter
+
+import (
+	"strconv"
+	"wacc_32/interpreter/values"
+	"wacc_32/types"
+)
+
+//isSpace matches the whitespace skipped by scanf
+func isSpace(c byte) bool {
+	return c == ' ' || ('\t' <= c && c <= '\r')
+}
+
+//skipSpace consumes whitespace and returns the next byte without consuming it
+func (it *Interpreter) skipSpace() (byte, bool) {
+	for {
+		c, err := it.in.ReadByte()
+		if err != nil {
+			return 0, false
+		}
+		if !isSpace(c) {
+			it.in.UnreadByte()
+			return c, true
+		}
+	}
+}
+
+//read parses a value of type wt from the input the same way scanf does
+//It returns false if nothing could be read, leaving the variable unchanged
+func (it *Interpreter) read(wt types.WaccType) (values.Value, bool) {
+	it.inMu.Lock()
+	defer it.inMu.Unlock()
+
+	if _, ok := it.skipSpace(); !ok {
+		return nil, false
+	}
+	switch {
+	case wt.Is(types.Char):
+		c, _ := it.in.ReadByte()
+		return c, true
+	case wt.Is(types.Integer):
+		return it.readInt()
+	}
+	return it.readWord(), true
+}
+
+//readInt reads an optionally signed decimal integer, truncating it to 4 bytes
+func (it *Interpreter) readInt() (values.Value, bool) {
+	digits := ""
+	if c, _ := it.in.ReadByte(); c == '-' || c == '+' {
+		digits += string(c)
+	} else {
+		it.in.UnreadByte()
+	}
+	for {
+		c, err := it.in.ReadByte()
+		if err != nil {
+			break
+		}
+		if c < '0' || c > '9' {
+			it.in.UnreadByte()
+			break
+		}
+		digits += string(c)
+	}
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	return int32(n), true
+}
+
+//readWord reads characters up to the next whitespace
+func (it *Interpreter) readWord() values.Value {
+	word := ""
+	for {
+		c, err := it.in.ReadByte()
+		if err != nil {
+			break
+		}
+		if isSpace(c) {
+			it.in.UnreadByte()
+			break
+		}
+		word += string(c)
+	}
+	return values.NewString(word)
+}
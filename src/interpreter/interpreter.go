@@ -0,0 +1,123 @@
+package interpreter
+
+import (
+	"bufio"
+	"io"
+	"sync"
+	"sync/atomic"
+	"wacc_32/assembly/builtins"
+	"wacc_32/ast"
+	"wacc_32/interpreter/values"
+)
+
+//go:generate ./../visitor_generator/visitor_generator.sh values.Control values.Value *values.Frame
+
+var _ ast.ControlValueVisitor = &Interpreter{}
+
+//runtimeErrorCode is the exit code of a program which hits a runtime error
+const runtimeErrorCode = 255
+
+//exit is raised to stop the whole program with an exit code
+type exit int
+
+//Interpreter executes a semantically checked AST without generating any code
+type Interpreter struct {
+	funcs   map[string]*ast.Function
+	classes map[string]*ast.UserType
+	threads int64
+	exit    chan int
+	lockdep *values.LockOrder //The order locks are acquired in, nil unless checked
+
+	outMu  sync.Mutex
+	out    io.Writer
+	exited bool
+
+	inMu sync.Mutex
+	in   *bufio.Reader
+}
+
+//NewInterpreter creates an interpreter which reads from in and prints to out
+func NewInterpreter(in io.Reader, out io.Writer) *Interpreter {
+	return &Interpreter{
+		funcs:   make(map[string]*ast.Function),
+		classes: make(map[string]*ast.UserType),
+		exit:  make(chan int, 1),
+		out:   out,
+		in:    bufio.NewReader(in),
+	}
+}
+
+//SetLockdep stops the program with a runtime error when a thread acquires two locks
+//in the opposite order to an earlier acquisition
+func (it *Interpreter) SetLockdep() {
+	it.lockdep = values.NewLockOrder()
+}
+
+//Run executes the program and returns its exit code
+//It returns as soon as main finishes, even if other wacc routines are running
+func (it *Interpreter) Run(tree ast.AST) int {
+	go it.thread(func(thread int64) {
+		it.VisitAST(tree, values.NewFrame(thread))
+		panic(exit(0))
+	})
+	return <-it.exit
+}
+
+//thread runs f in a new thread, stopping the program on exit or on a runtime error
+func (it *Interpreter) thread(f func(thread int64)) {
+	defer func() {
+		switch r := recover().(type) {
+		case nil:
+		case exit:
+			it.stop(int(r))
+		case builtins.RuntimeErrType:
+			it.print(r.Error())
+			it.stop(runtimeErrorCode)
+		case builtins.LockOrderError:
+			it.print(r.Error())
+			it.stop(runtimeErrorCode)
+		default:
+			panic(r)
+		}
+	}()
+	f(atomic.AddInt64(&it.threads, 1))
+}
+
+//stop reports the exit code of the program, only the first one is kept
+func (it *Interpreter) stop(code int) {
+	it.outMu.Lock()
+	defer it.outMu.Unlock()
+	if it.exited {
+		return
+	}
+	it.exited = true
+	it.exit <- code & 0xFF
+}
+
+//print writes str to the output unless the program has already stopped
+func (it *Interpreter) print(str string) {
+	it.outMu.Lock()
+	defer it.outMu.Unlock()
+	if !it.exited {
+		io.WriteString(it.out, str)
+	}
+}
+
+//VisitProgram registers all functions and runs main
+func (it *Interpreter) VisitProgram(node ast.Program, ctx *values.Frame) values.Control {
+	for _, st := range node.GetStructs() {
+		it.VisitUserType(*st, ctx)
+	}
+
+	for _, fn := range node.GetFuncs() {
+		it.funcs[fn.GetInternalName()] = fn
+	}
+
+	return it.VisitFunction(*it.funcs["0main"], ctx)
+}
+
+//VisitUserType registers a struct or class, whose methods calls look up at runtime
+func (it *Interpreter) VisitUserType(node ast.UserType, ctx *values.Frame) values.Control {
+	it.classes[node.GetName()] = &node
+	return values.Next
+}
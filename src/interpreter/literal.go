@@ -0,0 +1,84 @@
+package interpreter
+
+import (
+	"wacc_32/ast"
+	"wacc_32/interpreter/values"
+	"wacc_32/symboltable"
+	"wacc_32/types"
+)
+
+var escapeCodes = map[byte]byte{
+	'\\': '\\',
+	't':  '\t',
+	'n':  '\n',
+	'b':  '\b',
+	'f':  '\f',
+	'r':  '\r',
+	'"':  '"',
+	'\'': '\'',
+	'0':  byte(0),
+}
+
+//unescape removes the quotes around a char or string literal and replaces its escape sequences
+func unescape(literal string) string {
+	if len(literal) < 2 {
+		return literal
+	}
+	str := make([]byte, 0, len(literal)-2)
+	for i := 1; i < len(literal)-1; i++ {
+		c := literal[i]
+		if c == '\\' {
+			i++
+			c = escapeCodes[literal[i]]
+		}
+		str = append(str, c)
+	}
+	return string(str)
+}
+
+//VisitLiteral visits AST node ast.Literal
+func (it *Interpreter) VisitLiteral(node ast.Literal, ctx *values.Frame) values.Value {
+	wt := node.EvalType(symboltable.SymbolTable{})
+	if node.GetValue() == nil {
+		//An uninitialised variable of a type parameter
+		return values.Zero(types.Substitute(wt, ctx.Types))
+	}
+	if wt.Is(types.Array) {
+		return values.NewArray(it.visitExpressions(node.GetValue().([]ast.Expression), ctx))
+	}
+
+	if wt.Is(types.UserDefinedType) {
+		fields, ok := node.GetValue().([]ast.Expression)
+		if !ok {
+			return nil //An uninitialised field
+		}
+		return values.NewStruct(wt.(types.UserType).GetName(), it.visitExpressions(fields, ctx))
+	}
+
+	switch wt {
+	case types.Integer:
+		return int32(node.GetValue().(int))
+	case types.Boolean:
+		return node.GetValue().(bool)
+	case types.Char:
+		char := unescape(node.GetValue().(string))
+		if char == "" {
+			return byte(0)
+		}
+		return char[0]
+	case types.Str:
+		return values.NewString(unescape(node.GetValue().(string)))
+	case types.Sema:
+		return values.NewSema(node.GetValue().(int))
+	}
+	return nil
+}
+
+//visitExpressions evaluates the elements of an array literal or the fields of a struct literal in order
+func (it *Interpreter) visitExpressions(exprs []ast.Expression, ctx *values.Frame) []values.Value {
+	elems := make([]values.Value, len(exprs))
+	for i, expr := range exprs {
+		elems[i] = it.VisitExpression(expr, ctx)
+	}
+	return elems
+}
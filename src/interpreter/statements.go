@@ -0,0 +1,169 @@
+package interpreter
+
+import (
+	"strconv"
+	"wacc_32/assembly/builtins"
+	"wacc_32/ast"
+	"wacc_32/interpreter/values"
+	"wacc_32/symboltable"
+	"wacc_32/types"
+)
+
+//VisitAST visits AST node ast.AST
+func (it *Interpreter) VisitAST(node ast.AST, ctx *values.Frame) values.Control {
+	return node.(ast.ControlAcceptor).AcceptControl(it, ctx)
+}
+
+//VisitStatement visits AST node ast.Statement
+func (it *Interpreter) VisitStatement(node ast.Statement, ctx *values.Frame) values.Control {
+	return it.VisitAST(node, ctx)
+}
+
+//VisitStatSkip visits AST node ast.StatSkip
+func (it *Interpreter) VisitStatSkip(node ast.StatSkip, ctx *values.Frame) values.Control {
+	return values.Next
+}
+
+//VisitStatRead reads an int, char or string from the input into the lhs
+func (it *Interpreter) VisitStatRead(node ast.StatRead, ctx *values.Frame) values.Control {
+	toRead := node.GetToRead()
+	ref := it.reference(toRead, ctx)
+	if v, ok := it.read(types.Substitute(toRead.EvalType(*node.GetSymbolTable()), ctx.Types)); ok {
+		*ref = v
+	}
+	return values.Next
+}
+
+//VisitStatFree visits AST node ast.StatFree
+//Memory is garbage collected, but freeing null is still a runtime error
+func (it *Interpreter) VisitStatFree(node ast.StatFree, ctx *values.Frame) values.Control {
+	if it.VisitExpression(node.GetExpression(), ctx) == nil {
+		panic(builtins.NullPointerReferenceError)
+	}
+	return values.Next
+}
+
+//VisitStatNewassign declares a variable, locks and condition variables are always
+//created fresh
+func (it *Interpreter) VisitStatNewassign(node ast.StatNewassign, ctx *values.Frame) values.Control {
+	v := it.VisitRHS(node.GetRHS(), ctx)
+	if node.GetType().Is(types.Lock) {
+		lock := values.NewLock()
+		lock.Name = node.GetName()
+		v = lock
+	} else if node.GetType().Is(types.Cond) {
+		v = values.NewCond()
+	}
+	ctx.Declare(node.GetSymbolTable(), node.GetName(), v)
+	return values.Next
+}
+
+type printer interface {
+	GetExprToPrint() ast.Expression
+	GetSymbolTable() *symboltable.SymbolTable
+}
+
+//visitPrinter formats the printed expression according to its static type, like printf would
+func (it *Interpreter) visitPrinter(node printer, ctx *values.Frame) string {
+	toPrint := node.GetExprToPrint()
+	v := it.VisitExpression(toPrint, ctx)
+	printType := types.Substitute(toPrint.EvalType(*node.GetSymbolTable()), ctx.Types)
+
+	isArr := printType.Is(types.Array)
+	switch {
+	case isArr && printType.GetChildren()[0].Is(types.Char):
+		return v.(*values.Array).String()
+	case isArr || printType.Is(types.Pair):
+		return values.Format(v)
+	case printType.Is(types.Str):
+		return v.(*values.Array).String()
+	case printType.Is(types.Char):
+		return string([]byte{v.(byte)})
+	case printType.Is(types.Boolean):
+		return strconv.FormatBool(v.(bool))
+	case printType.Is(types.Integer):
+		return strconv.Itoa(int(v.(int32)))
+	}
+	return values.Format(v)
+}
+
+//VisitStatPrint visits AST node ast.StatPrint
+func (it *Interpreter) VisitStatPrint(node ast.StatPrint, ctx *values.Frame) values.Control {
+	it.print(it.visitPrinter(node, ctx))
+	return values.Next
+}
+
+//VisitStatPrintln visits AST node ast.StatPrintln
+func (it *Interpreter) VisitStatPrintln(node ast.StatPrintln, ctx *values.Frame) values.Control {
+	it.print(it.visitPrinter(node, ctx) + "\n")
+	return values.Next
+}
+
+//VisitStatExit stops the whole program
+func (it *Interpreter) VisitStatExit(node ast.StatExit, ctx *values.Frame) values.Control {
+	panic(exit(it.VisitExpression(node.GetCode(), ctx).(int32)))
+}
+
+//VisitStatFor visits AST node ast.StatFor
+func (it *Interpreter) VisitStatFor(node ast.StatFor, ctx *values.Frame) values.Control {
+	it.VisitStatNewassign(node.GetInitial(), ctx)
+	for it.VisitExpression(node.GetCond(), ctx).(bool) {
+		if ctl := it.VisitStatement(node.GetBody(), ctx); ctl.Return {
+			return ctl
+		}
+		it.VisitStatAssign(node.GetChange(), ctx)
+	}
+	return values.Next
+}
+
+//VisitStatWhile visits AST node ast.StatWhile
+func (it *Interpreter) VisitStatWhile(node ast.StatWhile, ctx *values.Frame) values.Control {
+	for it.VisitExpression(node.GetCond(), ctx).(bool) {
+		if ctl := it.VisitStatement(node.GetBody(), ctx); ctl.Return {
+			return ctl
+		}
+	}
+	return values.Next
+}
+
+//VisitStatDoWhile visits AST node ast.StatDoWhile
+func (it *Interpreter) VisitStatDoWhile(node ast.StatDoWhile, ctx *values.Frame) values.Control {
+	for {
+		if ctl := it.VisitStatement(node.GetBody(), ctx); ctl.Return {
+			return ctl
+		}
+		if !it.VisitExpression(node.GetCond(), ctx).(bool) {
+			return values.Next
+		}
+	}
+}
+
+//VisitStatBegin visits AST node ast.StatBegin
+func (it *Interpreter) VisitStatBegin(node ast.StatBegin, ctx *values.Frame) values.Control {
+	return it.VisitStatement(node.GetStat(), ctx)
+}
+
+//VisitStatAssign evaluates the rhs before the lhs, like the code generator
+func (it *Interpreter) VisitStatAssign(node ast.StatAssign, ctx *values.Frame) values.Control {
+	v := it.VisitRHS(node.GetRHS(), ctx)
+	*it.reference(node.GetLHS(), ctx) = v
+	return values.Next
+}
+
+//VisitStatIf visits AST node ast.StatIf
+func (it *Interpreter) VisitStatIf(node ast.StatIf, ctx *values.Frame) values.Control {
+	if it.VisitExpression(node.GetCondition(), ctx).(bool) {
+		return it.VisitStatement(node.GetIfStat(), ctx)
+	}
+	return it.VisitStatement(node.GetElseStat(), ctx)
+}
+
+//VisitStatMultiple visits AST node ast.StatMultiple
+func (it *Interpreter) VisitStatMultiple(node ast.StatMultiple, ctx *values.Frame) values.Control {
+	for _, stat := range node {
+		if ctl := it.VisitStatement(stat, ctx); ctl.Return {
+			return ctl
+		}
+	}
+	return values.Next
+}
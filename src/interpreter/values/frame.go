@@ -0,0 +1,49 @@
+package values
+
+import (
+	"wacc_32/symboltable"
+	"wacc_32/types"
+)
+
+//slot identifies a variable by the scope it was declared in
+type slot struct {
+	scope *symboltable.SymbolTable
+	name  string
+}
+
+//Frame holds the variables of a single function call
+type Frame struct {
+	vars   map[slot]*Value
+	Thread int64
+	Types  types.Substitution //The types the type parameters of a generic function stand for
+}
+
+//NewFrame creates an empty frame for a function call running on thread
+func NewFrame(thread int64) *Frame {
+	return &Frame{
+		vars:   make(map[slot]*Value),
+		Thread: thread,
+	}
+}
+
+//Declare creates a new variable in scope, shadowing any previous declaration
+func (f *Frame) Declare(scope *symboltable.SymbolTable, name string, v Value) {
+	f.vars[slot{scope, name}] = &v
+}
+
+//Lookup returns a reference to the variable name declared in scope
+//Assumes that the variable has already been declared
+func (f *Frame) Lookup(scope *symboltable.SymbolTable, name string) *Value {
+	return f.vars[slot{scope, name}]
+}
+
+//Copy returns a new frame for a call running on thread, starting with the values of f's
+//variables. Writes to either frame aren't seen by the other
+func (f *Frame) Copy(thread int64) *Frame {
+	cp := NewFrame(thread)
+	cp.Types = f.Types
+	for s, v := range f.vars {
+		cp.Declare(s.scope, s.name, *v)
+	}
+	return cp
+}
@@ -0,0 +1,61 @@
+package values
+
+import "sync"
+
+//LockOrder records the locks each thread holds and which locks have been acquired
+//while holding which, to catch two locks being acquired in opposite orders
+type LockOrder struct {
+	mu    sync.Mutex
+	held  map[int64][]*Lock
+	after map[*Lock]map[*Lock]bool //after[a][b] if b was acquired while holding a
+}
+
+//NewLockOrder creates a LockOrder which has seen no acquisitions
+func NewLockOrder() *LockOrder {
+	return &LockOrder{
+		held:  make(map[int64][]*Lock),
+		after: make(map[*Lock]map[*Lock]bool),
+	}
+}
+
+//Acquiring records thread acquiring l after the locks it holds, it returns a lock the
+//thread holds which was acquired while holding l before, nil if there is none
+func (o *LockOrder) Acquiring(thread int64, l *Lock) *Lock {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, held := range o.held[thread] {
+		if held != l && o.after[l][held] {
+			return held
+		}
+	}
+	for _, held := range o.held[thread] {
+		if held == l {
+			continue
+		}
+		if o.after[held] == nil {
+			o.after[held] = make(map[*Lock]bool)
+		}
+		o.after[held][l] = true
+	}
+	return nil
+}
+
+//Acquired records thread holding l
+func (o *LockOrder) Acquired(thread int64, l *Lock) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.held[thread] = append(o.held[thread], l)
+}
+
+//Released records thread no longer holding l
+func (o *LockOrder) Released(thread int64, l *Lock) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	held := o.held[thread]
+	for i, h := range held {
+		if h == l {
+			o.held[thread] = append(held[:i:i], held[i+1:]...)
+			return
+		}
+	}
+}
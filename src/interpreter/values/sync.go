@@ -0,0 +1,234 @@
+package values
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+//Lock is an error checking mutex, it remembers which thread holds it
+//state is 1 while the lock is held and is only set by compare and swap, so a
+//try_lock never blocks. Threads acquiring a held lock wait on cond
+type Lock struct {
+	mu    sync.Mutex
+	cond  *sync.Cond
+	state int32
+	owner int64
+	Name  string //The variable the lock was declared as
+}
+
+//NewLock creates an unlocked lock
+func NewLock() *Lock {
+	l := &Lock{}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+//Acquire blocks until thread holds the lock
+//It returns false if thread already holds the lock (EDEADLK)
+func (l *Lock) Acquire(thread int64) bool {
+	if atomic.LoadInt64(&l.owner) == thread {
+		return false
+	}
+	l.mu.Lock()
+	for !atomic.CompareAndSwapInt32(&l.state, 0, 1) {
+		l.cond.Wait()
+	}
+	l.mu.Unlock()
+	atomic.StoreInt64(&l.owner, thread)
+	return true
+}
+
+//Release unlocks the lock
+//It returns false if thread doesn't hold the lock (EPERM)
+func (l *Lock) Release(thread int64) bool {
+	if atomic.LoadInt64(&l.owner) != thread {
+		return false
+	}
+	atomic.StoreInt64(&l.owner, 0)
+	//Clearing the state holding mu means a thread about to wait can't miss the signal
+	l.mu.Lock()
+	atomic.StoreInt32(&l.state, 0)
+	l.mu.Unlock()
+	l.cond.Signal()
+	return true
+}
+
+//TryAcquire acquires the lock without blocking, it returns whether it succeeded
+func (l *Lock) TryAcquire(thread int64) bool {
+	if !atomic.CompareAndSwapInt32(&l.state, 0, 1) {
+		return false
+	}
+	atomic.StoreInt64(&l.owner, thread)
+	return true
+}
+
+//Sema is a counting semaphore
+type Sema struct {
+	mu    sync.Mutex
+	cond  *sync.Cond
+	value int
+}
+
+//NewSema creates a semaphore with an initial value
+func NewSema(value int) *Sema {
+	s := &Sema{value: value}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+//Up increments the semaphore, waking up a waiting thread
+func (s *Sema) Up() {
+	s.mu.Lock()
+	s.value++
+	s.mu.Unlock()
+	s.cond.Signal()
+}
+
+//Down blocks until the semaphore is positive and then decrements it
+func (s *Sema) Down() {
+	s.mu.Lock()
+	for s.value == 0 {
+		s.cond.Wait()
+	}
+	s.value--
+	s.mu.Unlock()
+}
+
+//Cond is a condition variable, threads wait on it holding a lock which is released
+//until they are woken up
+type Cond struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+}
+
+//NewCond creates a condition variable no thread is waiting on
+func NewCond() *Cond {
+	c := &Cond{}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+//Wait releases lock, blocks until the thread is woken up and then reacquires lock
+//It returns false if thread doesn't hold the lock (EPERM)
+func (c *Cond) Wait(lock *Lock, thread int64) bool {
+	c.mu.Lock()
+	//Releasing the lock while holding mu means a signal can't be missed
+	if !lock.Release(thread) {
+		c.mu.Unlock()
+		return false
+	}
+	c.cond.Wait()
+	c.mu.Unlock()
+	lock.Acquire(thread)
+	return true
+}
+
+//Signal wakes up one of the threads waiting, if there are any
+func (c *Cond) Signal() {
+	c.mu.Lock()
+	c.cond.Signal()
+	c.mu.Unlock()
+}
+
+//Broadcast wakes up every thread waiting
+func (c *Cond) Broadcast() {
+	c.mu.Lock()
+	c.cond.Broadcast()
+	c.mu.Unlock()
+}
+
+//Future is the handle of a wacc routine, it holds the result once the routine returns
+type Future struct {
+	done  chan struct{}
+	value Value
+}
+
+//NewFuture creates the handle of a routine which hasn't returned yet
+func NewFuture() *Future {
+	return &Future{done: make(chan struct{})}
+}
+
+//Complete stores the result of the routine, waking up the threads joining it
+func (f *Future) Complete(value Value) {
+	f.value = value
+	close(f.done)
+}
+
+//Join blocks until the routine has returned and then returns its result
+func (f *Future) Join() Value {
+	<-f.done
+	return f.value
+}
+
+//Chan is a channel of values, buffering up to its capacity of them. A send on an
+//unbuffered channel waits until the value is received
+type Chan struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	buf      []Value
+	capacity int
+	closed   bool
+	sent     int //The number of values ever sent
+	received int //The number of values ever received
+}
+
+//NewChan creates an open channel with a capacity
+func NewChan(capacity int) *Chan {
+	c := &Chan{capacity: capacity}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+//Send blocks until there is room for value and adds it to the channel, then until it
+//is received if the channel is unbuffered. It returns false if the channel is closed
+func (c *Chan) Send(value Value) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	//An unbuffered channel has room for the one value being handed over
+	for !c.closed && len(c.buf) >= c.capacity && len(c.buf) > 0 {
+		c.cond.Wait()
+	}
+	if c.closed {
+		return false
+	}
+	c.buf = append(c.buf, value)
+	c.sent++
+	ticket := c.sent
+	c.cond.Broadcast()
+	for c.capacity == 0 && c.received < ticket {
+		c.cond.Wait()
+	}
+	return true
+}
+
+//Recv blocks until a value has been sent or the channel is closed, and returns the
+//first value sent and not yet received. It returns false if the channel is closed and
+//has no values left
+func (c *Chan) Recv() (Value, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for !c.closed && len(c.buf) == 0 {
+		c.cond.Wait()
+	}
+	if len(c.buf) == 0 {
+		return nil, false
+	}
+	value := c.buf[0]
+	c.buf = c.buf[1:]
+	c.received++
+	c.cond.Broadcast()
+	return value, true
+}
+
+//Close stops values being sent on the channel, waking up the threads waiting on it
+//It returns false if the channel is already closed
+func (c *Chan) Close() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return false
+	}
+	c.closed = true
+	c.cond.Broadcast()
+	return true
+}
@@ -0,0 +1,132 @@
+package values
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockCannotBeAcquiredTwiceBySameThread(t *testing.T) {
+	l := NewLock()
+	assert.True(t, l.Acquire(1))
+	assert.False(t, l.Acquire(1))
+}
+
+func TestLockCanOnlyBeReleasedByOwner(t *testing.T) {
+	l := NewLock()
+	assert.False(t, l.Release(1))
+	assert.True(t, l.Acquire(1))
+	assert.False(t, l.Release(2))
+	assert.True(t, l.Release(1))
+}
+
+func TestTryAcquireFailsOnHeldLock(t *testing.T) {
+	l := NewLock()
+	assert.True(t, l.TryAcquire(1))
+	assert.False(t, l.TryAcquire(1))
+	assert.False(t, l.TryAcquire(2))
+	assert.True(t, l.Release(1))
+	assert.True(t, l.TryAcquire(2))
+}
+
+func TestAcquireWaitsForRelease(t *testing.T) {
+	l := NewLock()
+	assert.True(t, l.TryAcquire(1))
+	done := make(chan struct{})
+	go func() {
+		assert.True(t, l.Acquire(2))
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("acquired a held lock")
+	case <-time.After(10 * time.Millisecond):
+	}
+	assert.True(t, l.Release(1))
+	<-done
+	assert.False(t, l.TryAcquire(1))
+	assert.True(t, l.Release(2))
+}
+
+func TestSemaDownWaitsForUp(t *testing.T) {
+	s := NewSema(0)
+	done := make(chan struct{})
+	go func() {
+		s.Down()
+		close(done)
+	}()
+	s.Up()
+	<-done
+	assert.Equal(t, 0, s.value)
+}
+
+func TestCondWaitReleasesLockUntilSignalled(t *testing.T) {
+	c, l := NewCond(), NewLock()
+	assert.False(t, c.Wait(l, 1))
+
+	assert.True(t, l.Acquire(1))
+	woken := make(chan struct{})
+	go func() {
+		//The waiter has released the lock once it can be acquired
+		assert.True(t, l.Acquire(2))
+		c.Signal()
+		assert.True(t, l.Release(2))
+		close(woken)
+	}()
+	assert.True(t, c.Wait(l, 1))
+	<-woken
+	assert.True(t, l.Release(1))
+}
+
+func TestJoinWaitsForResult(t *testing.T) {
+	f := NewFuture()
+	go f.Complete(int32(42))
+	assert.Equal(t, int32(42), f.Join())
+	assert.Equal(t, int32(42), f.Join())
+}
+
+func TestChanKeepsOrderAndDrainsAfterClose(t *testing.T) {
+	c := NewChan(2)
+	assert.True(t, c.Send(int32(1)))
+	assert.True(t, c.Send(int32(2)))
+	assert.True(t, c.Close())
+	assert.False(t, c.Send(int32(3)))
+	assert.False(t, c.Close())
+
+	for _, expected := range []int32{1, 2} {
+		v, ok := c.Recv()
+		assert.True(t, ok)
+		assert.Equal(t, expected, v)
+	}
+	_, ok := c.Recv()
+	assert.False(t, ok)
+}
+
+func TestUnbufferedSendWaitsForRecv(t *testing.T) {
+	c := NewChan(0)
+	sent := make(chan struct{})
+	go func() {
+		c.Send(int32(7))
+		close(sent)
+	}()
+	v, _ := c.Recv()
+	<-sent
+	assert.Equal(t, int32(7), v)
+	assert.Equal(t, 1, c.received)
+}
+
+func TestLockOrderCatchesInversion(t *testing.T) {
+	o := NewLockOrder()
+	a, b := NewLock(), NewLock()
+	assert.Nil(t, o.Acquiring(1, a))
+	o.Acquired(1, a)
+	assert.Nil(t, o.Acquiring(1, b))
+	o.Acquired(1, b)
+	o.Released(1, b)
+	o.Released(1, a)
+
+	assert.Nil(t, o.Acquiring(2, b))
+	o.Acquired(2, b)
+	assert.Same(t, b, o.Acquiring(2, a))
+}
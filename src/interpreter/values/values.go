@@ -0,0 +1,121 @@
+package values
+
+import (
+	"fmt"
+	"wacc_32/types"
+)
+
+//Value is the runtime representation of a wacc value
+//int    -> int32
+//bool   -> bool
+//char   -> byte
+//string -> *Array of bytes
+//arrays -> *Array
+//pairs  -> *Pair
+//classes and structs -> *Struct
+//lock   -> *Lock
+//sema   -> *Sema
+//future -> *Future
+//chan   -> *Chan
+//cond   -> *Cond
+//functions -> *Closure
+//null references are an untyped nil
+type Value interface{}
+
+//Control tells the interpreter what to do after a statement
+type Control struct {
+	Return bool
+	Value  Value
+}
+
+//Next continues with the following statement
+var Next = Control{}
+
+//NewReturn stops the current function and returns value
+func NewReturn(value Value) Control {
+	return Control{Return: true, Value: value}
+}
+
+//Array is a heap allocated array, strings are arrays of chars
+type Array struct {
+	Elems []Value
+}
+
+//NewArray creates an array containing elems
+func NewArray(elems []Value) *Array {
+	return &Array{Elems: elems}
+}
+
+//NewString creates an array of chars containing str
+func NewString(str string) *Array {
+	elems := make([]Value, len(str))
+	for i := 0; i < len(str); i++ {
+		elems[i] = str[i]
+	}
+	return NewArray(elems)
+}
+
+//String returns the characters of a char array
+func (a *Array) String() string {
+	str := make([]byte, len(a.Elems))
+	for i, c := range a.Elems {
+		str[i], _ = c.(byte)
+	}
+	return string(str)
+}
+
+//Pair is a heap allocated pair
+type Pair struct {
+	Fst, Snd Value
+}
+
+//NewPair creates a pair of fst and snd
+func NewPair(fst, snd Value) *Pair {
+	return &Pair{Fst: fst, Snd: snd}
+}
+
+//Struct is a heap allocated struct or class, fields are stored in declaration order.
+//Class is the struct or class it was created as
+type Struct struct {
+	Class  string
+	Fields []Value
+}
+
+//NewStruct creates an object of the struct or class with the given fields
+func NewStruct(class string, fields []Value) *Struct {
+	return &Struct{Class: class, Fields: fields}
+}
+
+//Closure is a heap allocated function value, Code is the *ast.Function or *ast.Lambda it
+//runs and Env holds the variables a lambda captured, nil for named functions
+type Closure struct {
+	Code interface{}
+	Env  *Frame
+}
+
+//NewClosure creates a function value running code with the captured variables in env
+func NewClosure(code interface{}, env *Frame) *Closure {
+	return &Closure{Code: code, Env: env}
+}
+
+//Zero returns the value of memory of type wt which hasn't been written to
+func Zero(wt types.WaccType) Value {
+	switch wt {
+	case types.Integer:
+		return int32(0)
+	case types.Boolean:
+		return false
+	case types.Char:
+		return byte(0)
+	default:
+		return nil
+	}
+}
+
+//Format returns the printf %p representation of a reference
+func Format(v Value) string {
+	if v == nil {
+		return "(nil)"
+	}
+	return fmt.Sprintf("%p", v)
+}
@@ -0,0 +1,227 @@
+package ir
+
+import (
+	"wacc_32/ast"
+	"wacc_32/ir/tac"
+	"wacc_32/types"
+)
+
+//A channel is a lock and a condition variable, broadcast whenever the channel changes,
+//followed by a ring buffer of slots, one per value. An unbuffered channel has a single
+//slot and a send waits until its value has been received. Every field is a pointer
+//size slot, counts are words
+const (
+	chanLock = iota
+	chanCond
+	chanBuf
+	chanCapacity
+	chanSlots
+	chanCount
+	chanHead
+	chanClosed
+	chanSent
+	chanReceived
+	chanSize
+)
+
+//channel holds the operands shared by the code of an operation on a channel
+type channel struct {
+	ptr        tac.Operand
+	lock, cond tac.Temp
+}
+
+//field returns the offset of a field of a channel
+func field(f int) int {
+	return f * int(types.PointerSize())
+}
+
+//VisitMakeChan allocates an open channel and its buffer
+func (g *Generator) VisitMakeChan(node ast.MakeChan, ctx *tac.Builder) tac.Operand {
+	capacity := g.VisitExpression(node.GetCapacity(), ctx)
+	ctx.Emit(tac.Check{Kind: tac.CapacityCheck, Args: []tac.Operand{capacity}})
+	ptr := types.PointerSize()
+	ch := g.malloc(tac.Imm(chanSize*ptr), ctx)
+	lock, cond := ctx.NewTemp(ptr), ctx.NewTemp(ptr)
+	ctx.Emit(tac.NewLock{Dst: lock})
+	ctx.Emit(tac.NewCond{Dst: cond})
+	ctx.Emit(tac.Store{Src: lock, Addr: ch, Offset: field(chanLock), Size: ptr})
+	ctx.Emit(tac.Store{Src: cond, Addr: ch, Offset: field(chanCond), Size: ptr})
+
+	slots := ctx.NewTemp(types.Word)
+	ctx.Emit(tac.Move{Dst: slots, Src: capacity})
+	unbufferedBlock, bufBlock := ctx.NewBlock(), ctx.NewBlock()
+	ctx.Terminate(tac.Branch{Cond: capacity, Then: bufBlock, Else: unbufferedBlock})
+	ctx.SetBlock(unbufferedBlock)
+	ctx.Emit(tac.Move{Dst: slots, Src: tac.Imm(1)})
+	ctx.Jump(bufBlock)
+
+	ctx.SetBlock(bufBlock)
+	size := ctx.NewTemp(types.Word)
+	ctx.Emit(tac.Index{Dst: size, Base: tac.Imm(0), Index: slots, Scale: int(ptr)})
+	buf := g.malloc(size, ctx)
+	ctx.Emit(tac.Store{Src: buf, Addr: ch, Offset: field(chanBuf), Size: ptr})
+	ctx.Emit(tac.Store{Src: capacity, Addr: ch, Offset: field(chanCapacity), Size: types.Word})
+	ctx.Emit(tac.Store{Src: slots, Addr: ch, Offset: field(chanSlots), Size: types.Word})
+	for _, f := range []int{chanCount, chanHead, chanClosed, chanSent, chanReceived} {
+		ctx.Emit(tac.Store{Src: tac.Imm(0), Addr: ch, Offset: field(f), Size: types.Word})
+	}
+	return ch
+}
+
+//lockChannel acquires the lock of the channel ptr points to
+func (g *Generator) lockChannel(ptr tac.Operand, ctx *tac.Builder) channel {
+	size := types.PointerSize()
+	ch := channel{ptr: ptr, lock: ctx.NewTemp(size), cond: ctx.NewTemp(size)}
+	ctx.Emit(tac.Check{Kind: tac.NullCheck, Args: []tac.Operand{ch.ptr}})
+	ctx.Emit(tac.Load{Dst: ch.lock, Addr: ch.ptr, Offset: field(chanLock), Size: size})
+	ctx.Emit(tac.Load{Dst: ch.cond, Addr: ch.ptr, Offset: field(chanCond), Size: size})
+	ctx.Emit(tac.Call{Dst: tac.NoTemp, Func: "pthread_mutex_lock", Args: []tac.Operand{ch.lock}, C: true})
+	return ch
+}
+
+//load reads a word field of the channel
+func (ch channel) load(f int, ctx *tac.Builder) tac.Temp {
+	t := ctx.NewTemp(types.Word)
+	ctx.Emit(tac.Load{Dst: t, Addr: ch.ptr, Offset: field(f), Size: types.Word})
+	return t
+}
+
+//increment adds one to a word field of the channel, returning its new value
+func (ch channel) increment(f int, ctx *tac.Builder) tac.Temp {
+	t := ch.load(f, ctx)
+	ctx.Emit(tac.BinOp{Op: tac.Add, Dst: t, Left: t, Right: tac.Imm(1)})
+	ctx.Emit(tac.Store{Src: t, Addr: ch.ptr, Offset: field(f), Size: types.Word})
+	return t
+}
+
+//wait releases the lock of the channel until it changes and jumps back to loop
+func (ch channel) wait(loop *tac.Block, ctx *tac.Builder) {
+	ctx.Emit(tac.Call{Dst: tac.NoTemp, Func: "pthread_cond_wait", Args: []tac.Operand{ch.cond, ch.lock}, C: true})
+	ctx.Jump(loop)
+}
+
+//changed wakes up the threads waiting on the channel
+func (ch channel) changed(ctx *tac.Builder) {
+	ctx.Emit(tac.Call{Dst: tac.NoTemp, Func: "pthread_cond_broadcast", Args: []tac.Operand{ch.cond}, C: true})
+}
+
+//unlock releases the lock of the channel
+func (ch channel) unlock(ctx *tac.Builder) {
+	ctx.Emit(tac.Call{Dst: tac.NoTemp, Func: "pthread_mutex_unlock", Args: []tac.Operand{ch.lock}, C: true})
+}
+
+//wrap returns an index less than twice the number of slots of the channel as the
+//index of a slot of its ring buffer
+func (ch channel) wrap(index tac.Operand, ctx *tac.Builder) tac.Temp {
+	pos := ctx.NewTemp(types.Word)
+	ctx.Emit(tac.Move{Dst: pos, Src: index})
+	slots := ch.load(chanSlots, ctx)
+	wrap := ctx.NewTemp(types.TypeSize(types.Boolean))
+	ctx.Emit(tac.BinOp{Op: tac.Ge, Dst: wrap, Left: pos, Right: slots})
+	wrapBlock, endBlock := ctx.NewBlock(), ctx.NewBlock()
+	ctx.Terminate(tac.Branch{Cond: wrap, Then: wrapBlock, Else: endBlock})
+	ctx.SetBlock(wrapBlock)
+	ctx.Emit(tac.BinOp{Op: tac.Sub, Dst: pos, Left: pos, Right: slots})
+	ctx.Jump(endBlock)
+	ctx.SetBlock(endBlock)
+	return pos
+}
+
+//slot returns the address of the slot of the ring buffer at an index, see wrap
+func (ch channel) slot(index tac.Operand, ctx *tac.Builder) tac.Temp {
+	pos := ch.wrap(index, ctx)
+	buf, addr := ctx.NewTemp(types.PointerSize()), ctx.NewTemp(types.PointerSize())
+	ctx.Emit(tac.Load{Dst: buf, Addr: ch.ptr, Offset: field(chanBuf), Size: types.PointerSize()})
+	ctx.Emit(tac.Index{Dst: addr, Base: buf, Index: pos, Scale: int(types.PointerSize())})
+	return addr
+}
+
+//VisitStatSend waits for a free slot in the channel and stores the value in it. An
+//unbuffered channel then waits until the value has been received
+func (g *Generator) VisitStatSend(node ast.StatSend, ctx *tac.Builder) tac.Terminator {
+	elemType := g.evalType(node.GetChannel()).GetChildren()[0]
+	ptr := g.VisitExpression(node.GetChannel(), ctx)
+	value := g.visitAs(node.GetValue(), elemType, ctx)
+	ch := g.lockChannel(ptr, ctx)
+
+	loopBlock, fullBlock, waitBlock, putBlock := ctx.NewBlock(), ctx.NewBlock(), ctx.NewBlock(), ctx.NewBlock()
+	ctx.Jump(loopBlock)
+	ctx.SetBlock(loopBlock)
+	ctx.Terminate(tac.Branch{Cond: ch.load(chanClosed, ctx), Then: putBlock, Else: fullBlock})
+	ctx.SetBlock(fullBlock)
+	full := ctx.NewTemp(types.TypeSize(types.Boolean))
+	ctx.Emit(tac.BinOp{Op: tac.Ge, Dst: full, Left: ch.load(chanCount, ctx), Right: ch.load(chanSlots, ctx)})
+	ctx.Terminate(tac.Branch{Cond: full, Then: waitBlock, Else: putBlock})
+	ctx.SetBlock(waitBlock)
+	ch.wait(loopBlock, ctx)
+
+	ctx.SetBlock(putBlock)
+	ctx.Emit(tac.Check{Kind: tac.ClosedCheck, Args: []tac.Operand{ch.load(chanClosed, ctx)}})
+	tail := ctx.NewTemp(types.Word)
+	ctx.Emit(tac.BinOp{Op: tac.Add, Dst: tail, Left: ch.load(chanHead, ctx), Right: ch.load(chanCount, ctx)})
+	ctx.Emit(tac.Store{Src: value, Addr: ch.slot(tail, ctx), Size: types.TypeSize(elemType)})
+	ch.increment(chanCount, ctx)
+	ticket := ch.increment(chanSent, ctx)
+	ch.changed(ctx)
+
+	handoffBlock, waitRecvBlock, endBlock := ctx.NewBlock(), ctx.NewBlock(), ctx.NewBlock()
+	ctx.Terminate(tac.Branch{Cond: ch.load(chanCapacity, ctx), Then: endBlock, Else: handoffBlock})
+	ctx.SetBlock(handoffBlock)
+	pending := ctx.NewTemp(types.TypeSize(types.Boolean))
+	ctx.Emit(tac.BinOp{Op: tac.Lt, Dst: pending, Left: ch.load(chanReceived, ctx), Right: ticket})
+	ctx.Terminate(tac.Branch{Cond: pending, Then: waitRecvBlock, Else: endBlock})
+	ctx.SetBlock(waitRecvBlock)
+	ch.wait(handoffBlock, ctx)
+
+	ctx.SetBlock(endBlock)
+	ch.unlock(ctx)
+	return nil
+}
+
+//VisitRecv waits for a value in the channel and takes it out of the slot at the head.
+//A closed channel with no values left gives zero, the default value of every type
+func (g *Generator) VisitRecv(node ast.Recv, ctx *tac.Builder) tac.Operand {
+	size := types.TypeSize(g.evalType(&node))
+	ch := g.lockChannel(g.VisitExpression(node.GetChannel(), ctx), ctx)
+	dst := ctx.NewTemp(size)
+
+	loopBlock, emptyBlock, waitBlock := ctx.NewBlock(), ctx.NewBlock(), ctx.NewBlock()
+	takeBlock, closedBlock, endBlock := ctx.NewBlock(), ctx.NewBlock(), ctx.NewBlock()
+	ctx.Jump(loopBlock)
+	ctx.SetBlock(loopBlock)
+	ctx.Terminate(tac.Branch{Cond: ch.load(chanCount, ctx), Then: takeBlock, Else: emptyBlock})
+	ctx.SetBlock(emptyBlock)
+	ctx.Terminate(tac.Branch{Cond: ch.load(chanClosed, ctx), Then: closedBlock, Else: waitBlock})
+	ctx.SetBlock(waitBlock)
+	ch.wait(loopBlock, ctx)
+
+	ctx.SetBlock(takeBlock)
+	head := ch.load(chanHead, ctx)
+	ctx.Emit(tac.Load{Dst: dst, Addr: ch.slot(head, ctx), Size: size})
+	ctx.Emit(tac.BinOp{Op: tac.Add, Dst: head, Left: head, Right: tac.Imm(1)})
+	ctx.Emit(tac.Store{Src: ch.wrap(head, ctx), Addr: ch.ptr, Offset: field(chanHead), Size: types.Word})
+	count := ch.load(chanCount, ctx)
+	ctx.Emit(tac.BinOp{Op: tac.Sub, Dst: count, Left: count, Right: tac.Imm(1)})
+	ctx.Emit(tac.Store{Src: count, Addr: ch.ptr, Offset: field(chanCount), Size: types.Word})
+	ch.increment(chanReceived, ctx)
+	ch.changed(ctx)
+	ctx.Jump(endBlock)
+
+	ctx.SetBlock(closedBlock)
+	ctx.Emit(tac.Move{Dst: dst, Src: tac.Imm(0)})
+	ctx.Jump(endBlock)
+
+	ctx.SetBlock(endBlock)
+	ch.unlock(ctx)
+	return dst
+}
+
+//VisitStatClose marks a channel closed and wakes up the threads waiting on it
+func (g *Generator) VisitStatClose(node ast.StatClose, ctx *tac.Builder) tac.Terminator {
+	ch := g.lockChannel(g.VisitExpression(node.GetChannel(), ctx), ctx)
+	ctx.Emit(tac.Check{Kind: tac.ClosedCheck, Args: []tac.Operand{ch.load(chanClosed, ctx)}})
+	ctx.Emit(tac.Store{Src: tac.Imm(1), Addr: ch.ptr, Offset: field(chanClosed), Size: types.Word})
+	ch.changed(ctx)
+	ch.unlock(ctx)
+	return nil
+}
@@ -0,0 +1,143 @@
+package ir
+
+import (
+	"wacc_32/ast"
+	"wacc_32/ir/tac"
+	"wacc_32/types"
+)
+
+//VisitWaccRoutine runs a function in a new thread
+func (g *Generator) VisitWaccRoutine(node ast.WaccRoutine, ctx *tac.Builder) tac.Terminator {
+	ctx.Emit(g.spawn(*node.RHSFunctionCall, g.visitArgs(*node.RHSFunctionCall, ctx), ctx))
+	return nil
+}
+
+//spawn returns the instruction starting a call in a new thread. A virtual method is
+//started through the vtable of the object, and a method of an interface through the
+//itable of the fat pointer, like they are called
+func (g *Generator) spawn(node ast.RHSFunctionCall, args []tac.Operand, ctx *tac.Builder) tac.Spawn {
+	if class, _, _ := node.GetMethod(); class.IsInterface() {
+		code := g.interfaceCode(node, args, ctx)
+		return tac.Spawn{Code: code, Args: args, Sizes: g.argSizes(node)}
+	}
+	if node.IsVirtual() {
+		code := g.methodCode(node, args, ctx)
+		return tac.Spawn{Code: code, Args: args, Sizes: g.argSizes(node)}
+	}
+	return tac.Spawn{Func: g.callee(node), Args: args}
+}
+
+//A future is the joinable thread running a routine, followed by whether it has been
+//joined and the result of the routine once it has
+const (
+	futureJoined = 1
+	futureResult = 2
+	futureSize   = 3
+)
+
+//VisitWaccFuture runs a function in a new thread which is joined to read its result
+func (g *Generator) VisitWaccFuture(node ast.WaccFuture, ctx *tac.Builder) tac.Operand {
+	spawn := g.spawn(*node.RHSFunctionCall, g.visitArgs(*node.RHSFunctionCall, ctx), ctx)
+	ptr := types.PointerSize()
+	future := g.malloc(tac.Imm(futureSize*ptr), ctx)
+	ctx.Emit(tac.Store{Src: tac.Imm(0), Addr: future, Offset: int(futureJoined * ptr), Size: types.Word})
+	spawn.Handle = future
+	ctx.Emit(spawn)
+	return future
+}
+
+//join waits for the thread of a future the first time it is joined and stores the
+//result of its routine in dst. Later joins read the result kept in the future
+func (g *Generator) join(future tac.Operand, dst tac.Temp, ctx *tac.Builder) {
+	ptr := types.PointerSize()
+	ctx.Emit(tac.Check{Kind: tac.NullCheck, Args: []tac.Operand{future}})
+	joined := ctx.NewTemp(types.Word)
+	ctx.Emit(tac.Load{Dst: joined, Addr: future, Offset: int(futureJoined * ptr), Size: types.Word})
+	joinBlock, endBlock := ctx.NewBlock(), ctx.NewBlock()
+	ctx.Terminate(tac.Branch{Cond: joined, Then: endBlock, Else: joinBlock})
+
+	//pthread_join stores the value the thread returned with in the future
+	ctx.SetBlock(joinBlock)
+	thread, result := ctx.NewTemp(ptr), ctx.NewTemp(ptr)
+	ctx.Emit(tac.Load{Dst: thread, Addr: future, Size: ptr})
+	ctx.Emit(tac.Index{Dst: result, Base: future, Index: tac.Imm(futureResult), Scale: int(ptr)})
+	ctx.Emit(tac.Call{Dst: tac.NoTemp, Func: "pthread_join", Args: []tac.Operand{thread, result}, C: true})
+	ctx.Emit(tac.Store{Src: tac.Imm(1), Addr: future, Offset: int(futureJoined * ptr), Size: types.Word})
+	ctx.Jump(endBlock)
+
+	ctx.SetBlock(endBlock)
+	ctx.Emit(tac.Load{Dst: dst, Addr: future, Offset: int(futureResult * ptr), Size: ctx.Func().Size(dst)})
+}
+
+//VisitStatLock acquires or releases a lock, checking for the errors an error checking mutex reports
+func (g *Generator) VisitStatLock(node ast.StatLock, ctx *tac.Builder) tac.Terminator {
+	g.lockOp(g.VisitIdent(*node.GetIdent(), ctx), node.GetType(), ctx)
+	return nil
+}
+
+func (g *Generator) lockOp(lock tac.Operand, op ast.LockStatType, ctx *tac.Builder) {
+	res := ctx.NewTemp(types.Word)
+	function, check := "pthread_mutex_lock", tac.LockCheck
+	if op == ast.Release {
+		function, check = "pthread_mutex_unlock", tac.UnlockCheck
+	}
+	if g.lockdep {
+		function = lockdepAcquire
+		if op == ast.Release {
+			function = lockdepRelease
+		}
+	}
+	ctx.Emit(tac.Call{Dst: res, Func: function, Args: []tac.Operand{lock}, C: !g.lockdep})
+	ctx.Emit(tac.Check{Kind: check, Args: []tac.Operand{res}})
+}
+
+//VisitStatSema visits AST node ast.StatSema
+func (g *Generator) VisitStatSema(node ast.StatSema, ctx *tac.Builder) tac.Terminator {
+	sema := g.VisitIdent(*node.GetIdent(), ctx)
+	function := "sem_wait"
+	if node.IsUp() {
+		function = "sem_post"
+	}
+	ctx.Emit(tac.Call{Dst: tac.NoTemp, Func: function, Args: []tac.Operand{sema}, C: true})
+	return nil
+}
+
+//VisitStatCond waits on a condition variable, an error checking mutex which isn't held
+//makes pthread_cond_wait fail like unlocking it would. With lockdep the order the lock
+//is reacquired in is checked like acquire does
+func (g *Generator) VisitStatCond(node ast.StatCond, ctx *tac.Builder) tac.Terminator {
+	cond := g.VisitIdent(*node.GetCond(), ctx)
+	switch node.GetType() {
+	case ast.Wait:
+		lock := g.VisitIdent(*node.GetLock(), ctx)
+		res := ctx.NewTemp(types.Word)
+		if g.lockdep {
+			ctx.Emit(tac.Call{Dst: res, Func: lockdepWait, Args: []tac.Operand{cond, lock}})
+		} else {
+			ctx.Emit(tac.Call{Dst: res, Func: "pthread_cond_wait", Args: []tac.Operand{cond, lock}, C: true})
+		}
+		ctx.Emit(tac.Check{Kind: tac.UnlockCheck, Args: []tac.Operand{res}})
+	case ast.Signal:
+		ctx.Emit(tac.Call{Dst: tac.NoTemp, Func: "pthread_cond_signal", Args: []tac.Operand{cond}, C: true})
+	case ast.Broadcast:
+		ctx.Emit(tac.Call{Dst: tac.NoTemp, Func: "pthread_cond_broadcast", Args: []tac.Operand{cond}, C: true})
+	}
+	return nil
+}
+
+//VisitStatWith holds the lock while the body runs. The lock is kept in a temp of its
+//own, so it is the one released even if the variable is assigned in the body, and a
+//return in the body releases it before returning
+func (g *Generator) VisitStatWith(node ast.StatWith, ctx *tac.Builder) tac.Terminator {
+	lock := ctx.NewTemp(types.PointerSize())
+	ctx.Emit(tac.Move{Dst: lock, Src: g.VisitIdent(*node.GetIdent(), ctx)})
+	g.lockOp(lock, ast.Acquire, ctx)
+	g.withLocks = append(g.withLocks, lock)
+	term := g.VisitStatement(node.GetStat(), ctx)
+	g.withLocks = g.withLocks[:len(g.withLocks)-1]
+	if ctx.Block() == nil {
+		return term
+	}
+	g.lockOp(lock, ast.Release, ctx)
+	return nil
+}
@@ -0,0 +1,261 @@
+package ir
+
+import (
+	"fmt"
+	"wacc_32/ast"
+	"wacc_32/ir/tac"
+	"wacc_32/types"
+)
+
+//VisitExpression visits AST node ast.Expression
+func (g *Generator) VisitExpression(node ast.Expression, ctx *tac.Builder) tac.Operand {
+	return node.(ast.OperandAcceptor).AcceptOperand(g, ctx)
+}
+
+//VisitRHS visits AST node ast.RHS
+func (g *Generator) VisitRHS(node ast.RHS, ctx *tac.Builder) tac.Operand {
+	return g.VisitExpression(node, ctx)
+}
+
+var binOps = map[ast.BinopType]tac.Op{
+	ast.Star:      tac.Mul,
+	ast.Div:       tac.Div,
+	ast.Mod:       tac.Mod,
+	ast.Plus:      tac.Add,
+	ast.Minus:     tac.Sub,
+	ast.Greater:   tac.Gt,
+	ast.GreaterEq: tac.Ge,
+	ast.Less:      tac.Lt,
+	ast.LessEq:    tac.Le,
+	ast.Equal:     tac.Eq,
+	ast.NotEq:     tac.Ne,
+	ast.And:       tac.And,
+	ast.Or:        tac.Or,
+}
+
+//VisitBinOp evaluates both sides, && and || don't short circuit
+func (g *Generator) VisitBinOp(node ast.BinOp, ctx *tac.Builder) tac.Operand {
+	left := g.VisitExpression(node.GetLeftExpr(), ctx)
+	right := g.VisitExpression(node.GetRightExpr(), ctx)
+	dst := ctx.NewTemp(g.exprSize(&node))
+	ctx.Emit(tac.BinOp{Op: binOps[node.GetOpType()], Dst: dst, Left: left, Right: right})
+	return dst
+}
+
+//VisitUnOp visits AST node ast.UnOp
+func (g *Generator) VisitUnOp(node ast.UnOp, ctx *tac.Builder) tac.Operand {
+	src := g.VisitExpression(node.GetExpr(), ctx)
+	dst := ctx.NewTemp(g.exprSize(&node))
+	switch node.GetOpType() {
+	case ast.Not:
+		ctx.Emit(tac.UnOp{Op: tac.Not, Dst: dst, Src: src})
+	case ast.Neg:
+		ctx.Emit(tac.UnOp{Op: tac.Neg, Dst: dst, Src: src})
+	case ast.Len:
+		ctx.Emit(tac.Load{Dst: dst, Addr: src, Size: types.Word})
+	case ast.Ord, ast.Chr:
+		ctx.Emit(tac.Move{Dst: dst, Src: src})
+	case ast.TryLock:
+		//pthread_mutex_trylock returns EBUSY if the lock is held
+		res := ctx.NewTemp(types.Word)
+		if g.lockdep {
+			ctx.Emit(tac.Call{Dst: res, Func: lockdepTrylock, Args: []tac.Operand{src}})
+		} else {
+			ctx.Emit(tac.Call{Dst: res, Func: "pthread_mutex_trylock", Args: []tac.Operand{src}, C: true})
+		}
+		ctx.Emit(tac.BinOp{Op: tac.Ne, Dst: dst, Left: res, Right: tac.Imm(16)})
+	case ast.Join:
+		g.join(src, dst, ctx)
+	}
+	return dst
+}
+
+//VisitTernaryOp only evaluates the chosen branch
+func (g *Generator) VisitTernaryOp(node ast.TernaryOp, ctx *tac.Builder) tac.Operand {
+	cond := g.VisitExpression(node.GetCondition(), ctx)
+	t := g.evalType(&node)
+	if imm, ok := cond.(tac.Imm); ok {
+		if imm != 0 {
+			return g.visitAs(node.GetIfExpr(), t, ctx)
+		}
+		return g.visitAs(node.GetElseExpr(), t, ctx)
+	}
+
+	dst := ctx.NewTemp(g.exprSize(&node))
+	thenBlock, elseBlock, endBlock := ctx.NewBlock(), ctx.NewBlock(), ctx.NewBlock()
+	ctx.Terminate(tac.Branch{Cond: cond, Then: thenBlock, Else: elseBlock})
+
+	ctx.SetBlock(thenBlock)
+	ctx.Emit(tac.Move{Dst: dst, Src: g.visitAs(node.GetIfExpr(), t, ctx)})
+	ctx.Jump(endBlock)
+
+	ctx.SetBlock(elseBlock)
+	ctx.Emit(tac.Move{Dst: dst, Src: g.visitAs(node.GetElseExpr(), t, ctx)})
+	ctx.Jump(endBlock)
+
+	ctx.SetBlock(endBlock)
+	return dst
+}
+
+//VisitRHSNewPair allocates a pair and stores both elements in it
+func (g *Generator) VisitRHSNewPair(node ast.RHSNewPair, ctx *tac.Builder) tac.Operand {
+	fst, snd := node.GetExpr(0), node.GetExpr(1)
+	fstVal := g.VisitExpression(fst, ctx)
+	sndVal := g.VisitExpression(snd, ctx)
+	fstSize, sndSize := g.exprSize(fst), g.exprSize(snd)
+
+	pair := g.malloc(tac.Imm(fstSize+sndSize), ctx)
+	ctx.Emit(tac.Store{Src: fstVal, Addr: pair, Size: fstSize})
+	ctx.Emit(tac.Store{Src: sndVal, Addr: pair, Offset: int(fstSize), Size: sndSize})
+	return pair
+}
+
+//malloc allocates size bytes on the heap
+func (g *Generator) malloc(size tac.Operand, ctx *tac.Builder) tac.Temp {
+	ptr := ctx.NewTemp(types.PointerSize())
+	ctx.Emit(tac.Call{Dst: ptr, Func: "malloc", Args: []tac.Operand{size}, C: true})
+	return ptr
+}
+
+//VisitMake allocates an array of uninitialised elements, the length is stored in the first word
+func (g *Generator) VisitMake(node ast.Make, ctx *tac.Builder) tac.Operand {
+	length := g.VisitExpression(node.GetLengthExpression(), ctx)
+	elemSize := types.TypeSize(g.evalType(&node).GetChildren()[0])
+
+	size := ctx.NewTemp(types.Word)
+	ctx.Emit(tac.Index{Dst: size, Base: tac.Imm(types.Word), Index: length, Scale: int(elemSize)})
+	arr := g.malloc(size, ctx)
+	ctx.Emit(tac.Store{Src: length, Addr: arr, Size: types.Word})
+	return arr
+}
+
+//VisitPairElem visits AST node ast.PairElem
+func (g *Generator) VisitPairElem(node ast.PairElem, ctx *tac.Builder) tac.Operand {
+	return g.locate(&node, ctx).load(ctx)
+}
+
+//VisitArrayElem visits AST node ast.ArrayElem
+func (g *Generator) VisitArrayElem(node ast.ArrayElem, ctx *tac.Builder) tac.Operand {
+	return g.locate(&node, ctx).load(ctx)
+}
+
+//VisitIdent visits AST node ast.Ident
+func (g *Generator) VisitIdent(node ast.Ident, ctx *tac.Builder) tac.Operand {
+	if node.IsFunction() {
+		return g.functionValue(node.GetName()[1:], ctx)
+	}
+	return g.locate(&node, ctx).load(ctx)
+}
+
+//location is somewhere a value can be stored, a variable's temp or memory
+type location struct {
+	temp   tac.Temp
+	addr   tac.Operand
+	offset int
+	size   types.Size
+}
+
+//load returns the value stored at the location
+func (l location) load(ctx *tac.Builder) tac.Operand {
+	if l.addr == nil {
+		return l.temp
+	}
+	t := ctx.NewTemp(l.size)
+	ctx.Emit(tac.Load{Dst: t, Addr: l.addr, Offset: l.offset, Size: l.size})
+	return t
+}
+
+//store writes value to the location
+func (l location) store(ctx *tac.Builder, value tac.Operand) {
+	if l.addr == nil {
+		ctx.Emit(tac.Move{Dst: l.temp, Src: value})
+		return
+	}
+	ctx.Emit(tac.Store{Src: value, Addr: l.addr, Offset: l.offset, Size: l.size})
+}
+
+//locate returns the location an assignable expression refers to
+func (g *Generator) locate(lhs ast.Expression, ctx *tac.Builder) location {
+	switch node := lhs.(type) {
+	case *ast.Ident:
+		return g.locateIdent(*node, ctx)
+	case *ast.ArrayElem:
+		return g.locateArrayElem(*node, ctx)
+	case *ast.PairElem:
+		return g.locatePairElem(*node, ctx)
+	}
+	panic(fmt.Sprintf("%s can't be assigned to", lhs))
+}
+
+//locateIdent returns a variable, or a field of a class or struct
+func (g *Generator) locateIdent(node ast.Ident, ctx *tac.Builder) location {
+	table := node.GetSymbolTable()
+	if !node.IsNamespaced() {
+		return location{temp: g.lookup(table, node.GetName())}
+	}
+
+	components := node.GetNameComponents()
+	scope, _ := table.Find(components[0])
+	var ptr tac.Operand = g.lookup(scope, components[0])
+	t, _ := table.GetType(components[0])
+	var loc location
+	for i, fieldName := range components[1:] {
+		if i > 0 {
+			ptr = loc.load(ctx)
+		}
+		uType, _ := ast.LookupUserType(t.(types.UserType), *table)
+		fieldNames := uType.GetFieldNames()
+		fieldTypes := uType.GetFieldTypes()
+		offset := headerSize(uType)
+		for j := range fieldNames {
+			if fieldNames[j] == fieldName {
+				t = fieldTypes[j]
+				break
+			}
+			offset += int(g.typeSize(fieldTypes[j]))
+		}
+		loc = location{addr: ptr, offset: offset, size: g.typeSize(t)}
+	}
+	return loc
+}
+
+//locateArrayElem checks every index against the length of its dimension
+func (g *Generator) locateArrayElem(node ast.ArrayElem, ctx *tac.Builder) location {
+	arr := g.VisitIdent(*node.GetIdent(), ctx)
+	indices := node.GetIndices()
+	size := g.typeSize(node.EvalType(*node.GetSymbolTable()))
+	var loc location
+	for i, expr := range indices {
+		if i > 0 {
+			arr = loc.load(ctx)
+		}
+		//Every dimension but the last holds pointers to the next array
+		elemSize := types.PointerSize()
+		if i == len(indices)-1 {
+			elemSize = size
+		}
+		index := g.VisitExpression(expr, ctx)
+		length := ctx.NewTemp(types.Word)
+		ctx.Emit(tac.Load{Dst: length, Addr: arr, Size: types.Word})
+		ctx.Emit(tac.Check{Kind: tac.BoundsCheck, Args: []tac.Operand{index, length}})
+
+		//Skip the first word (length)
+		addr := ctx.NewTemp(types.PointerSize())
+		ctx.Emit(tac.Index{Dst: addr, Base: arr, Index: index, Scale: int(elemSize), Offset: types.Word})
+		loc = location{addr: addr, size: elemSize}
+	}
+	return loc
+}
+
+//locatePairElem returns the fst or snd element of a non null pair
+func (g *Generator) locatePairElem(node ast.PairElem, ctx *tac.Builder) location {
+	value := node.GetValue()
+	pair := g.VisitExpression(value, ctx)
+	ctx.Emit(tac.Check{Kind: tac.NullCheck, Args: []tac.Operand{pair}})
+
+	offset := 0
+	if node.GetPairElemPos() == ast.SND {
+		offset = int(types.TypeSize(g.evalType(value).GetChildren()[0]))
+	}
+	return location{addr: pair, offset: offset, size: g.exprSize(&node)}
+}
@@ -0,0 +1,531 @@
+package ir
+
+import (
+	"fmt"
+	"strings"
+	"wacc_32/ast"
+	"wacc_32/errors"
+	"wacc_32/ir/tac"
+	"wacc_32/symboltable"
+	"wacc_32/types"
+)
+
+//go:generate ./../visitor_generator/visitor_generator.sh tac.Terminator tac.Operand *tac.Builder
+
+var _ ast.TerminatorOperandVisitor = &Generator{}
+
+//Generator converts a semantically checked AST into three address code.
+//Statements return the terminator which ends them if control never falls
+//through, expressions return the operand holding their value
+type Generator struct {
+	vars       map[variable]tac.Temp
+	scopes     map[*symboltable.SymbolTable]int
+	lambdas    []lambda
+	nLambda    int
+	subst      types.Substitution      //The types the type parameters of the function being generated stand for
+	generics   map[string]ast.Function //Generic functions by the name they are called by
+	instances  map[string]bool         //Labels of the instances of generic functions already queued
+	pending    []instance
+	slots      map[string][]vslot //The vtable slots of each class in a class hierarchy
+	interfaces map[string]bool    //The names of the interfaces
+	lockdep    bool               //Whether locks are acquired through the lockdep functions
+	withLocks  []tac.Temp         //The locks held by the with blocks around the statement being generated, innermost last
+}
+
+//lambda is a lambda waiting to be generated as a function of its own
+type lambda struct {
+	node     ast.Lambda
+	name     string
+	captures []ast.Capture
+	subst    types.Substitution
+}
+
+//instance is a generic function waiting to be generated for the types its type
+//parameters stand for
+type instance struct {
+	fn    ast.Function
+	name  string
+	subst types.Substitution
+}
+
+//variable identifies a wacc variable by the scope it was declared in
+type variable struct {
+	scope *symboltable.SymbolTable
+	name  string
+}
+
+//NewGenerator creates a Generator
+func NewGenerator() *Generator {
+	return &Generator{
+		generics:   make(map[string]ast.Function),
+		instances:  make(map[string]bool),
+		slots:      make(map[string][]vslot),
+		interfaces: make(map[string]bool),
+	}
+}
+
+//Generate returns the three address code of a whole program
+func Generate(tree ast.AST) *tac.Program {
+	return NewGenerator().Generate(tree)
+}
+
+//Generate returns the three address code of a whole program
+func (g *Generator) Generate(tree ast.AST) *tac.Program {
+	b := tac.NewBuilder()
+	g.VisitAST(tree, b)
+	return b.Program()
+}
+
+//declare creates the temp holding a variable of type wt declared at pos in scope
+func (g *Generator) declare(b *tac.Builder, scope *symboltable.SymbolTable, name string, wt types.WaccType, pos errors.Position) tac.Temp {
+	t := b.NewVar(g.typeSize(wt), name)
+	g.describe(b, t, scope, wt, pos)
+	return t
+}
+
+//describe records the type and declaration of the variable held in t
+func (g *Generator) describe(b *tac.Builder, t tac.Temp, scope *symboltable.SymbolTable, wt types.WaccType, pos errors.Position) {
+	info := &b.Func().Temps[t]
+	g.vars[variable{scope, info.Name}] = t
+	info.Type = types.Substitute(wt, g.subst)
+	info.Decl = g.loc(pos, scope, b)
+}
+
+//lookup returns the temp holding a variable declared in scope
+func (g *Generator) lookup(scope *symboltable.SymbolTable, name string) tac.Temp {
+	return g.vars[variable{scope, name}]
+}
+
+//evalType returns the type of an expression, using its own symbol table. In an instance
+//of a generic function the type parameters are replaced by the types they stand for
+func (g *Generator) evalType(e ast.Expression) types.WaccType {
+	var wt types.WaccType
+	if table := e.GetSymbolTable(); table != nil {
+		wt = e.EvalType(*table)
+	} else {
+		wt = e.EvalType(symboltable.SymbolTable{})
+	}
+	return types.Substitute(wt, g.subst)
+}
+
+func (g *Generator) exprSize(e ast.Expression) types.Size {
+	return types.TypeSize(g.evalType(e))
+}
+
+//typeSize returns the size of a type in the function being generated
+func (g *Generator) typeSize(wt types.WaccType) types.Size {
+	return types.TypeSize(types.Substitute(wt, g.subst))
+}
+
+//loc returns where code from pos in table starts
+func (g *Generator) loc(pos errors.Position, table *symboltable.SymbolTable, ctx *tac.Builder) tac.Loc {
+	return tac.Loc{File: pos.File(), Line: pos.StartLine(), Col: pos.StartCol(), Scope: g.scope(table, ctx)}
+}
+
+//scope returns the scope of the function being generated for a symbol table,
+//creating it and any scopes around it the first time they are seen
+func (g *Generator) scope(table *symboltable.SymbolTable, ctx *tac.Builder) int {
+	if table == nil {
+		return 0
+	}
+	if scope, ok := g.scopes[table]; ok {
+		return scope
+	}
+	parent := 0
+	if table.GetParentScope() != nil {
+		parent = g.scope(table.GetParentScope(), ctx)
+	}
+	scope := ctx.NewScope(parent)
+	g.scopes[table] = scope
+	return scope
+}
+
+//scoped is a node which knows the scope it is in
+type scoped interface {
+	GetSymbolTable() *symboltable.SymbolTable
+}
+
+//mark starts the code of a statement or expression which has a position in the source,
+//unless it can't be reached
+func (g *Generator) mark(node scoped, ctx *tac.Builder) {
+	if pos, ok := ast.Pos(node); ok && ctx.Block() != nil {
+		ctx.Emit(g.loc(pos, node.GetSymbolTable(), ctx))
+	}
+}
+
+//VisitProgram visits AST node ast.Program
+func (g *Generator) VisitProgram(node ast.Program, ctx *tac.Builder) tac.Terminator {
+	for _, st := range node.GetStructs() {
+		g.VisitUserType(*st, ctx)
+	}
+
+	for _, fn := range node.GetFuncs() {
+		if len(fn.GetTypeParams()) > 0 {
+			g.generics[fn.GetInternalName()] = *fn
+		}
+	}
+
+	for _, fn := range node.GetFuncs() {
+		if len(fn.GetTypeParams()) == 0 {
+			g.VisitFunction(*fn, ctx)
+			g.flushLambdas(ctx)
+		}
+	}
+
+	//Generic functions are generated once for each set of types they are called with,
+	//their instances can call further instances
+	for len(g.pending) > 0 {
+		inst := g.pending[0]
+		g.pending = g.pending[1:]
+		g.subst = inst.subst
+		g.startFunc(inst.name, inst.fn.GetSymbolTable(), inst.fn.GetParams(), inst.fn.GetPos(), ctx)
+		g.funcBody(inst.fn.GetStats(), ctx)
+		g.flushLambdas(ctx)
+	}
+	if g.lockdep {
+		g.lockdepRuntime(ctx)
+	}
+	return nil
+}
+
+//flushLambdas generates the lambdas of the function which was just generated, the
+//lambdas nested in them are queued as they are generated
+func (g *Generator) flushLambdas(ctx *tac.Builder) {
+	for len(g.lambdas) > 0 {
+		l := g.lambdas[0]
+		g.lambdas = g.lambdas[1:]
+		g.lambda(l, ctx)
+	}
+}
+
+//instantiate returns the label of the instance of the generic function name for the
+//types s gives its type parameters, queueing the instance the first time it is needed
+func (g *Generator) instantiate(name string, s types.Substitution) string {
+	fn := g.generics[name]
+	args := make([]string, len(fn.GetTypeParams()))
+	for i, param := range fn.GetTypeParams() {
+		args[i] = mangle(s[param])
+	}
+	label := name[1:] + "." + strings.Join(args, ".")
+	if !g.instances[label] {
+		g.instances[label] = true
+		g.pending = append(g.pending, instance{fn: fn, name: label, subst: s})
+	}
+	return label
+}
+
+//mangle spells a type with the characters labels can have
+func mangle(wt types.WaccType) string {
+	if ut, ok := wt.(types.UserType); ok {
+		if len(ut.GetTypeArgs()) == 0 {
+			return ut.GetName()
+		}
+		args := make([]string, len(ut.GetTypeArgs()))
+		for i, arg := range ut.GetTypeArgs() {
+			args[i] = mangle(arg)
+		}
+		return ut.GetName() + "_L_" + strings.Join(args, "_") + "_E"
+	}
+	children := wt.GetChildren()
+	names := make([]string, len(children))
+	for i, child := range children {
+		names[i] = mangle(child)
+	}
+	switch {
+	case wt.Is(types.Function) && len(children) > 0:
+		return "F_" + strings.Join(names, "_") + "_E"
+	case wt.Is(types.Array) && len(children) == 1:
+		return names[0] + "_A"
+	case wt.Is(types.Pair) && len(children) == 2:
+		return "P_" + strings.Join(names, "_") + "_E"
+	case wt.Is(types.Future) && len(children) == 1:
+		return "U_" + names[0] + "_E"
+	case wt.Is(types.Chan) && len(children) == 1:
+		return "C_" + names[0] + "_E"
+	}
+	return wt.String()
+}
+
+//VisitUserType records the layout of a struct or class
+//Methods are generated along with the other functions, a class in a class hierarchy gets
+//a vtable of the functions it runs for its methods
+func (g *Generator) VisitUserType(node ast.UserType, ctx *tac.Builder) tac.Terminator {
+	ut := node.EvalType().(types.UserType)
+	ctx.AddUserType(ut)
+	if ut.IsInterface() {
+		g.interfaces[ut.GetName()] = true
+		return nil
+	}
+	g.addITables(node, ctx)
+	if ut.IsPolymorphic() {
+		slots := vslots(node)
+		funcs := make([]string, len(slots))
+		for i, slot := range slots {
+			if slot.iface != "" {
+				funcs[i] = itable(ut.GetName(), slot.iface)
+			} else {
+				funcs[i] = node.Implementation(slot.method)[1:]
+			}
+		}
+		ctx.AddVTable(vtable(ut.GetName()), funcs)
+		g.slots[ut.GetName()] = slots
+	}
+	return nil
+}
+
+//vslot is a slot of a vtable, holding the code of a method or the itable of an interface
+type vslot struct {
+	method string
+	iface  string
+}
+
+//vslots returns the slots of the vtable of a class: the slots of its parent, then one
+//for each method it adds, then one for each interface it adds. The vtable of a class
+//starts with the vtable of its parent, so the slot of a method or interface is the same
+//in every subclass
+func vslots(node ast.UserType) []vslot {
+	var slots []vslot
+	if parent := node.GetParent(); parent != nil {
+		slots = vslots(*parent)
+	}
+	has := func(slot vslot) bool {
+		for _, s := range slots {
+			if s == slot {
+				return true
+			}
+		}
+		return false
+	}
+	for _, method := range node.Methods() {
+		if slot := (vslot{method: method}); !has(slot) {
+			slots = append(slots, slot)
+		}
+	}
+	for _, iface := range node.GetInterfaces() {
+		if slot := (vslot{iface: iface.GetName()}); !has(slot) {
+			slots = append(slots, slot)
+		}
+	}
+	return slots
+}
+
+//slotIndex returns the index of a slot in the vtable of a class
+func (g *Generator) slotIndex(class string, slot vslot) int {
+	for i, s := range g.slots[class] {
+		if s == slot {
+			return i
+		}
+	}
+	return 0
+}
+
+//vtable returns the label of the vtable of a class
+func vtable(class string) string {
+	return class + ".vtable"
+}
+
+//headerSize returns the size of what objects of a struct or class hold before their
+//fields, the address of its vtable for a class in a class hierarchy
+func headerSize(ut types.UserType) int {
+	if ut.IsPolymorphic() {
+		return int(types.PointerSize())
+	}
+	return 0
+}
+
+//VisitFunction adds a function to the program, main exits with 0 if it reaches its end
+func (g *Generator) VisitFunction(node ast.Function, ctx *tac.Builder) tac.Terminator {
+	g.subst = nil
+	g.startFunc(node.GetName(), node.GetSymbolTable(), node.GetParams(), node.GetPos(), ctx)
+	if g.lockdep && ctx.Func().IsMain() {
+		g.lockdepInit(ctx)
+	}
+	g.funcBody(node.GetStats(), ctx)
+	return nil
+}
+
+//lambda generates the function a lambda is lifted to. Its closure is passed after its
+//parameters, the variables it captured are loaded from the closure on entry
+func (g *Generator) lambda(l lambda, ctx *tac.Builder) {
+	scope := l.node.GetScope()
+	g.subst = l.subst
+	g.startFunc(l.name, scope, l.node.GetParams(), l.node.GetPos(), ctx)
+	closure := ctx.NewParam(types.PointerSize(), "")
+	offset := int(types.PointerSize())
+	for _, c := range l.captures {
+		size := g.typeSize(c.Type)
+		t := ctx.NewVar(size, c.Name)
+		g.vars[variable{c.Scope, c.Name}] = t
+		info := &ctx.Func().Temps[t]
+		info.Type = types.Substitute(c.Type, g.subst)
+		info.Decl = g.loc(l.node.GetPos(), scope, ctx)
+		ctx.Emit(tac.Load{Dst: t, Addr: closure, Offset: offset, Size: size})
+		offset += int(size)
+	}
+	g.funcBody(l.node.GetStats(), ctx)
+}
+
+//startFunc adds a function whose parameters are declared in scope to the program
+func (g *Generator) startFunc(name string, scope *symboltable.SymbolTable, params ast.ParamList, pos errors.Position, ctx *tac.Builder) {
+	g.vars = make(map[variable]tac.Temp)
+	g.scopes = map[*symboltable.SymbolTable]int{scope: 0}
+	g.nLambda = 0
+	ctx.StartFunc(name).Loc = g.loc(pos, scope, ctx)
+
+	for _, param := range params {
+		t := ctx.NewParam(g.typeSize(param.GetType()), param.GetName())
+		g.describe(ctx, t, scope, param.GetType(), param.GetPos())
+	}
+}
+
+//funcBody generates the statements of a function, it returns 0 if it reaches its end
+func (g *Generator) funcBody(stats []ast.Statement, ctx *tac.Builder) {
+	for _, stat := range stats {
+		g.VisitStatement(stat, ctx)
+	}
+
+	//Every block ends with a terminator, even if it can't be reached
+	if ctx.Block() != nil {
+		ctx.Terminate(tac.Return{Value: tac.Imm(0)})
+	}
+}
+
+//VisitParamList visits AST node ast.ParamList
+func (g *Generator) VisitParamList(node ast.ParamList, ctx *tac.Builder) tac.Terminator {
+	return nil
+}
+
+//VisitParam visits AST node ast.Param
+func (g *Generator) VisitParam(node ast.Param, ctx *tac.Builder) tac.Terminator {
+	return nil
+}
+
+//VisitStatReturn visits AST node ast.StatReturn
+//The value is evaluated before the locks of the with blocks around it are released
+func (g *Generator) VisitStatReturn(node ast.StatReturn, ctx *tac.Builder) tac.Terminator {
+	ret := tac.Return{Value: g.visitAs(node.GetReturnExpr(), node.GetReturnType(), ctx)}
+	for i := len(g.withLocks) - 1; i >= 0; i-- {
+		g.lockOp(g.withLocks[i], ast.Release, ctx)
+	}
+	ctx.Terminate(ret)
+	return ret
+}
+
+//VisitRHSFunctionCall calls a wacc function, arguments are evaluated left to right
+func (g *Generator) VisitRHSFunctionCall(node ast.RHSFunctionCall, ctx *tac.Builder) tac.Operand {
+	dst := ctx.NewTemp(g.exprSize(&node))
+	args := g.visitArgs(node, ctx)
+	if ident := node.GetClosure(); ident != nil {
+		closure := g.VisitIdent(*ident, ctx)
+		ctx.Emit(tac.Check{Kind: tac.NullCheck, Args: []tac.Operand{closure}})
+		ctx.Emit(tac.CallClosure{Dst: dst, Closure: closure, Args: args, Sizes: g.argSizes(node)})
+		return dst
+	}
+	if class, _, _ := node.GetMethod(); class.IsInterface() {
+		g.callInterface(node, dst, args, ctx)
+		return dst
+	}
+	if node.IsVirtual() {
+		g.callMethod(node, dst, args, ctx)
+		return dst
+	}
+	ctx.Emit(tac.Call{Dst: dst, Func: g.callee(node), Args: args})
+	return dst
+}
+
+//callMethod calls the function the class of the object this, the last argument, runs for
+//a method
+func (g *Generator) callMethod(node ast.RHSFunctionCall, dst tac.Temp, args []tac.Operand, ctx *tac.Builder) {
+	fn := g.methodCode(node, args, ctx)
+	ctx.Emit(tac.CallIndirect{Dst: dst, Func: fn, Args: args, Sizes: g.argSizes(node)})
+}
+
+//methodCode returns the address of the function the class of the object this, the last
+//argument, runs for a method, which is in the method's slot of the vtable the object
+//starts with
+func (g *Generator) methodCode(node ast.RHSFunctionCall, args []tac.Operand, ctx *tac.Builder) tac.Temp {
+	class, method, _ := node.GetMethod()
+	slot := g.slotIndex(class.GetName(), vslot{method: method})
+	this := args[len(args)-1]
+	ctx.Emit(tac.Check{Kind: tac.NullCheck, Args: []tac.Operand{this}})
+	table := ctx.NewTemp(types.PointerSize())
+	ctx.Emit(tac.Load{Dst: table, Addr: this, Size: types.PointerSize()})
+	fn := ctx.NewTemp(types.PointerSize())
+	ctx.Emit(tac.Load{Dst: fn, Addr: table, Offset: slot * int(types.PointerSize()), Size: types.PointerSize()})
+	return fn
+}
+
+//visitArgs evaluates the arguments of a call in order, as values of the types of the
+//parameters they are passed as
+func (g *Generator) visitArgs(node ast.RHSFunctionCall, ctx *tac.Builder) []tac.Operand {
+	params := node.GetParamTypes()
+	args := make([]tac.Operand, len(node.GetArgs()))
+	for i, arg := range node.GetArgs() {
+		args[i] = g.visitAs(arg, params[i], ctx)
+	}
+	return args
+}
+
+//argSizes returns the sizes of the arguments of a call, as the parameters they are passed as
+func (g *Generator) argSizes(node ast.RHSFunctionCall) []types.Size {
+	params := node.GetParamTypes()
+	sizes := make([]types.Size, len(params))
+	for i, param := range params {
+		sizes[i] = g.typeSize(param)
+	}
+	return sizes
+}
+
+//callee returns the label of the function a call to a named function calls, a generic
+//function's instance for the types it is called with
+func (g *Generator) callee(node ast.RHSFunctionCall) string {
+	name, _ := node.FormatName()
+	if typeArgs := node.GetTypeArgs(); typeArgs != nil {
+		return g.instantiate(name, typeArgs.Compose(g.subst))
+	}
+	return name[1:]
+}
+
+//VisitLambda allocates the closure of a lambda, which is generated after the current
+//function. The closure holds the address of its code followed by the values of the
+//variables it captures
+func (g *Generator) VisitLambda(node ast.Lambda, ctx *tac.Builder) tac.Operand {
+	l := lambda{
+		node:     node,
+		name:     fmt.Sprintf("%s.lambda%d", ctx.Func().Name, g.nLambda),
+		captures: node.GetCaptures(),
+		subst:    g.subst,
+	}
+	g.nLambda++
+	g.lambdas = append(g.lambdas, l)
+
+	size := types.PointerSize()
+	for _, c := range l.captures {
+		size += g.typeSize(c.Type)
+	}
+	closure := g.malloc(tac.Imm(size), ctx)
+	ctx.Emit(tac.Store{Src: tac.Global(l.name), Addr: closure, Size: types.PointerSize()})
+	offset := int(types.PointerSize())
+	for _, c := range l.captures {
+		size := g.typeSize(c.Type)
+		ctx.Emit(tac.Store{Src: g.lookup(c.Scope, c.Name), Addr: closure, Offset: offset, Size: size})
+		offset += int(size)
+	}
+	return closure
+}
+
+//functionValue allocates the closure of a named function, which captures nothing
+func (g *Generator) functionValue(name string, ctx *tac.Builder) tac.Operand {
+	closure := g.malloc(tac.Imm(types.PointerSize()), ctx)
+	ctx.Emit(tac.Store{Src: tac.Global(name), Addr: closure, Size: types.PointerSize()})
+	return closure
+}
+
+//visitExpressions evaluates a list of expressions in order
+func (g *Generator) visitExpressions(exprs []ast.Expression, ctx *tac.Builder) []tac.Operand {
+	ops := make([]tac.Operand, len(exprs))
+	for i, expr := range exprs {
+		ops[i] = g.VisitExpression(expr, ctx)
+	}
+	return ops
+}
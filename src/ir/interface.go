@@ -0,0 +1,106 @@
+package ir
+
+import (
+	"wacc_32/ast"
+	"wacc_32/ir/tac"
+	"wacc_32/types"
+)
+
+//itable returns the label of the method table of a class for an interface it implements
+func itable(class, iface string) string {
+	return class + "." + iface + ".itable"
+}
+
+//addITables adds the method tables of a class for each interface it implements. An
+//interface's methods are in the order it lists them
+func (g *Generator) addITables(node ast.UserType, ctx *tac.Builder) {
+	for _, iface := range node.GetInterfaces() {
+		methods := iface.EvalType().(types.UserType).GetMethods()
+		funcs := make([]string, len(methods))
+		for i, method := range methods {
+			funcs[i] = node.Implementation(method)[1:]
+		}
+		ctx.AddVTable(itable(node.GetName(), iface.GetName()), funcs)
+	}
+}
+
+//visitAs evaluates an expression which is used as a value of type to, converting an
+//object to an interface its class implements
+func (g *Generator) visitAs(expr ast.Expression, to types.WaccType, ctx *tac.Builder) tac.Operand {
+	return g.convert(g.VisitExpression(expr, ctx), g.evalType(expr), to, ctx)
+}
+
+//convert returns value, of type from, as a value of type to. An object used as an
+//interface becomes a fat pointer holding the object followed by the method table of
+//its class for the interface
+func (g *Generator) convert(value tac.Operand, from, to types.WaccType, ctx *tac.Builder) tac.Operand {
+	class, ok1 := from.(types.UserType)
+	iface, ok2 := types.Substitute(to, g.subst).(types.UserType)
+	if !ok1 || !ok2 || class.GetName() == iface.GetName() || !g.interfaces[iface.GetName()] {
+		return value
+	}
+	fat := g.malloc(tac.Imm(2*types.PointerSize()), ctx)
+	ctx.Emit(tac.Store{Src: value, Addr: fat, Size: types.PointerSize()})
+	ctx.Emit(tac.Store{
+		Src:    g.itableOf(value, class.GetName(), iface.GetName(), ctx),
+		Addr:   fat,
+		Offset: int(types.PointerSize()),
+		Size:   types.PointerSize(),
+	})
+	return fat
+}
+
+//itableOf returns the method table for an interface of the runtime class of an object
+//whose static type is class. The object of a class in a class hierarchy may be of a
+//subclass, so the table is loaded from the slot for the interface in its vtable. A
+//null object keeps the table of its static class
+func (g *Generator) itableOf(object tac.Operand, class, iface string, ctx *tac.Builder) tac.Operand {
+	if _, ok := g.slots[class]; !ok {
+		return tac.Global(itable(class, iface))
+	}
+	slot := g.slotIndex(class, vslot{iface: iface})
+	table := ctx.NewTemp(types.PointerSize())
+	ctx.Emit(tac.Move{Dst: table, Src: tac.Global(itable(class, iface))})
+	loadBlock, endBlock := ctx.NewBlock(), ctx.NewBlock()
+	ctx.Terminate(tac.Branch{Cond: object, Then: loadBlock, Else: endBlock})
+	ctx.SetBlock(loadBlock)
+	vt := ctx.NewTemp(types.PointerSize())
+	ctx.Emit(tac.Load{Dst: vt, Addr: object, Size: types.PointerSize()})
+	ctx.Emit(tac.Load{Dst: table, Addr: vt, Offset: slot * int(types.PointerSize()), Size: types.PointerSize()})
+	ctx.Jump(endBlock)
+	ctx.SetBlock(endBlock)
+	return table
+}
+
+//callInterface calls the function the class of the object in the fat pointer this, the
+//last argument, runs for a method of an interface
+func (g *Generator) callInterface(node ast.RHSFunctionCall, dst tac.Temp, args []tac.Operand, ctx *tac.Builder) {
+	fn := g.interfaceCode(node, args, ctx)
+	ctx.Emit(tac.CallIndirect{Dst: dst, Func: fn, Args: args, Sizes: g.argSizes(node)})
+}
+
+//interfaceCode returns the address of the function the class of the object in the fat
+//pointer this, the last argument, runs for a method of an interface, which is in the
+//method's slot of the itable the fat pointer holds. The object replaces the fat pointer
+//in args, to be passed as this
+func (g *Generator) interfaceCode(node ast.RHSFunctionCall, args []tac.Operand, ctx *tac.Builder) tac.Temp {
+	iface, method, _ := node.GetMethod()
+	slot := 0
+	for i, m := range iface.GetMethods() {
+		if m == method {
+			slot = i
+		}
+	}
+	fat := args[len(args)-1]
+	ctx.Emit(tac.Check{Kind: tac.NullCheck, Args: []tac.Operand{fat}})
+	this := ctx.NewTemp(types.PointerSize())
+	ctx.Emit(tac.Load{Dst: this, Addr: fat, Size: types.PointerSize()})
+	ctx.Emit(tac.Check{Kind: tac.NullCheck, Args: []tac.Operand{this}})
+	table := ctx.NewTemp(types.PointerSize())
+	ctx.Emit(tac.Load{Dst: table, Addr: fat, Offset: int(types.PointerSize()), Size: types.PointerSize()})
+	fn := ctx.NewTemp(types.PointerSize())
+	ctx.Emit(tac.Load{Dst: fn, Addr: table, Offset: slot * int(types.PointerSize()), Size: types.PointerSize()})
+
+	args[len(args)-1] = this
+	return fn
+}
@@ -0,0 +1,102 @@
+package ir
+
+import (
+	"wacc_32/ast"
+	"wacc_32/ir/tac"
+	"wacc_32/symboltable"
+	"wacc_32/types"
+)
+
+var escapeCodes = map[byte]byte{
+	'\\': '\\',
+	't':  '\t',
+	'n':  '\n',
+	'b':  '\b',
+	'f':  '\f',
+	'r':  '\r',
+	'"':  '"',
+	'\'': '\'',
+	'0':  byte(0),
+}
+
+//Char is a string of the form '\?x'
+func parseEscapeChar(char string) byte {
+	if char[1] == '\\' {
+		return escapeCodes[char[2]]
+	}
+	return char[1]
+}
+
+//VisitLiteral visits AST node ast.Literal
+func (g *Generator) VisitLiteral(node ast.Literal, ctx *tac.Builder) tac.Operand {
+	if node.GetValue() == nil {
+		return tac.Imm(0)
+	}
+	wt := types.Substitute(node.EvalType(symboltable.SymbolTable{}), g.subst)
+	if wt.Is(types.Array) {
+		return g.visitArrayLiteral(node.GetValue().([]ast.Expression), wt.GetChildren()[0], ctx)
+	}
+
+	if wt.Is(types.UserDefinedType) {
+		fields, ok := node.GetValue().([]ast.Expression)
+		if !ok {
+			return tac.Imm(0) //An uninitialised field
+		}
+		return g.visitUserTypeLiteral(wt.(types.UserType), fields, ctx)
+	}
+
+	switch wt {
+	case types.Integer:
+		return tac.Imm(node.GetValue().(int))
+	case types.Boolean:
+		if node.GetValue().(bool) {
+			return tac.Imm(1)
+		}
+		return tac.Imm(0)
+	case types.Char:
+		return tac.Imm(parseEscapeChar(node.GetValue().(string)))
+	case types.Str:
+		return ctx.AddString(node.GetValue().(string))
+	case types.Sema:
+		sema := ctx.NewTemp(types.PointerSize())
+		ctx.Emit(tac.NewSema{Dst: sema, Value: node.GetValue().(int)})
+		return sema
+	}
+	return tac.Imm(0)
+}
+
+//visitArrayLiteral allocates the array before evaluating its elements
+func (g *Generator) visitArrayLiteral(elems []ast.Expression, elemType types.WaccType, ctx *tac.Builder) tac.Operand {
+	elemSize := types.TypeSize(elemType)
+	arr := g.malloc(tac.Imm(types.Word+len(elems)*int(elemSize)), ctx)
+	ctx.Emit(tac.Store{Src: tac.Imm(len(elems)), Addr: arr, Size: types.Word})
+	for i, expr := range elems {
+		ctx.Emit(tac.Store{
+			Src:    g.visitAs(expr, elemType, ctx),
+			Addr:   arr,
+			Offset: types.Word + i*int(elemSize),
+			Size:   elemSize,
+		})
+	}
+	return arr
+}
+
+//visitUserTypeLiteral allocates the struct before evaluating its fields
+func (g *Generator) visitUserTypeLiteral(ut types.UserType, fields []ast.Expression, ctx *tac.Builder) tac.Operand {
+	size := headerSize(ut)
+	for _, t := range ut.GetFieldTypes() {
+		size += int(g.typeSize(t))
+	}
+	ptr := g.malloc(tac.Imm(size), ctx)
+	if ut.IsPolymorphic() {
+		ctx.Emit(tac.Store{Src: tac.Global(vtable(ut.GetName())), Addr: ptr, Size: types.PointerSize()})
+	}
+
+	offset := headerSize(ut)
+	for i, expr := range fields {
+		fieldSize := g.exprSize(expr)
+		ctx.Emit(tac.Store{Src: g.visitAs(expr, ut.GetFieldTypes()[i], ctx), Addr: ptr, Offset: offset, Size: fieldSize})
+		offset += int(fieldSize)
+	}
+	return ptr
+}
@@ -0,0 +1,320 @@
+package ir
+
+import (
+	"wacc_32/assembly/builtins"
+	"wacc_32/ir/tac"
+	"wacc_32/types"
+)
+
+//With lockdep every lock is acquired and released through functions which record the
+//locks each thread holds and the order locks have been acquired in, in lists guarded by
+//a mutex of their own. A node of a list holds two words and then the next node
+const (
+	nodeA    = 0
+	nodeB    = 1
+	nodeNext = 2
+	nodeSize = 3
+)
+
+//runtimeErrorExit is the exit code of the builtins reporting runtime errors
+const runtimeErrorExit = -1
+
+//The labels of the lockdep globals and functions, none of them can clash with a wacc
+//function as they contain a dot
+const (
+	lockdepMutex = "lockdep.mutex" //The mutex guarding the lists
+	lockdepHeld  = "lockdep.held"  //The threads holding each lock
+	lockdepOrder = "lockdep.order" //A lock which has been acquired while holding another
+	lockdepNames = "lockdep.names" //The variable each lock was declared as
+
+	lockdepCreated   = "lockdep.created"
+	lockdepAcquire   = "lockdep.acquire"
+	lockdepRelease   = "lockdep.release"
+	lockdepWait      = "lockdep.wait"
+	lockdepTrylock   = "lockdep.trylock"
+	lockdepDestroyed = "lockdep.destroyed"
+	lockdepReport    = "lockdep.report"
+	lockdepName      = "lockdep.name"
+	lockdepFind      = "lockdep.find"
+	lockdepPush      = "lockdep.push"
+	lockdepRemove    = "lockdep.remove"
+)
+
+//SetLockdep makes the program stop with a runtime error when a thread acquires a lock
+//while holding one which was acquired while holding the first before
+func (g *Generator) SetLockdep() {
+	g.lockdep = true
+}
+
+//lockdepInit creates the mutex guarding the lockdep lists, at the start of main
+func (g *Generator) lockdepInit(ctx *tac.Builder) {
+	mutex := ctx.NewTemp(types.PointerSize())
+	ctx.Emit(tac.NewLock{Dst: mutex})
+	ctx.Emit(tac.Store{Src: mutex, Addr: tac.Global(lockdepMutex), Size: types.PointerSize()})
+}
+
+//lockdepCall calls one of the lockdep functions and returns its result, which is a
+//pointer or the result of a pthread function of the same size
+func lockdepCall(name string, ctx *tac.Builder, args ...tac.Operand) tac.Temp {
+	res := ctx.NewTemp(types.PointerSize())
+	ctx.Emit(tac.Call{Dst: res, Func: name, Args: args})
+	return res
+}
+
+//lockdepRuntime generates the globals and functions lockdep uses
+func (g *Generator) lockdepRuntime(ctx *tac.Builder) {
+	for _, label := range []string{lockdepMutex, lockdepHeld, lockdepOrder, lockdepNames} {
+		ctx.AddGlobal(label)
+	}
+	ptr := types.PointerSize()
+
+	//created(lock, name) records the name of a new lock
+	ctx.StartFunc(lockdepCreated)
+	lock, name := ctx.NewParam(ptr, ""), ctx.NewParam(ptr, "")
+	mutex := lockMeta(ctx)
+	lockdepCall(lockdepPush, ctx, tac.Global(lockdepNames), lock, name)
+	unlockMeta(mutex, ctx)
+	ctx.Terminate(tac.Return{Value: tac.Imm(0)})
+
+	//acquire(lock) checks the lock isn't acquired after any lock the thread holds which
+	//was acquired after it before, then locks it, returning the result of
+	//pthread_mutex_lock
+	ctx.StartFunc(lockdepAcquire)
+	lock = ctx.NewParam(ptr, "")
+	self := ctx.NewTemp(ptr)
+	ctx.Emit(tac.Call{Dst: self, Func: "pthread_self", C: true})
+	checkOrder(lock, self, ctx)
+	res := ctx.NewTemp(types.Word)
+	ctx.Emit(tac.Call{Dst: res, Func: "pthread_mutex_lock", Args: []tac.Operand{lock}, C: true})
+	recordHeld(res, lockdepPush, self, lock, ctx)
+
+	//wait(cond, lock) checks the lock is reacquired in order after the other locks the
+	//thread holds, like acquire, then waits on cond. The thread holds the lock again
+	//when it returns the result of pthread_cond_wait, so the held list is unchanged
+	ctx.StartFunc(lockdepWait)
+	cond := ctx.NewParam(ptr, "")
+	lock = ctx.NewParam(ptr, "")
+	self = ctx.NewTemp(ptr)
+	ctx.Emit(tac.Call{Dst: self, Func: "pthread_self", C: true})
+	checkOrder(lock, self, ctx)
+	res = ctx.NewTemp(types.Word)
+	ctx.Emit(tac.Call{Dst: res, Func: "pthread_cond_wait", Args: []tac.Operand{cond, lock}, C: true})
+	ctx.Terminate(tac.Return{Value: res})
+
+	//trylock(lock) locks the lock if it is free, a trylock can't deadlock so it orders
+	//nothing. It returns the result of pthread_mutex_trylock
+	ctx.StartFunc(lockdepTrylock)
+	lock = ctx.NewParam(ptr, "")
+	self = ctx.NewTemp(ptr)
+	ctx.Emit(tac.Call{Dst: self, Func: "pthread_self", C: true})
+	res = ctx.NewTemp(types.Word)
+	ctx.Emit(tac.Call{Dst: res, Func: "pthread_mutex_trylock", Args: []tac.Operand{lock}, C: true})
+	recordHeld(res, lockdepPush, self, lock, ctx)
+
+	//release(lock) unlocks the lock, returning the result of pthread_mutex_unlock
+	ctx.StartFunc(lockdepRelease)
+	lock = ctx.NewParam(ptr, "")
+	self = ctx.NewTemp(ptr)
+	ctx.Emit(tac.Call{Dst: self, Func: "pthread_self", C: true})
+	res = ctx.NewTemp(types.Word)
+	ctx.Emit(tac.Call{Dst: res, Func: "pthread_mutex_unlock", Args: []tac.Operand{lock}, C: true})
+	recordHeld(res, lockdepRemove, self, lock, ctx)
+
+	//destroyed(lock) forgets a lock which is about to be freed, a new lock could be
+	//allocated in its place
+	ctx.StartFunc(lockdepDestroyed)
+	lock = ctx.NewParam(ptr, "")
+	mutex = lockMeta(ctx)
+	walkList(tac.Global(lockdepOrder), func(a, b tac.Temp) tac.Temp {
+		return either(ctx, a, b, lock)
+	}, unlink(ctx), ctx)
+	walkList(tac.Global(lockdepNames), func(a, _ tac.Temp) tac.Temp {
+		return compare(ctx, tac.Eq, a, lock)
+	}, unlink(ctx), ctx)
+	unlockMeta(mutex, ctx)
+	ctx.Terminate(tac.Return{Value: tac.Imm(0)})
+
+	//report(lock, held) prints the runtime error for acquiring lock while holding held
+	ctx.StartFunc(lockdepReport)
+	lock, held := ctx.NewParam(ptr, ""), ctx.NewParam(ptr, "")
+	names := []tac.Operand{lock, held, lock}
+	for i, msg := range builtins.LockOrderMsg {
+		ctx.Emit(tac.Print{Src: ctx.AddString(`"` + msg + `"`), Type: types.Str})
+		if i < len(names) {
+			ctx.Emit(tac.Print{Src: lockdepCall(lockdepName, ctx, names[i]), Type: types.Str})
+		}
+	}
+	ctx.Terminate(tac.Exit{Code: tac.Imm(runtimeErrorExit)})
+
+	//name(lock) returns the name of a lock
+	ctx.StartFunc(lockdepName)
+	lock = ctx.NewParam(ptr, "")
+	walkList(tac.Global(lockdepNames), func(a, _ tac.Temp) tac.Temp {
+		return compare(ctx, tac.Eq, a, lock)
+	}, func(node, _ tac.Temp) bool {
+		name := ctx.NewTemp(ptr)
+		ctx.Emit(tac.Load{Dst: name, Addr: node, Offset: nodeB * int(ptr), Size: ptr})
+		ctx.Terminate(tac.Return{Value: name})
+		return false
+	}, ctx)
+	ctx.Terminate(tac.Return{Value: ctx.AddString(`"a lock"`)})
+
+	//find(list, a, b) returns the node of a list holding a and b, 0 if there is none
+	ctx.StartFunc(lockdepFind)
+	list, a, b := ctx.NewParam(ptr, ""), ctx.NewParam(ptr, ""), ctx.NewParam(ptr, "")
+	walkList(list, func(x, y tac.Temp) tac.Temp {
+		return both(ctx, tac.Eq, x, a, tac.Eq, y, b)
+	}, func(node, _ tac.Temp) bool {
+		ctx.Terminate(tac.Return{Value: node})
+		return false
+	}, ctx)
+	ctx.Terminate(tac.Return{Value: tac.Imm(0)})
+
+	//push(list, a, b) adds a node holding a and b to the front of a list
+	ctx.StartFunc(lockdepPush)
+	list, a, b = ctx.NewParam(ptr, ""), ctx.NewParam(ptr, ""), ctx.NewParam(ptr, "")
+	node, head := g.malloc(tac.Imm(nodeSize*int(ptr)), ctx), ctx.NewTemp(ptr)
+	ctx.Emit(tac.Load{Dst: head, Addr: list, Size: ptr})
+	ctx.Emit(tac.Store{Src: a, Addr: node, Offset: nodeA * int(ptr), Size: ptr})
+	ctx.Emit(tac.Store{Src: b, Addr: node, Offset: nodeB * int(ptr), Size: ptr})
+	ctx.Emit(tac.Store{Src: head, Addr: node, Offset: nodeNext * int(ptr), Size: ptr})
+	ctx.Emit(tac.Store{Src: node, Addr: list, Size: ptr})
+	ctx.Terminate(tac.Return{Value: tac.Imm(0)})
+
+	//remove(list, a, b) removes the first node of a list holding a and b
+	ctx.StartFunc(lockdepRemove)
+	list, a, b = ctx.NewParam(ptr, ""), ctx.NewParam(ptr, ""), ctx.NewParam(ptr, "")
+	remove := unlink(ctx)
+	walkList(list, func(x, y tac.Temp) tac.Temp {
+		return both(ctx, tac.Eq, x, a, tac.Eq, y, b)
+	}, func(node, link tac.Temp) bool {
+		remove(node, link)
+		ctx.Terminate(tac.Return{Value: tac.Imm(0)})
+		return false
+	}, ctx)
+	ctx.Terminate(tac.Return{Value: tac.Imm(0)})
+}
+
+//checkOrder checks self acquiring lock doesn't invert the order of an earlier
+//acquisition, reporting both locks and exiting if it does, and records lock as acquired
+//after each other lock self holds
+func checkOrder(lock, self tac.Operand, ctx *tac.Builder) {
+	ptr := types.PointerSize()
+	mutex := lockMeta(ctx)
+	walkList(tac.Global(lockdepHeld), func(a, b tac.Temp) tac.Temp {
+		return both(ctx, tac.Eq, a, self, tac.Ne, b, lock)
+	}, func(node, _ tac.Temp) bool {
+		held := ctx.NewTemp(ptr)
+		ctx.Emit(tac.Load{Dst: held, Addr: node, Offset: nodeB * int(ptr), Size: ptr})
+		inverted := lockdepCall(lockdepFind, ctx, tac.Global(lockdepOrder), lock, held)
+		report, ordered := ctx.NewBlock(), ctx.NewBlock()
+		ctx.Terminate(tac.Branch{Cond: compare(ctx, tac.Ne, inverted, tac.Imm(0)), Then: report, Else: ordered})
+		ctx.SetBlock(report)
+		lockdepCall(lockdepReport, ctx, lock, held)
+		ctx.Terminate(tac.Exit{Code: tac.Imm(runtimeErrorExit)})
+
+		ctx.SetBlock(ordered)
+		known := lockdepCall(lockdepFind, ctx, tac.Global(lockdepOrder), held, lock)
+		add, done := ctx.NewBlock(), ctx.NewBlock()
+		ctx.Terminate(tac.Branch{Cond: compare(ctx, tac.Ne, known, tac.Imm(0)), Then: done, Else: add})
+		ctx.SetBlock(add)
+		lockdepCall(lockdepPush, ctx, tac.Global(lockdepOrder), held, lock)
+		ctx.Jump(done)
+		ctx.SetBlock(done)
+		return false
+	}, ctx)
+	unlockMeta(mutex, ctx)
+}
+
+//lockMeta locks the mutex guarding the lockdep lists and returns it
+func lockMeta(ctx *tac.Builder) tac.Temp {
+	mutex := ctx.NewTemp(types.PointerSize())
+	ctx.Emit(tac.Load{Dst: mutex, Addr: tac.Global(lockdepMutex), Size: types.PointerSize()})
+	ctx.Emit(tac.Call{Dst: tac.NoTemp, Func: "pthread_mutex_lock", Args: []tac.Operand{mutex}, C: true})
+	return mutex
+}
+
+func unlockMeta(mutex tac.Temp, ctx *tac.Builder) {
+	ctx.Emit(tac.Call{Dst: tac.NoTemp, Func: "pthread_mutex_unlock", Args: []tac.Operand{mutex}, C: true})
+}
+
+//recordHeld pushes or removes the node of self holding lock in the held list if res,
+//the result of locking or unlocking it, is 0, and then returns res
+func recordHeld(res tac.Temp, update string, self, lock tac.Operand, ctx *tac.Builder) {
+	failed := compare(ctx, tac.Ne, res, tac.Imm(0))
+	record, end := ctx.NewBlock(), ctx.NewBlock()
+	ctx.Terminate(tac.Branch{Cond: failed, Then: end, Else: record})
+	ctx.SetBlock(record)
+	mutex := lockMeta(ctx)
+	lockdepCall(update, ctx, tac.Global(lockdepHeld), self, lock)
+	unlockMeta(mutex, ctx)
+	ctx.Jump(end)
+	ctx.SetBlock(end)
+	ctx.Terminate(tac.Return{Value: res})
+}
+
+func compare(ctx *tac.Builder, op tac.Op, left, right tac.Operand) tac.Temp {
+	res := ctx.NewTemp(types.Word)
+	ctx.Emit(tac.BinOp{Op: op, Dst: res, Left: left, Right: right})
+	return res
+}
+
+//both returns whether a op1 x and b op2 y
+func both(ctx *tac.Builder, op1 tac.Op, a, x tac.Operand, op2 tac.Op, b, y tac.Operand) tac.Temp {
+	return compare(ctx, tac.And, compare(ctx, op1, a, x), compare(ctx, op2, b, y))
+}
+
+//either returns whether a or b is x
+func either(ctx *tac.Builder, a, b, x tac.Operand) tac.Temp {
+	return compare(ctx, tac.Or, compare(ctx, tac.Eq, a, x), compare(ctx, tac.Eq, b, x))
+}
+
+//unlink returns a function which removes a node from its list and frees it, for
+//walkList
+func unlink(ctx *tac.Builder) func(node, link tac.Temp) bool {
+	return func(node, link tac.Temp) bool {
+		ptr := types.PointerSize()
+		next := ctx.NewTemp(ptr)
+		ctx.Emit(tac.Load{Dst: next, Addr: node, Offset: nodeNext * int(ptr), Size: ptr})
+		ctx.Emit(tac.Store{Src: next, Addr: link, Size: ptr})
+		ctx.Emit(tac.Call{Dst: tac.NoTemp, Func: "free", Args: []tac.Operand{node}, C: true})
+		return true
+	}
+}
+
+//walkList emits a loop over the nodes of the list whose head is stored at list. match
+//emits whether a node holding a and b is wanted, found emits what is done with it
+//given the address the node is stored at, its link. found returns true if it removed
+//the node from the list, the loop carries on unless found ends the block
+func walkList(list tac.Operand, match func(a, b tac.Temp) tac.Temp, found func(node, link tac.Temp) bool, ctx *tac.Builder) {
+	ptr := types.PointerSize()
+	link, node := ctx.NewTemp(ptr), ctx.NewTemp(ptr)
+	ctx.Emit(tac.Move{Dst: link, Src: list})
+	loop, body, end := ctx.NewBlock(), ctx.NewBlock(), ctx.NewBlock()
+	ctx.Jump(loop)
+
+	ctx.SetBlock(loop)
+	ctx.Emit(tac.Load{Dst: node, Addr: link, Size: ptr})
+	ctx.Terminate(tac.Branch{Cond: compare(ctx, tac.Eq, node, tac.Imm(0)), Then: end, Else: body})
+
+	ctx.SetBlock(body)
+	a, b := ctx.NewTemp(ptr), ctx.NewTemp(ptr)
+	ctx.Emit(tac.Load{Dst: a, Addr: node, Offset: nodeA * int(ptr), Size: ptr})
+	ctx.Emit(tac.Load{Dst: b, Addr: node, Offset: nodeB * int(ptr), Size: ptr})
+	hit, next := ctx.NewBlock(), ctx.NewBlock()
+	ctx.Terminate(tac.Branch{Cond: match(a, b), Then: hit, Else: next})
+
+	ctx.SetBlock(hit)
+	if found(node, link) {
+		//The node after the one removed is now stored at link
+		ctx.Jump(loop)
+	} else {
+		ctx.Jump(next)
+	}
+
+	ctx.SetBlock(next)
+	ctx.Emit(tac.Index{Dst: link, Base: node, Index: tac.Imm(0), Scale: 1, Offset: nodeNext * int(ptr)})
+	ctx.Jump(loop)
+
+	ctx.SetBlock(end)
+}
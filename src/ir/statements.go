@@ -0,0 +1,221 @@
+package ir
+
+import (
+	"wacc_32/ast"
+	"wacc_32/ir/tac"
+	"wacc_32/types"
+)
+
+//VisitAST visits AST node ast.AST
+func (g *Generator) VisitAST(node ast.AST, ctx *tac.Builder) tac.Terminator {
+	return node.(ast.TerminatorAcceptor).AcceptTerminator(g, ctx)
+}
+
+//VisitStatement starts the code of a statement on its line of the source
+func (g *Generator) VisitStatement(node ast.Statement, ctx *tac.Builder) tac.Terminator {
+	g.mark(node, ctx)
+	return g.VisitAST(node, ctx)
+}
+
+//VisitStatSkip visits AST node ast.StatSkip
+func (g *Generator) VisitStatSkip(node ast.StatSkip, ctx *tac.Builder) tac.Terminator {
+	return nil
+}
+
+//VisitStatRead reads into a variable, or through a temp into memory
+func (g *Generator) VisitStatRead(node ast.StatRead, ctx *tac.Builder) tac.Terminator {
+	toRead := node.GetToRead()
+	loc := g.locate(toRead, ctx)
+	wt := types.Substitute(toRead.EvalType(*node.GetSymbolTable()), g.subst)
+	if loc.addr == nil {
+		ctx.Emit(tac.Read{Dst: loc.temp, Type: wt})
+		return nil
+	}
+	t := loc.load(ctx).(tac.Temp)
+	ctx.Emit(tac.Read{Dst: t, Type: wt})
+	loc.store(ctx, t)
+	return nil
+}
+
+//VisitStatFree frees a non null reference, locks and condition variables are
+//destroyed first
+func (g *Generator) VisitStatFree(node ast.StatFree, ctx *tac.Builder) tac.Terminator {
+	expr := node.GetExpression()
+	ptr := g.VisitExpression(expr, ctx)
+	ctx.Emit(tac.Check{Kind: tac.NullCheck, Args: []tac.Operand{ptr}})
+	switch freeType := expr.EvalType(*node.GetSymbolTable()); {
+	case freeType.Is(types.Lock):
+		if g.lockdep {
+			ctx.Emit(tac.Call{Dst: tac.NoTemp, Func: lockdepDestroyed, Args: []tac.Operand{ptr}})
+		}
+		ctx.Emit(tac.Call{Dst: tac.NoTemp, Func: "pthread_mutex_destroy", Args: []tac.Operand{ptr}, C: true})
+	case freeType.Is(types.Cond):
+		ctx.Emit(tac.Call{Dst: tac.NoTemp, Func: "pthread_cond_destroy", Args: []tac.Operand{ptr}, C: true})
+	}
+	ctx.Emit(tac.Call{Dst: tac.NoTemp, Func: "free", Args: []tac.Operand{ptr}, C: true})
+	return nil
+}
+
+//VisitStatNewassign declares a variable, locks and condition variables are always
+//created fresh
+func (g *Generator) VisitStatNewassign(node ast.StatNewassign, ctx *tac.Builder) tac.Terminator {
+	value := g.visitAs(node.GetRHS(), node.GetType(), ctx)
+	pos, _ := ast.Pos(&node)
+	t := g.declare(ctx, node.GetSymbolTable(), node.GetName(), node.GetType(), pos)
+	if node.GetType().Is(types.Lock) {
+		ctx.Emit(tac.NewLock{Dst: t})
+		if g.lockdep {
+			name := ctx.AddString(`"` + node.GetName() + `"`)
+			ctx.Emit(tac.Call{Dst: tac.NoTemp, Func: lockdepCreated, Args: []tac.Operand{t, name}})
+		}
+	} else if node.GetType().Is(types.Cond) {
+		ctx.Emit(tac.NewCond{Dst: t})
+	} else {
+		ctx.Emit(tac.Move{Dst: t, Src: value})
+	}
+	return nil
+}
+
+type printer interface {
+	GetExprToPrint() ast.Expression
+}
+
+func (g *Generator) visitPrinter(node printer, ctx *tac.Builder) {
+	toPrint := node.GetExprToPrint()
+	value := g.VisitExpression(toPrint, ctx)
+	ctx.Emit(tac.Print{Src: value, Type: g.evalType(toPrint)})
+}
+
+//VisitStatPrint visits AST node ast.StatPrint
+func (g *Generator) VisitStatPrint(node ast.StatPrint, ctx *tac.Builder) tac.Terminator {
+	g.visitPrinter(node, ctx)
+	return nil
+}
+
+//VisitStatPrintln visits AST node ast.StatPrintln
+func (g *Generator) VisitStatPrintln(node ast.StatPrintln, ctx *tac.Builder) tac.Terminator {
+	g.visitPrinter(node, ctx)
+	ctx.Emit(tac.PrintLine{})
+	return nil
+}
+
+//VisitStatExit visits AST node ast.StatExit
+func (g *Generator) VisitStatExit(node ast.StatExit, ctx *tac.Builder) tac.Terminator {
+	exit := tac.Exit{Code: g.VisitExpression(node.GetCode(), ctx)}
+	ctx.Terminate(exit)
+	return exit
+}
+
+//loop generates
+//
+//		jump cond
+//	cond:
+//		branch <cond>, body, end
+//	body:
+//		<body>
+//		jump cond
+//	end:
+func (g *Generator) loop(cond ast.Expression, body func(), ctx *tac.Builder) {
+	condBlock, bodyBlock, endBlock := ctx.NewBlock(), ctx.NewBlock(), ctx.NewBlock()
+	ctx.Jump(condBlock)
+
+	ctx.SetBlock(condBlock)
+	g.mark(cond, ctx)
+	ctx.Terminate(tac.Branch{Cond: g.VisitExpression(cond, ctx), Then: bodyBlock, Else: endBlock})
+
+	ctx.SetBlock(bodyBlock)
+	body()
+	ctx.Jump(condBlock)
+
+	ctx.SetBlock(endBlock)
+}
+
+//VisitStatFor visits AST node ast.StatFor
+func (g *Generator) VisitStatFor(node ast.StatFor, ctx *tac.Builder) tac.Terminator {
+	g.VisitStatNewassign(node.GetInitial(), ctx)
+	g.loop(node.GetCond(), func() {
+		g.VisitStatement(node.GetBody(), ctx)
+		change := node.GetChange()
+		g.mark(&change, ctx)
+		g.VisitStatAssign(change, ctx)
+	}, ctx)
+	return nil
+}
+
+//VisitStatWhile visits AST node ast.StatWhile
+func (g *Generator) VisitStatWhile(node ast.StatWhile, ctx *tac.Builder) tac.Terminator {
+	g.loop(node.GetCond(), func() {
+		g.VisitStatement(node.GetBody(), ctx)
+	}, ctx)
+	return nil
+}
+
+//VisitStatDoWhile runs the body before checking the condition
+func (g *Generator) VisitStatDoWhile(node ast.StatDoWhile, ctx *tac.Builder) tac.Terminator {
+	bodyBlock, endBlock := ctx.NewBlock(), ctx.NewBlock()
+	ctx.Jump(bodyBlock)
+
+	ctx.SetBlock(bodyBlock)
+	g.VisitStatement(node.GetBody(), ctx)
+	g.mark(node.GetCond(), ctx)
+	ctx.Terminate(tac.Branch{Cond: g.VisitExpression(node.GetCond(), ctx), Then: bodyBlock, Else: endBlock})
+
+	ctx.SetBlock(endBlock)
+	return nil
+}
+
+//VisitStatBegin visits AST node ast.StatBegin
+func (g *Generator) VisitStatBegin(node ast.StatBegin, ctx *tac.Builder) tac.Terminator {
+	return g.VisitStatement(node.GetStat(), ctx)
+}
+
+//VisitStatAssign evaluates the rhs before the lhs
+func (g *Generator) VisitStatAssign(node ast.StatAssign, ctx *tac.Builder) tac.Terminator {
+	value := g.visitAs(node.GetRHS(), g.evalType(node.GetLHS()), ctx)
+	g.locate(node.GetLHS(), ctx).store(ctx, value)
+	return nil
+}
+
+//VisitStatIf only generates the chosen branch if the condition is a literal
+func (g *Generator) VisitStatIf(node ast.StatIf, ctx *tac.Builder) tac.Terminator {
+	cond := g.VisitExpression(node.GetCondition(), ctx)
+	if imm, ok := cond.(tac.Imm); ok {
+		if imm != 0 {
+			return g.VisitStatement(node.GetIfStat(), ctx)
+		}
+		return g.VisitStatement(node.GetElseStat(), ctx)
+	}
+
+	thenBlock, elseBlock, endBlock := ctx.NewBlock(), ctx.NewBlock(), ctx.NewBlock()
+	ctx.Terminate(tac.Branch{Cond: cond, Then: thenBlock, Else: elseBlock})
+
+	ctx.SetBlock(thenBlock)
+	thenTerm := g.VisitStatement(node.GetIfStat(), ctx)
+	fallsThrough := ctx.Block() != nil
+	ctx.Jump(endBlock)
+
+	ctx.SetBlock(elseBlock)
+	elseTerm := g.VisitStatement(node.GetElseStat(), ctx)
+	fallsThrough = fallsThrough || ctx.Block() != nil
+	ctx.Jump(endBlock)
+
+	if !fallsThrough {
+		if thenTerm != nil {
+			return thenTerm
+		}
+		return elseTerm
+	}
+	ctx.SetBlock(endBlock)
+	return nil
+}
+
+//VisitStatMultiple returns the first statement which doesn't fall through
+func (g *Generator) VisitStatMultiple(node ast.StatMultiple, ctx *tac.Builder) tac.Terminator {
+	var term tac.Terminator
+	for _, stat := range node {
+		if t := g.VisitStatement(stat, ctx); term == nil {
+			term = t
+		}
+	}
+	return term
+}
@@ -0,0 +1,103 @@
+package tac
+
+import (
+	"fmt"
+	"strings"
+)
+
+//Block is a basic block, a straight line sequence of instructions which is
+//only entered at the top and left through its terminator
+type Block struct {
+	Label  string
+	Instrs []Instr
+	Term   Terminator
+}
+
+//String returns
+//
+//	<label>:
+//		<instr>
+//		...
+//		<terminator>
+func (b *Block) String() string {
+	strs := make([]string, 0, len(b.Instrs)+2)
+	strs = append(strs, b.Label+":")
+	for _, instr := range b.Instrs {
+		strs = append(strs, "\t"+instr.String())
+	}
+	if b.Term != nil {
+		strs = append(strs, "\t"+b.Term.String())
+	}
+	return strings.Join(strs, "\n")
+}
+
+//Successors returns the blocks control can flow to from b
+func (b *Block) Successors() []*Block {
+	if b.Term == nil {
+		return nil
+	}
+	return b.Term.Successors()
+}
+
+//Terminator ends a basic block
+type Terminator interface {
+	fmt.Stringer
+	Successors() []*Block
+}
+
+//Jump continues at Target
+type Jump struct {
+	Target *Block
+}
+
+func (j Jump) String() string {
+	return "jump " + j.Target.Label
+}
+
+//Successors returns the target
+func (j Jump) Successors() []*Block {
+	return []*Block{j.Target}
+}
+
+//Branch continues at Then if Cond is true, otherwise at Else
+type Branch struct {
+	Cond       Operand
+	Then, Else *Block
+}
+
+func (b Branch) String() string {
+	return fmt.Sprintf("branch %s, %s, %s", b.Cond, b.Then.Label, b.Else.Label)
+}
+
+//Successors returns both targets
+func (b Branch) Successors() []*Block {
+	return []*Block{b.Then, b.Else}
+}
+
+//Return leaves the function with a value
+type Return struct {
+	Value Operand
+}
+
+func (r Return) String() string {
+	return "ret " + r.Value.String()
+}
+
+//Successors returns nothing
+func (r Return) Successors() []*Block {
+	return nil
+}
+
+//Exit stops the whole program with an exit code
+type Exit struct {
+	Code Operand
+}
+
+func (e Exit) String() string {
+	return "exit " + e.Code.String()
+}
+
+//Successors returns nothing
+func (e Exit) Successors() []*Block {
+	return nil
+}
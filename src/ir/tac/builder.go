@@ -0,0 +1,134 @@
+package tac
+
+import (
+	"strconv"
+	"wacc_32/types"
+)
+
+//Builder appends instructions to the current block of the function being built
+type Builder struct {
+	prog    *Program
+	fn      *Func
+	block   *Block
+	nBlocks int
+}
+
+//NewBuilder creates a builder for an empty program
+func NewBuilder() *Builder {
+	return &Builder{prog: &Program{UserTypes: make(map[string]types.UserType)}}
+}
+
+//Program returns the program built so far
+func (b *Builder) Program() *Program {
+	return b.prog
+}
+
+//Func returns the function being built
+func (b *Builder) Func() *Func {
+	return b.fn
+}
+
+//StartFunc adds a function to the program and continues in its entry block
+func (b *Builder) StartFunc(name string) *Func {
+	b.fn = &Func{Name: name, Scopes: []int{NoScope}}
+	b.prog.Funcs = append(b.prog.Funcs, b.fn)
+	b.SetBlock(b.NewBlock())
+	return b.fn
+}
+
+//NewTemp creates a temp holding a value of the given size
+func (b *Builder) NewTemp(size types.Size) Temp {
+	return b.NewVar(size, "")
+}
+
+//NewVar creates a temp holding the wacc variable name
+func (b *Builder) NewVar(size types.Size, name string) Temp {
+	b.fn.Temps = append(b.fn.Temps, TempInfo{Size: size, Name: name})
+	return Temp(len(b.fn.Temps) - 1)
+}
+
+//NewParam creates a temp holding the next parameter of the function
+func (b *Builder) NewParam(size types.Size, name string) Temp {
+	t := b.NewVar(size, name)
+	b.fn.Params = append(b.fn.Params, t)
+	return t
+}
+
+//NoScope is the parent of the outermost scope of a function
+const NoScope = -1
+
+//NewScope creates a scope of the function nested in parent
+func (b *Builder) NewScope(parent int) int {
+	b.fn.Scopes = append(b.fn.Scopes, parent)
+	return len(b.fn.Scopes) - 1
+}
+
+//AddUserType adds a struct, class or interface to the program
+func (b *Builder) AddUserType(ut types.UserType) {
+	b.prog.UserTypes[ut.GetName()] = ut
+}
+
+//NewBlock creates a block with a unique label, it isn't part of the function
+//until it is passed to SetBlock
+func (b *Builder) NewBlock() *Block {
+	b.nBlocks++
+	return &Block{Label: ".L" + strconv.Itoa(b.nBlocks-1)}
+}
+
+//SetBlock places blk at the end of the function and continues building there
+func (b *Builder) SetBlock(blk *Block) {
+	b.fn.Blocks = append(b.fn.Blocks, blk)
+	b.block = blk
+}
+
+//Block returns the block being built, nil if the current position is unreachable
+func (b *Builder) Block() *Block {
+	return b.block
+}
+
+//current returns the block being built, code after a terminator goes in a new
+//block which nothing jumps to
+func (b *Builder) current() *Block {
+	if b.block == nil {
+		b.SetBlock(b.NewBlock())
+	}
+	return b.block
+}
+
+//Emit appends instr to the current block
+func (b *Builder) Emit(instr Instr) {
+	blk := b.current()
+	blk.Instrs = append(blk.Instrs, instr)
+}
+
+//Terminate ends the current block, nothing can be added to it afterwards
+func (b *Builder) Terminate(term Terminator) {
+	b.current().Term = term
+	b.block = nil
+}
+
+//Jump ends the current block with a jump to target, if it is reachable
+func (b *Builder) Jump(target *Block) {
+	if b.block != nil {
+		b.Terminate(Jump{Target: target})
+	}
+}
+
+//AddVTable adds the vtable of a class to the data section and returns its address
+func (b *Builder) AddVTable(label string, funcs []string) Global {
+	b.prog.VTables = append(b.prog.VTables, VTable{Label: label, Funcs: funcs})
+	return Global(label)
+}
+
+//AddGlobal adds a word which starts as 0 to the data section and returns its address
+func (b *Builder) AddGlobal(label string) Global {
+	b.prog.Globals = append(b.prog.Globals, label)
+	return Global(label)
+}
+
+//AddString adds a string literal to the data section and returns its address
+func (b *Builder) AddString(value string) Global {
+	label := "msg_" + strconv.Itoa(len(b.prog.Strings))
+	b.prog.Strings = append(b.prog.Strings, StringLit{Label: label, Value: value})
+	return Global(label)
+}
@@ -0,0 +1,77 @@
+package tac
+
+import (
+	"testing"
+	"wacc_32/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilderTerminate(t *testing.T) {
+	b := NewBuilder()
+	fn := b.StartFunc(MainName)
+	x := b.NewVar(types.Word, "x")
+	b.Emit(Move{Dst: x, Src: Imm(1)})
+	b.Terminate(Exit{Code: x})
+	assert.Nil(t, b.Block(), "Code after a terminator is unreachable")
+
+	//Unreachable code still goes in a block of its own
+	b.Emit(Move{Dst: x, Src: Imm(2)})
+	b.Terminate(Return{Value: x})
+	assert.Len(t, fn.Blocks, 2)
+	assert.Equal(t, Exit{Code: x}, fn.Blocks[0].Term)
+	assert.Len(t, fn.Blocks[1].Instrs, 1)
+}
+
+func TestBuilderJump(t *testing.T) {
+	b := NewBuilder()
+	fn := b.StartFunc(MainName)
+	end := b.NewBlock()
+	b.Jump(end)
+	b.Jump(end)
+	assert.Len(t, fn.Blocks, 1, "Only reachable blocks jump")
+
+	b.SetBlock(end)
+	b.Terminate(Return{Value: Imm(0)})
+	assert.Equal(t, []*Block{end}, fn.Blocks[0].Successors())
+	assert.Nil(t, end.Successors())
+}
+
+func TestProgramString(t *testing.T) {
+	b := NewBuilder()
+	b.StartFunc("f")
+	x := b.NewParam(types.Word, "x")
+	str := b.AddString(`"hi"`)
+	b.Emit(Print{Src: str, Type: types.Str})
+	y := b.NewTemp(types.Word)
+	b.Emit(BinOp{Op: Add, Dst: y, Left: x, Right: Imm(1)})
+	b.Terminate(Return{Value: y})
+
+	expected := `@msg_0 = "hi"
+
+func f(%0:i32 x) {
+.L0:
+	print string @msg_0
+	%1 = add %0, 1
+	ret %1
+}`
+	assert.Equal(t, expected, b.Program().String())
+}
+
+func TestProgramGlobals(t *testing.T) {
+	b := NewBuilder()
+	b.StartFunc(MainName)
+	count := b.AddGlobal("count")
+	n := b.NewTemp(types.Word)
+	b.Emit(Load{Dst: n, Addr: count, Size: types.Word})
+	b.Terminate(Return{Value: n})
+
+	expected := `@count = 0
+
+func main() {
+.L0:
+	%0 = load i32 [@count]
+	ret %0
+}`
+	assert.Equal(t, expected, b.Program().String())
+}
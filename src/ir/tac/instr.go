@@ -0,0 +1,324 @@
+package tac
+
+import (
+	"fmt"
+	"strings"
+	"wacc_32/types"
+)
+
+//Instr is a three address instruction, it reads at most a few operands and
+//writes at most one temp
+type Instr interface {
+	fmt.Stringer
+	instr()
+}
+
+//Op is an arithmetic, comparison or logical operation
+type Op int
+
+//The operations, Add, Sub, Mul and Neg raise an overflow error and Div and Mod
+//raise a divide by zero error at runtime
+const (
+	Add Op = iota + 1
+	Sub
+	Mul
+	Div
+	Mod
+	Lt
+	Le
+	Gt
+	Ge
+	Eq
+	Ne
+	And
+	Or
+	Xor
+	Neg
+	Not
+)
+
+var opStrings = []string{"add", "sub", "mul", "div", "mod", "lt", "le", "gt", "ge", "eq", "ne", "and", "or", "xor", "neg", "not"}
+
+func (op Op) String() string {
+	return opStrings[op-1]
+}
+
+//CheckKind is a runtime check which stops the program with an error
+type CheckKind int
+
+//The runtime checks
+const (
+	NullCheck CheckKind = iota + 1
+	BoundsCheck
+	LockCheck
+	UnlockCheck
+	ClosedCheck
+	CapacityCheck
+)
+
+var checkStrings = []string{"null", "bounds", "lock", "unlock", "closed", "capacity"}
+
+func (c CheckKind) String() string {
+	return checkStrings[c-1]
+}
+
+//sizeString names the machine type of a value of the given size
+func sizeString(size types.Size) string {
+	return fmt.Sprintf("i%d", 8*size)
+}
+
+func operandsString(ops []Operand) string {
+	strs := make([]string, len(ops))
+	for i, op := range ops {
+		strs[i] = op.String()
+	}
+	return strings.Join(strs, ", ")
+}
+
+func addrString(addr Operand, offset int) string {
+	if offset == 0 {
+		return "[" + addr.String() + "]"
+	}
+	return fmt.Sprintf("[%s + %d]", addr, offset)
+}
+
+//Move copies Src to Dst, converting between sizes
+type Move struct {
+	Dst Temp
+	Src Operand
+}
+
+func (m Move) String() string {
+	return fmt.Sprintf("%s = %s", m.Dst, m.Src)
+}
+
+//BinOp stores Left Op Right in Dst
+type BinOp struct {
+	Op          Op
+	Dst         Temp
+	Left, Right Operand
+}
+
+func (b BinOp) String() string {
+	return fmt.Sprintf("%s = %s %s, %s", b.Dst, b.Op, b.Left, b.Right)
+}
+
+//UnOp stores Op Src in Dst
+type UnOp struct {
+	Op  Op
+	Dst Temp
+	Src Operand
+}
+
+func (u UnOp) String() string {
+	return fmt.Sprintf("%s = %s %s", u.Dst, u.Op, u.Src)
+}
+
+//Load reads Size bytes at Addr + Offset into Dst
+type Load struct {
+	Dst    Temp
+	Addr   Operand
+	Offset int
+	Size   types.Size
+}
+
+func (l Load) String() string {
+	return fmt.Sprintf("%s = load %s %s", l.Dst, sizeString(l.Size), addrString(l.Addr, l.Offset))
+}
+
+//Store writes the bottom Size bytes of Src to Addr + Offset
+type Store struct {
+	Src    Operand
+	Addr   Operand
+	Offset int
+	Size   types.Size
+}
+
+func (s Store) String() string {
+	return fmt.Sprintf("store %s %s, %s", sizeString(s.Size), s.Src, addrString(s.Addr, s.Offset))
+}
+
+//Index stores the address Base + Index * Scale + Offset in Dst
+type Index struct {
+	Dst           Temp
+	Base, Index   Operand
+	Scale, Offset int
+}
+
+func (i Index) String() string {
+	return fmt.Sprintf("%s = index %s, %s * %d + %d", i.Dst, i.Base, i.Index, i.Scale, i.Offset)
+}
+
+//Call calls Func with Args and stores the result in Dst, unless Dst is NoTemp.
+//wacc functions take their arguments on the stack, C functions in registers
+type Call struct {
+	Dst  Temp
+	Func string
+	Args []Operand
+	C    bool
+}
+
+func (c Call) String() string {
+	call := "call"
+	if c.C {
+		call = "ccall"
+	}
+	str := fmt.Sprintf("%s %s(%s)", call, c.Func, operandsString(c.Args))
+	if c.Dst == NoTemp {
+		return str
+	}
+	return c.Dst.String() + " = " + str
+}
+
+//CallClosure calls the function value Closure with Args, of the given Sizes, and stores the
+//result in Dst. The closure is passed after the arguments, its first word is the address
+//of the code to run
+type CallClosure struct {
+	Dst     Temp
+	Closure Operand
+	Args    []Operand
+	Sizes   []types.Size
+}
+
+func (c CallClosure) String() string {
+	return fmt.Sprintf("%s = call *%s(%s)", c.Dst, c.Closure, operandsString(c.Args))
+}
+
+//CallIndirect calls the wacc function at the address Func with Args, of the given Sizes,
+//and stores the result in Dst
+type CallIndirect struct {
+	Dst   Temp
+	Func  Operand
+	Args  []Operand
+	Sizes []types.Size
+}
+
+func (c CallIndirect) String() string {
+	return fmt.Sprintf("%s = call %s(%s)", c.Dst, c.Func, operandsString(c.Args))
+}
+
+//Spawn runs the wacc function Func with Args in a new thread, or the wacc function at
+//the address Code with Args of the given Sizes when Code is given. The thread is detached
+//unless Handle is given, then it is created in the memory Handle points to so it can
+//be joined
+type Spawn struct {
+	Func   string
+	Code   Operand
+	Args   []Operand
+	Sizes  []types.Size
+	Handle Operand
+}
+
+func (s Spawn) String() string {
+	fn := s.Func
+	if s.Code != nil {
+		fn = s.Code.String()
+	}
+	if s.Handle != nil {
+		return fmt.Sprintf("spawn %s(%s) in %s", fn, operandsString(s.Args), s.Handle)
+	}
+	return fmt.Sprintf("spawn %s(%s)", fn, operandsString(s.Args))
+}
+
+//NewLock creates an error checking mutex
+type NewLock struct {
+	Dst Temp
+}
+
+func (n NewLock) String() string {
+	return n.Dst.String() + " = lock"
+}
+
+//NewSema creates a semaphore with an initial value
+type NewSema struct {
+	Dst   Temp
+	Value int
+}
+
+func (n NewSema) String() string {
+	return fmt.Sprintf("%s = sema %d", n.Dst, n.Value)
+}
+
+//NewCond creates a condition variable
+type NewCond struct {
+	Dst Temp
+}
+
+func (n NewCond) String() string {
+	return n.Dst.String() + " = cond"
+}
+
+//Print prints Src formatted according to its wacc type
+type Print struct {
+	Src  Operand
+	Type types.WaccType
+}
+
+func (p Print) String() string {
+	return fmt.Sprintf("print %s %s", p.Type, p.Src)
+}
+
+//PrintLine prints a newline
+type PrintLine struct{}
+
+func (p PrintLine) String() string {
+	return "println"
+}
+
+//Read reads a value of type Type into Dst, Dst is unchanged if nothing could be read
+type Read struct {
+	Dst  Temp
+	Type types.WaccType
+}
+
+func (r Read) String() string {
+	return fmt.Sprintf("read %s %s", r.Type, r.Dst)
+}
+
+//Check stops the program with a runtime error if its arguments fail the check
+//null:   Args[0] is null
+//bounds: Args[0] is not a valid index into an array of length Args[1]
+//lock:   Args[0], the result of pthread_mutex_lock, is EDEADLK
+//unlock: Args[0], the result of pthread_mutex_unlock, is EPERM
+type Check struct {
+	Kind CheckKind
+	Args []Operand
+}
+
+func (c Check) String() string {
+	return fmt.Sprintf("check %s %s", c.Kind, operandsString(c.Args))
+}
+
+//Loc marks the start of the code for a line of the source, File is empty for the
+//file being compiled and Col counts from 0. The code is in Scope of the function
+type Loc struct {
+	File      string
+	Line, Col int
+	Scope     int
+}
+
+func (l Loc) String() string {
+	if l.File == "" {
+		return fmt.Sprintf("loc %d:%d", l.Line, l.Col)
+	}
+	return fmt.Sprintf("loc %s:%d:%d", l.File, l.Line, l.Col)
+}
+
+func (m Move) instr()         {}
+func (b BinOp) instr()        {}
+func (u UnOp) instr()         {}
+func (l Load) instr()         {}
+func (s Store) instr()        {}
+func (i Index) instr()        {}
+func (c Call) instr()         {}
+func (c CallClosure) instr()  {}
+func (c CallIndirect) instr() {}
+func (s Spawn) instr()        {}
+func (n NewLock) instr()      {}
+func (n NewSema) instr()      {}
+func (n NewCond) instr()      {}
+func (p Print) instr()        {}
+func (p PrintLine) instr()    {}
+func (r Read) instr()         {}
+func (c Check) instr()        {}
+func (l Loc) instr()          {}
@@ -0,0 +1,157 @@
+package tac
+
+//TempSet is a set of temps
+type TempSet map[Temp]bool
+
+//Copy returns a new set with the same temps
+func (s TempSet) Copy() TempSet {
+	c := make(TempSet, len(s))
+	for t := range s {
+		c[t] = true
+	}
+	return c
+}
+
+//operandTemps returns the temps among ops
+func operandTemps(ops ...Operand) []Temp {
+	temps := make([]Temp, 0, len(ops))
+	for _, op := range ops {
+		if t, ok := op.(Temp); ok {
+			temps = append(temps, t)
+		}
+	}
+	return temps
+}
+
+//Uses returns the temps read by instr
+func Uses(instr Instr) []Temp {
+	switch i := instr.(type) {
+	case Move:
+		return operandTemps(i.Src)
+	case BinOp:
+		return operandTemps(i.Left, i.Right)
+	case UnOp:
+		return operandTemps(i.Src)
+	case Load:
+		return operandTemps(i.Addr)
+	case Store:
+		return operandTemps(i.Src, i.Addr)
+	case Index:
+		return operandTemps(i.Base, i.Index)
+	case Call:
+		return operandTemps(i.Args...)
+	case CallClosure:
+		return operandTemps(append([]Operand{i.Closure}, i.Args...)...)
+	case CallIndirect:
+		return operandTemps(append([]Operand{i.Func}, i.Args...)...)
+	case Spawn:
+		return operandTemps(append([]Operand{i.Code, i.Handle}, i.Args...)...)
+	case Print:
+		return operandTemps(i.Src)
+	case Read:
+		//Dst is unchanged if nothing could be read
+		return []Temp{i.Dst}
+	case Check:
+		return operandTemps(i.Args...)
+	}
+	return nil
+}
+
+//Def returns the temp written by instr, NoTemp if it doesn't write one
+func Def(instr Instr) Temp {
+	switch i := instr.(type) {
+	case Move:
+		return i.Dst
+	case BinOp:
+		return i.Dst
+	case UnOp:
+		return i.Dst
+	case Load:
+		return i.Dst
+	case Index:
+		return i.Dst
+	case Call:
+		return i.Dst
+	case CallClosure:
+		return i.Dst
+	case CallIndirect:
+		return i.Dst
+	case NewLock:
+		return i.Dst
+	case NewSema:
+		return i.Dst
+	case NewCond:
+		return i.Dst
+	case Read:
+		return i.Dst
+	}
+	return NoTemp
+}
+
+//TermUses returns the temps read by a terminator
+func TermUses(term Terminator) []Temp {
+	switch t := term.(type) {
+	case Branch:
+		return operandTemps(t.Cond)
+	case Return:
+		return operandTemps(t.Value)
+	case Exit:
+		return operandTemps(t.Code)
+	}
+	return nil
+}
+
+//LiveOut returns the temps live at the end of every block of f
+func (f *Func) LiveOut() map[*Block]TempSet {
+	liveIn := make(map[*Block]TempSet, len(f.Blocks))
+	liveOut := make(map[*Block]TempSet, len(f.Blocks))
+	for _, b := range f.Blocks {
+		liveIn[b] = TempSet{}
+		liveOut[b] = TempSet{}
+	}
+
+	//Iterate backwards until nothing changes, blocks are mostly in program order
+	for changed := true; changed; {
+		changed = false
+		for i := len(f.Blocks) - 1; i >= 0; i-- {
+			b := f.Blocks[i]
+			out := liveOut[b]
+			for _, succ := range b.Successors() {
+				for t := range liveIn[succ] {
+					if !out[t] {
+						out[t] = true
+						changed = true
+					}
+				}
+			}
+			in := b.LiveIn(out)
+			if len(in) != len(liveIn[b]) {
+				changed = true
+			}
+			liveIn[b] = in
+		}
+	}
+	return liveOut
+}
+
+//LiveIn returns the temps live at the start of b, given those live at its end
+func (b *Block) LiveIn(liveOut TempSet) TempSet {
+	live := liveOut.Copy()
+	for _, t := range TermUses(b.Term) {
+		live[t] = true
+	}
+	for i := len(b.Instrs) - 1; i >= 0; i-- {
+		StepBack(b.Instrs[i], live)
+	}
+	return live
+}
+
+//StepBack updates the temps live after instr to those live before it
+func StepBack(instr Instr, live TempSet) {
+	if d := Def(instr); d != NoTemp {
+		delete(live, d)
+	}
+	for _, t := range Uses(instr) {
+		live[t] = true
+	}
+}
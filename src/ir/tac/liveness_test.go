@@ -0,0 +1,44 @@
+package tac
+
+import (
+	"testing"
+	"wacc_32/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLiveOutLoop(t *testing.T) {
+	b := NewBuilder()
+	fn := b.StartFunc("f")
+	n := b.NewParam(types.Word, "n")
+	i := b.NewVar(types.Word, "i")
+	c := b.NewTemp(types.Byte)
+	b.Emit(Move{Dst: i, Src: Imm(0)})
+
+	loop, body, end := b.NewBlock(), b.NewBlock(), b.NewBlock()
+	b.Jump(loop)
+	b.SetBlock(loop)
+	b.Emit(BinOp{Op: Lt, Dst: c, Left: i, Right: n})
+	b.Terminate(Branch{Cond: c, Then: body, Else: end})
+	b.SetBlock(body)
+	b.Emit(BinOp{Op: Add, Dst: i, Left: i, Right: Imm(1)})
+	b.Jump(loop)
+	b.SetBlock(end)
+	b.Terminate(Return{Value: i})
+
+	liveOut := fn.LiveOut()
+	assert.Equal(t, TempSet{n: true, i: true}, liveOut[fn.Blocks[0]])
+	assert.Equal(t, TempSet{n: true, i: true}, liveOut[body], "n is live around the loop")
+	assert.Equal(t, TempSet{}, liveOut[end])
+	assert.Equal(t, TempSet{n: true}, fn.Blocks[0].LiveIn(liveOut[fn.Blocks[0]]))
+}
+
+func TestStepBack(t *testing.T) {
+	live := TempSet{0: true}
+	StepBack(BinOp{Op: Add, Dst: 0, Left: Temp(1), Right: Imm(1)}, live)
+	assert.Equal(t, TempSet{1: true}, live)
+
+	//A failed read leaves its target unchanged
+	StepBack(Read{Dst: 1, Type: types.Integer}, live)
+	assert.Equal(t, TempSet{1: true}, live)
+}
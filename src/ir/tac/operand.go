@@ -0,0 +1,40 @@
+package tac
+
+import (
+	"fmt"
+	"strconv"
+)
+
+//Operand is a value an instruction can read
+type Operand interface {
+	fmt.Stringer
+	operand()
+}
+
+//Temp is a virtual register, every function has an unlimited supply of them
+type Temp int
+
+//NoTemp is the destination of calls whose result is thrown away
+const NoTemp Temp = -1
+
+//Imm is an integer constant, booleans and chars are stored as their ordinal
+type Imm int
+
+//Global is the address of a label, a string in the data section or a function
+type Global string
+
+func (t Temp) String() string {
+	return "%" + strconv.Itoa(int(t))
+}
+
+func (i Imm) String() string {
+	return strconv.Itoa(int(i))
+}
+
+func (g Global) String() string {
+	return "@" + string(g)
+}
+
+func (t Temp) operand()   {}
+func (i Imm) operand()    {}
+func (g Global) operand() {}
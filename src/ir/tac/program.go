@@ -0,0 +1,112 @@
+package tac
+
+import (
+	"fmt"
+	"strings"
+	"wacc_32/types"
+)
+
+//MainName is the name of the function the program starts in
+const MainName = "main"
+
+//Program is the three address code of a whole wacc program, UserTypes are the
+//structs and classes it declares by name. Globals are the labels of words in the data
+//section which start as 0
+type Program struct {
+	Strings   []StringLit
+	VTables   []VTable
+	Globals   []string
+	Funcs     []*Func
+	UserTypes map[string]types.UserType
+}
+
+//VTable is a table in the data section of the functions a class runs for each of its
+//methods, in the order of the method's slots
+type VTable struct {
+	Label string
+	Funcs []string
+}
+
+//StringLit is a string in the data section, Value is quoted and escaped as in the source
+type StringLit struct {
+	Label string
+	Value string
+}
+
+//String returns the string literals, vtables and globals followed by every function
+func (p *Program) String() string {
+	strs := make([]string, 0, len(p.Strings)+len(p.VTables)+len(p.Globals)+len(p.Funcs))
+	for _, str := range p.Strings {
+		strs = append(strs, fmt.Sprintf("%s = %s", Global(str.Label), str.Value))
+	}
+	for _, vt := range p.VTables {
+		funcs := make([]string, len(vt.Funcs))
+		for i, fn := range vt.Funcs {
+			funcs[i] = Global(fn).String()
+		}
+		strs = append(strs, fmt.Sprintf("%s = [%s]", Global(vt.Label), strings.Join(funcs, ", ")))
+	}
+	for _, label := range p.Globals {
+		strs = append(strs, fmt.Sprintf("%s = 0", Global(label)))
+	}
+	for _, fn := range p.Funcs {
+		strs = append(strs, fn.String())
+	}
+	return strings.Join(strs, "\n\n")
+}
+
+//TempInfo describes a temp, Name is the wacc variable it holds if there is one.
+//Variables also have their wacc type and where they are declared
+type TempInfo struct {
+	Size types.Size
+	Name string
+	Type types.WaccType
+	Decl Loc
+}
+
+//Func is a function made of basic blocks, Blocks[0] is the entry
+type Func struct {
+	Name   string
+	Params []Temp
+	Blocks []*Block
+	Temps  []TempInfo
+	Loc    Loc   //Where the function is declared
+	Scopes []int //The parent of each scope of the function, Scopes[0] is the function's own
+}
+
+//IsMain returns true for the function the program starts in
+func (f *Func) IsMain() bool {
+	return f.Name == MainName
+}
+
+//Size returns the size of the value held in t
+func (f *Func) Size(t Temp) types.Size {
+	return f.Temps[t].Size
+}
+
+//String returns
+//
+//	func <name>(<params>) {
+//	<blocks>
+//	}
+func (f *Func) String() string {
+	params := make([]string, len(f.Params))
+	for i, p := range f.Params {
+		params[i] = f.tempString(p)
+	}
+	strs := make([]string, 0, len(f.Blocks)+2)
+	strs = append(strs, fmt.Sprintf("func %s(%s) {", f.Name, strings.Join(params, ", ")))
+	for _, b := range f.Blocks {
+		strs = append(strs, b.String())
+	}
+	return strings.Join(append(strs, "}"), "\n")
+}
+
+func (f *Func) tempString(t Temp) string {
+	info := f.Temps[t]
+	str := t.String() + ":" + sizeString(info.Size)
+	if info.Name != "" {
+		str += " " + info.Name
+	}
+	return str
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"wacc_32/assembly"
+	"wacc_32/ast"
+	"wacc_32/errors"
+	"wacc_32/interpreter"
+	"wacc_32/types"
+	"wacc_32/visitor"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	lockCycleProgram = "../tests/extensions/warnings/valid/lockCycle.wacc"
+	waitCycleProgram = "../tests/extensions/warnings/valid/waitCycle.wacc"
+)
+
+//warnings returns the warnings checking a file reports
+func warnings(t *testing.T, file string) []string {
+	data, err := ioutil.ReadFile(file)
+	assert.NoError(t, err)
+	wp := visitor.NewWaccParser(string(data), "")
+	tree := visitor.NewWaccVisitor("", filepath.Dir(file), wp).Visit(wp.GetParseTree()).(ast.AST)
+
+	errChan := make(chan error)
+	found := make(chan []string)
+	go func() {
+		var warnings []string
+		for err := range errChan {
+			if _, isWarning := err.(errors.Warning); isWarning {
+				warnings = append(warnings, err.Error())
+			}
+		}
+		found <- warnings
+	}()
+	tree.Check(ast.Context{SemanticErrChan: errChan})
+	return <-found
+}
+
+//TestLockdepInterpreter checks that with lockdep acquiring two locks in the opposite
+//order to an earlier acquisition stops the program, naming both locks
+func TestLockdepInterpreter(t *testing.T) {
+	types.SetPointerSize(types.Word)
+	tree, ok := checkFile(lockCycleProgram)
+	assert.True(t, ok)
+
+	var out strings.Builder
+	assert.Equal(t, 0, interpreter.NewInterpreter(strings.NewReader(""), &out).Run(tree))
+	assert.Equal(t, "1\n2\n", out.String())
+
+	out.Reset()
+	it := interpreter.NewInterpreter(strings.NewReader(""), &out)
+	it.SetLockdep()
+	assert.Equal(t, 255, it.Run(tree))
+	assert.Equal(t, "1\nDeadlock: acquiring x while holding y, which was acquired while holding x before", out.String())
+}
+
+//TestLockdepWait checks that wait reacquiring its lock while holding a lock acquired
+//after it is warned about, and stops the program with lockdep
+func TestLockdepWait(t *testing.T) {
+	types.SetPointerSize(types.Word)
+	found := false
+	for _, w := range warnings(t, waitCycleProgram) {
+		found = found || strings.Contains(w, "locks x -> y -> x may be acquired in a cycle")
+	}
+	assert.True(t, found)
+
+	tree, ok := checkFile(waitCycleProgram)
+	assert.True(t, ok)
+	var out strings.Builder
+	assert.Equal(t, 0, interpreter.NewInterpreter(strings.NewReader(""), &out).Run(tree))
+	assert.Equal(t, "woken\n", out.String())
+
+	out.Reset()
+	it := interpreter.NewInterpreter(strings.NewReader(""), &out)
+	it.SetLockdep()
+	assert.Equal(t, 255, it.Run(tree))
+	assert.Equal(t, "Deadlock: acquiring x while holding y, which was acquired while holding x before", out.String())
+}
+
+//TestLockdepCodeGen checks that with lockdep locks are acquired and released through
+//the lockdep functions on every target
+func TestLockdepCodeGen(t *testing.T) {
+	for _, target := range assembly.Targets() {
+		t.Run(target, func(t *testing.T) {
+			plain, _ := assembly.NewCodeGenerator(target)
+			code, ok := compile(lockCycleProgram, plain)
+			assert.True(t, ok)
+			assert.NotContains(t, code, "lockdep")
+
+			lockdep, _ := assembly.NewCodeGenerator(target)
+			lockdep.SetLockdep()
+			code, _ = compile(lockCycleProgram, lockdep)
+			for _, label := range []string{"lockdep.acquire", "lockdep.release", "lockdep.created", "lockdep.mutex"} {
+				assert.Contains(t, code, "\n"+label+":")
+			}
+
+			lockdep, _ = assembly.NewCodeGenerator(target)
+			lockdep.SetLockdep()
+			code, _ = compile(waitCycleProgram, lockdep)
+			assert.Contains(t, code, "\nlockdep.wait:")
+			assert.Regexp(t, `lockdep\.wait(@PLT)?\n`, function(code, "main"))
+		})
+	}
+}
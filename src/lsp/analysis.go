@@ -0,0 +1,123 @@
+package lsp
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime/debug"
+	"sync"
+	"time"
+	"wacc_32/ast"
+	"wacc_32/errors"
+	"wacc_32/visitor"
+)
+
+//analysisTimeout is how long a document is checked for before the editor is told it is slow
+const analysisTimeout = 10 * time.Second
+
+//analysis is a document which has been checked without syntax errors, kept so it can be
+//queried while the document is being edited
+type analysis struct {
+	prog *ast.Program
+	libs map[string]string //Function name prefix of each imported library, by alias
+}
+
+//fatalErrors collects the errors which stop a document being built, they can be found
+//by any of the goroutines building it
+type fatalErrors struct {
+	mu   sync.Mutex
+	errs []errors.Diagnostic
+}
+
+func (f *fatalErrors) add(errs []errors.Diagnostic) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errs = append(f.errs, errs...)
+}
+
+func (f *fatalErrors) get() []errors.Diagnostic {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.errs
+}
+
+//analyse checks text, the contents of the file at path. The diagnostics are returned
+//even if the document can't be analysed, the error is a panic found checking it.
+//slow is called if checking takes longer than analysisTimeout, it carries on as the
+//goroutine checking text can't be stopped
+func analyse(path, text string, slow func()) ([]errors.Diagnostic, *analysis, error) {
+	type result struct {
+		diags []errors.Diagnostic
+		a     *analysis
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		var r result
+		fatal := &fatalErrors{}
+		//A fatal error found by this goroutine ends it, but deferred calls are still run
+		defer func() {
+			if p := recover(); p != nil {
+				r = result{err: fmt.Errorf("panic checking %s: %v\n%s", path, p, debug.Stack())}
+			}
+			if r.diags == nil && r.a == nil {
+				r.diags = fatal.get()
+			}
+			done <- r
+		}()
+		diags, a := build(path, text, fatal)
+		if a != nil {
+			diags = append(diags, check(a.prog)...)
+		}
+		r = result{diags: diags, a: a}
+	}()
+	var r result
+	select {
+	case r = <-done:
+	case <-time.After(analysisTimeout):
+		slow()
+		r = <-done
+	}
+	return r.diags, r.a, r.err
+}
+
+//build parses text and turns it into an AST, returning the errors which stop it being built
+func build(path, text string, fatal *fatalErrors) ([]errors.Diagnostic, *analysis) {
+	wp := visitor.NewWaccParser(text, "")
+	wp.OnFatal(fatal.add)
+	tree := wp.GetParseTree()
+	if errs := wp.Diagnostics(); len(errs) > 0 {
+		return errs, nil
+	}
+	w := visitor.NewWaccVisitor("", filepath.Dir(path), wp)
+	prog := w.Visit(tree).(*ast.Program)
+	if errs := fatal.get(); len(errs) > 0 {
+		return errs, nil
+	}
+	return nil, &analysis{prog, w.Libraries()}
+}
+
+//check runs the semantic checks over prog and returns the errors and warnings found.
+//Folding and pruning are undone so prog still matches the source
+func check(prog *ast.Program) []errors.Diagnostic {
+	errChan := make(chan error)
+	diags := make(chan []errors.Diagnostic)
+	go func() {
+		found := make([]errors.Diagnostic, 0)
+		for err := range errChan {
+			if err != nil {
+				found = append(found, errors.AsDiagnostic(err))
+			}
+		}
+		diags <- found
+	}()
+	defer func() {
+		//Check closes errChan when it returns, it is closed here if Check panics
+		if p := recover(); p != nil {
+			close(errChan)
+			<-diags
+			panic(p)
+		}
+	}()
+	prog.Check(ast.Context{SemanticErrChan: errChan, Analysis: true})
+	return <-diags
+}
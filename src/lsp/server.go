@@ -0,0 +1,532 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"sync"
+	"wacc_32/ast"
+	"wacc_32/errors"
+)
+
+//Server is a WACC language server which talks JSON-RPC to an editor
+type Server struct {
+	conn        *conn
+	mu          sync.Mutex
+	docs        map[string]*document //Open documents by URI
+	initialized bool
+	shutdown    bool
+	encoding    string //The position encoding agreed with the client
+}
+
+//document is a file open in the editor
+type document struct {
+	text     string
+	version  int
+	analysis *analysis //The last version without syntax errors, nil if there hasn't been one
+	checking bool      //Whether a goroutine is checking the document
+}
+
+//NewServer creates a server which reads messages from in and writes them to out
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		conn: newConn(in, out),
+		docs: make(map[string]*document),
+	}
+}
+
+//Run handles messages until the editor asks the server to exit. It returns an error
+//if the connection is lost or the server exits without being shut down
+func (s *Server) Run() error {
+	for {
+		body, err := s.conn.read()
+		if err != nil {
+			return err
+		}
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			s.reply(nil, nil, &responseError{parseError, err.Error()})
+			continue
+		}
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return fmt.Errorf("exit before shutdown")
+			}
+			return nil
+		}
+		result, rErr := s.handle(req)
+		if req.ID != nil {
+			s.reply(req.ID, result, rErr)
+		}
+	}
+}
+
+func (s *Server) reply(id *json.RawMessage, result interface{}, err *responseError) {
+	if result == nil && err == nil {
+		result = null
+	}
+	s.conn.write(response{JSONRPC: "2.0", ID: id, Result: result, Error: err})
+}
+
+func (s *Server) notify(method string, params interface{}) {
+	s.conn.write(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+//log shows a message in the editor's log for the server
+func (s *Server) log(messageType int, message string) {
+	s.notify("window/logMessage", logMessageParams{Type: messageType, Message: message})
+}
+
+//handle runs the method of req and returns its result
+func (s *Server) handle(req request) (interface{}, *responseError) {
+	if req.Method == "initialize" {
+		var params initializeParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &responseError{invalidParams, err.Error()}
+		}
+		//UTF-32 counts code points like positions in the tree, so it is preferred to the default
+		s.encoding = encodingUTF16
+		for _, encoding := range params.Capabilities.General.PositionEncodings {
+			if encoding == encodingUTF32 {
+				s.encoding = encodingUTF32
+			}
+		}
+		s.initialized = true
+		return initializeResult{
+			Capabilities: serverCapabilities{
+				PositionEncoding:       s.encoding,
+				TextDocumentSync:       textDocumentSyncFull,
+				HoverProvider:          true,
+				DefinitionProvider:     true,
+				CompletionProvider:     completionOptions{TriggerCharacters: []string{".", ":"}},
+				DocumentSymbolProvider: true,
+			},
+			ServerInfo: serverInfo{Name: "wacc-lsp"},
+		}, nil
+	}
+	if !s.initialized {
+		return nil, &responseError{serverNotInitialized, "server not initialized"}
+	}
+	var err error
+	var result interface{}
+	switch req.Method {
+	case "initialized":
+	case "shutdown":
+		s.shutdown = true
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			s.update(params.TextDocument.URI, params.TextDocument.Version, params.TextDocument.Text)
+		}
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err = json.Unmarshal(req.Params, &params); err == nil && len(params.ContentChanges) > 0 {
+			changes := params.ContentChanges
+			s.update(params.TextDocument.URI, params.TextDocument.Version, changes[len(changes)-1].Text)
+		}
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			s.close(params.TextDocument.URI)
+		}
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			result = s.hover(params)
+		}
+	case "textDocument/definition":
+		var params textDocumentPositionParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			result = s.definition(params)
+		}
+	case "textDocument/completion":
+		var params textDocumentPositionParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			result = s.completion(params)
+		}
+	case "textDocument/documentSymbol":
+		var params documentSymbolParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			result = s.documentSymbols(params)
+		}
+	default:
+		if req.ID != nil {
+			return nil, &responseError{methodNotFound, "method not found: " + req.Method}
+		}
+	}
+	if err != nil {
+		return nil, &responseError{invalidParams, err.Error()}
+	}
+	return result, nil
+}
+
+/* ****************************** DOCUMENTS ****************************** */
+
+//update records the new text of a document and checks it in the background
+func (s *Server) update(uri string, version int, text string) {
+	s.mu.Lock()
+	doc, ok := s.docs[uri]
+	if !ok {
+		doc = &document{}
+		s.docs[uri] = doc
+	}
+	doc.text, doc.version = text, version
+	checking := doc.checking
+	doc.checking = true
+	s.mu.Unlock()
+	if !checking {
+		go s.refresh(uri, doc)
+	}
+}
+
+//refresh checks the latest version of a document and publishes its diagnostics, until
+//the version checked is still the latest. Only one goroutine checks a document at a
+//time, so a check which never finishes doesn't leave a goroutine behind for every change
+func (s *Server) refresh(uri string, doc *document) {
+	for {
+		s.mu.Lock()
+		version, text := doc.version, doc.text
+		s.mu.Unlock()
+		diags, a, err := analyse(uriToPath(uri), text, func() {
+			s.log(messageWarning, fmt.Sprintf("checking %s is taking longer than %s", uri, analysisTimeout))
+		})
+		if err != nil {
+			s.log(messageError, err.Error())
+		}
+
+		s.mu.Lock()
+		if s.docs[uri] != doc {
+			//The document has been closed
+			s.mu.Unlock()
+			return
+		}
+		if doc.version != version {
+			s.mu.Unlock()
+			continue
+		}
+		doc.checking = false
+		if a != nil {
+			doc.analysis = a
+		}
+		if err == nil {
+			cols := s.columns(uri, text)
+			published := make([]diagnostic, 0, len(diags))
+			for _, d := range diags {
+				published = append(published, toDiagnostic(uri, d, cols))
+			}
+			s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: uri, Diagnostics: published})
+		}
+		s.mu.Unlock()
+		return
+	}
+}
+
+func (s *Server) close(uri string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.docs, uri)
+	s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: uri, Diagnostics: []diagnostic{}})
+}
+
+//get returns the text and last analysis of a document
+func (s *Server) get(uri string) (string, *analysis) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doc, ok := s.docs[uri]
+	if !ok {
+		return "", nil
+	}
+	return doc.text, doc.analysis
+}
+
+/* ******************************* QUERIES ******************************* */
+
+func (s *Server) hover(params textDocumentPositionParams) interface{} {
+	text, a := s.get(params.TextDocument.URI)
+	if a == nil {
+		return nil
+	}
+	cols := s.columns(params.TextDocument.URI, text)
+	line, col := fromPosition(cols.fromClient(params.TextDocument.URI, params.Position))
+	ref, ok := a.prog.Lookup(line, col)
+	if !ok {
+		return nil
+	}
+	value := ref.Type
+	if ref.Kind != ast.FunctionSymbol && ref.Kind != ast.MethodSymbol && ref.Name != "" {
+		value += " " + ref.Name
+	}
+	return hover{
+		Contents: markupContent{Kind: "markdown", Value: "```wacc\n" + value + "\n```"},
+		Range:    cols.textRange(params.TextDocument.URI, toRange(ref.Pos)),
+	}
+}
+
+func (s *Server) definition(params textDocumentPositionParams) interface{} {
+	text, a := s.get(params.TextDocument.URI)
+	if a == nil {
+		return nil
+	}
+	cols := s.columns(params.TextDocument.URI, text)
+	line, col := fromPosition(cols.fromClient(params.TextDocument.URI, params.Position))
+	ref, ok := a.prog.Lookup(line, col)
+	if !ok || !ref.Defined {
+		return nil
+	}
+	return []location{cols.location(toLocation(params.TextDocument.URI, ref.Definition))}
+}
+
+//accessPath matches the identifiers being accessed before the cursor, e.g. a.b. or lib::
+var accessPath = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_]*)(::|(?:\.[A-Za-z_][A-Za-z0-9_]*)*\.)[A-Za-z0-9_]*$`)
+
+func (s *Server) completion(params textDocumentPositionParams) interface{} {
+	text, a := s.get(params.TextDocument.URI)
+	items := make([]completionItem, 0)
+	if a == nil {
+		return items
+	}
+	pos := s.columns(params.TextDocument.URI, text).fromClient(params.TextDocument.URI, params.Position)
+	line, col := fromPosition(pos)
+	var symbols []ast.Symbol
+	if match := accessPath.FindStringSubmatch(linePrefix(text, pos)); match != nil {
+		if match[2] == "::" {
+			lib, ok := a.libs[match[1]]
+			if !ok {
+				return items
+			}
+			symbols = a.prog.Functions(lib)
+		} else {
+			path := append([]string{match[1]}, strings.Split(strings.Trim(match[2], "."), ".")...)
+			if path[len(path)-1] == "" {
+				path = path[:len(path)-1]
+			}
+			symbols = a.prog.FieldCompletions(line, col, path)
+		}
+	} else {
+		symbols = a.prog.Completions(line, col)
+		for alias := range a.libs {
+			items = append(items, completionItem{Label: alias, Kind: completionModule, Detail: "import"})
+		}
+	}
+	for _, symbol := range symbols {
+		items = append(items, completionItem{
+			Label:  symbol.Name,
+			Kind:   completionKinds[symbol.Kind],
+			Detail: symbol.Type,
+		})
+	}
+	return items
+}
+
+func (s *Server) documentSymbols(params documentSymbolParams) interface{} {
+	text, a := s.get(params.TextDocument.URI)
+	if a == nil {
+		return nil
+	}
+	return toDocumentSymbols(a.prog.Symbols(), params.TextDocument.URI, s.columns(params.TextDocument.URI, text))
+}
+
+//toDocumentSymbols returns the symbols of the document at uri as LSP document symbols
+func toDocumentSymbols(symbols []ast.Symbol, uri string, cols *columns) []documentSymbol {
+	if len(symbols) == 0 {
+		return nil
+	}
+	docSymbols := make([]documentSymbol, len(symbols))
+	for i, symbol := range symbols {
+		docSymbols[i] = documentSymbol{
+			Name:           symbol.Name,
+			Detail:         symbol.Type,
+			Kind:           symbolKinds[symbol.Kind],
+			Range:          cols.textRange(uri, toRange(symbol.Pos)),
+			SelectionRange: cols.textRange(uri, toRange(symbol.Pos)),
+			Children:       toDocumentSymbols(symbol.Children, uri, cols),
+		}
+	}
+	return docSymbols
+}
+
+/* ***************************** CONVERSIONS ***************************** */
+
+//Completion item kinds defined by the language server protocol
+const (
+	completionMethod    = 2
+	completionFunction  = 3
+	completionField     = 5
+	completionVariable  = 6
+	completionClass     = 7
+	completionInterface = 8
+	completionModule    = 9
+	completionStruct    = 22
+)
+
+var completionKinds = map[ast.SymbolKind]int{
+	ast.FunctionSymbol:  completionFunction,
+	ast.MethodSymbol:    completionMethod,
+	ast.StructSymbol:    completionStruct,
+	ast.ClassSymbol:     completionClass,
+	ast.FieldSymbol:     completionField,
+	ast.VariableSymbol:  completionVariable,
+	ast.InterfaceSymbol: completionInterface,
+}
+
+//Symbol kinds defined by the language server protocol
+var symbolKinds = map[ast.SymbolKind]int{
+	ast.FunctionSymbol:  12,
+	ast.MethodSymbol:    6,
+	ast.StructSymbol:    23,
+	ast.ClassSymbol:     5,
+	ast.FieldSymbol:     8,
+	ast.VariableSymbol:  13,
+	ast.InterfaceSymbol: 11,
+}
+
+//fromPosition returns the line, counting from 1, and column of an LSP position
+func fromPosition(p position) (line, col int) {
+	return p.Line + 1, p.Character
+}
+
+//toRange returns the LSP range covered by p
+func toRange(p errors.Position) textRange {
+	if p.StartLine() == 0 {
+		return textRange{}
+	}
+	//The range ends after the last token, whose length may not be known
+	end := p.EndCol()
+	for p.Contains(p.EndLine(), end) {
+		end++
+	}
+	return textRange{position{p.StartLine() - 1, p.StartCol()}, position{p.EndLine() - 1, end}}
+}
+
+//toLocation returns the location of p, which is in the document at uri unless it is in a library
+func toLocation(uri string, p errors.Position) location {
+	if p.File() != "" {
+		uri = pathToURI(p.File())
+	}
+	return location{URI: uri, Range: toRange(p)}
+}
+
+//toDiagnostic returns d, found while checking the document at uri, as an LSP diagnostic.
+//Diagnostics found in libraries are reported at the start of the document, pointing at
+//where they were found
+func toDiagnostic(uri string, d errors.Diagnostic, cols *columns) diagnostic {
+	diag := diagnostic{
+		Range:    cols.textRange(uri, toRange(d.Pos())),
+		Severity: severityError,
+		Code:     d.Kind(),
+		Source:   "wacc",
+		Message:  d.Message(),
+	}
+	if d.Severity() == errors.SeverityWarning {
+		diag.Severity = severityWarning
+	}
+	if file := d.Pos().File(); file != "" {
+		diag.Range = textRange{}
+		diag.Message = fmt.Sprintf("%s (in %s)", diag.Message, file)
+		diag.RelatedInformation = append(diag.RelatedInformation, diagnosticRelatedInformation{
+			Location: cols.location(toLocation(uri, d.Pos())),
+			Message:  d.Message(),
+		})
+	}
+	if l, ok := d.(interface{ Labels() []errors.Label }); ok {
+		for _, label := range l.Labels() {
+			diag.RelatedInformation = append(diag.RelatedInformation, diagnosticRelatedInformation{
+				Location: cols.location(toLocation(uri, label.Pos())),
+				Message:  label.Message(),
+			})
+		}
+	}
+	return diag
+}
+
+//columns converts the characters of positions between the code points positions in
+//the tree count and the encoding agreed with the client. Characters outside the basic
+//multilingual plane are two UTF-16 code units
+type columns struct {
+	utf16 bool
+	uri   string //The document being queried, whose text is text
+	text  string
+	lines map[string][]string //The lines of the files positions have been converted in, by URI
+}
+
+//columns returns the converter for positions in the document at uri, other files are
+//read as they are needed
+func (s *Server) columns(uri, text string) *columns {
+	return &columns{utf16: s.encoding != encodingUTF32, uri: uri, text: text, lines: make(map[string][]string)}
+}
+
+//line returns the characters of a line of the file at uri
+func (c *columns) line(uri string, n int) []rune {
+	lines, ok := c.lines[uri]
+	if !ok {
+		text := c.text
+		if uri != c.uri {
+			data, _ := ioutil.ReadFile(uriToPath(uri))
+			text = string(data)
+		}
+		lines = strings.Split(text, "\n")
+		c.lines[uri] = lines
+	}
+	if n < 0 || n >= len(lines) {
+		return nil
+	}
+	return []rune(strings.TrimSuffix(lines[n], "\r"))
+}
+
+//toClient returns p, whose characters are code points, in the client's encoding
+func (c *columns) toClient(uri string, p position) position {
+	if !c.utf16 {
+		return p
+	}
+	line := c.line(uri, p.Line)
+	units := 0
+	for i := 0; i < p.Character; i++ {
+		units++
+		if i < len(line) && line[i] > 0xFFFF {
+			units++
+		}
+	}
+	return position{p.Line, units}
+}
+
+//fromClient returns p, whose characters are in the client's encoding, in code points.
+//A position between the halves of a surrogate pair is after the pair
+func (c *columns) fromClient(uri string, p position) position {
+	if !c.utf16 {
+		return p
+	}
+	line := c.line(uri, p.Line)
+	points := 0
+	for units := 0; units < p.Character; points++ {
+		units++
+		if points < len(line) && line[points] > 0xFFFF {
+			units++
+		}
+	}
+	return position{p.Line, points}
+}
+
+func (c *columns) textRange(uri string, r textRange) textRange {
+	return textRange{c.toClient(uri, r.Start), c.toClient(uri, r.End)}
+}
+
+func (c *columns) location(l location) location {
+	l.Range = c.textRange(l.URI, l.Range)
+	return l
+}
+
+//linePrefix returns the text of the line at p before p
+func linePrefix(text string, p position) string {
+	lines := strings.Split(text, "\n")
+	if p.Line >= len(lines) {
+		return ""
+	}
+	line := []rune(strings.TrimSuffix(lines[p.Line], "\r"))
+	if p.Character < len(line) {
+		line = line[:p.Character]
+	}
+	return string(line)
+}
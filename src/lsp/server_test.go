@@ -0,0 +1,296 @@
+package lsp
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testURI = "file:///tmp/test.wacc"
+
+const testProgram = `begin
+  struct point is
+    int x
+    int y
+  end
+  int double(int n) is
+    return n * 2
+  end
+  point p = point{1, 2} ;
+  int d = call double(p.y) ;
+  println d
+end
+`
+
+//session is an editor talking to a server
+type session struct {
+	t    *testing.T
+	in   *io.PipeWriter
+	out  *conn
+	done chan error
+}
+
+func newSession(t *testing.T) *session {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	s := &session{t, inW, newConn(outR, nil), make(chan error, 1)}
+	go func() {
+		s.done <- NewServer(inR, outW).Run()
+	}()
+	return s
+}
+
+func (s *session) send(id int, method string, params interface{}) {
+	msg := map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params}
+	if id != 0 {
+		msg["id"] = id
+	}
+	require.NoError(s.t, (&conn{out: s.in}).write(msg))
+}
+
+//receive returns the next message sent by the server
+func (s *session) receive() map[string]interface{} {
+	body, err := s.out.read()
+	require.NoError(s.t, err)
+	var msg map[string]interface{}
+	require.NoError(s.t, json.Unmarshal(body, &msg))
+	return msg
+}
+
+//request sends a request and returns its result
+func (s *session) request(id int, method string, params interface{}) interface{} {
+	s.send(id, method, params)
+	msg := s.receive()
+	assert.Equal(s.t, float64(id), msg["id"])
+	return msg["result"]
+}
+
+//open initializes the server and opens a document, returning its diagnostics
+func (s *session) open(text string) []interface{} {
+	s.request(1, "initialize", map[string]interface{}{})
+	return s.openDocument(text)
+}
+
+//openDocument opens a document in an initialized server, returning its diagnostics
+func (s *session) openDocument(text string) []interface{} {
+	s.send(0, "textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": testURI, "version": 1, "text": text},
+	})
+	msg := s.receive()
+	assert.Equal(s.t, "textDocument/publishDiagnostics", msg["method"])
+	return msg["params"].(map[string]interface{})["diagnostics"].([]interface{})
+}
+
+func (s *session) exit() {
+	s.request(99, "shutdown", nil)
+	s.send(0, "exit", nil)
+	assert.NoError(s.t, <-s.done)
+}
+
+func at(line, character int) map[string]interface{} {
+	return map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": testURI},
+		"position":     map[string]interface{}{"line": line, "character": character},
+	}
+}
+
+func TestDiagnosticsArePublishedOnOpen(t *testing.T) {
+	s := newSession(t)
+	diags := s.open("begin\n  int x = true ;\n  println x\nend\n")
+
+	require.Len(t, diags, 1)
+	diag := diags[0].(map[string]interface{})
+	assert.Equal(t, "TypeError", diag["code"])
+	assert.Equal(t, float64(severityError), diag["severity"])
+	assert.Equal(t, map[string]interface{}{
+		"start": map[string]interface{}{"line": float64(1), "character": float64(2)},
+		"end":   map[string]interface{}{"line": float64(1), "character": float64(14)},
+	}, diag["range"])
+	s.exit()
+}
+
+const raceProgram = `begin
+  int fill(int[] a, lock l) is
+    a[0] = 1 ;
+    return 0
+  end
+  int[] a = [0] ;
+  lock l ;
+  wacc fill(a, l) ;
+  acquire l ;
+  a[0] = 2 ;
+  release l
+end
+`
+
+//races returns the race warnings of diags
+func races(diags []interface{}) []map[string]interface{} {
+	found := make([]map[string]interface{}, 0)
+	for _, d := range diags {
+		if diag := d.(map[string]interface{}); diag["code"] == "RaceWarning" {
+			found = append(found, diag)
+		}
+	}
+	return found
+}
+
+func TestRaceWarningPointsAtBothWrites(t *testing.T) {
+	s := newSession(t)
+	diags := races(s.open(raceProgram))
+
+	require.Len(t, diags, 1)
+	assert.Equal(t, float64(severityWarning), diags[0]["severity"])
+	assert.Equal(t, map[string]interface{}{"line": float64(9), "character": float64(2)},
+		diags[0]["range"].(map[string]interface{})["start"])
+	related := diags[0]["relatedInformation"].([]interface{})
+	require.Len(t, related, 1)
+	assert.Equal(t, "a written by fill here", related[0].(map[string]interface{})["message"])
+	assert.Equal(t, map[string]interface{}{"line": float64(2), "character": float64(4)},
+		related[0].(map[string]interface{})["location"].(map[string]interface{})["range"].(map[string]interface{})["start"])
+
+	//The routine holding the lock too makes the writes safe
+	s.send(0, "textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": testURI, "version": 2},
+		"contentChanges": []interface{}{map[string]interface{}{"text": strings.Replace(raceProgram, "a[0] = 1 ;", "acquire l ;\n    a[0] = 1 ;\n    release l ;", 1)}},
+	})
+	msg := s.receive()
+	assert.Empty(t, races(msg["params"].(map[string]interface{})["diagnostics"].([]interface{})))
+	s.exit()
+}
+
+func TestHoverAndDefinition(t *testing.T) {
+	s := newSession(t)
+	assert.Empty(t, s.open(testProgram))
+
+	hover := s.request(2, "textDocument/hover", at(9, 24)).(map[string]interface{})
+	assert.Equal(t, "```wacc\nint y\n```", hover["contents"].(map[string]interface{})["value"])
+	hover = s.request(3, "textDocument/hover", at(9, 17)).(map[string]interface{})
+	assert.Equal(t, "```wacc\nint double(int n)\n```", hover["contents"].(map[string]interface{})["value"])
+
+	locations := s.request(4, "textDocument/definition", at(9, 22)).([]interface{})
+	require.Len(t, locations, 1)
+	start := locations[0].(map[string]interface{})["range"].(map[string]interface{})["start"]
+	assert.Equal(t, map[string]interface{}{"line": float64(8), "character": float64(2)}, start)
+	s.exit()
+}
+
+//emojiProgram has a character outside the basic multilingual plane before an error and
+//a variable on the same line, which are a code unit further right in UTF-16
+const emojiProgram = `begin
+  string s = "😀" ; println s ; exit true
+end
+`
+
+func TestPositionEncoding(t *testing.T) {
+	for _, test := range []struct {
+		offered  []string
+		encoding string
+		shift    int
+	}{
+		{nil, encodingUTF16, 1},
+		{[]string{encodingUTF16}, encodingUTF16, 1},
+		{[]string{encodingUTF16, encodingUTF32}, encodingUTF32, 0},
+	} {
+		s := newSession(t)
+		capabilities := map[string]interface{}{}
+		if test.offered != nil {
+			capabilities["general"] = map[string]interface{}{"positionEncodings": test.offered}
+		}
+		result := s.request(1, "initialize", map[string]interface{}{"capabilities": capabilities}).(map[string]interface{})
+		assert.Equal(t, test.encoding, result["capabilities"].(map[string]interface{})["positionEncoding"])
+
+		diags := s.openDocument(emojiProgram)
+		require.Len(t, diags, 1)
+		start := diags[0].(map[string]interface{})["range"].(map[string]interface{})["start"]
+		assert.Equal(t, map[string]interface{}{"line": float64(1), "character": float64(31 + test.shift)}, start)
+
+		hover := s.request(2, "textDocument/hover", at(1, 27+test.shift)).(map[string]interface{})
+		assert.Equal(t, "```wacc\nstring s\n```", hover["contents"].(map[string]interface{})["value"])
+		start = hover["range"].(map[string]interface{})["start"]
+		assert.Equal(t, map[string]interface{}{"line": float64(1), "character": float64(27 + test.shift)}, start)
+		s.exit()
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	s := newSession(t)
+	s.open(testProgram)
+
+	labels := func(result interface{}) []string {
+		names := make([]string, 0)
+		for _, item := range result.([]interface{}) {
+			names = append(names, item.(map[string]interface{})["label"].(string))
+		}
+		return names
+	}
+	assert.Equal(t, []string{"p", "point", "double"}, labels(s.request(2, "textDocument/completion", at(9, 2))))
+
+	s.send(0, "textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": testURI, "version": 2},
+		"contentChanges": []interface{}{map[string]interface{}{"text": strings.Replace(testProgram, "println d", "println d ;\n  p.", 1)}},
+	})
+	s.receive()
+	//The document has a syntax error so the last version which parsed is used
+	assert.Equal(t, []string{"x", "y"}, labels(s.request(3, "textDocument/completion", at(11, 4))))
+	s.exit()
+}
+
+func TestDocumentSymbols(t *testing.T) {
+	s := newSession(t)
+	s.open(testProgram)
+
+	symbols := s.request(2, "textDocument/documentSymbol", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": testURI},
+	}).([]interface{})
+	require.Len(t, symbols, 2)
+	point := symbols[0].(map[string]interface{})
+	assert.Equal(t, "point", point["name"])
+	assert.Len(t, point["children"], 2)
+	assert.Equal(t, "double", symbols[1].(map[string]interface{})["name"])
+	s.exit()
+}
+
+func TestRequestsBeforeInitialize(t *testing.T) {
+	s := newSession(t)
+	s.send(1, "textDocument/hover", at(0, 0))
+	msg := s.receive()
+	assert.Equal(t, float64(serverNotInitialized), msg["error"].(map[string]interface{})["code"])
+	s.send(0, "exit", nil)
+	assert.Error(t, <-s.done)
+}
+
+const foldedProgram = `begin
+  int unused() is
+    return 1
+  end
+  int x = 1 ;
+  if x == 1 then
+    println x
+  else
+    println x + 1
+  fi
+end
+`
+
+func TestPrunedCodeCanBeQueried(t *testing.T) {
+	s := newSession(t)
+	codes := make([]string, 0)
+	for _, d := range s.open(foldedProgram) {
+		codes = append(codes, d.(map[string]interface{})["code"].(string))
+	}
+	assert.ElementsMatch(t, []string{"UnreachableCodeWarning", "UnusedFunctionWarning"}, codes)
+
+	//The dead branch and the unused function are still there after being reported
+	hover := s.request(2, "textDocument/hover", at(8, 12)).(map[string]interface{})
+	assert.Equal(t, "```wacc\nint x\n```", hover["contents"].(map[string]interface{})["value"])
+	symbols := s.request(3, "textDocument/documentSymbol", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": testURI},
+	}).([]interface{})
+	require.Len(t, symbols, 1)
+	assert.Equal(t, "unused", symbols[0].(map[string]interface{})["name"])
+	s.exit()
+}
@@ -8,6 +8,8 @@ import (
 	"path/filepath"
 	"strings"
 	"wacc_32/assembly"
+	"wacc_32/errors"
+	"wacc_32/types"
 	"wacc_32/visitor"
 )
 
@@ -26,23 +28,88 @@ func main() {
 	astPtr := flag.Bool("t", false, "View AST. Display AST generated by the parser.")
 	semPtr := flag.Bool("s", false, "Semantic check. Check the input file for semantic errors.")
 	exePtr := flag.Bool("x", false, "Assembly generation. Generate arm assembly")
+	runPtr := flag.Bool("run", false, "Interpret. Run the program directly instead of generating assembly")
+	irPtr := flag.Bool("ir", false, "View IR. Display the three address code generated from the AST")
+	statsPtr := flag.Bool("stats", false, "Register allocation statistics. Report the temps spilled in each function")
+	debugPtr := flag.Bool("g", false, "Debug information. Mark the source line of each instruction, describe every stack frame and where every variable is")
+	lockdepPtr := flag.Bool("lockdep", false, "Lock order checking. Stop with a runtime error when a thread acquires two locks in the opposite order to an earlier acquisition")
+	optimisation := 1
+	flag.Var(optLevel{&optimisation, 0}, "O0", "No optimisation. Don't run the peephole optimiser over the generated code")
+	flag.Var(optLevel{&optimisation, 1}, "O1", "Optimise. Run the peephole optimiser over the generated code")
+	peepholePtr := flag.String(
+		"peephole",
+		"",
+		"Peephole rules. Comma separated list of the rules to run with -O1, all of them by default",
+	)
+	noWarningPtrs := make(map[string]*bool)
+	for _, name := range errors.WarningNames() {
+		noWarningPtrs[name] = flag.Bool("Wno-"+name, false, "Disable "+name+" warnings")
+	}
+	werrorPtr := flag.Bool("Werror", false, "Treat warnings as semantic errors")
+	formatPtr := flag.String(
+		"diagnostics-format",
+		"text",
+		"Diagnostics format. How errors and warnings are written to stderr, one of: "+
+			strings.Join(errors.Formats(), ", "),
+	)
+	fmtPtr := flag.Bool("fmt", false, "Format. Print the canonical source of each input file")
+	checkPtr := flag.Bool(
+		"check",
+		false,
+		"Check formatting. With -fmt, list the files which aren't formatted instead of printing them",
+	)
+	targetPtr := flag.String(
+		"target",
+		"arm11",
+		"Target architecture. One of: "+strings.Join(assembly.Targets(), ", "),
+	)
 	flag.Parse()
 
 	var err error
 
 	file := flag.Arg(0)
 
+	codeGen, err := assembly.NewCodeGenerator(*targetPtr)
+	if err == nil {
+		err = setOptimisation(codeGen, optimisation, *peepholePtr)
+	}
+	format, formatErr := errors.ParseFormat(*formatPtr)
+	if err == nil {
+		err = formatErr
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if *fmtPtr {
+		os.Exit(formatFiles(flag.Args(), *checkPtr, format))
+	}
+	//Stack offsets are assigned during semantic analysis so they need the target's pointer size
+	types.SetPointerSize(codeGen.PointerSize)
+
 	var wp *visitor.WaccParser
 	data, err := ioutil.ReadFile(file)
 	if err != nil {
 		panic(fmt.Sprintf("No file called %s found!!", file))
 	}
+	if *debugPtr {
+		//The assembly refers to the source by its absolute path as it is written elsewhere
+		path, err := filepath.Abs(file)
+		if err != nil {
+			path = file
+		}
+		codeGen.SetDebug(path)
+	}
+	if *lockdepPtr {
+		codeGen.SetLockdep()
+	}
 	if *exePtr {
 		os.Chdir(filepath.Dir(file))
 	}
 
 	/* ****************************** PARSING ****************************** */
 	wp = visitor.NewWaccParser(string(data), "")
+	wp.SetDiagnostics(format, file)
 	parseTree := wp.GetParseTree()
 	if *parsePtr {
 		wp.PrintParseTree(parseTree)
@@ -63,22 +130,37 @@ func main() {
 	}
 
 	/* ************************* SEMANTIC ANALYSIS ************************* */
-	semanticCheck(ast)
+	opts := diagnosticOptions{
+		disabled: make(map[string]bool),
+		werror:   *werrorPtr,
+		format:   format,
+		file:     file,
+	}
+	for name, ptr := range noWarningPtrs {
+		opts.disabled[name] = *ptr
+	}
+	semanticCheck(ast, opts)
 	if *semPtr {
 		return
 	}
 
-	/* ************************** ASM GENERATION *************************** */
-	v := assembly.NewArm11CodeGenerator()
-	code := v.GenerateCode(ast)
+	/* **************************** INTERPRETER **************************** */
+	if *runPtr {
+		os.Exit(runProgram(ast, *lockdepPtr))
+	}
 
+	if *irPtr {
+		printIR(ast, *lockdepPtr)
+		return
+	}
+
+	/* ************************** ASM GENERATION *************************** */
 	filename := getFilename(file)
 	if *exePtr {
 		filename = "input.s"
 	}
-
-	err = ioutil.WriteFile(filename, []byte(code), 0644)
-	if err != nil {
-		panic(err)
+	generateCode(ast, codeGen, filename)
+	if *statsPtr {
+		printStats(codeGen)
 	}
 }
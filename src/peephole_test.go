@@ -0,0 +1,127 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"wacc_32/assembly"
+	"wacc_32/ast"
+	"wacc_32/errors"
+	"wacc_32/types"
+	"wacc_32/visitor"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "rewrite the peephole golden files")
+
+//validPrograms returns every valid test program which doesn't import a library
+func validPrograms(t *testing.T) []string {
+	files := make([]string, 0)
+	err := filepath.Walk("../tests", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == "imports" {
+			return filepath.SkipDir
+		}
+		if filepath.Ext(path) == ".wacc" && filepath.Base(filepath.Dir(path)) == "valid" {
+			files = append(files, path)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	sort.Strings(files)
+	return files
+}
+
+//compile returns the assembly generated for a file, or false if it doesn't compile
+func compile(file string, codeGen *assembly.CodeGenerator) (string, bool) {
+	types.SetPointerSize(codeGen.PointerSize)
+	tree, ok := checkFile(file)
+	if !ok {
+		return "", false
+	}
+	return codeGen.GenerateCode(tree), true
+}
+
+//checkFile returns the semantically checked AST of a file, or false if it has errors
+func checkFile(file string) (ast.AST, bool) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, false
+	}
+	wp := visitor.NewWaccParser(string(data), "")
+	parseTree := wp.GetParseTree()
+	tree := visitor.NewWaccVisitor("", filepath.Dir(file), wp).Visit(parseTree).(ast.AST)
+
+	errChan := make(chan error)
+	failed := make(chan bool)
+	go func() {
+		res := false
+		for err := range errChan {
+			if _, isWarning := err.(errors.Warning); !isWarning && err != nil {
+				res = true
+			}
+		}
+		failed <- res
+	}()
+	tree.Check(ast.Context{SemanticErrChan: errChan})
+	return tree, !<-failed
+}
+
+//countInstructions counts the indented lines of assembly which aren't directives
+func countInstructions(code string) int {
+	n := 0
+	for _, line := range strings.Split(code, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(line, "\t") && trimmed != "" && !strings.HasPrefix(trimmed, ".") {
+			n++
+		}
+	}
+	return n
+}
+
+//TestPeephole compares the number of instructions generated for every valid
+//test program with and without the peephole optimiser against
+//testdata/peephole_<target>.golden. Run with -update to rewrite them
+func TestPeephole(t *testing.T) {
+	files := validPrograms(t)
+	for _, target := range assembly.Targets() {
+		t.Run(target, func(t *testing.T) {
+			var golden strings.Builder
+			total0, total1 := 0, 0
+			for _, file := range files {
+				o0, _ := assembly.NewCodeGenerator(target)
+				o0.SetPeephole(nil)
+				code0, ok := compile(file, o0)
+				if !ok {
+					continue
+				}
+				o1, _ := assembly.NewCodeGenerator(target)
+				code1, _ := compile(file, o1)
+				n0, n1 := countInstructions(code0), countInstructions(code1)
+				assert.LessOrEqual(t, n1, n0, file)
+				total0 += n0
+				total1 += n1
+				fmt.Fprintf(&golden, "%s %d %d\n", filepath.ToSlash(strings.TrimPrefix(file, "../")), n0, n1)
+			}
+			assert.Less(t, total1, total0)
+
+			path := filepath.Join("testdata", "peephole_"+target+".golden")
+			if *update {
+				assert.NoError(t, os.MkdirAll("testdata", 0755))
+				assert.NoError(t, ioutil.WriteFile(path, []byte(golden.String()), 0644))
+				return
+			}
+			expected, err := ioutil.ReadFile(path)
+			assert.NoError(t, err)
+			assert.Equal(t, string(expected), golden.String())
+		})
+	}
+}
@@ -2,6 +2,7 @@ package symboltable
 
 import (
 	"fmt"
+	"sort"
 	"wacc_32/errors"
 	"wacc_32/types"
 )
@@ -94,6 +95,30 @@ func (st *SymbolTable) GetType(ident string) (types.WaccType, error) {
 	return metadata.wt, err
 }
 
+//GetPosition returns where an identifier was declared, or an error if it doesn't exist
+func (st *SymbolTable) GetPosition(ident string) (errors.Position, error) {
+	metadata, err := st.getMetadata(ident)
+	if err != nil {
+		return errors.Position{}, err
+	}
+	return metadata.pos, nil
+}
+
+//GetNames returns the identifiers declared in the symbol table, not its parents, in order
+func (st *SymbolTable) GetNames() []string {
+	names := make([]string, 0, len(st.declarations))
+	for name := range st.declarations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//GetParentScope returns the scope enclosing the symbol table, nil at the top level
+func (st *SymbolTable) GetParentScope() *SymbolTable {
+	return st.parentScope
+}
+
 //SetOffset sets the offset field for the ident in the current context
 //Assumes that the ident exists in the current context
 func (st *SymbolTable) SetOffset(ident string, offset int) {
@@ -85,3 +85,27 @@ func TestCannotGetIdentInParallelLevel(t *testing.T) {
 
 	assert.Error(t, err)
 }
+
+func TestGetPositionFindsOuterDeclaration(t *testing.T) {
+	st := NewTopSymbolTable()
+	st2 := NewSymbolTable(st)
+	outer := errors.NewPosition(1, 2, 1, 8)
+
+	st.AddDeclaration("wacc", types.Integer, outer)
+	st2.AddDeclaration("wacc", types.Integer, pos)
+	p, err := st2.GetParentScope().GetPosition("wacc")
+
+	assert.Nil(t, err)
+	assert.Equal(t, outer, p)
+	assert.Nil(t, st.GetParentScope())
+}
+
+func TestGetNamesOnlyListsTheScope(t *testing.T) {
+	st := NewTopSymbolTable()
+	st.AddDeclaration("outer", types.Integer, pos)
+	st2 := NewSymbolTable(st)
+	st2.AddDeclaration("b", types.Integer, pos)
+	st2.AddDeclaration("a", types.Boolean, pos)
+
+	assert.Equal(t, []string{"a", "b"}, st2.GetNames())
+}
@@ -39,7 +39,7 @@ func (arr array) Is(wt WaccType) bool {
 	case waccBaseType:
 		return w == Array
 	case array:
-		return w.baseType.Is(arr.baseType) && w.depth == arr.depth
+		return elemIs(w.baseType, arr.baseType) && w.depth == arr.depth
 	default:
 		return false
 	}
@@ -0,0 +1,43 @@
+package types
+
+var _ WaccType = channel{}
+
+//channel carries values of elemType between wacc routines
+type channel struct {
+	elemType WaccType
+}
+
+//NewChan creates the type of a channel of elemType values
+func NewChan(elemType WaccType) WaccType {
+	return channel{elemType: elemType}
+}
+
+//DefaultValue of a channel is null, sending on it is a null reference error
+func (c channel) DefaultValue() interface{} {
+	return nil
+}
+
+func (c channel) GetFormatString() string {
+	return "%p"
+}
+
+func (c channel) Is(wt WaccType) bool {
+	switch w := wt.(type) {
+	case waccBaseType:
+		return w == Chan
+	case channel:
+		return elemIs(c.elemType, w.elemType)
+	default:
+		return false
+	}
+}
+
+//String returns the type as it is written in wacc, chan<<element type>>
+func (c channel) String() string {
+	return "chan<" + c.elemType.String() + ">"
+}
+
+//GetChildren returns the type of the elements
+func (c channel) GetChildren() []WaccType {
+	return []WaccType{c.elemType}
+}
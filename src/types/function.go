@@ -1,5 +1,7 @@
 package types
 
+import "strings"
+
 var _ WaccType = function{}
 
 type function struct {
@@ -15,9 +17,9 @@ func NewFunction(returnType WaccType, paramTypes []WaccType) WaccType {
 	}
 }
 
-//DefaultValue should not be called
+//DefaultValue of a function value is null, calling it is a null reference error
 func (f function) DefaultValue() interface{} {
-	panic("Default Value on function should not have been called as we do not allow higher order function")
+	return nil
 }
 
 func (f function) GetFormatString() string {
@@ -29,25 +31,32 @@ func (f function) Is(wt WaccType) bool {
 	case waccBaseType:
 		return w == Function
 	case function:
+		if len(f.paramTypes) != len(w.paramTypes) {
+			return false
+		}
 		for i, fType := range f.paramTypes {
-			if w.paramTypes[i] != fType {
+			if !elemIs(fType, w.paramTypes[i]) {
 				return false
 			}
 		}
-		return f.returnType == w.returnType
+		return elemIs(f.returnType, w.returnType)
 	default:
 		return false
 	}
 }
 
+//String returns the type as it is written in wacc, fn(<params>) -> <return type>
 func (f function) String() string {
-	var str string
-	for _, pType := range f.paramTypes {
-		str += pType.String() + " -> "
+	params := make([]string, len(f.paramTypes))
+	for i, pType := range f.paramTypes {
+		params[i] = pType.String()
 	}
-	return str + f.returnType.String()
+	return "fn(" + strings.Join(params, ", ") + ") -> " + f.returnType.String()
 }
 
+//GetChildren returns the parameter types followed by the return type
 func (f function) GetChildren() []WaccType {
-	return append(f.paramTypes, f.returnType)
+	children := make([]WaccType, 0, len(f.paramTypes)+1)
+	children = append(children, f.paramTypes...)
+	return append(children, f.returnType)
 }
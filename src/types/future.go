@@ -0,0 +1,43 @@
+package types
+
+var _ WaccType = future{}
+
+//future is the handle of a wacc routine, joining it gives the value the routine returned
+type future struct {
+	resultType WaccType
+}
+
+//NewFuture creates the type of the handle of a routine returning resultType
+func NewFuture(resultType WaccType) WaccType {
+	return future{resultType: resultType}
+}
+
+//DefaultValue of a future is null, joining it is a null reference error
+func (f future) DefaultValue() interface{} {
+	return nil
+}
+
+func (f future) GetFormatString() string {
+	return "%p"
+}
+
+func (f future) Is(wt WaccType) bool {
+	switch w := wt.(type) {
+	case waccBaseType:
+		return w == Future
+	case future:
+		return elemIs(f.resultType, w.resultType)
+	default:
+		return false
+	}
+}
+
+//String returns the type as it is written in wacc, future<<result type>>
+func (f future) String() string {
+	return "future<" + f.resultType.String() + ">"
+}
+
+//GetChildren returns the type of the result
+func (f future) GetChildren() []WaccType {
+	return []WaccType{f.resultType}
+}
@@ -0,0 +1,194 @@
+package types
+
+var _ WaccType = typeVar{}
+
+//typeVar is a type parameter of a generic function, struct or class. Inside the
+//declaration it stands for a type it knows nothing about
+type typeVar struct {
+	name string
+}
+
+//NewTypeVar creates the type parameter name
+func NewTypeVar(name string) WaccType {
+	return typeVar{name: name}
+}
+
+//DefaultValue of a type parameter is null, the value is never read before it is set
+func (v typeVar) DefaultValue() interface{} {
+	return nil
+}
+
+func (v typeVar) GetFormatString() string {
+	return "%p"
+}
+
+//Is only holds for the same type parameter
+func (v typeVar) Is(wt WaccType) bool {
+	w, ok := wt.(typeVar)
+	return ok && w.name == v.name
+}
+
+func (v typeVar) String() string {
+	return v.name
+}
+
+func (v typeVar) GetChildren() []WaccType {
+	return []WaccType{}
+}
+
+//Substitution maps the type parameters of a generic declaration to the types they stand for
+type Substitution map[string]WaccType
+
+//Compose returns s with the type parameters in its types replaced by those outer maps,
+//for a call from a generic function whose own type parameters outer gives
+func (s Substitution) Compose(outer Substitution) Substitution {
+	if len(s) == 0 || len(outer) == 0 {
+		return s
+	}
+	composed := make(Substitution, len(s))
+	for param, t := range s {
+		composed[param] = Substitute(t, outer)
+	}
+	return composed
+}
+
+//Substitute replaces the type parameters in wt which s maps
+func Substitute(wt WaccType, s Substitution) WaccType {
+	if len(s) == 0 {
+		return wt
+	}
+	switch w := wt.(type) {
+	case typeVar:
+		if t, ok := s[w.name]; ok {
+			return t
+		}
+	case array:
+		base := Substitute(w.baseType, s)
+		if arr, ok := base.(array); ok {
+			return array{baseType: arr.baseType, depth: arr.depth + w.depth}
+		}
+		return array{baseType: base, depth: w.depth}
+	case pair:
+		return pair{fstType: Substitute(w.fstType, s), sndType: Substitute(w.sndType, s)}
+	case function:
+		return function{returnType: Substitute(w.returnType, s), paramTypes: substituteAll(w.paramTypes, s)}
+	case future:
+		return future{resultType: Substitute(w.resultType, s)}
+	case channel:
+		return channel{elemType: Substitute(w.elemType, s)}
+	case UserType:
+		w.typeArgs = substituteAll(w.typeArgs, s)
+		w.fieldTypes = substituteAll(w.fieldTypes, s)
+		return w
+	}
+	return wt
+}
+
+func substituteAll(wts []WaccType, s Substitution) []WaccType {
+	if wts == nil {
+		return nil
+	}
+	substituted := make([]WaccType, len(wts))
+	for i, wt := range wts {
+		substituted[i] = Substitute(wt, s)
+	}
+	return substituted
+}
+
+//Unify binds the type parameters in param so it matches arg, adding them to s.
+//It returns false if no binding makes arg a param. Empty array literals and null
+//match any array or pair without binding anything
+func Unify(param, arg WaccType, s Substitution) bool {
+	switch p := param.(type) {
+	case typeVar:
+		if t, ok := s[p.name]; ok {
+			return t.Is(arg)
+		}
+		s[p.name] = arg
+		return true
+	case array:
+		if arg == Array {
+			return true
+		}
+		if !arg.Is(Array) {
+			return false
+		}
+		return Unify(p.GetChildren()[0], arg.GetChildren()[0], s)
+	case pair:
+		if arg == Pair {
+			return true
+		}
+		a, ok := arg.(pair)
+		return ok && Unify(p.fstType, a.fstType, s) && Unify(p.sndType, a.sndType, s)
+	case function:
+		a, ok := arg.(function)
+		if !ok || len(a.paramTypes) != len(p.paramTypes) {
+			return false
+		}
+		for i, pType := range p.paramTypes {
+			if !Unify(pType, a.paramTypes[i], s) {
+				return false
+			}
+		}
+		return Unify(p.returnType, a.returnType, s)
+	case future:
+		a, ok := arg.(future)
+		return ok && Unify(p.resultType, a.resultType, s)
+	case channel:
+		a, ok := arg.(channel)
+		return ok && Unify(p.elemType, a.elemType, s)
+	case UserType:
+		a, ok := arg.(UserType)
+		if !ok || a.name != p.name || len(a.typeArgs) != len(p.typeArgs) {
+			return param.Is(arg)
+		}
+		for i, pType := range p.typeArgs {
+			if !Unify(pType, a.typeArgs[i], s) {
+				return false
+			}
+		}
+		return true
+	}
+	return param.Is(arg)
+}
+
+//TypeVars returns the type parameters wt refers to, in the order they first appear
+func TypeVars(wt WaccType) []string {
+	var names []string
+	seen := make(map[string]bool)
+	var walk func(WaccType)
+	walk = func(wt WaccType) {
+		switch w := wt.(type) {
+		case typeVar:
+			if !seen[w.name] {
+				seen[w.name] = true
+				names = append(names, w.name)
+			}
+		case array:
+			walk(w.baseType)
+		case pair:
+			walk(w.fstType)
+			walk(w.sndType)
+		case function:
+			for _, p := range w.paramTypes {
+				walk(p)
+			}
+			walk(w.returnType)
+		case future:
+			walk(w.resultType)
+		case channel:
+			walk(w.elemType)
+		case UserType:
+			for _, arg := range w.typeArgs {
+				walk(arg)
+			}
+		}
+	}
+	walk(wt)
+	return names
+}
+
+//IsGeneric checks whether wt refers to any type parameter
+func IsGeneric(wt WaccType) bool {
+	return len(TypeVars(wt)) > 0
+}
@@ -31,7 +31,7 @@ func (p pair) Is(wt WaccType) bool {
 	case waccBaseType:
 		return w == Pair
 	case pair:
-		return p.fstType.Is(w.fstType) && p.sndType.Is(w.sndType)
+		return elemIs(p.fstType, w.fstType) && elemIs(p.sndType, w.sndType)
 	default:
 		return false
 	}
@@ -15,8 +15,8 @@ type WaccType interface {
 
 var _ WaccType = Integer
 
-var typeStrings = []string{"NULL", "int", "bool", "char", "string", "pair", "array", "function", "structure", "lock", "sema"}
-var typeFormatStrings = []string{"%p", "%d", "true\\0false", " %c", "%.*s", "%p", "%p", "", "", "%p", "%p"}
+var typeStrings = []string{"NULL", "int", "bool", "char", "string", "pair", "array", "function", "structure", "lock", "sema", "future", "chan", "cond"}
+var typeFormatStrings = []string{"%p", "%d", "true\\0false", " %c", "%.*s", "%p", "%p", "", "", "%p", "%p", "%p", "%p", "%p"}
 var defaultValues = []interface{}{
 	nil,
 	0,
@@ -30,6 +30,8 @@ var defaultValues = []interface{}{
 	nil,
 	nil,
 	nil,
+	nil,
+	nil,
 }
 
 type waccBaseType int
@@ -47,6 +49,9 @@ const (
 	UserDefinedType
 	Lock
 	Sema
+	Future
+	Chan
+	Cond
 )
 
 func (wbt waccBaseType) String() string {
@@ -74,6 +79,10 @@ func (wbt waccBaseType) Is(wt WaccType) bool {
 		return wbt == Pair
 	case function:
 		return wbt == Function
+	case future:
+		return wbt == Future
+	case channel:
+		return wbt == Chan
 	default:
 		return wbt == w
 	}
@@ -91,10 +100,25 @@ const (
 	Byte = 1 << iota
 	HalfWord
 	Word
+	DoubleWord
 )
 
 var sizeStrings = []string{"b", "sh", ""}
 
+//pointerSize is the size of a reference (string, array, pair...) on the target
+var pointerSize Size = Word
+
+//SetPointerSize changes the size of references, this must be called before the
+//symbol tables are filled in so stack offsets match the target architecture
+func SetPointerSize(size Size) {
+	pointerSize = size
+}
+
+//PointerSize returns the size of a reference on the target
+func PointerSize() Size {
+	return pointerSize
+}
+
 func (s Size) String() string {
 	return sizeStrings[s>>1]
 }
@@ -105,7 +129,9 @@ func TypeSize(wt WaccType) Size {
 		fallthrough
 	case Char:
 		return Byte
-	default:
+	case Integer:
 		return Word
+	default:
+		return pointerSize
 	}
 }
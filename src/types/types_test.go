@@ -47,3 +47,105 @@ func TestWaccTypeEqualityHolds(t *testing.T) {
 	assert.False(t, arr1 == xarr2)
 	assert.False(t, arr2 == xbase)
 }
+
+func TestFunctionTypeString(t *testing.T) {
+	f := NewFunction(NewFunction(Boolean, []WaccType{Char}), []WaccType{Integer, NewArray(Integer, 1)})
+
+	assert.Equal(t, "fn(int, int[]) -> fn(char) -> bool", f.String())
+}
+
+func TestFunctionTypeIsStructural(t *testing.T) {
+	f := NewFunction(Integer, []WaccType{Integer})
+
+	assert.True(t, f.Is(NewFunction(Integer, []WaccType{Integer})))
+	assert.True(t, f.Is(Function))
+	assert.True(t, Function.Is(f))
+	assert.False(t, f.Is(NewFunction(Boolean, []WaccType{Integer})))
+	assert.False(t, f.Is(NewFunction(Integer, []WaccType{Integer, Integer})))
+	assert.False(t, f.Is(Integer))
+}
+
+func TestUnifyInfersTypeParameters(t *testing.T) {
+	T, U := NewTypeVar("T"), NewTypeVar("U")
+	param := NewPair(NewArray(T, 1), U)
+	s := Substitution{}
+
+	assert.True(t, Unify(param, NewPair(NewArray(Char, 1), Integer), s))
+	assert.Equal(t, Char, s["T"])
+	assert.Equal(t, Integer, s["U"])
+	assert.False(t, Unify(T, Boolean, s))
+	assert.True(t, Unify(NewArray(T, 1), Array, s))
+}
+
+func TestSubstituteFlattensArrays(t *testing.T) {
+	T := NewTypeVar("T")
+	s := Substitution{"T": NewArray(Integer, 1)}
+
+	assert.Equal(t, NewArray(Integer, 2), Substitute(NewArray(T, 1), s))
+	assert.Equal(t, "fn(int[]) -> bool", Substitute(NewFunction(Boolean, []WaccType{T}), s).String())
+	assert.Equal(t, []string{"T"}, TypeVars(NewPair(T, T)))
+	assert.False(t, IsGeneric(Substitute(NewPair(T, Char), s)))
+}
+
+func TestUserTypeInstantiate(t *testing.T) {
+	T := NewTypeVar("T")
+	box := NewUserType("Box", []string{"value"}, []WaccType{T}, false).WithTypeParams([]string{"T"})
+	intBox := box.Instantiate([]WaccType{Integer})
+
+	fieldType, _ := intBox.GetType("value")
+	assert.Equal(t, Integer, fieldType)
+	assert.Equal(t, "Box<int>", intBox.String())
+	assert.True(t, intBox.Is(NewUserTypeRef("Box", []WaccType{Integer})))
+	assert.False(t, intBox.Is(NewUserTypeRef("Box", []WaccType{Char})))
+}
+
+func TestSubclassIsItsSupers(t *testing.T) {
+	animal := NewUserTypeRef("Animal", nil)
+	dog := NewUserTypeRef("Dog", nil).WithSupers([]string{"Animal"})
+	puppy := NewUserTypeRef("Puppy", nil).WithSupers([]string{"Dog", "Animal"})
+
+	assert.True(t, animal.Is(puppy))
+	assert.False(t, puppy.Is(animal))
+	assert.False(t, NewArray(animal, 1).Is(NewArray(dog, 1)))
+
+	common, ok := CommonSuper(dog, puppy)
+	assert.True(t, ok)
+	assert.Equal(t, "Dog", common.String())
+	common, ok = CommonSuper(puppy, animal)
+	assert.True(t, ok)
+	assert.Equal(t, "Animal", common.String())
+	_, ok = CommonSuper(dog, Integer)
+	assert.False(t, ok)
+}
+
+func TestClassIsItsInterfaces(t *testing.T) {
+	shape := NewUserTypeRef("Shape", nil)
+	circle := NewUserTypeRef("Circle", nil).WithInterfaces([]string{"Shape"})
+
+	assert.True(t, shape.Is(circle))
+	assert.False(t, circle.Is(shape))
+	assert.False(t, NewArray(shape, 1).Is(NewArray(circle, 1)))
+	assert.True(t, NewInterface("Shape", []string{"0area"}).IsInterface())
+	assert.False(t, circle.IsInterface())
+}
+
+func TestFutureIsOfItsResult(t *testing.T) {
+	f := NewFuture(Integer)
+
+	assert.True(t, f.Is(NewFuture(Integer)))
+	assert.False(t, f.Is(NewFuture(Boolean)))
+	assert.True(t, Future.Is(f))
+	assert.Equal(t, "future<int[]>", NewFuture(NewArray(Integer, 1)).String())
+	assert.Equal(t, NewFuture(Char), Substitute(NewFuture(NewTypeVar("T")), Substitution{"T": Char}))
+}
+
+func TestChanIsOfItsElement(t *testing.T) {
+	c := NewChan(Integer)
+
+	assert.True(t, c.Is(NewChan(Integer)))
+	assert.False(t, c.Is(NewChan(Char)))
+	assert.False(t, c.Is(NewFuture(Integer)))
+	assert.True(t, Chan.Is(c))
+	assert.Equal(t, "chan<pair(int,bool)>", NewChan(NewPair(Integer, Boolean)).String())
+	assert.Equal(t, NewChan(Char), Substitute(NewChan(NewTypeVar("T")), Substitution{"T": Char}))
+}
@@ -2,16 +2,24 @@ package types
 
 import (
 	"fmt"
+	"strings"
 )
 
 var _ WaccType = UserType{}
 
 type UserType struct {
-	name       string
-	fieldTypes []WaccType
-	fieldNames []string
-	IsClass    bool
-	funcTypes  []WaccType
+	name        string
+	fieldTypes  []WaccType
+	fieldNames  []string
+	IsClass     bool
+	funcTypes   []WaccType
+	typeParams  []string   //The type parameters of a generic declaration
+	typeArgs    []WaccType //The types a reference gives the type parameters
+	supers      []string   //The classes a class extends, its parent first
+	subclassed  bool       //Whether any class extends the class
+	interfaces  []string   //The interfaces a class implements, with those of its supers
+	isInterface bool
+	methods     []string //The methods an interface lists, in the order of its method tables
 }
 
 //NewUserType creates a new userType type
@@ -24,6 +32,121 @@ func NewUserType(name string, fieldNames []string, fieldTypes []WaccType, isClas
 	}
 }
 
+//NewUserTypeRef refers to the struct or class name, typeArgs are the types its type
+//parameters stand for
+func NewUserTypeRef(name string, typeArgs []WaccType) UserType {
+	return UserType{
+		name:     name,
+		typeArgs: typeArgs,
+	}
+}
+
+//NewInterface creates the interface name listing methods
+func NewInterface(name string, methods []string) UserType {
+	return UserType{
+		name:       name,
+		fieldTypes:  []WaccType{},
+		isInterface: true,
+		methods:     methods,
+	}
+}
+
+//WithTypeParams returns the declaration of a generic struct or class
+func (s UserType) WithTypeParams(params []string) UserType {
+	s.typeParams = params
+	return s
+}
+
+//WithSupers returns the class extending supers, the names of its parent and the
+//classes the parent extends in order
+func (s UserType) WithSupers(supers []string) UserType {
+	s.supers = supers
+	return s
+}
+
+//WithSubclasses returns the declaration of a class which other classes extend
+func (s UserType) WithSubclasses() UserType {
+	s.subclassed = true
+	return s
+}
+
+//WithInterfaces returns the class implementing interfaces, those its supers implement
+//included
+func (s UserType) WithInterfaces(interfaces []string) UserType {
+	s.interfaces = interfaces
+	return s
+}
+
+//GetInterfaces returns the interfaces a class implements
+func (s UserType) GetInterfaces() []string {
+	return s.interfaces
+}
+
+//IsInterface checks whether the declared user type is an interface
+func (s UserType) IsInterface() bool {
+	return s.isInterface
+}
+
+//GetMethods returns the methods a declared interface lists, the index of a method is its
+//slot in the method tables of the interface
+func (s UserType) GetMethods() []string {
+	return s.methods
+}
+
+//GetSupers returns the classes a class extends, its parent first
+func (s UserType) GetSupers() []string {
+	return s.supers
+}
+
+//HasSubclasses checks whether other classes extend the declared class, so its methods
+//can be overridden
+func (s UserType) HasSubclasses() bool {
+	return s.subclassed
+}
+
+//IsPolymorphic checks whether the declared class is part of a class hierarchy, its
+//objects then start with a pointer to the methods of their class
+func (s UserType) IsPolymorphic() bool {
+	return len(s.supers) > 0 || s.subclassed
+}
+
+//GetTypeParams returns the type parameters of a generic declaration
+func (s UserType) GetTypeParams() []string {
+	return s.typeParams
+}
+
+//GetTypeArgs returns the types a reference gives the type parameters
+func (s UserType) GetTypeArgs() []WaccType {
+	return s.typeArgs
+}
+
+//Instantiate returns the declaration with its type parameters replaced by args
+func (s UserType) Instantiate(args []WaccType) UserType {
+	sub := make(Substitution, len(s.typeParams))
+	for i, param := range s.typeParams {
+		sub[param] = args[i]
+	}
+	inst := Substitute(s, sub).(UserType)
+	inst.typeArgs = args
+	return inst
+}
+
+//FullName returns the name of the type with its type arguments, name<arg, ...>
+func (s UserType) FullName() string {
+	if len(s.typeArgs) == 0 {
+		return s.name
+	}
+	args := make([]string, len(s.typeArgs))
+	for i, arg := range s.typeArgs {
+		if ut, ok := arg.(UserType); ok {
+			args[i] = ut.FullName()
+		} else {
+			args[i] = arg.String()
+		}
+	}
+	return s.name + "<" + strings.Join(args, ", ") + ">"
+}
+
 func (s UserType) GetName() string {
 	return s.name
 }
@@ -73,16 +196,66 @@ func (s UserType) Is(wt WaccType) bool {
 	case waccBaseType:
 		return UserDefinedType == w
 	case UserType:
-		return s.name == w.name
+		//An object of a subclass can be used as one of its superclass, and an object of
+		//a class as one of the interfaces it implements
+		if s.name != w.name {
+			return len(s.typeArgs) == 0 && (findString(s.name, w.supers) != -1 || findString(s.name, w.interfaces) != -1)
+		}
+		//A reference without type arguments is checked when it is looked up
+		if len(s.typeArgs) == 0 || len(w.typeArgs) == 0 {
+			return true
+		}
+		if len(s.typeArgs) != len(w.typeArgs) {
+			return false
+		}
+		for i, arg := range s.typeArgs {
+			if !arg.Is(w.typeArgs[i]) {
+				return false
+			}
+		}
+		return true
 	default:
 		return false
 	}
 }
 
 func (s UserType) String() string {
-	if s.IsClass {
-		return "struct"
-	} else {
-		return "class"
+	return s.FullName()
+}
+
+//CommonSuper returns the type both a and b are, which for objects of different classes is
+//the nearest class they both extend. Other types are compared by how they are written
+func CommonSuper(a, b WaccType) (WaccType, bool) {
+	if a.String() == b.String() {
+		return a, true
+	}
+	s, ok1 := a.(UserType)
+	_, ok2 := b.(UserType)
+	if !ok1 || !ok2 || len(s.typeArgs) > 0 {
+		return nil, false
+	}
+	if s.Is(b) {
+		return s, true
 	}
+	for i, super := range s.supers {
+		c := NewUserTypeRef(super, nil).WithSupers(s.supers[i+1:])
+		if c.Is(b) {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+//isUpcast checks whether actual is a subclass of the class expected, or a class
+//implementing the interface expected
+func isUpcast(expected, actual WaccType) bool {
+	e, ok1 := expected.(UserType)
+	a, ok2 := actual.(UserType)
+	return ok1 && ok2 && e.name != a.name
+}
+
+//elemIs checks the types of the elements of arrays, pairs and functions, which aren't
+//upcast. A Dog[] isn't an Animal[], as any Animal could be stored in it
+func elemIs(expected, actual WaccType) bool {
+	return expected.Is(actual) && !isUpcast(expected, actual)
 }
@@ -27,7 +27,14 @@ func (w *WaccVisitor) VisitLeftPairElem(ctx *parser.LeftPairElemContext) interfa
 
 //VisitSetlhs returns a PairElem
 func (w *WaccVisitor) VisitSetlhs(ctx *parser.SetlhsContext) interface{} {
-	if ident := ctx.Libident(); ident != nil {
+	if libCtx := ctx.Libident(); libCtx != nil {
+		//Variables assigned to in a library keep their names, only its functions are prefixed
+		var ident *ast.Ident
+		if lib := libCtx.(*parser.LibidentContext); lib.ACCESSOR() == nil {
+			ident = lib.Fieldident().Accept(w).(*ast.Ident)
+		} else {
+			ident = libCtx.Accept(w).(*ast.Ident)
+		}
 		if exprsCtx := ctx.AllExpr(); len(exprsCtx) != 0 {
 			exprs := make([]ast.Expression, len(exprsCtx))
 
@@ -35,9 +42,9 @@ func (w *WaccVisitor) VisitSetlhs(ctx *parser.SetlhsContext) interface{} {
 				exprs[i] = exprCtx.Accept(w).(ast.Expression)
 			}
 			pos := getPos(ctx)
-			return ast.NewArrayElem(ident.Accept(w).(*ast.Ident), exprs, pos)
+			return ast.NewArrayElem(ident, exprs, pos)
 		}
-		return ident.Accept(w)
+		return ident
 	}
 	if pairType := ctx.Pairtype(); pairType != nil {
 		return pairType.Accept(w)
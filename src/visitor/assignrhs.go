@@ -58,7 +58,12 @@ func (w *WaccVisitor) VisitRightFunctionCall(ctx *parser.RightFunctionCallContex
 		arglist = append(arglist, thisArg)
 	}
 
-	return ast.NewRHSFunctionCall(ast.NewIdent("0"+fName.GetName(), fName.GetPos()), arglist, isMethod, pos)
+	ident := ast.NewIdent("0"+fName.GetName(), fName.GetPos())
+	//Calls through variables of a library use the variable's own name
+	if ctx.Libident().(*parser.LibidentContext).ACCESSOR() == nil {
+		ident.SetLibrary(w.importName)
+	}
+	return ast.NewRHSFunctionCall(ident, arglist, isMethod, pos)
 }
 
 //VisitRightArrayLiter return an array literal
@@ -76,8 +81,11 @@ func (w *WaccVisitor) VisitRightNewUserType(ctx *parser.RightNewUserTypeContext)
 	ident := ctx.Libident().Accept(w).(*ast.Ident)
 	pos := getPos(ctx)
 
-	utName := ident.GetName()
-	utType := types.NewUserType(utName, nil, nil, false)
+	var typeArgs []types.WaccType
+	if argsCtx := ctx.Typeargs(); argsCtx != nil {
+		typeArgs = argsCtx.Accept(w).([]types.WaccType)
+	}
+	utType := w.userTypeRef(ident.GetName(), typeArgs)
 
 	return ast.NewLiteral(utType, values, pos)
 }
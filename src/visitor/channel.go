@@ -0,0 +1,33 @@
+package visitor
+
+import (
+	"wacc_32/ast"
+	"wacc_32/parser"
+	"wacc_32/types"
+)
+
+//VisitMakeChan returns the creation of a channel
+func (w *WaccVisitor) VisitMakeChan(ctx *parser.MakeChanContext) interface{} {
+	elemType := ctx.Wacctype().Accept(w).(types.WaccType)
+	capacity := ctx.Expr().Accept(w).(ast.Expression)
+	return ast.NewMakeChan(elemType, capacity, getPos(ctx))
+}
+
+//VisitRightRecv returns a receive from a channel
+func (w *WaccVisitor) VisitRightRecv(ctx *parser.RightRecvContext) interface{} {
+	channel := ctx.Expr().Accept(w).(ast.Expression)
+	return ast.NewRecv(channel, getPos(ctx))
+}
+
+//VisitStatSend returns a send on a channel
+func (w *WaccVisitor) VisitStatSend(ctx *parser.StatSendContext) interface{} {
+	channel := ctx.Expr(0).Accept(w).(ast.Expression)
+	value := ctx.Expr(1).Accept(w).(ast.Expression)
+	return ast.NewStatSend(channel, value, getPos(ctx))
+}
+
+//VisitStatClose returns the closing of a channel
+func (w *WaccVisitor) VisitStatClose(ctx *parser.StatCloseContext) interface{} {
+	channel := ctx.Expr().Accept(w).(ast.Expression)
+	return ast.NewStatClose(channel, getPos(ctx))
+}
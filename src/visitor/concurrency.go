@@ -1,27 +1,44 @@
 package visitor
 
 import (
+	"strings"
 	"wacc_32/ast"
+	"wacc_32/errors"
 	"wacc_32/parser"
 	"wacc_32/types"
 )
 
 //VisitStatWacc returns a function call in a new thread
 func (w *WaccVisitor) VisitStatWacc(ctx *parser.StatWaccContext) interface{} {
-	fName := ctx.Libident().Accept(w).(*ast.Ident)
+	fName, isMethod, arglist := w.routineCall(ctx.Libident(), ctx.Arglist(), getPos(ctx))
+	return ast.NewWaccRoutine(fName, isMethod, arglist, getPos(ctx))
+}
+
+//VisitRightWacc returns a function call in a new thread whose result can be joined
+func (w *WaccVisitor) VisitRightWacc(ctx *parser.RightWaccContext) interface{} {
+	fName, isMethod, arglist := w.routineCall(ctx.Libident(), ctx.Arglist(), getPos(ctx))
+	return ast.NewWaccFuture(fName, isMethod, arglist, getPos(ctx))
+}
+
+//routineCall returns the function a wacc routine runs, whether it is a method and the
+//arguments it is called with. Like any method call, the object a method is called on
+//is the last argument
+func (w *WaccVisitor) routineCall(libCtx parser.ILibidentContext, argsCtx parser.IArglistContext, pos errors.Position) (*ast.Ident, bool, []ast.Expression) {
+	fName := libCtx.Accept(w).(*ast.Ident)
 	w.libMng.concurrentFunctions <- fName.GetName()
 	arglist := make([]ast.Expression, 0)
-	if args := ctx.Arglist(); args != nil {
-		arglist = args.Accept(w).([]ast.Expression)
+	if argsCtx != nil {
+		arglist = argsCtx.Accept(w).([]ast.Expression)
 	}
-	pos := getPos(ctx)
 	if fName.IsNamespaced() {
 		components := fName.GetNameComponents()
-		thisStr := components[len(components)-2]
-		thisArg := ast.NewIdent(thisStr, pos)
-		arglist = append([]ast.Expression{thisArg}, arglist...)
+		thisStr := strings.Join(components[:len(components)-1], "!")
+		if len(components) > 2 {
+			thisStr = "1" + thisStr
+		}
+		arglist = append(arglist, ast.NewIdent(thisStr, pos))
 	}
-	return ast.NewWaccRoutine(ast.NewIdent("0"+fName.GetName(), fName.GetPos()), fName.IsNamespaced(), arglist, pos)
+	return ast.NewIdent("0"+fName.GetName(), fName.GetPos()), fName.IsNamespaced(), arglist
 }
 
 //VisitStatAcquire returns an acquire statement
@@ -46,6 +63,32 @@ func (w *WaccVisitor) VisitStatDown(ctx *parser.StatDownContext) interface{} {
 	return ast.NewSemaDown(ident, getPos(ctx))
 }
 
+//VisitStatWait returns a wait on a condition variable
+func (w *WaccVisitor) VisitStatWait(ctx *parser.StatWaitContext) interface{} {
+	cond := ctx.Fieldident(0).Accept(w).(*ast.Ident)
+	lock := ctx.Fieldident(1).Accept(w).(*ast.Ident)
+	return ast.NewWait(cond, lock, getPos(ctx))
+}
+
+//VisitStatSignal returns a signal of a condition variable
+func (w *WaccVisitor) VisitStatSignal(ctx *parser.StatSignalContext) interface{} {
+	cond := ctx.Fieldident().Accept(w).(*ast.Ident)
+	return ast.NewSignal(cond, getPos(ctx))
+}
+
+//VisitStatBroadcast returns a broadcast of a condition variable
+func (w *WaccVisitor) VisitStatBroadcast(ctx *parser.StatBroadcastContext) interface{} {
+	cond := ctx.Fieldident().Accept(w).(*ast.Ident)
+	return ast.NewBroadcast(cond, getPos(ctx))
+}
+
+//VisitStatWith returns a block holding a lock
+func (w *WaccVisitor) VisitStatWith(ctx *parser.StatWithContext) interface{} {
+	lock := ctx.Fieldident().Accept(w).(*ast.Ident)
+	stat := ctx.Stat().Accept(w).(ast.Statement)
+	return ast.NewStatWith(lock, stat, getPos(ctx))
+}
+
 func (w *WaccVisitor) VisitExprSemaLiter(ctx *parser.ExprSemaLiterContext) interface{} {
 	return ctx.Semaliter().Accept(w)
 }
@@ -2,12 +2,22 @@ package visitor
 
 import (
 	"wacc_32/ast"
+	"wacc_32/errors"
 	"wacc_32/parser"
 	"wacc_32/types"
+
+	"github.com/antlr/antlr4/runtime/Go/antlr"
 )
 
 //VisitFunction retuns a Function with the correct signature and enclosed body statements
+//Its type parameters follow those of the class it is a method of
 func (w *WaccVisitor) VisitFunction(ctx *parser.FunctionContext) interface{} {
+	outer := w.typeParams
+	defer func() { w.typeParams = outer }()
+	if paramsCtx := ctx.Typeparams(); paramsCtx != nil {
+		w.typeParams = append(append([]string{}, outer...), paramsCtx.Accept(w).([]string)...)
+	}
+
 	retType := ctx.Wacctype().Accept(w).(types.WaccType)
 	ident := ctx.Ident().Accept(w).(*ast.Ident)
 
@@ -19,7 +29,27 @@ func (w *WaccVisitor) VisitFunction(ctx *parser.FunctionContext) interface{} {
 	stats := ctx.Funcbody().Accept(w).(ast.Statement)
 	pos := getPos(ctx)
 
-	return ast.NewFunction(retType, ast.NewIdent("0"+w.importName+ident.String(), ident.GetPos()), params, stats, pos)
+	fn := ast.NewFunction(retType, ast.NewIdent("0"+w.importName+ident.String(), ident.GetPos()), params, stats, pos)
+	fn.SetTypeParams(w.typeParams)
+	return fn
+}
+
+//VisitExprLambda returns an anonymous function
+func (w *WaccVisitor) VisitExprLambda(ctx *parser.ExprLambdaContext) interface{} {
+	return ctx.Lambda().Accept(w)
+}
+
+//VisitLambda returns a Lambda with its signature and body statements
+func (w *WaccVisitor) VisitLambda(ctx *parser.LambdaContext) interface{} {
+	retType := ctx.Wacctype().Accept(w).(types.WaccType)
+
+	params := ast.NewEmptyParamList()
+	if paramsCtx := ctx.Paramlist(); paramsCtx != nil {
+		params = paramsCtx.Accept(w).(ast.ParamList)
+	}
+
+	stats := ctx.Funcbody().Accept(w).(ast.Statement)
+	return ast.NewLambda(retType, params, stats, getPos(ctx))
 }
 
 //VisitReturnable returns an return or exit statement based
@@ -47,6 +77,10 @@ func (w *WaccVisitor) VisitFuncbody(ctx *parser.FuncbodyContext) interface{} {
 	if returnableCtx != nil {
 		ret := returnableCtx.Accept(w).(ast.Statement)
 		stats = append(stats, ret)
+	} else if ctx.WITH() != nil {
+		lock := ctx.Fieldident().Accept(w).(*ast.Ident)
+		body := ctx.Funcbody(0).Accept(w).(ast.StatMultiple)
+		stats = append(stats, ast.NewStatWith(lock, ast.NewStatMultiple(body), tailPos(ctx.WITH(), ctx)))
 	} else { // if statement
 		cond := ctx.Expr().Accept(w).(ast.Expression)
 
@@ -58,15 +92,21 @@ func (w *WaccVisitor) VisitFuncbody(ctx *parser.FuncbodyContext) interface{} {
 
 		ifStat := ast.NewStatMultiple(ifStatList)
 		elseStat := ast.NewStatMultiple(elseStatList)
-		pos := getPos(ifFBodyCtx)
-
-		statIf := ast.NewStatIf(cond, ifStat, elseStat, pos)
+		statIf := ast.NewStatIf(cond, ifStat, elseStat, tailPos(ctx.IF(), ctx))
 		stats = append(stats, statIf)
 	}
 
 	return stats
 }
 
+//tailPos returns the position of the if or with block ending a function body, from its
+//first token to the end of the body, after any statements before it
+func tailPos(first antlr.TerminalNode, ctx *parser.FuncbodyContext) errors.Position {
+	start, stop := first.GetSymbol(), ctx.GetStop()
+	return errors.NewSourcePosition(tokenFile(stop), start.GetLine(), start.GetColumn(),
+		stop.GetLine(), stop.GetColumn(), len([]rune(stop.GetText())))
+}
+
 //VisitArglist returns an an ArgList
 func (w *WaccVisitor) VisitArglist(ctx *parser.ArglistContext) interface{} {
 	exprsCtx := ctx.AllExpr()
@@ -104,7 +144,7 @@ func (w *WaccVisitor) VisitParamNormal(ctx *parser.ParamNormalContext) interface
 //VisitParam returns a Param
 func (w *WaccVisitor) VisitParamUserType(ctx *parser.ParamUserTypeContext) interface{} {
 	libIdent := ctx.Libident().Accept(w).(*ast.Ident)
-	wType := types.NewUserType(libIdent.GetName(), nil, nil, false)
+	wType := w.userTypeRef(libIdent.GetName(), nil)
 	ident := ctx.Ident().Accept(w).(*ast.Ident)
 	pos := getPos(ctx)
 
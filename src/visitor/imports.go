@@ -1,7 +1,6 @@
 package visitor
 
 import (
-	"os"
 	"regexp"
 	"strings"
 	"wacc_32/ast"
@@ -29,8 +28,7 @@ func (w *WaccVisitor) VisitLibident(ctx *parser.LibidentContext) interface{} {
 }
 
 func (w *WaccVisitor) throwImportError(err error) {
-	println(err.Error())
-	os.Exit(semanticError)
+	w.parser.errorCounter.fatal(semanticError, errors.AsDiagnostic(err))
 }
 
 //VisitImportfile visits and registers an imported file
@@ -39,7 +37,7 @@ func (w *WaccVisitor) VisitImportfile(ctx *parser.ImportfileContext) interface{}
 	if ctx.AS() != nil {
 		waccFile.filename = ctx.Ident().GetText()
 	}
-	loaded := w.libMng.addLib(waccFile.filepath, waccFile.filename)
+	loaded := w.libMng.addLib(waccFile.filepath, waccFile.filename, getPos(ctx))
 	return importFilePair{
 		filepath: waccFile.filepath,
 		loaded:   loaded,
@@ -56,8 +54,7 @@ var filepathParser = regexp.MustCompile(`\"((.*/)*(.*)\.wacc)\"`) //Index 1 to g
 func (w *WaccVisitor) VisitWaccfile(ctx *parser.WaccfileContext) interface{} {
 	name := ctx.STRING_LITER().GetText()
 	if strings.Contains(name, "$") {
-		println(errors.NewInvalidImportPathError(getPos(ctx)))
-		os.Exit(100)
+		w.parser.errorCounter.fatal(syntaxError, errors.AsDiagnostic(errors.NewInvalidImportPathError(getPos(ctx))))
 	}
 	names := filepathParser.FindAllStringSubmatch(name, 1)[0]
 	return waccfile{
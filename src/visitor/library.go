@@ -1,14 +1,18 @@
 package visitor
 
 import (
+	"sort"
 	"strings"
 	"sync"
 	"wacc_32/ast"
+	"wacc_32/errors"
 )
 
 type libManager struct {
 	visited             map[string]struct{} //This is a set in Go
 	aliases             map[string]string
+	imports             map[string]errors.Position //Where each alias was imported
+	used                map[string]bool            //Aliases which have been accessed
 	calledFunctions     chan string
 	concurrentFunctions chan string //Functions called with the wacc keyword
 	functions           chan *ast.Function
@@ -20,6 +24,8 @@ func newLibManager() *libManager {
 	return &libManager{
 		visited:             make(map[string]struct{}),
 		aliases:             make(map[string]string),
+		imports:             make(map[string]errors.Position),
+		used:                make(map[string]bool),
 		calledFunctions:     make(chan string, 10), //10 is just an arbitrary buffer size
 		concurrentFunctions: make(chan string, 10), //10 is just an arbitrary buffer size
 		functions:           make(chan *ast.Function),
@@ -31,6 +37,8 @@ func (l *libManager) derive() *libManager {
 	return &libManager{
 		visited:             l.visited,
 		aliases:             make(map[string]string),
+		imports:             make(map[string]errors.Position),
+		used:                make(map[string]bool),
 		calledFunctions:     l.calledFunctions,
 		concurrentFunctions: l.concurrentFunctions,
 		functions:           l.functions,
@@ -39,23 +47,56 @@ func (l *libManager) derive() *libManager {
 }
 
 //Add a lock
-func (l *libManager) addLib(filepath, alias string) (exists bool) {
+func (l *libManager) addLib(filepath, alias string, pos errors.Position) (exists bool) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	_, exists = l.visited[filepath]
 	l.visited[filepath] = struct{}{}
 	l.aliases[alias] = filepath
+	if _, ok := l.imports[alias]; !ok {
+		l.imports[alias] = pos
+	}
 	return exists
 }
 
 func (l *libManager) getLib(alias string) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	lib, ok := l.aliases[alias]
+	l.used[alias] = true
 	return lib, ok
 }
 
-//formatFilePath replaces all /'s from the filepath with $
+//unusedImports returns warnings for the aliases which have never been accessed
+func (l *libManager) unusedImports() []error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	aliases := make([]string, 0)
+	for alias := range l.imports {
+		if !l.used[alias] {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+	warnings := make([]error, len(aliases))
+	for i, alias := range aliases {
+		warnings[i] = errors.NewUnusedImportWarning(l.imports[alias], alias)
+	}
+	return warnings
+}
+
+//formatFilePath replaces all /'s from the filepath with $, parent directories are spelt
+//up because labels can't start with $ on x86_64
 func formatFilepath(filepath string) string {
-	noSlashes := strings.Replace(filepath, "/", "$", -1)
-	splitAtDots := strings.Split(noSlashes, ".")
-	return splitAtDots[len(splitAtDots)-2] + "$"
+	var dirs []string
+	for _, dir := range strings.Split(strings.TrimSuffix(filepath, ".wacc"), "/") {
+		switch dir {
+		case "", ".":
+		case "..":
+			dirs = append(dirs, "up")
+		default:
+			dirs = append(dirs, dir)
+		}
+	}
+	return strings.Join(dirs, "$") + "$"
 }
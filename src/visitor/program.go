@@ -13,6 +13,9 @@ import (
 func (w *WaccVisitor) VisitProgram(ctx *parser.ProgramContext) interface{} {
 	//Visit all library functions before the main program
 	go func() {
+		//The channels are closed even if a fatal error ends the goroutine early
+		defer close(w.libMng.functions)
+		defer close(w.libMng.calledFunctions)
 		w.VisitLibraryProgram(ctx)
 
 		mainStatCtx := ctx.Stat()
@@ -29,8 +32,6 @@ func (w *WaccVisitor) VisitProgram(ctx *parser.ProgramContext) interface{} {
 
 		w.libMng.functions <- ast.NewMainFunction(mainStat, mainPos)
 		w.libMng.calledFunctions <- "main"
-		close(w.libMng.calledFunctions)
-		close(w.libMng.functions)
 	}()
 
 	funcs := make([]*ast.Function, 0) //This is a set in Go
@@ -74,7 +75,11 @@ func (w *WaccVisitor) VisitProgram(ctx *parser.ProgramContext) interface{} {
 	//Visit statements
 	pos := getPos(ctx)
 
-	return ast.NewProgram(userTypes, funcs, pos)
+	prog := ast.NewProgram(userTypes, funcs, pos)
+	for _, warning := range w.libMng.unusedImports() {
+		prog.AddWarning(warning)
+	}
+	return prog
 }
 
 //Struct that stores a filepath and whether the library has been loaded
@@ -89,6 +94,8 @@ func (w *WaccVisitor) VisitLibraryProgram(ctx *parser.ProgramContext) interface{
 	funcsCtx := ctx.AllFunction()
 	userTypesCtx := ctx.AllUserType()
 	imports := ctx.AllImportfile()
+	w.supers = w.superclasses(userTypesCtx)
+	w.interfaces = w.implemented(userTypesCtx)
 
 	var wg sync.WaitGroup
 	//Visit all imports
@@ -114,6 +121,7 @@ func (w *WaccVisitor) VisitLibraryProgram(ctx *parser.ProgramContext) interface{
 					parser:                wParser,
 				}
 				parseTree := wParser.GetParseTree()
+				wParser.SyntaxCheck()
 				subVisitor.VisitLibraryProgram(parseTree.(*parser.ProgramContext))
 			}
 		}(importFile)
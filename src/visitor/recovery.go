@@ -0,0 +1,41 @@
+package visitor
+
+import (
+	"wacc_32/parser"
+
+	"github.com/antlr/antlr4/runtime/Go/antlr"
+)
+
+//statementBoundaries are the tokens which separate statements, once one of them is
+//matched the parser has resynchronised after an error
+var statementBoundaries = map[int]bool{
+	parser.WaccParserSEMICOLON: true,
+	parser.WaccParserBEGIN:     true,
+	parser.WaccParserEND:       true,
+	parser.WaccParserIS:        true,
+	parser.WaccParserTHEN:      true,
+	parser.WaccParserELSE:      true,
+	parser.WaccParserENDIF:     true,
+	parser.WaccParserDO:        true,
+	parser.WaccParserDONE:      true,
+}
+
+//statementRecovery is ANTLR's default error strategy, which recovers from an error by
+//skipping to a token that can follow the rule it is in so independent errors are all
+//reported. The default strategy reports errors again as soon as it matches any token,
+//but errors found before the parser gets past the statement it recovered in are
+//usually caused by the recovery, so only the first error of each statement is reported
+type statementRecovery struct {
+	*antlr.DefaultErrorStrategy
+}
+
+func newStatementRecovery() *statementRecovery {
+	return &statementRecovery{antlr.NewDefaultErrorStrategy()}
+}
+
+//ReportMatch only ends the error condition once a statement boundary has been matched
+func (s *statementRecovery) ReportMatch(recognizer antlr.Parser) {
+	if statementBoundaries[recognizer.GetCurrentToken().GetTokenType()] {
+		s.DefaultErrorStrategy.ReportMatch(recognizer)
+	}
+}
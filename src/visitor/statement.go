@@ -18,7 +18,16 @@ func getPos(ctx hasPosition) errors.Position {
 	start := ctx.GetStart()
 	stop := ctx.GetStop()
 
-	return errors.NewPosition(start.GetLine(), start.GetColumn(), stop.GetLine(), stop.GetColumn())
+	return errors.NewSourcePosition(tokenFile(stop), start.GetLine(), start.GetColumn(),
+		stop.GetLine(), stop.GetColumn(), len([]rune(stop.GetText())))
+}
+
+//tokenFile returns the file a token was read from, empty for the file being compiled
+func tokenFile(token antlr.Token) string {
+	if input := token.GetInputStream(); input != nil {
+		return input.GetSourceName()
+	}
+	return ""
 }
 
 //VisitStatSkip returns a StatSkip
@@ -51,8 +60,9 @@ func (w *WaccVisitor) VisitStatFor(ctx *parser.StatForContext) interface{} {
 	cond := ctx.Expr().Accept(w).(ast.Expression)
 	change := ctx.Assign().Accept(w).(*ast.StatAssign)
 	bodyStat := ctx.Stat().Accept(w).(ast.Statement)
+	pos := getPos(ctx)
 
-	return ast.NewStatFor(*initial, cond, *change, bodyStat)
+	return ast.NewStatFor(*initial, cond, *change, bodyStat, pos)
 }
 
 //VisitStatWhile returns a StatWhile with correct condition and body statements
@@ -68,27 +78,33 @@ func (w *WaccVisitor) VisitStatWhile(ctx *parser.StatWhileContext) interface{} {
 func (w *WaccVisitor) VisitStatDoWhile(ctx *parser.StatDoWhileContext) interface{} {
 	bodyStat := ctx.Stat().Accept(w).(ast.Statement)
 	cond := ctx.Expr().Accept(w).(ast.Expression)
+	pos := getPos(ctx)
 
-	return ast.NewStatDoWhile(bodyStat, cond)
+	return ast.NewStatDoWhile(bodyStat, cond, pos)
 }
 
 //VisitStatBegin returns a StatBegin with the correct enclosed statement
 func (w *WaccVisitor) VisitStatBegin(ctx *parser.StatBeginContext) interface{} {
 	stat := ctx.Stat().Accept(w).(ast.Statement)
+	pos := getPos(ctx)
 
-	return ast.NewStatBegin(stat)
+	return ast.NewStatBegin(stat, pos)
 }
 
 //VisitStatPrintln returns a StatPrintln with the correct expression
 func (w *WaccVisitor) VisitStatPrintln(ctx *parser.StatPrintlnContext) interface{} {
 	exprToPrint := ctx.Expr().Accept(w).(ast.Expression)
-	return ast.NewStatPrintln(exprToPrint)
+	pos := getPos(ctx)
+
+	return ast.NewStatPrintln(exprToPrint, pos)
 }
 
 //VisitStatPrint returns a StatPrint with the correct expression
 func (w *WaccVisitor) VisitStatPrint(ctx *parser.StatPrintContext) interface{} {
 	exprToPrint := ctx.Expr().Accept(w).(ast.Expression)
-	return ast.NewStatPrint(exprToPrint)
+	pos := getPos(ctx)
+
+	return ast.NewStatPrint(exprToPrint, pos)
 }
 
 //VisitStatAssign returns a StatAssign with the correct rhs ad lhs
@@ -64,6 +64,27 @@ func (w *WaccVisitor) VisitPairtype(ctx *parser.PairtypeContext) interface{} {
 	return types.NewPair(fst, snd)
 }
 
+//VisitFunctype returns a function type, the last type is the return type
+func (w *WaccVisitor) VisitFunctype(ctx *parser.FunctypeContext) interface{} {
+	typeCtxs := ctx.AllWacctype()
+	params := make([]types.WaccType, len(typeCtxs)-1)
+	for i, typeCtx := range typeCtxs[:len(params)] {
+		params[i] = typeCtx.Accept(w).(types.WaccType)
+	}
+	retType := typeCtxs[len(params)].Accept(w).(types.WaccType)
+	return types.NewFunction(retType, params)
+}
+
+//VisitFuturetype returns the type of the handle of a routine
+func (w *WaccVisitor) VisitFuturetype(ctx *parser.FuturetypeContext) interface{} {
+	return types.NewFuture(ctx.Wacctype().Accept(w).(types.WaccType))
+}
+
+//VisitChantype returns the type of a channel
+func (w *WaccVisitor) VisitChantype(ctx *parser.ChantypeContext) interface{} {
+	return types.NewChan(ctx.Wacctype().Accept(w).(types.WaccType))
+}
+
 //VisitPairliter returns an empty pair literal
 func (w *WaccVisitor) VisitPairliter(ctx *parser.PairliterContext) interface{} {
 	return ast.NewLiteral(types.Pair, nil, getPos(ctx))
@@ -77,6 +98,9 @@ func (w *WaccVisitor) VisitPairelemtype(ctx *parser.PairelemtypeContext) interfa
 	if aType := ctx.Arraytype(); aType != nil {
 		return aType.Accept(w)
 	}
+	if libCtx := ctx.Libident(); libCtx != nil {
+		return w.namedType(libCtx, ctx.Typeargs())
+	}
 	return types.Pair
 }
 
@@ -97,6 +121,9 @@ func (w *WaccVisitor) VisitBasetype(ctx *parser.BasetypeContext) interface{} {
 	if semaType := ctx.SEMA(); semaType != nil {
 		return types.Sema
 	}
+	if condType := ctx.COND(); condType != nil {
+		return types.Cond
+	}
 	return types.Str
 }
 
@@ -106,6 +133,15 @@ func (w *WaccVisitor) VisitArraytype(ctx *parser.ArraytypeContext) interface{} {
 	if bType := ctx.Basetype(); bType != nil {
 		return types.NewArray(bType.Accept(w).(types.WaccType), dim)
 	}
+	if libCtx := ctx.Libident(); libCtx != nil {
+		return types.NewArray(w.namedType(libCtx, ctx.Typeargs()), dim)
+	}
+	if fType := ctx.Futuretype(); fType != nil {
+		return types.NewArray(fType.Accept(w).(types.WaccType), dim)
+	}
+	if cType := ctx.Chantype(); cType != nil {
+		return types.NewArray(cType.Accept(w).(types.WaccType), dim)
+	}
 	pType := ctx.Pairtype().Accept(w)
 
 	return types.NewArray(pType.(types.WaccType), dim)
@@ -114,8 +150,44 @@ func (w *WaccVisitor) VisitArraytype(ctx *parser.ArraytypeContext) interface{} {
 //VisitWacctype returns the correct WaccType
 func (w *WaccVisitor) VisitWacctype(ctx *parser.WacctypeContext) interface{} {
 	if libCtx := ctx.Libident(); libCtx != nil {
-		libIdent := libCtx.Accept(w).(*ast.Ident)
-		return types.NewUserType(libIdent.GetName(), nil, nil, false)
+		return w.namedType(libCtx, ctx.Typeargs())
 	}
 	return w.VisitChildren(ctx).([]interface{})[0].(types.WaccType)
 }
+
+//namedType returns the type parameter in scope or the struct or class a type names
+func (w *WaccVisitor) namedType(libCtx parser.ILibidentContext, argsCtx parser.ITypeargsContext) types.WaccType {
+	if argsCtx == nil && libCtx.(*parser.LibidentContext).ACCESSOR() == nil {
+		for _, param := range w.typeParams {
+			if param == libCtx.GetText() {
+				return types.NewTypeVar(param)
+			}
+		}
+	}
+	libIdent := libCtx.Accept(w).(*ast.Ident)
+	var args []types.WaccType
+	if argsCtx != nil {
+		args = argsCtx.Accept(w).([]types.WaccType)
+	}
+	return w.userTypeRef(libIdent.GetName(), args)
+}
+
+//VisitTypeparams returns the names of the type parameters
+func (w *WaccVisitor) VisitTypeparams(ctx *parser.TypeparamsContext) interface{} {
+	identCtxs := ctx.AllIdent()
+	params := make([]string, len(identCtxs))
+	for i, identCtx := range identCtxs {
+		params[i] = identCtx.GetText()
+	}
+	return params
+}
+
+//VisitTypeargs returns the types given to the type parameters
+func (w *WaccVisitor) VisitTypeargs(ctx *parser.TypeargsContext) interface{} {
+	typeCtxs := ctx.AllWacctype()
+	args := make([]types.WaccType, len(typeCtxs))
+	for i, typeCtx := range typeCtxs {
+		args[i] = typeCtx.Accept(w).(types.WaccType)
+	}
+	return args
+}
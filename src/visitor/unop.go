@@ -23,6 +23,9 @@ func (w *WaccVisitor) VisitUnaryoper(ctx *parser.UnaryoperContext) interface{} {
 	if op := ctx.TRYLOCK(); op != nil {
 		return ast.TryLock
 	}
+	if op := ctx.JOIN(); op != nil {
+		return ast.Join
+	}
 	return ast.Neg
 }
 
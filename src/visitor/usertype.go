@@ -11,6 +11,23 @@ func (w *WaccVisitor) VisitUserType(ctx *parser.UserTypeContext) interface{} {
 	ident := ctx.Ident().Accept(w).(*ast.Ident)
 	ident = ast.NewIdent(w.importName+ident.GetName(), ident.GetPos())
 
+	if ctx.INTERFACE() != nil {
+		sigsCtx := ctx.AllSignature()
+		sigs := make([]*ast.Signature, len(sigsCtx))
+		for i, sigCtx := range sigsCtx {
+			sigs[i] = sigCtx.Accept(w).(*ast.Signature)
+			sigs[i].MakeMethod(w.userTypeRef(ident.GetName(), nil))
+		}
+		return ast.NewInterface(ident, sigs)
+	}
+
+	var typeParams []string
+	if paramsCtx := ctx.Typeparams(); paramsCtx != nil {
+		typeParams = paramsCtx.Accept(w).([]string)
+	}
+	w.typeParams = typeParams
+	defer func() { w.typeParams = nil }()
+
 	fieldsCtx := ctx.AllDeclaration()
 	fields := make([]*ast.StatNewassign, len(fieldsCtx))
 
@@ -24,20 +41,120 @@ func (w *WaccVisitor) VisitUserType(ctx *parser.UserTypeContext) interface{} {
 	funcs := make([]*ast.Function, len(funcsCtx))
 	for i, funcCtx := range funcsCtx {
 		fn := funcCtx.Accept(w).(*ast.Function)
-		fn.MakeMethod(types.NewUserType(ident.GetName(), nil, nil, false))
+		fn.MakeMethod(w.userTypeRef(ident.GetName(), typeVars(typeParams)))
 		w.libMng.functions <- fn
 		funcs[i] = fn
 	}
 	userType := ast.NewUserType(ident, fields, isClass, funcs)
+	userType.SetTypeParams(typeParams)
+	if superCtx := ctx.Superclass(); superCtx != nil {
+		userType.SetParent(superCtx.Accept(w).(*ast.Ident))
+	}
+	if interfacesCtx := ctx.Interfaces(); interfacesCtx != nil {
+		userType.SetInterfaces(interfacesCtx.Accept(w).([]*ast.Ident))
+	}
 	return userType
 }
 
+//VisitInterfaces returns the interfaces a class implements
+func (w *WaccVisitor) VisitInterfaces(ctx *parser.InterfacesContext) interface{} {
+	identsCtx := ctx.AllIdent()
+	idents := make([]*ast.Ident, len(identsCtx))
+	for i, identCtx := range identsCtx {
+		ident := identCtx.Accept(w).(*ast.Ident)
+		idents[i] = ast.NewIdent(w.importName+ident.GetName(), ident.GetPos())
+	}
+	return idents
+}
+
+//VisitSignature returns a method an interface lists
+func (w *WaccVisitor) VisitSignature(ctx *parser.SignatureContext) interface{} {
+	retType := ctx.Wacctype().Accept(w).(types.WaccType)
+	ident := ctx.Ident().Accept(w).(*ast.Ident)
+	var params ast.ParamList
+	if paramsCtx := ctx.Paramlist(); paramsCtx != nil {
+		params = paramsCtx.Accept(w).(ast.ParamList)
+	}
+	return ast.NewSignature(retType, ast.NewIdent("0"+w.importName+ident.String(), ident.GetPos()), params, getPos(ctx))
+}
+
+//userTypeRef refers to the struct, class or interface name, with the classes and
+//interfaces a class can be used as
+func (w *WaccVisitor) userTypeRef(name string, typeArgs []types.WaccType) types.UserType {
+	return types.NewUserTypeRef(name, typeArgs).WithSupers(w.supers[name]).WithInterfaces(w.interfaces[name])
+}
+
+//VisitSuperclass returns the class a class extends
+func (w *WaccVisitor) VisitSuperclass(ctx *parser.SuperclassContext) interface{} {
+	ident := ctx.Ident().Accept(w).(*ast.Ident)
+	return ast.NewIdent(w.importName+ident.GetName(), ident.GetPos())
+}
+
+//superclasses finds the classes each class of a file extends before any type is visited,
+//so references to a class know the classes it can be used as
+func (w *WaccVisitor) superclasses(ctxs []parser.IUserTypeContext) map[string][]string {
+	parents := make(map[string]string)
+	for _, ctx := range ctxs {
+		if superCtx := ctx.(*parser.UserTypeContext).Superclass(); superCtx != nil {
+			name := w.importName + ctx.(*parser.UserTypeContext).Ident().GetText()
+			parents[name] = w.importName + superCtx.(*parser.SuperclassContext).Ident().GetText()
+		}
+	}
+	supers := make(map[string][]string)
+	for name, parent := range parents {
+		//A class which extends itself is reported when the program is checked
+		seen := map[string]bool{name: true}
+		for ok := true; ok && !seen[parent]; parent, ok = parents[parent] {
+			seen[parent] = true
+			supers[name] = append(supers[name], parent)
+		}
+	}
+	return supers
+}
+
+//implemented finds the interfaces each class of a file implements, itself or through the
+//classes it extends, so references to a class know the interfaces it can be used as
+func (w *WaccVisitor) implemented(ctxs []parser.IUserTypeContext) map[string][]string {
+	own := make(map[string][]string)
+	for _, ctx := range ctxs {
+		if interfacesCtx := ctx.(*parser.UserTypeContext).Interfaces(); interfacesCtx != nil {
+			name := w.importName + ctx.(*parser.UserTypeContext).Ident().GetText()
+			for _, identCtx := range interfacesCtx.(*parser.InterfacesContext).AllIdent() {
+				own[name] = append(own[name], w.importName+identCtx.GetText())
+			}
+		}
+	}
+	interfaces := make(map[string][]string)
+	for _, ctx := range ctxs {
+		name := w.importName + ctx.(*parser.UserTypeContext).Ident().GetText()
+		for _, class := range append([]string{name}, w.supers[name]...) {
+			interfaces[name] = append(interfaces[name], own[class]...)
+		}
+	}
+	return interfaces
+}
+
+//typeVars returns the type parameters names stand for
+func typeVars(names []string) []types.WaccType {
+	if len(names) == 0 {
+		return nil
+	}
+	vars := make([]types.WaccType, len(names))
+	for i, name := range names {
+		vars[i] = types.NewTypeVar(name)
+	}
+	return vars
+}
+
 func (w *WaccVisitor) VisitDeclaration(ctx *parser.DeclarationContext) interface{} {
 	ident := ctx.Ident().Accept(w).(*ast.Ident)
 
 	t := ctx.Wacctype().Accept(w).(types.WaccType)
 	pos := getPos(ctx)
 
+	if ctx.Assignrhs() == nil {
+		return ast.NewStatDeclaration(t, ident, pos)
+	}
 	rhs := ast.NewDefaultLiteral(t, pos)
 	return ast.NewStatNewassign(t, ident, rhs, pos)
 }
@@ -48,5 +165,7 @@ func (w *WaccVisitor) VisitFieldident(ctx *parser.FieldidentContext) interface{}
 		accessedName := ctx.Fieldident().GetText()
 		name = "1" + name + "!" + strings.Replace(accessedName, ".", "!", -1)
 	}
-	return ast.NewIdent(name, getPos(ctx))
+	ident := ast.NewIdent(name, getPos(ctx))
+	ident.SetLibrary(w.importName)
+	return ident
 }
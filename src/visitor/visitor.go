@@ -17,6 +17,9 @@ type WaccVisitor struct {
 	location   string //Used for relative addressing
 	libMng     *libManager
 	parser     *WaccParser
+	typeParams []string            //The type parameters in scope of the declaration being visited
+	supers     map[string][]string //The classes each class of the file extends, its parent first
+	interfaces map[string][]string //The interfaces each class of the file implements
 }
 
 //NewWaccVisitor constructs a WaccVistor
@@ -30,6 +33,18 @@ func NewWaccVisitor(importName, location string, waccParser *WaccParser) *WaccVi
 	}
 }
 
+//Libraries returns the prefix of the names of the functions in each library the file
+//imports, keyed by the alias it is imported as
+func (w *WaccVisitor) Libraries() map[string]string {
+	w.libMng.mu.Lock()
+	defer w.libMng.mu.Unlock()
+	libs := make(map[string]string)
+	for alias, path := range w.libMng.aliases {
+		libs[alias] = formatFilepath(path)
+	}
+	return libs
+}
+
 //Visit visits and returns the result of visiting the child
 func (w *WaccVisitor) Visit(tree antlr.ParseTree) interface{} {
 	return tree.Accept(w)
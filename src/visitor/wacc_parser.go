@@ -3,12 +3,19 @@ package visitor
 import (
 	"fmt"
 	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"wacc_32/errors"
 	"wacc_32/parser"
 
 	"github.com/antlr/antlr4/runtime/Go/antlr"
 )
 
-const semanticError = 200
+const (
+	syntaxError   = 100
+	semanticError = 200
+)
 
 type WaccParser struct {
 	*parser.WaccParser
@@ -16,31 +23,54 @@ type WaccParser struct {
 }
 
 func NewWaccParser(data string, location string) *WaccParser {
-	inputStream := antlr.NewInputStream(data)
+	counter := &syntaxErrorCounter{format: errors.TextFormat}
+	return newWaccParser(data, location, counter)
+}
+
+//newWaccParser creates a parser for data which reports its syntax errors to counter
+func newWaccParser(data string, location string, counter *syntaxErrorCounter) *WaccParser {
+	errors.AddSource(location, data)
+	inputStream := &sourceStream{antlr.NewInputStream(data), location}
 	lexer := parser.NewWaccLexer(inputStream)
 	tokenStream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
 	wp := &WaccParser{
-		WaccParser: parser.NewWaccParser(tokenStream),
-		errorCounter: &syntaxErrorCounter{
-			antlr.NewDefaultErrorListener(),
-			0,
-			location,
-		},
+		WaccParser:   parser.NewWaccParser(tokenStream),
+		errorCounter: counter,
 	}
-	wp.AddErrorListener(wp.errorCounter)
+	//Errors are collected so they can be written in any format
+	listener := &syntaxErrorListener{
+		DefaultErrorListener: antlr.NewDefaultErrorListener(),
+		counter:              counter,
+		location:             location,
+	}
+	lexer.RemoveErrorListeners()
+	lexer.AddErrorListener(listener)
+	wp.RemoveErrorListeners()
+	wp.AddErrorListener(listener)
+	wp.SetErrorHandler(newStatementRecovery())
 	return wp
 }
 
+//sourceStream is an InputStream which knows the file it was read from, so positions
+//in imported libraries can be traced back to them
+type sourceStream struct {
+	*antlr.InputStream
+	file string
+}
+
+//GetSourceName returns the file the stream was read from, empty for the file being compiled
+func (s *sourceStream) GetSourceName() string {
+	return s.file
+}
+
+//SetDiagnostics sets the format and file syntax and import errors are reported with
+func (wp *WaccParser) SetDiagnostics(format errors.Format, file string) {
+	wp.errorCounter.format = format
+	wp.errorCounter.file = file
+}
+
 func (w *WaccParser) derive(data string, libLocation string) *WaccParser {
-	inputStream := antlr.NewInputStream(data)
-	lexer := parser.NewWaccLexer(inputStream)
-	tokenStream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
-	wp := &WaccParser{
-		parser.NewWaccParser(tokenStream),
-		w.errorCounter,
-	}
-	wp.AddErrorListener(wp.errorCounter)
-	return wp
+	return newWaccParser(data, libLocation, w.errorCounter)
 }
 
 func (wp *WaccParser) GetParseTree() antlr.ParseTree {
@@ -82,25 +112,113 @@ func printHeader(num int) {
 	}
 }
 
-//SyntaxCheck exits if we have more than 1 syntax error
+//SyntaxCheck reports the syntax errors and exits if there are any
 func (wp *WaccParser) SyntaxCheck() {
-	if wp.errorCounter.errors > 0 {
-		os.Exit(100)
+	wp.errorCounter.mu.Lock()
+	errs := wp.errorCounter.errors
+	wp.errorCounter.mu.Unlock()
+	if len(errs) == 0 {
+		return
+	}
+	counter := wp.errorCounter
+	if counter.onFatal != nil {
+		counter.fatal(syntaxError, errs...)
+	}
+	counter.format.Write(os.Stderr, counter.file, errs)
+	if counter.format == errors.TextFormat {
+		if len(errs) == 1 {
+			fmt.Fprintln(os.Stderr, "1 syntax error detected")
+		} else {
+			fmt.Fprintf(os.Stderr, "%d syntax errors detected\n", len(errs))
+		}
 	}
+	os.Exit(syntaxError)
 }
 
+//Diagnostics returns the syntax errors found so far
+func (wp *WaccParser) Diagnostics() []errors.Diagnostic {
+	wp.errorCounter.mu.Lock()
+	defer wp.errorCounter.mu.Unlock()
+	return append([]errors.Diagnostic{}, wp.errorCounter.errors...)
+}
+
+//Comments returns the comments in the parsed file, which the lexer puts on the hidden
+//channel so they don't reach the parser
+func (wp *WaccParser) Comments() []antlr.Token {
+	stream, ok := wp.GetTokenStream().(*antlr.CommonTokenStream)
+	if !ok {
+		return nil
+	}
+	comments := make([]antlr.Token, 0)
+	for _, token := range stream.GetAllTokens() {
+		if token.GetTokenType() == parser.WaccLexerCOMMENT {
+			comments = append(comments, token)
+		}
+	}
+	return comments
+}
+
+//OnFatal makes errors which stop compilation, such as a missing library, be passed
+//to handler instead of exiting. The goroutine which found them ends after handler returns
+func (wp *WaccParser) OnFatal(handler func([]errors.Diagnostic)) {
+	wp.errorCounter.onFatal = handler
+}
+
+//syntaxErrorCounter collects the syntax errors of a file and the libraries it imports
 type syntaxErrorCounter struct {
+	mu      sync.Mutex
+	errors  []errors.Diagnostic
+	format  errors.Format
+	file    string
+	onFatal func([]errors.Diagnostic)
+}
+
+//fatal reports errors which stop compilation and exits with code
+func (sel *syntaxErrorCounter) fatal(code int, errs ...errors.Diagnostic) {
+	if sel.onFatal != nil {
+		sel.onFatal(errs)
+		runtime.Goexit()
+	}
+	sel.format.Write(os.Stderr, sel.file, errs)
+	os.Exit(code)
+}
+
+//syntaxErrorListener turns the errors ANTLR finds in one file into diagnostics
+type syntaxErrorListener struct {
 	*antlr.DefaultErrorListener
-	errors   int
-	location string
+	counter  *syntaxErrorCounter
+	location string //Path of the file, empty for the file being compiled
 }
 
-func (sel *syntaxErrorCounter) SyntaxError(_ antlr.Recognizer, _ interface{},
-	_, _ int, _ string, _ antlr.RecognitionException) {
-	sel.errors++
-	errMsg := "Syntax error"
-	if sel.location != "" {
-		errMsg += " in " + sel.location
+func (sel *syntaxErrorListener) SyntaxError(recognizer antlr.Recognizer, offendingSymbol interface{},
+	line, column int, msg string, _ antlr.RecognitionException) {
+	width := 1
+	if token, ok := offendingSymbol.(antlr.Token); ok && token.GetTokenType() != antlr.TokenEOF {
+		width = len([]rune(token.GetText()))
+	}
+	pos := errors.NewSourcePosition(sel.location, line, column, line, column, width)
+	err := errors.NewSyntaxError(pos, msg, expectedTokens(recognizer, msg))
+	sel.counter.mu.Lock()
+	defer sel.counter.mu.Unlock()
+	sel.counter.errors = append(sel.counter.errors, err.(errors.Diagnostic))
+}
+
+//expectedTokens returns the tokens the parser could have accepted when it reported msg.
+//ANTLR includes them in most messages, when no alternative matched they are found from
+//the parser's state
+func expectedTokens(recognizer antlr.Recognizer, msg string) []string {
+	var set string
+	if i := strings.Index(msg, " expecting "); i >= 0 {
+		set = msg[i+len(" expecting "):]
+	} else if strings.HasPrefix(msg, "missing ") && strings.Contains(msg, " at ") {
+		set = msg[len("missing "):strings.LastIndex(msg, " at ")]
+	} else if p, ok := recognizer.(antlr.Parser); ok && strings.HasPrefix(msg, "no viable alternative") {
+		set = p.GetExpectedTokens().StringVerbose(p.GetLiteralNames(), p.GetSymbolicNames(), false)
+	}
+	//Sets of more than one token are written {a, b}
+	set = strings.TrimSuffix(strings.TrimPrefix(set, "{"), "}")
+	if set == "" {
+		return nil
 	}
-	println(errMsg)
+	return strings.Split(set, ", ")
 }
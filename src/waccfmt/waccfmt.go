@@ -0,0 +1,300 @@
+package waccfmt
+
+import (
+	"math"
+	"strings"
+	"wacc_32/parser"
+
+	"github.com/antlr/antlr4/runtime/Go/antlr"
+)
+
+//indentation is written once for each level of nesting
+const indentation = "  "
+
+//Format returns the canonical source of a program without syntax errors: one statement
+//per line, blocks indented and single spaces between tokens. comments are the comments
+//in the program's source, which are kept on the line they were found on, or on a line of
+//their own if they started one
+func Format(tree antlr.ParseTree, comments []antlr.Token) string {
+	p := &printer{comments: comments, lineStart: true}
+	collectTokens(tree, &p.tokens)
+	p.visit(tree, nil)
+	p.flushComments(math.MaxInt32)
+	p.newline()
+	return p.buf.String()
+}
+
+//printer writes the tokens of a parse tree
+type printer struct {
+	buf        strings.Builder
+	indent     int
+	tokens     []antlr.Token //The tokens of the tree in order
+	next       int           //Index of the next token to write
+	comments   []antlr.Token
+	nextCmt    int         //Index of the next comment to write
+	lineStart  bool        //Whether nothing has been written on the current line
+	blockStart bool        //Whether the current line is the first of a block
+	last       antlr.Token //The last token written
+	lastParent antlr.Tree  //The rule the last token written is in
+	lastLine   int         //The line of the source the last thing written ended on
+	glue       bool        //Whether the next token follows the last without a space
+}
+
+//collectTokens appends the tokens of tree to tokens in order, leaving out EOF
+func collectTokens(tree antlr.Tree, tokens *[]antlr.Token) {
+	if node, ok := tree.(antlr.TerminalNode); ok {
+		if token := node.GetSymbol(); token.GetTokenType() != antlr.TokenEOF {
+			*tokens = append(*tokens, token)
+		}
+		return
+	}
+	for _, child := range tree.GetChildren() {
+		collectTokens(child, tokens)
+	}
+}
+
+//visit writes tree, which is in the rule parent, starting its declarations and statements
+//on new lines
+func (p *printer) visit(tree, parent antlr.Tree) {
+	switch tree.(type) {
+	case antlr.TerminalNode:
+		p.terminal(tree.(antlr.TerminalNode).GetSymbol(), parent)
+		return
+	case *parser.ImportfileContext, *parser.UserTypeContext, *parser.FunctionContext, *parser.SignatureContext:
+		p.newline()
+	case *parser.DeclarationContext:
+		if _, inUserType := parent.(*parser.UserTypeContext); inUserType {
+			p.newline()
+		}
+	case parser.IStatContext:
+		if _, isMain := parent.(*parser.ProgramContext); isMain {
+			p.newline()
+		}
+	}
+	for _, child := range tree.GetChildren() {
+		p.visit(child, tree)
+	}
+}
+
+//terminal writes a token in the rule parent, laying out the blocks it opens and closes.
+//The parent of a terminal node can't be used as it is the rule's embedded base context
+func (p *printer) terminal(token antlr.Token, parent antlr.Tree) {
+	switch token.GetTokenType() {
+	case antlr.TokenEOF:
+	case parser.WaccParserBEGIN, parser.WaccParserIS, parser.WaccParserTHEN:
+		if _, isProgram := parent.(*parser.ProgramContext); isProgram {
+			p.newline()
+		}
+		p.token(token, parent)
+		p.open()
+	case parser.WaccParserDO:
+		if _, isDoWhile := parent.(*parser.StatDoWhileContext); isDoWhile {
+			p.newline()
+		}
+		p.token(token, parent)
+		p.open()
+	case parser.WaccParserELSE:
+		p.close()
+		p.token(token, parent)
+		p.open()
+	case parser.WaccParserEND, parser.WaccParserENDIF:
+		p.close()
+		p.token(token, parent)
+	case parser.WaccParserWHILE:
+		if _, isDoWhile := parent.(*parser.StatDoWhileContext); isDoWhile {
+			p.close()
+		}
+		p.token(token, parent)
+	case parser.WaccParserDONE:
+		if _, isDoWhile := parent.(*parser.StatDoWhileContext); isDoWhile {
+			p.newline()
+		} else {
+			p.close()
+		}
+		p.token(token, parent)
+	case parser.WaccParserSEMICOLON:
+		p.token(token, parent)
+		switch parent.(type) {
+		case *parser.StatMultipleContext, *parser.FuncbodyContext, *parser.ImportfileContext:
+			p.newline()
+		}
+	default:
+		p.token(token, parent)
+	}
+}
+
+//open starts an indented block on the next line
+func (p *printer) open() {
+	p.newline()
+	p.indent++
+	p.blockStart = true
+}
+
+//close ends the current block, keeping any comments left at its end inside it
+func (p *printer) close() {
+	p.newline()
+	p.flushComments(p.tokens[p.next].GetStart())
+	p.indent--
+	p.blockStart = false
+}
+
+//token writes token, which is in the rule parent, preceded by the comments before it if
+//it starts a line
+func (p *printer) token(token antlr.Token, parent antlr.Tree) {
+	if p.lineStart {
+		p.flushComments(token.GetStart())
+		p.startLine(token.GetLine())
+	} else if !p.glue && p.spaced(token, parent) {
+		p.buf.WriteString(" ")
+	}
+	p.buf.WriteString(token.GetText())
+	p.lineStart = false
+	p.last = token
+	p.lastParent = parent
+	p.lastLine = token.GetLine() + strings.Count(token.GetText(), "\n")
+	p.next++
+	p.glue = glued(token, parent)
+}
+
+//glued returns whether the token after token follows it without a space, as it does
+//after the sign of a number or a symbolic unary operator
+func glued(token antlr.Token, parent antlr.Tree) bool {
+	switch parent.(type) {
+	case *parser.UnaryoperContext, *parser.IntliterContext:
+		switch token.GetTokenType() {
+		case parser.WaccParserNOT, parser.WaccParserMINUS, parser.WaccParserPLUS:
+			return true
+		}
+	}
+	return false
+}
+
+//startLine indents a new line for code from line of the source, keeping a single blank
+//line if there was at least one before it
+func (p *printer) startLine(line int) {
+	if p.lastLine > 0 && line > p.lastLine+1 && !p.blockStart && !p.closing() {
+		p.buf.WriteString("\n")
+	}
+	p.buf.WriteString(strings.Repeat(indentation, p.indent))
+	p.blockStart = false
+}
+
+//closing returns whether the next token ends a block
+func (p *printer) closing() bool {
+	if p.next >= len(p.tokens) {
+		return false
+	}
+	switch p.tokens[p.next].GetTokenType() {
+	case parser.WaccParserEND, parser.WaccParserENDIF, parser.WaccParserELSE,
+		parser.WaccParserDONE, parser.WaccParserWHILE:
+		return true
+	}
+	return false
+}
+
+//newline ends the current line, after any comments which were on it in the source
+func (p *printer) newline() {
+	if p.lineStart {
+		return
+	}
+	if p.last != nil {
+		nextStart := math.MaxInt32
+		if p.next < len(p.tokens) {
+			nextStart = p.tokens[p.next].GetStart()
+		}
+		for p.nextCmt < len(p.comments) {
+			comment := p.comments[p.nextCmt]
+			if comment.GetStart() > nextStart ||
+				comment.GetStart() > p.last.GetStart() && comment.GetLine() != p.last.GetLine() {
+				break
+			}
+			p.buf.WriteString(" " + commentText(comment))
+			p.nextCmt++
+		}
+	}
+	p.buf.WriteString("\n")
+	p.lineStart = true
+}
+
+//flushComments writes the comments before offset on lines of their own
+func (p *printer) flushComments(offset int) {
+	for p.nextCmt < len(p.comments) && p.comments[p.nextCmt].GetStart() < offset {
+		if !p.lineStart {
+			p.newline()
+			continue
+		}
+		comment := p.comments[p.nextCmt]
+		p.startLine(comment.GetLine())
+		//The comment runs to the end of the line
+		p.buf.WriteString(commentText(comment) + "\n")
+		p.lastLine = comment.GetLine()
+		p.nextCmt++
+	}
+}
+
+//commentText returns a comment without the newline which ends it
+func commentText(comment antlr.Token) string {
+	return strings.TrimRight(comment.GetText(), "\r\n")
+}
+
+//spaced returns whether token, which is in the rule parent, is separated from the last
+//token by a space
+func (p *printer) spaced(token antlr.Token, parent antlr.Tree) bool {
+	last := p.last.GetTokenType()
+	switch token.GetTokenType() {
+	case parser.WaccParserRPAREN, parser.WaccParserRBRACKET, parser.WaccParserRBRACES,
+		parser.WaccParserCOMMA, parser.WaccParserDOT, parser.WaccParserACCESSOR,
+		parser.WaccParserINC, parser.WaccParserDEC:
+		return false
+	case parser.WaccParserLESS, parser.WaccParserGREATER:
+		//Type parameters and arguments are written Box<T>
+		if typeBrackets(parent) {
+			return false
+		}
+	case parser.WaccParserSEMICOLON:
+		//Only statements are separated with " ;"
+		switch parent.(type) {
+		case *parser.StatMultipleContext, *parser.FuncbodyContext:
+			return true
+		}
+		return false
+	case parser.WaccParserLPAREN:
+		switch last {
+		case parser.WaccParserIDENT, parser.WaccParserPAIR, parser.WaccParserNEWPAIR,
+			parser.WaccParserMAKE, parser.WaccParserMAKE_CHAN, parser.WaccParserSEMA, parser.WaccParserFN:
+			return false
+		}
+	case parser.WaccParserLBRACKET:
+		switch last {
+		case parser.WaccParserIDENT, parser.WaccParserRBRACKET, parser.WaccParserRPAREN,
+			parser.WaccParserINT, parser.WaccParserBOOL, parser.WaccParserCHAR,
+			parser.WaccParserSTRING, parser.WaccParserLOCK, parser.WaccParserSEMA, parser.WaccParserCOND:
+			return false
+		}
+	case parser.WaccParserLBRACES:
+		return last != parser.WaccParserIDENT && !(last == parser.WaccParserGREATER && typeBrackets(p.lastParent))
+	}
+	switch last {
+	case parser.WaccParserLPAREN, parser.WaccParserLBRACKET, parser.WaccParserLBRACES,
+		parser.WaccParserDOT, parser.WaccParserACCESSOR:
+		return false
+	case parser.WaccParserLESS:
+		return !typeBrackets(p.lastParent)
+	case parser.WaccParserGREATER:
+		switch token.GetTokenType() {
+		case parser.WaccParserLPAREN, parser.WaccParserLBRACKET:
+			return !typeBrackets(p.lastParent)
+		}
+	}
+	return true
+}
+
+//typeBrackets returns whether the rule parent is a list of type parameters or arguments,
+//or a future or channel type
+func typeBrackets(parent antlr.Tree) bool {
+	switch parent.(type) {
+	case *parser.TypeparamsContext, *parser.TypeargsContext, *parser.FuturetypeContext, *parser.ChantypeContext:
+		return true
+	}
+	return false
+}
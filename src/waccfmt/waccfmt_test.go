@@ -0,0 +1,156 @@
+package waccfmt
+
+import (
+	"testing"
+	"wacc_32/visitor"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func format(t *testing.T, src string) string {
+	wp := visitor.NewWaccParser(src, "")
+	tree := wp.GetParseTree()
+	require.Empty(t, wp.Diagnostics())
+	return Format(tree, wp.Comments())
+}
+
+func TestFormatIndentsBlocks(t *testing.T) {
+	src := "begin int f(int x) is if x>0 then return -x else return x fi end\n" +
+		"int y=call f(-3);while y<10 do y+=1 done;do skip while false done;" +
+		"for (int i=0;i<3;i=i+1) do println i done end"
+
+	assert.Equal(t, "begin\n"+
+		"  int f(int x) is\n"+
+		"    if x > 0 then\n"+
+		"      return -x\n"+
+		"    else\n"+
+		"      return x\n"+
+		"    fi\n"+
+		"  end\n"+
+		"  int y = call f(-3) ;\n"+
+		"  while y < 10 do\n"+
+		"    y += 1\n"+
+		"  done ;\n"+
+		"  do\n"+
+		"    skip\n"+
+		"  while false\n"+
+		"  done ;\n"+
+		"  for (int i = 0; i < 3; i = i + 1) do\n"+
+		"    println i\n"+
+		"  done\n"+
+		"end\n", format(t, src))
+}
+
+func TestFormatUserTypesAndExpressions(t *testing.T) {
+	src := "import \"lib.wacc\" as l ;\nbegin struct s is int a int[] b end\n" +
+		"int[] a = [2,3];s v = s{1,a};pair(int,char) p = newpair(len v.b,'c');int x = call l::g(v.b[0], !true ? 1 : 2) end"
+
+	assert.Equal(t, "import \"lib.wacc\" as l;\n"+
+		"begin\n"+
+		"  struct s is\n"+
+		"    int a\n"+
+		"    int[] b\n"+
+		"  end\n"+
+		"  int[] a = [2, 3] ;\n"+
+		"  s v = s{1, a} ;\n"+
+		"  pair(int, char) p = newpair(len v.b, 'c') ;\n"+
+		"  int x = call l::g(v.b[0], !true ? 1 : 2)\n"+
+		"end\n", format(t, src))
+}
+
+func TestFormatInterfaces(t *testing.T) {
+	src := "begin interface shape is int area() bool fits(int w,int h) end\n" +
+		"class sq implements shape is int side int area() is return this.side end " +
+		"bool fits(int w,int h) is return true end end skip end"
+
+	assert.Equal(t, "begin\n"+
+		"  interface shape is\n"+
+		"    int area()\n"+
+		"    bool fits(int w, int h)\n"+
+		"  end\n"+
+		"  class sq implements shape is\n"+
+		"    int side\n"+
+		"    int area() is\n"+
+		"      return this.side\n"+
+		"    end\n"+
+		"    bool fits(int w, int h) is\n"+
+		"      return true\n"+
+		"    end\n"+
+		"  end\n"+
+		"  skip\n"+
+		"end\n", format(t, src))
+}
+
+func TestFormatFutures(t *testing.T) {
+	src := "begin int f(int x) is return x end future < int >[] fs = make(future<int>,1);" +
+		"future<int> h = wacc f(1);fs[0] = h;println join fs[0] end"
+
+	assert.Equal(t, "begin\n"+
+		"  int f(int x) is\n"+
+		"    return x\n"+
+		"  end\n"+
+		"  future<int>[] fs = make(future<int>, 1) ;\n"+
+		"  future<int> h = wacc f(1) ;\n"+
+		"  fs[0] = h ;\n"+
+		"  println join fs[0]\n"+
+		"end\n", format(t, src))
+}
+
+func TestFormatChannels(t *testing.T) {
+	src := "begin chan < int > c = make_chan(int,1);send c,1;int x = recv c;close c end"
+
+	assert.Equal(t, "begin\n"+
+		"  chan<int> c = make_chan(int, 1) ;\n"+
+		"  send c, 1 ;\n"+
+		"  int x = recv c ;\n"+
+		"  close c\n"+
+		"end\n", format(t, src))
+}
+
+func TestFormatCondVars(t *testing.T) {
+	src := "begin int f(lock l,int x) is with l do return x done end lock l;cond c;cond[] cs = [c];" +
+		"with l do wait c,l;signal c done;broadcast c;int r = call f(l,1);println r end"
+
+	assert.Equal(t, "begin\n"+
+		"  int f(lock l, int x) is\n"+
+		"    with l do\n"+
+		"      return x\n"+
+		"    done\n"+
+		"  end\n"+
+		"  lock l ;\n"+
+		"  cond c ;\n"+
+		"  cond[] cs = [c] ;\n"+
+		"  with l do\n"+
+		"    wait c, l ;\n"+
+		"    signal c\n"+
+		"  done ;\n"+
+		"  broadcast c ;\n"+
+		"  int r = call f(l, 1) ;\n"+
+		"  println r\n"+
+		"end\n", format(t, src))
+}
+
+func TestFormatKeepsComments(t *testing.T) {
+	src := "# Output:\n# 1\n\n\n\nbegin   # main\n  int x = 1 ; # one\n\n\n" +
+		"  # print it\n  println x\n  # done\nend\n# bye"
+
+	assert.Equal(t, "# Output:\n"+
+		"# 1\n"+
+		"\n"+
+		"begin # main\n"+
+		"  int x = 1 ; # one\n"+
+		"\n"+
+		"  # print it\n"+
+		"  println x\n"+
+		"  # done\n"+
+		"end\n"+
+		"# bye\n", format(t, src))
+}
+
+func TestFormatIsIdempotent(t *testing.T) {
+	src := "begin\n  begin skip # a\n end ; if true then # b\n skip else skip fi\nend\n"
+	formatted := format(t, src)
+
+	assert.Equal(t, formatted, format(t, formatted))
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"wacc_32/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const x86Prologue = "\tpushq %rbp\n\tpushq %rbx\n\tpushq %r12\n\tpushq %r13\n\tpushq %r14\n\tpushq %r15\n\tleaq -8(%rsp), %rsp\n"
+
+//TestX86_64CallingConvention checks that wacc functions are passed their arguments
+//on the stack and return in %rax, C functions are passed theirs in %rdi and %rsi
+//with %eax cleared, and every function saves the System V callee saved registers
+//keeping the stack 16 byte aligned
+func TestX86_64CallingConvention(t *testing.T) {
+	code := compileFor(t, "x86_64", callsProgram)
+
+	f := function(code, "f")
+	assert.True(t, strings.HasPrefix(f, x86Prologue), f)
+	assert.Contains(t, f, "\tmovq %rdi, %rax\n\tleaq 8(%rsp), %rsp\n\tpopq %r15\n\tpopq %r14\n\tpopq %r13\n\tpopq %r12\n\tpopq %rbx\n\tpopq %rbp\n\tret\n")
+
+	main := function(code, "main")
+	assert.True(t, strings.HasPrefix(main, x86Prologue), main)
+	assert.Regexp(t, `\tmovq %r\w+, \(%rsp\)\n(?:.*\n)*?\tmovl %\w+, 8\(%rsp\)\n\tmovl \$0, %eax\n\tcall f@PLT\n\tmovq %rax, %rdi\n`, main)
+	assert.Contains(t, main, "\tmovq $12, %rdi\n\tmovl $0, %eax\n\tcall malloc@PLT\n\tmovq %rax, %rdi\n")
+	assert.Regexp(t, `\tmovq \$0, %rdi\n\tmovq %r\w+, %rsi\n\tmovl \$0, %eax\n\tcall p_check_array_index_out_of_bounds@PLT\n`, f)
+	assert.Contains(t, code, "\n.section .note.GNU-stack,\"\",@progbits\n")
+}
+
+//TestX86_64PointerSize checks that references take 8 bytes on x86_64, in the
+//arguments of a function, in pairs and in arrays
+func TestX86_64PointerSize(t *testing.T) {
+	code := compileFor(t, "x86_64", callsProgram)
+	assert.Equal(t, types.Size(types.DoubleWord), types.PointerSize())
+
+	args := regexp.MustCompile(`\tmovq (\d+)\(%rsp\), %r\w+\n\tmovslq (\d+)\(%rsp\), %r\w+\n`).FindStringSubmatch(function(code, "f"))
+	if assert.Len(t, args, 3) {
+		a, _ := strconv.Atoi(args[1])
+		b, _ := strconv.Atoi(args[2])
+		assert.Equal(t, 8, b-a)
+	}
+
+	main := function(code, "main")
+	assert.Regexp(t, `\tmovq \$16, %rdi\n\tmovl \$0, %eax\n\tcall malloc@PLT\n\tmovq %rax, %rdi\n\tmovq %rdi, %r\w+\n\tmovq %r\w+, \(%r\w+\)\n\tmovq %r\w+, 8\(%r\w+\)\n`, main)
+	assert.Contains(t, main, "\tmovq $28, %rdi\n")
+	assert.Regexp(t, `\tmovq %r\w+, 20\(%r\w+\)\n`, main)
+}
+
+//TestX86_64RuntimeErrors checks that each operation which can fail calls its check,
+//that every check is defined and that errors exit with -1
+func TestX86_64RuntimeErrors(t *testing.T) {
+	code := compileFor(t, "x86_64", runtimeErrorsProgram)
+
+	main := function(code, "main")
+	for _, check := range []string{"p_check_array_index_out_of_bounds", "p_check_divide_by_zero", "p_check_int_overflow", "p_check_null_pointer"} {
+		assert.Contains(t, main, "\tcall "+check+"@PLT\n")
+	}
+	assert.Regexp(t, `\taddq %r\w+, %r\w+\n\tmovslq %r\w+, %rax\n\tcmpq %rax, %r\w+\n\tmovl \$0, %eax\n\tcall p_check_int_overflow@PLT\n`, main)
+	assert.Regexp(t, `\tmovq %r\w+, %rdi\n\tmovq %r\w+, %rsi\n\tmovl \$0, %eax\n\tcall p_check_divide_by_zero@PLT\n(?:.*\n)*?\tidivq %rsi\n`, main)
+	assertCallsDefined(t, code, regexp.MustCompile(`\tcall (p_\w+)@PLT\n`))
+
+	overflow := function(code, "p_check_int_overflow")
+	assert.Contains(t, overflow, x86Prologue+"\tjne not_ok\n")
+
+	printError := function(code, "p_print_error")
+	assert.Contains(t, printError, "\tmovq $-1, %rdi\n\tmovl $0, %eax\n\tcall exit@PLT\n")
+	for _, err := range []string{"ArrayIndexNegativeError", "ArrayIndexTooLargeError", "DivideByZeroError", "IntegerOverflowError", "NullPointerReferenceError"} {
+		assert.Contains(t, code, "\nerr_"+err+":\n")
+		assert.Contains(t, code, "\tleaq err_"+err+"(%rip), %rdi\n\tmovl $0, %eax\n\tcall p_print_error@PLT\n")
+	}
+}
+
+//TestX86_64DivideMinusOne runs a program dividing INT_MIN by -1, which wraps to INT_MIN
+//with a remainder of 0 like on the other targets instead of trapping
+func TestX86_64DivideMinusOne(t *testing.T) {
+	code := compileFor(t, "x86_64", "testdata/targets/divideMinusOne.wacc")
+	out := runX86_64(t, code)
+	assert.Equal(t, "-2147483648\n0\n-7\n-1\n-3\n", out)
+}
+
+//runX86_64 assembles and runs x86_64 code, returning what it prints. The test is
+//skipped where the code can't run
+func runX86_64(t *testing.T, code string) string {
+	if runtime.GOOS != "linux" || runtime.GOARCH != "amd64" {
+		t.Skip("x86_64 code only runs on linux/amd64")
+	}
+	gcc, err := exec.LookPath("gcc")
+	if err != nil {
+		t.Skip("gcc is needed to assemble x86_64 code")
+	}
+	dir, err := ioutil.TempDir("", "wacc")
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	defer os.RemoveAll(dir)
+	asm, bin := filepath.Join(dir, "prog.s"), filepath.Join(dir, "prog")
+	assert.NoError(t, ioutil.WriteFile(asm, []byte(code), 0644))
+	msg, err := exec.Command(gcc, "-o", bin, asm, "-lpthread").CombinedOutput()
+	if !assert.NoError(t, err, string(msg)) {
+		return ""
+	}
+	out, err := exec.Command(bin).Output()
+	assert.NoError(t, err)
+	return string(out)
+}